@@ -0,0 +1,291 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+// MockClient represents a mocked Hetzner Cloud API
+type MockClient struct {
+	Server *httptest.Server
+	Mux    *http.ServeMux
+	mutex  sync.Mutex
+
+	lastID int
+
+	actions         map[int]schema.Action
+	sshKeys         map[int]schema.SSHKey
+	networks        map[int]schema.Network
+	firewalls       map[int]schema.Firewall
+	loadBalancers   map[int]schema.LoadBalancer
+	placementGroups map[int]schema.PlacementGroup
+	servers         map[int]schema.Server
+	volumes         map[int]schema.Volume
+}
+
+// CreateClient will create a new mock Hetzner Cloud API
+func CreateClient() *MockClient {
+	m := &MockClient{}
+	m.Mux = http.NewServeMux()
+	m.Reset()
+	m.mockActions()
+	m.mockSSHKeys()
+	m.mockNetworks()
+	m.mockFirewalls()
+	m.mockLoadBalancers()
+	m.mockPlacementGroups()
+	m.mockServers()
+	m.mockVolumes()
+	m.Server = httptest.NewServer(m.Mux)
+	return m
+}
+
+// Client returns a hcloud client talking to the mock API
+func (m *MockClient) Client() *hcloud.Client {
+	return hcloud.NewClient(
+		hcloud.WithEndpoint(m.Server.URL),
+		hcloud.WithToken("mock"),
+		hcloud.WithPollInterval(time.Millisecond),
+	)
+}
+
+// Reset will empty the state of the mock data
+func (m *MockClient) Reset() {
+	m.actions = make(map[int]schema.Action)
+	m.sshKeys = make(map[int]schema.SSHKey)
+	m.networks = make(map[int]schema.Network)
+	m.firewalls = make(map[int]schema.Firewall)
+	m.loadBalancers = make(map[int]schema.LoadBalancer)
+	m.placementGroups = make(map[int]schema.PlacementGroup)
+	m.servers = make(map[int]schema.Server)
+	m.volumes = make(map[int]schema.Volume)
+}
+
+// All returns a map of all resource IDs to their resources
+func (m *MockClient) All() map[string]interface{} {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	all := make(map[string]interface{})
+	for id, r := range m.sshKeys {
+		all[fmt.Sprintf("ssh_key/%d", id)] = r
+	}
+	for id, r := range m.networks {
+		all[fmt.Sprintf("network/%d", id)] = r
+	}
+	for id, r := range m.firewalls {
+		all[fmt.Sprintf("firewall/%d", id)] = r
+	}
+	for id, r := range m.loadBalancers {
+		all[fmt.Sprintf("load_balancer/%d", id)] = r
+	}
+	for id, r := range m.placementGroups {
+		all[fmt.Sprintf("placement_group/%d", id)] = r
+	}
+	for id, r := range m.servers {
+		all[fmt.Sprintf("server/%d", id)] = r
+	}
+	for id, r := range m.volumes {
+		all[fmt.Sprintf("volume/%d", id)] = r
+	}
+	return all
+}
+
+// nextID returns a new unique resource ID
+func (m *MockClient) nextID() int {
+	m.lastID++
+	return m.lastID
+}
+
+// newAction records a finished action on the resource and returns it
+func (m *MockClient) newAction(command string, resourceType string, resourceID int) schema.Action {
+	now := time.Now()
+	action := schema.Action{
+		ID:       m.nextID(),
+		Status:   string(hcloud.ActionStatusSuccess),
+		Command:  command,
+		Progress: 100,
+		Started:  now,
+		Finished: &now,
+		Resources: []schema.ActionResourceReference{
+			{ID: resourceID, Type: resourceType},
+		},
+	}
+	m.actions[action.ID] = action
+	return action
+}
+
+// handleResource registers the handler of a resource collection.
+// The handler receives the resource ID and the action name, both empty when not part of the path.
+func (m *MockClient) handleResource(collection string, handler func(w http.ResponseWriter, r *http.Request, id int, action string)) {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		// Paths are of the form /<collection>[/<id>[/actions/<action>]]
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/"+collection), "/"), "/")
+		id := 0
+		action := ""
+		if parts[0] != "" {
+			var err error
+			id, err = strconv.Atoi(parts[0])
+			if err != nil {
+				writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("invalid id %q", parts[0]))
+				return
+			}
+		}
+		if len(parts) == 3 && parts[1] == "actions" {
+			action = parts[2]
+		} else if len(parts) > 1 {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("unknown path %q", r.URL.Path))
+			return
+		}
+
+		handler(w, r, id, action)
+	}
+	m.Mux.HandleFunc("/"+collection, f)
+	m.Mux.HandleFunc("/"+collection+"/", f)
+}
+
+func (m *MockClient) mockActions() {
+	m.handleResource("actions", func(w http.ResponseWriter, r *http.Request, id int, _ string) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusBadRequest, "invalid_input", "unsupported method")
+			return
+		}
+		if id != 0 {
+			action, ok := m.actions[id]
+			if !ok {
+				writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("action %d not found", id))
+				return
+			}
+			writeJSON(w, http.StatusOK, schema.ActionGetResponse{Action: action})
+			return
+		}
+
+		resp := schema.ActionListResponse{Actions: []schema.Action{}}
+		for _, s := range r.URL.Query()["id"] {
+			actionID, err := strconv.Atoi(s)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid action id %q", s))
+				return
+			}
+			if action, ok := m.actions[actionID]; ok {
+				resp.Actions = append(resp.Actions, action)
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// matchesQuery returns true if the name and labels match the name and label selector filters of the request
+func matchesQuery(r *http.Request, name string, labels map[string]string) bool {
+	query := r.URL.Query()
+	if n := query.Get("name"); n != "" && n != name {
+		return false
+	}
+	return matchesLabelSelector(query.Get("label_selector"), labels)
+}
+
+// matchesLabelSelector returns true if the labels match the comma separated terms of the selector.
+// Only the equality and existence operators are supported.
+func matchesLabelSelector(selector string, labels map[string]string) bool {
+	if selector == "" {
+		return true
+	}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		switch {
+		case strings.Contains(term, "!="):
+			kv := strings.SplitN(term, "!=", 2)
+			if v, ok := labels[kv[0]]; ok && v == kv[1] {
+				return false
+			}
+		case strings.Contains(term, "=="):
+			kv := strings.SplitN(term, "==", 2)
+			if v, ok := labels[kv[0]]; !ok || v != kv[1] {
+				return false
+			}
+		case strings.Contains(term, "="):
+			kv := strings.SplitN(term, "=", 2)
+			if v, ok := labels[kv[0]]; !ok || v != kv[1] {
+				return false
+			}
+		case strings.HasPrefix(term, "!"):
+			if _, ok := labels[strings.TrimPrefix(term, "!")]; ok {
+				return false
+			}
+		default:
+			if _, ok := labels[term]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// copyLabels returns a copy of the labels, never nil
+func copyLabels(labels *map[string]string) map[string]string {
+	result := make(map[string]string)
+	if labels != nil {
+		for k, v := range *labels {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "json_error", fmt.Sprintf("error decoding request: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	respB, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", v))
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, schema.ErrorResponse{
+		Error: schema.Error{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
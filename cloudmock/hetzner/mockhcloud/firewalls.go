@@ -0,0 +1,217 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+func (m *MockClient) mockFirewalls() {
+	m.handleResource("firewalls", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listFirewalls(w, r)
+		case r.Method == http.MethodGet && action == "":
+			m.getFirewall(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createFirewall(w, r)
+		case r.Method == http.MethodPut && action == "":
+			m.updateFirewall(w, r, id)
+		case r.Method == http.MethodPost && action == "set_rules":
+			m.setFirewallRules(w, r, id)
+		case r.Method == http.MethodPost && action == "apply_to_resources":
+			m.applyFirewallResources(w, r, id)
+		case r.Method == http.MethodPost && action == "remove_from_resources":
+			m.removeFirewallResources(w, r, id)
+		case r.Method == http.MethodDelete && action == "":
+			m.deleteFirewall(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listFirewalls(w http.ResponseWriter, r *http.Request) {
+	resp := schema.FirewallListResponse{Firewalls: []schema.Firewall{}}
+	for _, firewall := range m.firewalls {
+		if matchesQuery(r, firewall.Name, firewall.Labels) {
+			resp.Firewalls = append(resp.Firewalls, firewall)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getFirewall(w http.ResponseWriter, id int) {
+	firewall, ok := m.firewalls[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("firewall %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.FirewallGetResponse{Firewall: firewall})
+}
+
+func (m *MockClient) createFirewall(w http.ResponseWriter, r *http.Request) {
+	var create schema.FirewallCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	for _, firewall := range m.firewalls {
+		if firewall.Name == create.Name {
+			writeError(w, http.StatusConflict, "uniqueness_error", fmt.Sprintf("firewall %q already exists", create.Name))
+			return
+		}
+	}
+
+	firewall := schema.Firewall{
+		ID:        m.nextID(),
+		Name:      create.Name,
+		Labels:    copyLabels(create.Labels),
+		Created:   time.Now(),
+		Rules:     create.Rules,
+		AppliedTo: create.ApplyTo,
+	}
+	m.firewalls[firewall.ID] = firewall
+
+	resp := schema.FirewallCreateResponse{
+		Firewall: firewall,
+		Actions:  []schema.Action{},
+	}
+	if len(create.ApplyTo) > 0 {
+		resp.Actions = append(resp.Actions, m.newAction("apply_firewall", "firewall", firewall.ID))
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (m *MockClient) updateFirewall(w http.ResponseWriter, r *http.Request, id int) {
+	firewall, ok := m.firewalls[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("firewall %d not found", id))
+		return
+	}
+
+	var update schema.FirewallUpdateRequest
+	if !decodeJSON(w, r, &update) {
+		return
+	}
+	if update.Name != nil {
+		firewall.Name = *update.Name
+	}
+	if update.Labels != nil {
+		firewall.Labels = copyLabels(update.Labels)
+	}
+	m.firewalls[id] = firewall
+
+	writeJSON(w, http.StatusOK, schema.FirewallUpdateResponse{Firewall: firewall})
+}
+
+func (m *MockClient) setFirewallRules(w http.ResponseWriter, r *http.Request, id int) {
+	firewall, ok := m.firewalls[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("firewall %d not found", id))
+		return
+	}
+
+	var request schema.FirewallActionSetRulesRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	firewall.Rules = request.Rules
+	m.firewalls[id] = firewall
+
+	action := m.newAction("set_firewall_rules", "firewall", id)
+	writeJSON(w, http.StatusCreated, schema.FirewallActionSetRulesResponse{Actions: []schema.Action{action}})
+}
+
+func (m *MockClient) applyFirewallResources(w http.ResponseWriter, r *http.Request, id int) {
+	firewall, ok := m.firewalls[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("firewall %d not found", id))
+		return
+	}
+
+	var request schema.FirewallActionApplyToResourcesRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	firewall.AppliedTo = append(firewall.AppliedTo, request.ApplyTo...)
+	m.firewalls[id] = firewall
+
+	action := m.newAction("apply_firewall", "firewall", id)
+	writeJSON(w, http.StatusCreated, schema.FirewallActionApplyToResourcesResponse{Actions: []schema.Action{action}})
+}
+
+func (m *MockClient) removeFirewallResources(w http.ResponseWriter, r *http.Request, id int) {
+	firewall, ok := m.firewalls[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("firewall %d not found", id))
+		return
+	}
+
+	var request schema.FirewallActionRemoveFromResourcesRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	var appliedTo []schema.FirewallResource
+	for _, resource := range firewall.AppliedTo {
+		removed := false
+		for _, remove := range request.RemoveFrom {
+			if sameFirewallResource(resource, remove) {
+				removed = true
+			}
+		}
+		if !removed {
+			appliedTo = append(appliedTo, resource)
+		}
+	}
+	firewall.AppliedTo = appliedTo
+	m.firewalls[id] = firewall
+
+	action := m.newAction("remove_firewall", "firewall", id)
+	writeJSON(w, http.StatusCreated, schema.FirewallActionRemoveFromResourcesResponse{Actions: []schema.Action{action}})
+}
+
+func (m *MockClient) deleteFirewall(w http.ResponseWriter, id int) {
+	firewall, ok := m.firewalls[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("firewall %d not found", id))
+		return
+	}
+	if len(firewall.AppliedTo) > 0 {
+		writeError(w, http.StatusConflict, "resource_in_use", fmt.Sprintf("firewall %d is still in use", id))
+		return
+	}
+	delete(m.firewalls, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sameFirewallResource(a, b schema.FirewallResource) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Server != nil && b.Server != nil {
+		return a.Server.ID == b.Server.ID
+	}
+	if a.LabelSelector != nil && b.LabelSelector != nil {
+		return a.LabelSelector.Selector == b.LabelSelector.Selector
+	}
+	return false
+}
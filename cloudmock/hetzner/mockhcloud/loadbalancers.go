@@ -0,0 +1,337 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+func (m *MockClient) mockLoadBalancers() {
+	m.handleResource("load_balancers", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listLoadBalancers(w, r)
+		case r.Method == http.MethodGet && action == "":
+			m.getLoadBalancer(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createLoadBalancer(w, r)
+		case r.Method == http.MethodPut && action == "":
+			m.updateLoadBalancer(w, r, id)
+		case r.Method == http.MethodPost && action == "attach_to_network":
+			m.attachLoadBalancerToNetwork(w, r, id)
+		case r.Method == http.MethodPost && action == "add_service":
+			m.addLoadBalancerService(w, r, id)
+		case r.Method == http.MethodPost && action == "update_service":
+			m.updateLoadBalancerService(w, r, id)
+		case r.Method == http.MethodPost && action == "add_target":
+			m.addLoadBalancerTarget(w, r, id)
+		case r.Method == http.MethodPost && action == "remove_target":
+			m.removeLoadBalancerTarget(w, r, id)
+		case r.Method == http.MethodDelete && action == "":
+			m.deleteLoadBalancer(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listLoadBalancers(w http.ResponseWriter, r *http.Request) {
+	resp := schema.LoadBalancerListResponse{LoadBalancers: []schema.LoadBalancer{}}
+	for _, loadBalancer := range m.loadBalancers {
+		if matchesQuery(r, loadBalancer.Name, loadBalancer.Labels) {
+			resp.LoadBalancers = append(resp.LoadBalancers, loadBalancer)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getLoadBalancer(w http.ResponseWriter, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.LoadBalancerGetResponse{LoadBalancer: loadBalancer})
+}
+
+func (m *MockClient) createLoadBalancer(w http.ResponseWriter, r *http.Request) {
+	var create schema.LoadBalancerCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	for _, loadBalancer := range m.loadBalancers {
+		if loadBalancer.Name == create.Name {
+			writeError(w, http.StatusConflict, "uniqueness_error", fmt.Sprintf("load balancer %q already exists", create.Name))
+			return
+		}
+	}
+	loadBalancerType, ok := create.LoadBalancerType.(string)
+	if !ok || create.Location == nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", "load balancer type and location names are required")
+		return
+	}
+
+	loadBalancer := schema.LoadBalancer{
+		ID:               m.nextID(),
+		Name:             create.Name,
+		Location:         schema.Location{Name: *create.Location},
+		LoadBalancerType: schema.LoadBalancerType{Name: loadBalancerType},
+		Labels:           copyLabels(create.Labels),
+		Created:          time.Now(),
+		Services:         []schema.LoadBalancerService{},
+		Targets:          []schema.LoadBalancerTarget{},
+		PrivateNet:       []schema.LoadBalancerPrivateNet{},
+		Algorithm:        schema.LoadBalancerAlgorithm{Type: "round_robin"},
+	}
+	if create.PublicInterface == nil || *create.PublicInterface {
+		loadBalancer.PublicNet = schema.LoadBalancerPublicNet{
+			Enabled: true,
+			IPv4:    schema.LoadBalancerPublicNetIPv4{IP: fmt.Sprintf("192.0.2.%d", loadBalancer.ID%256)},
+		}
+	}
+	if create.Network != nil {
+		if _, ok := m.networks[*create.Network]; !ok {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", *create.Network))
+			return
+		}
+		loadBalancer.PrivateNet = append(loadBalancer.PrivateNet, schema.LoadBalancerPrivateNet{Network: *create.Network})
+	}
+	for _, service := range create.Services {
+		s := schema.LoadBalancerService{Protocol: service.Protocol}
+		if service.ListenPort != nil {
+			s.ListenPort = *service.ListenPort
+		}
+		if service.DestinationPort != nil {
+			s.DestinationPort = *service.DestinationPort
+		}
+		s.HealthCheck = defaultHealthCheck(s)
+		loadBalancer.Services = append(loadBalancer.Services, s)
+	}
+	for _, target := range create.Targets {
+		t := schema.LoadBalancerTarget{Type: target.Type}
+		if target.LabelSelector != nil {
+			t.LabelSelector = &schema.LoadBalancerTargetLabelSelector{Selector: target.LabelSelector.Selector}
+		}
+		if target.Server != nil {
+			t.Server = &schema.LoadBalancerTargetServer{ID: target.Server.ID}
+		}
+		if target.UsePrivateIP != nil {
+			t.UsePrivateIP = *target.UsePrivateIP
+		}
+		loadBalancer.Targets = append(loadBalancer.Targets, t)
+	}
+	m.loadBalancers[loadBalancer.ID] = loadBalancer
+
+	action := m.newAction("create_load_balancer", "load_balancer", loadBalancer.ID)
+	writeJSON(w, http.StatusCreated, schema.LoadBalancerCreateResponse{
+		LoadBalancer: loadBalancer,
+		Action:       action,
+	})
+}
+
+func (m *MockClient) updateLoadBalancer(w http.ResponseWriter, r *http.Request, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+
+	var update schema.LoadBalancerUpdateRequest
+	if !decodeJSON(w, r, &update) {
+		return
+	}
+	if update.Name != nil {
+		loadBalancer.Name = *update.Name
+	}
+	if update.Labels != nil {
+		loadBalancer.Labels = copyLabels(update.Labels)
+	}
+	m.loadBalancers[id] = loadBalancer
+
+	writeJSON(w, http.StatusOK, schema.LoadBalancerUpdateResponse{LoadBalancer: loadBalancer})
+}
+
+func (m *MockClient) attachLoadBalancerToNetwork(w http.ResponseWriter, r *http.Request, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+
+	var request schema.LoadBalancerActionAttachToNetworkRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	if _, ok := m.networks[request.Network]; !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", request.Network))
+		return
+	}
+	loadBalancer.PrivateNet = append(loadBalancer.PrivateNet, schema.LoadBalancerPrivateNet{Network: request.Network})
+	m.loadBalancers[id] = loadBalancer
+
+	action := m.newAction("attach_to_network", "load_balancer", id)
+	writeJSON(w, http.StatusCreated, schema.LoadBalancerActionAttachToNetworkResponse{Action: action})
+}
+
+func (m *MockClient) addLoadBalancerService(w http.ResponseWriter, r *http.Request, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+
+	var request schema.LoadBalancerActionAddServiceRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	service := schema.LoadBalancerService{Protocol: request.Protocol}
+	if request.ListenPort != nil {
+		service.ListenPort = *request.ListenPort
+	}
+	if request.DestinationPort != nil {
+		service.DestinationPort = *request.DestinationPort
+	}
+	service.HealthCheck = defaultHealthCheck(service)
+	for _, s := range loadBalancer.Services {
+		if s.ListenPort == service.ListenPort {
+			writeError(w, http.StatusConflict, "source_port_already_used", fmt.Sprintf("port %d is already used", service.ListenPort))
+			return
+		}
+	}
+	loadBalancer.Services = append(loadBalancer.Services, service)
+	m.loadBalancers[id] = loadBalancer
+
+	action := m.newAction("add_service", "load_balancer", id)
+	writeJSON(w, http.StatusCreated, schema.LoadBalancerActionAddServiceResponse{Action: action})
+}
+
+func (m *MockClient) updateLoadBalancerService(w http.ResponseWriter, r *http.Request, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+
+	var request schema.LoadBalancerActionUpdateServiceRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	found := false
+	for i, s := range loadBalancer.Services {
+		if s.ListenPort != request.ListenPort {
+			continue
+		}
+		if request.Protocol != nil {
+			loadBalancer.Services[i].Protocol = *request.Protocol
+		}
+		if request.DestinationPort != nil {
+			loadBalancer.Services[i].DestinationPort = *request.DestinationPort
+		}
+		found = true
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("service %d not found", request.ListenPort))
+		return
+	}
+	m.loadBalancers[id] = loadBalancer
+
+	action := m.newAction("update_service", "load_balancer", id)
+	writeJSON(w, http.StatusCreated, schema.LoadBalancerActionUpdateServiceResponse{Action: action})
+}
+
+func (m *MockClient) addLoadBalancerTarget(w http.ResponseWriter, r *http.Request, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+
+	var request schema.LoadBalancerActionAddTargetRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	target := schema.LoadBalancerTarget{Type: request.Type}
+	if request.LabelSelector != nil {
+		target.LabelSelector = &schema.LoadBalancerTargetLabelSelector{Selector: request.LabelSelector.Selector}
+	}
+	if request.Server != nil {
+		target.Server = &schema.LoadBalancerTargetServer{ID: request.Server.ID}
+	}
+	if request.UsePrivateIP != nil {
+		target.UsePrivateIP = *request.UsePrivateIP
+	}
+	loadBalancer.Targets = append(loadBalancer.Targets, target)
+	m.loadBalancers[id] = loadBalancer
+
+	action := m.newAction("add_target", "load_balancer", id)
+	writeJSON(w, http.StatusCreated, schema.LoadBalancerActionAddTargetResponse{Action: action})
+}
+
+func (m *MockClient) removeLoadBalancerTarget(w http.ResponseWriter, r *http.Request, id int) {
+	loadBalancer, ok := m.loadBalancers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+
+	var request schema.LoadBalancerActionRemoveTargetRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	var targets []schema.LoadBalancerTarget
+	for _, target := range loadBalancer.Targets {
+		if target.Type == request.Type {
+			if target.LabelSelector != nil && request.LabelSelector != nil && target.LabelSelector.Selector == request.LabelSelector.Selector {
+				continue
+			}
+			if target.Server != nil && request.Server != nil && target.Server.ID == request.Server.ID {
+				continue
+			}
+		}
+		targets = append(targets, target)
+	}
+	loadBalancer.Targets = targets
+	m.loadBalancers[id] = loadBalancer
+
+	action := m.newAction("remove_target", "load_balancer", id)
+	writeJSON(w, http.StatusCreated, schema.LoadBalancerActionRemoveTargetResponse{Action: action})
+}
+
+func (m *MockClient) deleteLoadBalancer(w http.ResponseWriter, id int) {
+	if _, ok := m.loadBalancers[id]; !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("load balancer %d not found", id))
+		return
+	}
+	delete(m.loadBalancers, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// defaultHealthCheck returns the health check the API configures when none is requested
+func defaultHealthCheck(service schema.LoadBalancerService) *schema.LoadBalancerServiceHealthCheck {
+	return &schema.LoadBalancerServiceHealthCheck{
+		Protocol: "tcp",
+		Port:     service.DestinationPort,
+		Interval: 15,
+		Timeout:  10,
+		Retries:  3,
+	}
+}
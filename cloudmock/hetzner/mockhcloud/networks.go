@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+func (m *MockClient) mockNetworks() {
+	m.handleResource("networks", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listNetworks(w, r)
+		case r.Method == http.MethodGet && action == "":
+			m.getNetwork(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createNetwork(w, r)
+		case r.Method == http.MethodPut && action == "":
+			m.updateNetwork(w, r, id)
+		case r.Method == http.MethodPost && action == "add_subnet":
+			m.addNetworkSubnet(w, r, id)
+		case r.Method == http.MethodDelete && action == "":
+			m.deleteNetwork(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listNetworks(w http.ResponseWriter, r *http.Request) {
+	resp := schema.NetworkListResponse{Networks: []schema.Network{}}
+	for _, network := range m.networks {
+		if matchesQuery(r, network.Name, network.Labels) {
+			resp.Networks = append(resp.Networks, network)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getNetwork(w http.ResponseWriter, id int) {
+	network, ok := m.networks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.NetworkGetResponse{Network: network})
+}
+
+func (m *MockClient) createNetwork(w http.ResponseWriter, r *http.Request) {
+	var create schema.NetworkCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	for _, network := range m.networks {
+		if network.Name == create.Name {
+			writeError(w, http.StatusConflict, "uniqueness_error", fmt.Sprintf("network %q already exists", create.Name))
+			return
+		}
+	}
+
+	network := schema.Network{
+		ID:      m.nextID(),
+		Name:    create.Name,
+		Created: time.Now(),
+		IPRange: create.IPRange,
+		Subnets: create.Subnets,
+		Routes:  create.Routes,
+		Servers: []int{},
+		Labels:  copyLabels(create.Labels),
+	}
+	m.networks[network.ID] = network
+
+	writeJSON(w, http.StatusCreated, schema.NetworkCreateResponse{Network: network})
+}
+
+func (m *MockClient) updateNetwork(w http.ResponseWriter, r *http.Request, id int) {
+	network, ok := m.networks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", id))
+		return
+	}
+
+	var update schema.NetworkUpdateRequest
+	if !decodeJSON(w, r, &update) {
+		return
+	}
+	if update.Name != "" {
+		network.Name = update.Name
+	}
+	if update.Labels != nil {
+		network.Labels = copyLabels(update.Labels)
+	}
+	m.networks[id] = network
+
+	writeJSON(w, http.StatusOK, schema.NetworkUpdateResponse{Network: network})
+}
+
+func (m *MockClient) addNetworkSubnet(w http.ResponseWriter, r *http.Request, id int) {
+	network, ok := m.networks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", id))
+		return
+	}
+
+	var request schema.NetworkActionAddSubnetRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	network.Subnets = append(network.Subnets, schema.NetworkSubnet{
+		Type:        request.Type,
+		IPRange:     request.IPRange,
+		NetworkZone: request.NetworkZone,
+		Gateway:     request.Gateway,
+	})
+	m.networks[id] = network
+
+	action := m.newAction("add_subnet", "network", id)
+	writeJSON(w, http.StatusCreated, schema.NetworkActionAddSubnetResponse{Action: action})
+}
+
+func (m *MockClient) deleteNetwork(w http.ResponseWriter, id int) {
+	network, ok := m.networks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", id))
+		return
+	}
+	if len(network.Servers) > 0 {
+		writeError(w, http.StatusConflict, "resource_in_use", fmt.Sprintf("network %d has attached servers", id))
+		return
+	}
+	delete(m.networks, id)
+	w.WriteHeader(http.StatusNoContent)
+}
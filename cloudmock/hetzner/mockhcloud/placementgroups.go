@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+func (m *MockClient) mockPlacementGroups() {
+	m.handleResource("placement_groups", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listPlacementGroups(w, r)
+		case r.Method == http.MethodGet && action == "":
+			m.getPlacementGroup(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createPlacementGroup(w, r)
+		case r.Method == http.MethodDelete && action == "":
+			m.deletePlacementGroup(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listPlacementGroups(w http.ResponseWriter, r *http.Request) {
+	resp := schema.PlacementGroupListResponse{PlacementGroups: []schema.PlacementGroup{}}
+	for _, placementGroup := range m.placementGroups {
+		if matchesQuery(r, placementGroup.Name, placementGroup.Labels) {
+			resp.PlacementGroups = append(resp.PlacementGroups, placementGroup)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getPlacementGroup(w http.ResponseWriter, id int) {
+	placementGroup, ok := m.placementGroups[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("placement group %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.PlacementGroupGetResponse{PlacementGroup: placementGroup})
+}
+
+func (m *MockClient) createPlacementGroup(w http.ResponseWriter, r *http.Request) {
+	var create schema.PlacementGroupCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	for _, placementGroup := range m.placementGroups {
+		if placementGroup.Name == create.Name {
+			writeError(w, http.StatusConflict, "uniqueness_error", fmt.Sprintf("placement group %q already exists", create.Name))
+			return
+		}
+	}
+
+	placementGroup := schema.PlacementGroup{
+		ID:      m.nextID(),
+		Name:    create.Name,
+		Labels:  copyLabels(create.Labels),
+		Created: time.Now(),
+		Servers: []int{},
+		Type:    create.Type,
+	}
+	m.placementGroups[placementGroup.ID] = placementGroup
+
+	writeJSON(w, http.StatusCreated, schema.PlacementGroupCreateResponse{PlacementGroup: placementGroup})
+}
+
+func (m *MockClient) deletePlacementGroup(w http.ResponseWriter, id int) {
+	placementGroup, ok := m.placementGroups[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("placement group %d not found", id))
+		return
+	}
+	if len(placementGroup.Servers) > 0 {
+		writeError(w, http.StatusConflict, "resource_in_use", fmt.Sprintf("placement group %d still has servers", id))
+		return
+	}
+	delete(m.placementGroups, id)
+	w.WriteHeader(http.StatusNoContent)
+}
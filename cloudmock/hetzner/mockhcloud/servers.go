@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+func (m *MockClient) mockServers() {
+	m.handleResource("servers", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listServers(w, r)
+		case r.Method == http.MethodGet && action == "":
+			m.getServer(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createServer(w, r)
+		case r.Method == http.MethodPut && action == "":
+			m.updateServer(w, r, id)
+		case r.Method == http.MethodDelete && action == "":
+			m.deleteServer(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listServers(w http.ResponseWriter, r *http.Request) {
+	resp := schema.ServerListResponse{Servers: []schema.Server{}}
+	for _, server := range m.servers {
+		if matchesQuery(r, server.Name, server.Labels) {
+			resp.Servers = append(resp.Servers, server)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getServer(w http.ResponseWriter, id int) {
+	server, ok := m.servers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("server %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.ServerGetResponse{Server: server})
+}
+
+func (m *MockClient) createServer(w http.ResponseWriter, r *http.Request) {
+	var create schema.ServerCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	for _, server := range m.servers {
+		if server.Name == create.Name {
+			writeError(w, http.StatusConflict, "uniqueness_error", fmt.Sprintf("server %q already exists", create.Name))
+			return
+		}
+	}
+	serverType, ok := create.ServerType.(string)
+	if !ok || create.Location == "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", "server type and location names are required")
+		return
+	}
+	image, ok := create.Image.(string)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_input", "image name is required")
+		return
+	}
+	for _, sshKeyID := range create.SSHKeys {
+		if _, ok := m.sshKeys[sshKeyID]; !ok {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("ssh key %d not found", sshKeyID))
+			return
+		}
+	}
+
+	server := schema.Server{
+		ID:      m.nextID(),
+		Name:    create.Name,
+		Status:  "running",
+		Created: time.Now(),
+		Datacenter: schema.Datacenter{
+			Name:     create.Location + "-dc1",
+			Location: schema.Location{Name: create.Location},
+		},
+		ServerType: schema.ServerType{Name: serverType},
+		Image:      &schema.Image{Name: &image},
+		Labels:     copyLabels(create.Labels),
+		PrivateNet: []schema.ServerPrivateNet{},
+		Volumes:    []int{},
+	}
+	server.PublicNet = schema.ServerPublicNet{
+		IPv4:        schema.ServerPublicNetIPv4{IP: fmt.Sprintf("198.51.100.%d", server.ID%256)},
+		FloatingIPs: []int{},
+		Firewalls:   []schema.ServerFirewall{},
+	}
+
+	for _, networkID := range create.Networks {
+		network, ok := m.networks[networkID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("network %d not found", networkID))
+			return
+		}
+		_, ipRange, err := net.ParseCIDR(network.IPRange)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid network ip range %q", network.IPRange))
+			return
+		}
+		ip := ipRange.IP.To4()
+		ip[2], ip[3] = byte(server.ID/256), byte(server.ID%256)
+		server.PrivateNet = append(server.PrivateNet, schema.ServerPrivateNet{
+			Network: networkID,
+			IP:      ip.String(),
+		})
+		network.Servers = append(network.Servers, server.ID)
+		m.networks[networkID] = network
+	}
+
+	if create.PlacementGroup != 0 {
+		placementGroup, ok := m.placementGroups[create.PlacementGroup]
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("placement group %d not found", create.PlacementGroup))
+			return
+		}
+		placementGroup.Servers = append(placementGroup.Servers, server.ID)
+		m.placementGroups[placementGroup.ID] = placementGroup
+		server.PlacementGroup = &placementGroup
+	}
+
+	m.servers[server.ID] = server
+
+	action := m.newAction("create_server", "server", server.ID)
+	nextAction := m.newAction("start_server", "server", server.ID)
+	writeJSON(w, http.StatusCreated, schema.ServerCreateResponse{
+		Server:      server,
+		Action:      action,
+		NextActions: []schema.Action{nextAction},
+	})
+}
+
+func (m *MockClient) updateServer(w http.ResponseWriter, r *http.Request, id int) {
+	server, ok := m.servers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("server %d not found", id))
+		return
+	}
+
+	var update schema.ServerUpdateRequest
+	if !decodeJSON(w, r, &update) {
+		return
+	}
+	if update.Name != "" {
+		server.Name = update.Name
+	}
+	if update.Labels != nil {
+		server.Labels = copyLabels(update.Labels)
+	}
+	m.servers[id] = server
+
+	writeJSON(w, http.StatusOK, schema.ServerUpdateResponse{Server: server})
+}
+
+func (m *MockClient) deleteServer(w http.ResponseWriter, id int) {
+	server, ok := m.servers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("server %d not found", id))
+		return
+	}
+
+	for _, privateNet := range server.PrivateNet {
+		if network, ok := m.networks[privateNet.Network]; ok {
+			network.Servers = removeID(network.Servers, id)
+			m.networks[network.ID] = network
+		}
+	}
+	if server.PlacementGroup != nil {
+		if placementGroup, ok := m.placementGroups[server.PlacementGroup.ID]; ok {
+			placementGroup.Servers = removeID(placementGroup.Servers, id)
+			m.placementGroups[placementGroup.ID] = placementGroup
+		}
+	}
+	for volumeID, volume := range m.volumes {
+		if volume.Server != nil && *volume.Server == id {
+			volume.Server = nil
+			m.volumes[volumeID] = volume
+		}
+	}
+	delete(m.servers, id)
+
+	action := m.newAction("delete_server", "server", id)
+	writeJSON(w, http.StatusOK, schema.ActionGetResponse{Action: action})
+}
+
+func removeID(ids []int, id int) []int {
+	var result []int
+	for _, i := range ids {
+		if i != id {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+	"k8s.io/kops/pkg/pki"
+)
+
+func (m *MockClient) mockSSHKeys() {
+	m.handleResource("ssh_keys", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listSSHKeys(w, r)
+		case r.Method == http.MethodGet:
+			m.getSSHKey(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createSSHKey(w, r)
+		case r.Method == http.MethodDelete && id != 0:
+			m.deleteSSHKey(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listSSHKeys(w http.ResponseWriter, r *http.Request) {
+	fingerprint := r.URL.Query().Get("fingerprint")
+
+	resp := schema.SSHKeyListResponse{SSHKeys: []schema.SSHKey{}}
+	for _, sshKey := range m.sshKeys {
+		if fingerprint != "" && sshKey.Fingerprint != fingerprint {
+			continue
+		}
+		if !matchesQuery(r, sshKey.Name, sshKey.Labels) {
+			continue
+		}
+		resp.SSHKeys = append(resp.SSHKeys, sshKey)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getSSHKey(w http.ResponseWriter, id int) {
+	sshKey, ok := m.sshKeys[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("ssh key %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.SSHKeyGetResponse{SSHKey: sshKey})
+}
+
+func (m *MockClient) createSSHKey(w http.ResponseWriter, r *http.Request) {
+	var create schema.SSHKeyCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	fingerprint, err := pki.ComputeOpenSSHKeyFingerprint(create.PublicKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid public key: %v", err))
+		return
+	}
+	for _, sshKey := range m.sshKeys {
+		if sshKey.Name == create.Name || sshKey.Fingerprint == fingerprint {
+			writeError(w, http.StatusConflict, "uniqueness_error", "SSH key not unique")
+			return
+		}
+	}
+
+	sshKey := schema.SSHKey{
+		ID:          m.nextID(),
+		Name:        create.Name,
+		Fingerprint: fingerprint,
+		PublicKey:   create.PublicKey,
+		Labels:      copyLabels(create.Labels),
+		Created:     time.Now(),
+	}
+	m.sshKeys[sshKey.ID] = sshKey
+
+	writeJSON(w, http.StatusCreated, schema.SSHKeyCreateResponse{SSHKey: sshKey})
+}
+
+func (m *MockClient) deleteSSHKey(w http.ResponseWriter, id int) {
+	if _, ok := m.sshKeys[id]; !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("ssh key %d not found", id))
+		return
+	}
+	delete(m.sshKeys, id)
+	w.WriteHeader(http.StatusNoContent)
+}
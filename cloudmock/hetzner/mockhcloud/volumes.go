@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhcloud
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+func (m *MockClient) mockVolumes() {
+	m.handleResource("volumes", func(w http.ResponseWriter, r *http.Request, id int, action string) {
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			m.listVolumes(w, r)
+		case r.Method == http.MethodGet && action == "":
+			m.getVolume(w, id)
+		case r.Method == http.MethodPost && id == 0:
+			m.createVolume(w, r)
+		case r.Method == http.MethodPut && action == "":
+			m.updateVolume(w, r, id)
+		case r.Method == http.MethodPost && action == "resize":
+			m.resizeVolume(w, r, id)
+		case r.Method == http.MethodDelete && action == "":
+			m.deleteVolume(w, id)
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("unsupported request %s %s", r.Method, r.URL.Path))
+		}
+	})
+}
+
+func (m *MockClient) listVolumes(w http.ResponseWriter, r *http.Request) {
+	resp := schema.VolumeListResponse{Volumes: []schema.Volume{}}
+	for _, volume := range m.volumes {
+		if matchesQuery(r, volume.Name, volume.Labels) {
+			resp.Volumes = append(resp.Volumes, volume)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (m *MockClient) getVolume(w http.ResponseWriter, id int) {
+	volume, ok := m.volumes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("volume %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, schema.VolumeGetResponse{Volume: volume})
+}
+
+func (m *MockClient) createVolume(w http.ResponseWriter, r *http.Request) {
+	var create schema.VolumeCreateRequest
+	if !decodeJSON(w, r, &create) {
+		return
+	}
+
+	for _, volume := range m.volumes {
+		if volume.Name == create.Name {
+			writeError(w, http.StatusConflict, "uniqueness_error", fmt.Sprintf("volume %q already exists", create.Name))
+			return
+		}
+	}
+	location, ok := create.Location.(string)
+	if !ok || location == "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", "location is required")
+		return
+	}
+
+	volume := schema.Volume{
+		ID:       m.nextID(),
+		Name:     create.Name,
+		Status:   "available",
+		Location: schema.Location{Name: location},
+		Size:     create.Size,
+		Labels:   copyLabels(create.Labels),
+		Created:  time.Now(),
+	}
+	volume.LinuxDevice = fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", volume.ID)
+	m.volumes[volume.ID] = volume
+
+	action := m.newAction("create_volume", "volume", volume.ID)
+	writeJSON(w, http.StatusCreated, schema.VolumeCreateResponse{
+		Volume:      volume,
+		Action:      &action,
+		NextActions: []schema.Action{},
+	})
+}
+
+func (m *MockClient) updateVolume(w http.ResponseWriter, r *http.Request, id int) {
+	volume, ok := m.volumes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("volume %d not found", id))
+		return
+	}
+
+	var update schema.VolumeUpdateRequest
+	if !decodeJSON(w, r, &update) {
+		return
+	}
+	if update.Name != "" {
+		volume.Name = update.Name
+	}
+	if update.Labels != nil {
+		volume.Labels = copyLabels(update.Labels)
+	}
+	m.volumes[id] = volume
+
+	writeJSON(w, http.StatusOK, schema.VolumeUpdateResponse{Volume: volume})
+}
+
+func (m *MockClient) resizeVolume(w http.ResponseWriter, r *http.Request, id int) {
+	volume, ok := m.volumes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("volume %d not found", id))
+		return
+	}
+
+	var request schema.VolumeActionResizeVolumeRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	if request.Size < volume.Size {
+		writeError(w, http.StatusBadRequest, "invalid_input", "volumes cannot be shrunk")
+		return
+	}
+	volume.Size = request.Size
+	m.volumes[id] = volume
+
+	action := m.newAction("resize_volume", "volume", id)
+	writeJSON(w, http.StatusCreated, schema.VolumeActionResizeVolumeResponse{Action: action})
+}
+
+func (m *MockClient) deleteVolume(w http.ResponseWriter, id int) {
+	volume, ok := m.volumes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("volume %d not found", id))
+		return
+	}
+	if volume.Server != nil {
+		writeError(w, http.StatusConflict, "locked", fmt.Sprintf("volume %d is attached to a server", id))
+		return
+	}
+	delete(m.volumes, id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -35,9 +35,12 @@ import (
 	nodeidentityazure "k8s.io/kops/pkg/nodeidentity/azure"
 	nodeidentitydo "k8s.io/kops/pkg/nodeidentity/do"
 	nodeidentitygce "k8s.io/kops/pkg/nodeidentity/gce"
+	nodeidentityhetzner "k8s.io/kops/pkg/nodeidentity/hetzner"
 	nodeidentityos "k8s.io/kops/pkg/nodeidentity/openstack"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/yaml"
@@ -90,13 +93,17 @@ func main() {
 		var err error
 		if opt.Server.Provider.AWS != nil {
 			verifier, err = awsup.NewAWSVerifier(opt.Server.Provider.AWS)
-			if err != nil {
-				setupLog.Error(err, "unable to create verifier")
-				os.Exit(1)
-			}
+		} else if opt.Server.Provider.GCE != nil {
+			verifier, err = gce.NewGCEVerifier(opt.Server.Provider.GCE)
+		} else if opt.Server.Provider.OpenStack != nil {
+			verifier, err = openstack.NewOpenstackVerifier(opt.Server.Provider.OpenStack)
 		} else {
 			klog.Fatalf("server cloud provider config not provided")
 		}
+		if err != nil {
+			setupLog.Error(err, "unable to create verifier")
+			os.Exit(1)
+		}
 
 		srv, err := server.NewServer(&opt, verifier)
 		if err != nil {
@@ -181,6 +188,12 @@ func addNodeController(mgr manager.Manager, opt *config.Options) error {
 			return fmt.Errorf("error building identifier: %v", err)
 		}
 
+	case "hetzner":
+		legacyIdentifier, err = nodeidentityhetzner.New()
+		if err != nil {
+			return fmt.Errorf("error building identifier: %v", err)
+		}
+
 	case "":
 		return fmt.Errorf("must specify cloud")
 
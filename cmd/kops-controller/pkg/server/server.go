@@ -33,6 +33,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/cmd/kops-controller/pkg/config"
 	"k8s.io/kops/pkg/apis/nodeup"
+	"k8s.io/kops/pkg/certificates"
 	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/pkg/rbac"
 	"k8s.io/kops/upup/pkg/fi"
@@ -105,7 +106,7 @@ func (s *Server) bootstrap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := s.verifier.VerifyToken(r.Header.Get("Authorization"), body)
+	id, err := s.verifier.VerifyToken(r, r.Header.Get("Authorization"), body)
 	if err != nil {
 		klog.Infof("bootstrap %s verify err: %v", r.RemoteAddr, err)
 		w.WriteHeader(http.StatusForbidden)
@@ -150,7 +151,7 @@ func (s *Server) bootstrap(w http.ResponseWriter, r *http.Request) {
 	// expire at different times, but all certificates on a given node expire around the same time.
 	hash := fnv.New32()
 	_, _ = hash.Write([]byte(r.RemoteAddr))
-	validHours := (455 * 24) + (hash.Sum32() % (30 * 24))
+	validHours := uint32(certificates.KopsControllerCertValidity.Hours()) + (hash.Sum32() % uint32(certificates.KopsControllerCertValidityJitter.Hours()))
 
 	for name, pubKey := range req.Certs {
 		cert, err := s.issueCert(name, pubKey, id, validHours, req.KeypairIDs)
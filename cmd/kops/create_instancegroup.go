@@ -149,9 +149,20 @@ func NewCmdCreateInstanceGroup(f *util.Factory, out io.Writer) *cobra.Command {
 }
 
 func RunCreateInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer, options *CreateInstanceGroupOptions) error {
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
-	if err != nil {
-		return fmt.Errorf("error getting cluster: %q: %v", options.ClusterName, err)
+	var cluster *kopsapi.Cluster
+	var err error
+	if options.DryRun {
+		cluster, err = GetCluster(ctx, f, options.ClusterName)
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %q: %v", options.ClusterName, err)
+		}
+	} else {
+		var unlock func()
+		cluster, unlock, err = lockAndGetCluster(ctx, f, options.ClusterName, "kops create instancegroup")
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %q: %v", options.ClusterName, err)
+		}
+		defer unlock()
 	}
 
 	clientset, err := rootCommand.Clientset()
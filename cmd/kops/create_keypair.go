@@ -159,6 +159,12 @@ func RunCreateKeypair(ctx context.Context, f *util.Factory, out io.Writer, optio
 		return fmt.Errorf("error getting clientset: %v", err)
 	}
 
+	unlock, err := lockClusterState(clientSet, cluster, "kops create keypair")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	keyStore, err := clientSet.KeyStore(cluster)
 	if err != nil {
 		return fmt.Errorf("error getting keystore: %v", err)
@@ -113,6 +113,18 @@ func RunDeleteCluster(ctx context.Context, f *util.Factory, out io.Writer, optio
 		if err != nil {
 			return err
 		}
+
+		if options.Yes {
+			clientset, err := f.Clientset()
+			if err != nil {
+				return err
+			}
+			unlock, err := lockClusterState(clientset, cluster, "kops delete cluster")
+			if err != nil {
+				return err
+			}
+			defer unlock()
+		}
 	}
 
 	wouldDeleteCloudResources := false
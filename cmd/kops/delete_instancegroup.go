@@ -130,9 +130,20 @@ func RunDeleteInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer,
 		return fmt.Errorf("GroupName is required")
 	}
 
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
-	if err != nil {
-		return err
+	var cluster *kops.Cluster
+	var err error
+	if options.Yes {
+		var unlock func()
+		cluster, unlock, err = lockAndGetCluster(ctx, f, options.ClusterName, "kops delete instancegroup")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	} else {
+		cluster, err = GetCluster(ctx, f, options.ClusterName)
+		if err != nil {
+			return err
+		}
 	}
 
 	clientset, err := f.Clientset()
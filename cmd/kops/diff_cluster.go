@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	diffClusterLong = templates.LongDesc(i18n.T(`
+	Display the changes made by a revision in the history of a cluster.
+
+	The object written by the revision, either the cluster spec or an instance
+	group spec, is compared with the previous revision of the same object.
+	The revisions are listed by "kops history cluster".`))
+
+	diffClusterExample = templates.Examples(i18n.T(`
+	# Display the changes made by revision 3.
+	kops diff cluster --revision 3 --name k8s-cluster.example.com --state s3://my-state-store
+	`))
+
+	diffClusterShort = i18n.T(`Display the changes made by a revision of a cluster.`)
+)
+
+type DiffClusterOptions struct {
+	ClusterName string
+	Revision    int
+}
+
+// NewCmdDiffCluster returns a diff cluster command.
+func NewCmdDiffCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &DiffClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "cluster [CLUSTER]",
+		Short:             diffClusterShort,
+		Long:              diffClusterLong,
+		Example:           diffClusterExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDiffCluster(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Revision, "revision", options.Revision, "Revision whose changes are displayed")
+	cmd.MarkFlagRequired("revision")
+
+	return cmd
+}
+
+// RunDiffCluster displays the changes made by a revision of a cluster.
+func RunDiffCluster(ctx context.Context, f *util.Factory, out io.Writer, options *DiffClusterOptions) error {
+	_, _, history, err := clusterHistory(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	revision, err := statehistory.Get(history, options.Revision)
+	if err != nil {
+		return err
+	}
+	previous, err := statehistory.Previous(history, revision)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Revision %d: %s %s/%s by %s at %s (kOps %s)\n\n", revision.Revision, revision.Operation, revision.Kind, revision.Name,
+		revision.User, revision.Timestamp.Local().Format(time.RFC3339), revision.KopsVersion)
+
+	switch {
+	case revision.Operation == statehistory.OperationDelete:
+		fmt.Fprintf(out, "%s %q was deleted.\n", revision.Kind, revision.Name)
+	case previous == nil || previous.Operation == statehistory.OperationDelete:
+		fmt.Fprint(out, diff.FormatDiff("", revision.Object))
+	default:
+		fmt.Fprint(out, diff.FormatDiff(previous.Object, revision.Object))
+	}
+	return nil
+}
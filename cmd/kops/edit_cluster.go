@@ -100,10 +100,11 @@ func NewCmdEditCluster(f *util.Factory, out io.Writer) *cobra.Command {
 }
 
 func RunEditCluster(ctx context.Context, f *util.Factory, out io.Writer, options *EditClusterOptions) error {
-	oldCluster, err := GetCluster(ctx, f, options.ClusterName)
+	oldCluster, unlock, err := lockAndGetCluster(ctx, f, options.ClusterName, "kops edit cluster")
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	err = oldCluster.FillDefaults()
 	if err != nil {
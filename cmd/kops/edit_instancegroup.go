@@ -125,10 +125,11 @@ func NewCmdEditInstanceGroup(f *util.Factory, out io.Writer) *cobra.Command {
 func RunEditInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer, options *EditInstanceGroupOptions) error {
 	groupName := options.GroupName
 
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	cluster, unlock, err := lockAndGetCluster(ctx, f, options.ClusterName, "kops edit instancegroup")
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	channel, err := cloudup.ChannelForCluster(cluster)
 	if err != nil {
@@ -283,35 +284,46 @@ func RunEditInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer, o
 }
 
 func updateInstanceGroup(ctx context.Context, clientset simple.Clientset, channel *api.Channel, cluster *api.Cluster, oldGroup, newGroup *api.InstanceGroup) (string, error) {
+	fullGroup, failure, err := populateAndValidateInstanceGroup(clientset, channel, cluster, newGroup)
+	if err != nil || failure != "" {
+		return failure, err
+	}
+
+	// Note we perform as much validation as we can, before writing a bad config
+	_, err = clientset.InstanceGroupsFor(cluster).Update(ctx, fullGroup, metav1.UpdateOptions{})
+	return "", err
+}
+
+// populateAndValidateInstanceGroup populates the spec of the instance group and validates it against the cluster,
+// returning the populated instance group, or a description of the failure if it is not valid.
+func populateAndValidateInstanceGroup(clientset simple.Clientset, channel *api.Channel, cluster *api.Cluster, newGroup *api.InstanceGroup) (*api.InstanceGroup, string, error) {
 	cloud, err := cloudup.BuildCloud(cluster)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
 	fullGroup, err := cloudup.PopulateInstanceGroupSpec(cluster, newGroup, cloud, channel)
 	if err != nil {
-		return fmt.Sprintf("error populating instance group spec: %s", err), nil
+		return nil, fmt.Sprintf("error populating instance group spec: %s", err), nil
 	}
 
 	// We need the full cluster spec to perform deep validation
 	// Note that we don't write it back though
 	err = cloudup.PerformAssignments(cluster, cloud)
 	if err != nil {
-		return "", fmt.Errorf("error populating configuration: %v", err)
+		return nil, "", fmt.Errorf("error populating configuration: %v", err)
 	}
 
 	assetBuilder := assets.NewAssetBuilder(cluster, false)
 	fullCluster, err := cloudup.PopulateClusterSpec(clientset, cluster, cloud, assetBuilder)
 	if err != nil {
-		return fmt.Sprintf("error populating cluster spec: %s", err), nil
+		return nil, fmt.Sprintf("error populating cluster spec: %s", err), nil
 	}
 
 	err = validation.CrossValidateInstanceGroup(fullGroup, fullCluster, cloud).ToAggregate()
 	if err != nil {
-		return fmt.Sprintf("validation failed: %s", err), nil
+		return nil, fmt.Sprintf("validation failed: %s", err), nil
 	}
 
-	// Note we perform as much validation as we can, before writing a bad config
-	_, err = clientset.InstanceGroupsFor(cluster).Update(ctx, fullGroup, metav1.UpdateOptions{})
-	return "", err
+	return fullGroup, "", nil
 }
@@ -108,7 +108,10 @@ func NewCmdGet(f *util.Factory, out io.Writer) *cobra.Command {
 
 	// create subcommands
 	cmd.AddCommand(NewCmdGetAssets(f, out, options))
+	cmd.AddCommand(NewCmdGetCertificates(f, out, options))
 	cmd.AddCommand(NewCmdGetCluster(f, out, options))
+	cmd.AddCommand(NewCmdGetDrift(f, out, options))
+	cmd.AddCommand(NewCmdGetEtcdBackups(f, out, options))
 	cmd.AddCommand(NewCmdGetInstanceGroups(f, out, options))
 	cmd.AddCommand(NewCmdGetKeypairs(f, out, options))
 	cmd.AddCommand(NewCmdGetSecrets(f, out, options))
@@ -0,0 +1,247 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/certificates"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	getCertificatesLong = templates.LongDesc(i18n.T(`
+	Display the certificates of a cluster and when they expire.
+
+	The certificates of the keypairs in the keystore are read from the state store.
+
+	The certificates kops-controller issues to nodes are only stored on the nodes.
+	They are listed for each node of the live cluster, using the kubeconfig context
+	named after the cluster. Their expiry is estimated from the creation of the node
+	and is the earliest time at which they can expire.`))
+
+	getCertificatesExample = templates.Examples(i18n.T(`
+	# List the certificates of a cluster.
+	kops get certificates --name k8s-cluster.example.com
+
+	# Fail if any certificate expires within 90 days.
+	kops get certificates --name k8s-cluster.example.com --expiring-within 2160h`))
+
+	getCertificatesShort = i18n.T(`Get the certificates of a cluster and when they expire.`)
+)
+
+type GetCertificatesOptions struct {
+	*GetOptions
+	Distrusted bool
+	// ExpiringWithin only lists the certificates that expire within the duration, and fails if there are any
+	ExpiringWithin time.Duration
+	// Kubeconfig is the kubeconfig used to list the nodes of the live cluster
+	Kubeconfig string
+	// SkipLive skips the certificates issued to the nodes of the live cluster
+	SkipLive bool
+}
+
+func NewCmdGetCertificates(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := &GetCertificatesOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "certificates",
+		Aliases: []string{"certificate", "certs"},
+		Short:   getCertificatesShort,
+		Long:    getCertificatesLong,
+		Example: getCertificatesExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				return err
+			}
+			options.clusterName = rootCommand.ClusterName(true)
+			if options.clusterName == "" {
+				return fmt.Errorf("--name is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGetCertificates(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Distrusted, "distrusted", options.Distrusted, "Include the certificates of distrusted keypairs")
+	cmd.Flags().DurationVar(&options.ExpiringWithin, "expiring-within", options.ExpiringWithin, "Only list the certificates that expire within this duration, and fail if there are any")
+	cmd.Flags().StringVar(&options.Kubeconfig, "kubeconfig", options.Kubeconfig, "Path to the kubeconfig file used to list the nodes of the live cluster")
+	cmd.Flags().BoolVar(&options.SkipLive, "skip-live", options.SkipLive, "Skip the certificates kops-controller issued to the nodes of the live cluster")
+
+	return cmd
+}
+
+func RunGetCertificates(ctx context.Context, f *util.Factory, out io.Writer, options *GetCertificatesOptions) error {
+	cluster, err := GetCluster(ctx, f, options.clusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	certs, err := certificates.ListKeystoreCertificates(keyStore, options.Distrusted)
+	if err != nil {
+		return err
+	}
+
+	if !options.SkipLive {
+		k8sClient, err := newCertificatesKubernetesClient(cluster, options.Kubeconfig)
+		if err != nil {
+			return err
+		}
+		nodes, err := k8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error listing nodes: %v", err)
+		}
+		issued, err := certificates.ListKopsControllerCertificates(cluster, nodes.Items, keyStore)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, issued...)
+	}
+
+	if options.ExpiringWithin != 0 {
+		deadline := time.Now().Add(options.ExpiringWithin)
+		var expiring []*certificates.Certificate
+		for _, cert := range certs {
+			if cert.ExpiresBefore(deadline) {
+				expiring = append(expiring, cert)
+			}
+		}
+		certs = expiring
+	}
+
+	switch options.output {
+	case OutputTable:
+		if len(certs) == 0 {
+			if options.ExpiringWithin != 0 {
+				klog.Infof("No certificates expire within %v", options.ExpiringWithin)
+				return nil
+			}
+			return fmt.Errorf("no certificates found")
+		}
+		if err := certificatesOutputTable(certs, out); err != nil {
+			return err
+		}
+	case OutputYaml:
+		y, err := yaml.Marshal(certs)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		if certs == nil {
+			certs = []*certificates.Certificate{}
+		}
+		j, err := json.Marshal(certs)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", options.output)
+	}
+
+	if options.ExpiringWithin != 0 && len(certs) != 0 {
+		return fmt.Errorf("%d certificate(s) expire within %v", len(certs), options.ExpiringWithin)
+	}
+	return nil
+}
+
+// newCertificatesKubernetesClient builds a client for the live cluster, using the kubeconfig context named after the cluster
+func newCertificatesKubernetesClient(cluster *kopsapi.Cluster, kubeconfig string) (kubernetes.Interface, error) {
+	contextName := cluster.ObjectMeta.Name
+	configLoadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		configLoadingRules.ExplicitPath = kubeconfig
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		configLoadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
+	}
+	return k8sClient, nil
+}
+
+func certificatesOutputTable(certs []*certificates.Certificate, out io.Writer) error {
+	t := &tables.Table{}
+	t.AddColumn("SOURCE", func(c *certificates.Certificate) string {
+		return c.Source
+	})
+	t.AddColumn("NAME", func(c *certificates.Certificate) string {
+		return c.Name
+	})
+	t.AddColumn("ID", func(c *certificates.Certificate) string {
+		if c.Node != "" {
+			return c.Node
+		}
+		return c.ID
+	})
+	t.AddColumn("SUBJECT", func(c *certificates.Certificate) string {
+		return c.Subject
+	})
+	t.AddColumn("ISSUER", func(c *certificates.Certificate) string {
+		return c.Issuer
+	})
+	t.AddColumn("STATUS", func(c *certificates.Certificate) string {
+		return c.Status
+	})
+	t.AddColumn("EXPIRES", func(c *certificates.Certificate) string {
+		expires := c.NotAfter.Local().Format("2006-01-02")
+		if c.Estimated {
+			expires = "~" + expires
+		}
+		return expires
+	})
+
+	return t.Render(certs, out, "SOURCE", "NAME", "ID", "SUBJECT", "ISSUER", "STATUS", "EXPIRES")
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/drift"
+	resourceops "k8s.io/kops/pkg/resources/ops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+type GetDriftOptions struct {
+	*GetOptions
+}
+
+var (
+	getDriftLong = templates.LongDesc(i18n.T(`
+	Display the drift between the cluster spec and the cloud.
+
+	Resources are reported as Changed if they differ from the cluster spec, Missing if they
+	do not exist, and Orphaned if they are owned by the cluster but are no longer part of its spec.
+	No changes are made to the cloud.
+
+	Resources created by controllers running in the cluster, such as load balancers for
+	Services of type LoadBalancer, are not part of the cluster spec and are also reported as Orphaned.`))
+
+	getDriftExample = templates.Examples(i18n.T(`
+	# Display the drift of a cluster.
+	kops get drift --name k8s-cluster.example.com
+
+	# Display the drift of a cluster in JSON, e.g. for a dashboard.
+	kops get drift --name k8s-cluster.example.com -o json
+	`))
+
+	getDriftShort = i18n.T(`Display drift between the cluster spec and the cloud.`)
+)
+
+func NewCmdGetDrift(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetDriftOptions{
+		GetOptions: getOptions,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "drift",
+		Short:   getDriftShort,
+		Long:    getDriftLong,
+		Example: getDriftExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			err := RunGetDrift(ctx, f, out, &options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func RunGetDrift(ctx context.Context, f *util.Factory, out io.Writer, options *GetDriftOptions) error {
+	clusterName := rootCommand.ClusterName(true)
+	options.clusterName = clusterName
+	if clusterName == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	updateClusterResults, err := RunUpdateCluster(ctx, f, io.Discard, &UpdateClusterOptions{
+		Target:      cloudup.TargetDryRun,
+		ClusterName: clusterName,
+		DryRunOut:   io.Discard,
+	})
+	if err != nil {
+		return err
+	}
+
+	target, ok := updateClusterResults.Target.(*fi.DryRunTarget)
+	if !ok {
+		return fmt.Errorf("unexpected target type %T", updateClusterResults.Target)
+	}
+	changes, err := target.TaskChanges(updateClusterResults.TaskMap)
+	if err != nil {
+		return err
+	}
+
+	cloud, err := cloudup.BuildCloud(updateClusterResults.Cluster)
+	if err != nil {
+		return err
+	}
+	cloudResources, err := resourceops.ListResources(cloud, updateClusterResults.Cluster, "")
+	if err != nil {
+		return fmt.Errorf("error listing cloud resources: %v", err)
+	}
+
+	report := drift.BuildReport(changes, updateClusterResults.TaskMap, cloudResources)
+
+	switch options.output {
+	case OutputTable:
+		if len(report) == 0 {
+			fmt.Fprintf(out, "No drift found\n")
+			return nil
+		}
+		return driftOutputTable(report, out)
+	case OutputYaml:
+		y, err := yaml.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		if report == nil {
+			report = []*drift.Drift{}
+		}
+		j, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", options.output)
+	}
+
+	return nil
+}
+
+func driftOutputTable(report []*drift.Drift, out io.Writer) error {
+	t := &tables.Table{}
+	t.AddColumn("STATUS", func(d *drift.Drift) string {
+		return string(d.Status)
+	})
+	t.AddColumn("TYPE", func(d *drift.Drift) string {
+		return d.Type
+	})
+	t.AddColumn("NAME", func(d *drift.Drift) string {
+		return d.Name
+	})
+	t.AddColumn("ID", func(d *drift.Drift) string {
+		return d.ID
+	})
+	t.AddColumn("FIELDS", func(d *drift.Drift) string {
+		var fields []string
+		for _, field := range d.Fields {
+			fields = append(fields, field.Field)
+		}
+		return strings.Join(fields, ",")
+	})
+
+	return t.Render(report, out, "STATUS", "TYPE", "NAME", "ID", "FIELDS")
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/etcdbackup"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kops/util/pkg/vfs"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+type GetEtcdBackupsOptions struct {
+	*GetOptions
+
+	// EtcdCluster is the name of the etcd cluster whose backups are listed; all etcd clusters if empty
+	EtcdCluster string
+	// Verify reads the snapshot of each backup to check that it is not corrupt
+	Verify bool
+	// MaxAge reports the backups of an etcd cluster as stale if the newest is older; 0 disables the check
+	MaxAge time.Duration
+	// Watch repeats the verification every Interval, reporting problems, until interrupted
+	Watch    bool
+	Interval time.Duration
+}
+
+var (
+	getEtcdBackupsLong = templates.LongDesc(i18n.T(`
+	Display the backups of the etcd clusters, read from their backup stores.
+
+	With --verify, the snapshot of each backup is read and decompressed to check that it is not corrupt,
+	and the command fails if any backup is corrupt or, with --max-age, if the newest backup of an
+	etcd cluster is stale.
+
+	With --watch, the backups are verified every --interval and problems are reported as warnings
+	until the command is interrupted.`))
+
+	getEtcdBackupsExample = templates.Examples(i18n.T(`
+	# List the backups of all etcd clusters.
+	kops get etcd-backups --name k8s-cluster.example.com
+
+	# Verify the backups of the main etcd cluster, failing if the newest is more than a day old.
+	kops get etcd-backups --name k8s-cluster.example.com --etcd-cluster main --verify --max-age 24h
+
+	# Verify the backups every hour.
+	kops get etcd-backups --name k8s-cluster.example.com --watch --interval 1h --max-age 24h
+	`))
+
+	getEtcdBackupsShort = i18n.T(`Display etcd backups.`)
+)
+
+func NewCmdGetEtcdBackups(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetEtcdBackupsOptions{
+		GetOptions: getOptions,
+		Interval:   time.Hour,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "etcd-backups",
+		Aliases: []string{"etcd-backup"},
+		Short:   getEtcdBackupsShort,
+		Long:    getEtcdBackupsLong,
+		Example: getEtcdBackupsExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				return err
+			}
+			options.clusterName = rootCommand.ClusterName(true)
+			if options.clusterName == "" {
+				return fmt.Errorf("--name is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGetEtcdBackups(context.TODO(), f, out, &options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.EtcdCluster, "etcd-cluster", options.EtcdCluster, "Name of the etcd cluster, e.g. main or events; all etcd clusters if not set")
+	cmd.RegisterFlagCompletionFunc("etcd-cluster", completeEtcdClusterName)
+	cmd.Flags().BoolVar(&options.Verify, "verify", options.Verify, "Read each backup to check that it is not corrupt")
+	cmd.Flags().DurationVar(&options.MaxAge, "max-age", options.MaxAge, "Report the backups as stale if the newest backup of an etcd cluster is older than this")
+	cmd.Flags().BoolVar(&options.Watch, "watch", options.Watch, "Verify the backups every interval until interrupted")
+	cmd.Flags().DurationVar(&options.Interval, "interval", options.Interval, "Interval between verifications with --watch")
+
+	return cmd
+}
+
+// etcdBackupStore is the backup store of an etcd cluster
+type etcdBackupStore struct {
+	etcdCluster string
+	store       vfs.Path
+}
+
+func RunGetEtcdBackups(ctx context.Context, f commandutils.Factory, out io.Writer, options *GetEtcdBackupsOptions) error {
+	if options.Watch && options.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	cluster, err := GetCluster(ctx, f, options.clusterName)
+	if err != nil {
+		return err
+	}
+
+	stores, err := etcdBackupStores(cluster, options.EtcdCluster)
+	if err != nil {
+		return err
+	}
+
+	if options.Watch {
+		for {
+			_, problems, err := listEtcdBackups(stores, true, options.MaxAge)
+			if err != nil {
+				klog.Warningf("error listing etcd backups: %v", err)
+			} else if len(problems) == 0 {
+				klog.Infof("Verified etcd backups of cluster %q", cluster.ObjectMeta.Name)
+			}
+			for _, problem := range problems {
+				klog.Warningf("%s", problem)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(options.Interval):
+			}
+		}
+	}
+
+	backups, problems, err := listEtcdBackups(stores, options.Verify, options.MaxAge)
+	if err != nil {
+		return err
+	}
+
+	switch options.output {
+	case OutputTable:
+		if len(backups) == 0 {
+			fmt.Fprintf(out, "No etcd backups found\n")
+		} else if err := etcdBackupsOutputTable(backups, out); err != nil {
+			return err
+		}
+	case OutputYaml:
+		y, err := yaml.Marshal(backups)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		if backups == nil {
+			backups = []*etcdbackup.Backup{}
+		}
+		j, err := json.Marshal(backups)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", options.output)
+	}
+
+	if options.Verify && len(problems) != 0 {
+		for _, problem := range problems {
+			klog.Warningf("%s", problem)
+		}
+		return fmt.Errorf("found %d problem(s) with etcd backups", len(problems))
+	}
+	return nil
+}
+
+// etcdBackupStores returns the backup stores of the etcd clusters, or of the named etcd cluster
+func etcdBackupStores(cluster *kops.Cluster, etcdClusterName string) ([]etcdBackupStore, error) {
+	var stores []etcdBackupStore
+	for i := range cluster.Spec.EtcdClusters {
+		etcdCluster := &cluster.Spec.EtcdClusters[i]
+		if etcdClusterName != "" && etcdCluster.Name != etcdClusterName {
+			continue
+		}
+		store, err := etcdbackup.BackupStore(cluster, etcdCluster)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, etcdBackupStore{etcdCluster: etcdCluster.Name, store: store})
+	}
+	if len(stores) == 0 && etcdClusterName != "" {
+		return nil, fmt.Errorf("etcd cluster %q not found", etcdClusterName)
+	}
+	return stores, nil
+}
+
+// listEtcdBackups lists, and optionally verifies, the backups in the backup stores, returning descriptions of their problems
+func listEtcdBackups(stores []etcdBackupStore, verify bool, maxAge time.Duration) ([]*etcdbackup.Backup, []string, error) {
+	var backups []*etcdbackup.Backup
+	var problems []string
+	now := time.Now()
+	for _, s := range stores {
+		storeBackups, err := etcdbackup.ListBackups(s.etcdCluster, s.store)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, backup := range storeBackups {
+			if verify {
+				etcdbackup.VerifyBackup(s.store, backup)
+			}
+			if backup.Status == etcdbackup.StatusCorrupt {
+				problems = append(problems, fmt.Sprintf("etcd cluster %q: backup %s is corrupt: %s", s.etcdCluster, backup.Name, backup.Problem))
+			}
+		}
+		if maxAge > 0 {
+			if problem := etcdbackup.StaleProblem(storeBackups, maxAge, now); problem != "" {
+				problems = append(problems, fmt.Sprintf("etcd cluster %q: backups are stale: %s", s.etcdCluster, problem))
+			}
+		}
+		backups = append(backups, storeBackups...)
+	}
+	return backups, problems, nil
+}
+
+func etcdBackupsOutputTable(backups []*etcdbackup.Backup, out io.Writer) error {
+	t := &tables.Table{}
+	t.AddColumn("ETCD-CLUSTER", func(b *etcdbackup.Backup) string {
+		return b.EtcdCluster
+	})
+	t.AddColumn("BACKUP", func(b *etcdbackup.Backup) string {
+		return b.Name
+	})
+	t.AddColumn("TIMESTAMP", func(b *etcdbackup.Backup) string {
+		if b.Timestamp.IsZero() {
+			return ""
+		}
+		return b.Timestamp.Format(time.RFC3339)
+	})
+	t.AddColumn("ETCD-VERSION", func(b *etcdbackup.Backup) string {
+		return b.EtcdVersion
+	})
+	t.AddColumn("SIZE", func(b *etcdbackup.Backup) string {
+		if b.Size == 0 {
+			return ""
+		}
+		return strconv.FormatInt(b.Size, 10)
+	})
+	t.AddColumn("STATUS", func(b *etcdbackup.Backup) string {
+		return string(b.Status)
+	})
+	t.AddColumn("PROBLEM", func(b *etcdbackup.Backup) string {
+		return b.Problem
+	})
+
+	return t.Render(backups, out, "ETCD-CLUSTER", "BACKUP", "TIMESTAMP", "ETCD-VERSION", "SIZE", "STATUS", "PROBLEM")
+}
+
+func completeEtcdClusterName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	commandutils.ConfigureKlogForCompletion()
+	ctx := context.TODO()
+
+	cluster, _, completions, directive := GetClusterForCompletion(ctx, &rootCommand, nil)
+	if cluster == nil {
+		return completions, directive
+	}
+
+	var names []string
+	for _, etcdCluster := range cluster.Spec.EtcdClusters {
+		names = append(names, etcdCluster.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kops/util/pkg/vfs"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	historyClusterLong = templates.LongDesc(i18n.T(`
+	Display the history of the changes to the cluster and instance group specs.
+
+	Each write of the cluster spec or of an instance group spec to the state store,
+	e.g. by "kops edit" or "kops replace", is recorded as a revision, with the time,
+	the user and the version of kOps that made it. The changes made by a revision
+	are shown by "kops diff cluster", and "kops rollback cluster" restores an object
+	to a revision.`))
+
+	historyClusterExample = templates.Examples(i18n.T(`
+	# Display the history of a cluster.
+	kops history cluster --name k8s-cluster.example.com --state s3://my-state-store
+	`))
+
+	historyClusterShort = i18n.T(`Display the history of the changes to a cluster.`)
+)
+
+type HistoryClusterOptions struct {
+	ClusterName string
+	Output      string
+}
+
+// NewCmdHistoryCluster returns a history cluster command.
+func NewCmdHistoryCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &HistoryClusterOptions{
+		Output: OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "cluster [CLUSTER]",
+		Short:             historyClusterShort,
+		Long:              historyClusterLong,
+		Example:           historyClusterExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunHistoryCluster(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format. One of table, json, or yaml")
+
+	return cmd
+}
+
+// RunHistoryCluster displays the history of the changes to a cluster.
+func RunHistoryCluster(ctx context.Context, f *util.Factory, out io.Writer, options *HistoryClusterOptions) error {
+	_, _, history, err := clusterHistory(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := statehistory.List(history)
+	if err != nil {
+		return err
+	}
+
+	switch options.Output {
+	case OutputTable:
+		if len(revisions) == 0 {
+			fmt.Fprintf(out, "No changes to the cluster have been recorded.\n")
+			return nil
+		}
+		t := &tables.Table{}
+		t.AddColumn("REVISION", func(r *statehistory.Revision) string {
+			return fmt.Sprintf("%d", r.Revision)
+		})
+		t.AddColumn("TIME", func(r *statehistory.Revision) string {
+			return r.Timestamp.Local().Format(time.RFC3339)
+		})
+		t.AddColumn("USER", func(r *statehistory.Revision) string {
+			return r.User
+		})
+		t.AddColumn("KOPS", func(r *statehistory.Revision) string {
+			return r.KopsVersion
+		})
+		t.AddColumn("OPERATION", func(r *statehistory.Revision) string {
+			return string(r.Operation)
+		})
+		t.AddColumn("OBJECT", func(r *statehistory.Revision) string {
+			return r.Kind + "/" + r.Name
+		})
+		t.AddColumn("HASH", func(r *statehistory.Revision) string {
+			return r.ShortHash()
+		})
+		return t.Render(revisions, out, "REVISION", "TIME", "USER", "KOPS", "OPERATION", "OBJECT", "HASH")
+	case OutputYaml:
+		y, err := yaml.Marshal(revisions)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.MarshalIndent(revisions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", options.Output)
+	}
+	return nil
+}
+
+// clusterHistory returns the cluster, the clientset and the path of the history of the cluster
+func clusterHistory(ctx context.Context, f *util.Factory, clusterName string) (*kopsapi.Cluster, simple.Clientset, vfs.Path, error) {
+	cluster, err := GetCluster(ctx, f, clusterName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	history, err := statehistory.PathForCluster(clientset, cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cluster, clientset, history, nil
+}
@@ -28,14 +28,17 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	mathrand "math/rand"
 	"os"
 	"path"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/ssh"
 	"k8s.io/kops/cmd/kops/util"
 	"k8s.io/kops/pkg/apis/kops/model"
@@ -48,6 +51,7 @@ import (
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup"
 	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/resourcegraph"
 	"sigs.k8s.io/yaml"
 )
 
@@ -176,6 +180,7 @@ func (i *integrationTest) withAddons(addons ...string) *integrationTest {
 func TestMinimal(t *testing.T) {
 	newIntegrationTest("minimal.example.com", "minimal").runTestTerraformAWS(t)
 	newIntegrationTest("minimal.example.com", "minimal").runTestCloudformation(t)
+	newIntegrationTest("minimal.example.com", "minimal").runTestResourceGraph(t)
 }
 
 // TestMinimal runs the test on a minimum gossip configuration
@@ -193,6 +198,32 @@ func TestMinimalGCEPrivate(t *testing.T) {
 	newIntegrationTest("minimal-gce-private.example.com", "minimal_gce_private").runTestTerraformGCE(t)
 }
 
+// TestMinimalOpenstack runs tests on a minimal Openstack configuration
+func TestMinimalOpenstack(t *testing.T) {
+	newIntegrationTest("minimal-openstack.k8s.local", "minimal_openstack").runTestTerraformOpenstack(t)
+}
+
+// TestFloatingIPOpenstack runs tests on an Openstack configuration with a router and floating IPs
+func TestFloatingIPOpenstack(t *testing.T) {
+	newIntegrationTest("floatingip-openstack.k8s.local", "openstack_floatingip").runTestTerraformOpenstack(t)
+}
+
+// TestMinimalDO runs tests on a minimal DigitalOcean configuration
+func TestMinimalDO(t *testing.T) {
+	newIntegrationTest("minimal-do.k8s.local", "minimal_do").runTestTerraformDO(t)
+}
+
+// TestMinimalAzure runs tests on a minimal Azure configuration
+func TestMinimalAzure(t *testing.T) {
+	featureflag.ParseFlags("+Azure")
+	unsetFeatureFlags := func() {
+		featureflag.ParseFlags("-Azure")
+	}
+	defer unsetFeatureFlags()
+
+	newIntegrationTest("minimal-azure.k8s.local", "minimal_azure").runTestTerraformAzure(t)
+}
+
 // TestHA runs the test on a simple HA configuration, similar to kops create cluster minimal.example.com --zones us-west-1a,us-west-1b,us-west-1c --master-count=3
 func TestHA(t *testing.T) {
 	newIntegrationTest("ha.example.com", "ha").withZones(3).runTestTerraformAWS(t)
@@ -1031,6 +1062,123 @@ func (i *integrationTest) runTestTerraformGCE(t *testing.T) {
 	i.runTest(t, h, expectedFilenames, "", "", nil)
 }
 
+func (i *integrationTest) runTestTerraformOpenstack(t *testing.T) {
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+
+	origRegion := os.Getenv("OS_REGION_NAME")
+	os.Setenv("OS_REGION_NAME", "us-test1")
+	defer func() {
+		os.Setenv("OS_REGION_NAME", origRegion)
+	}()
+
+	// The mock cloud generates random IDs, which are rendered for the external network
+	uuid.SetRand(mathrand.New(mathrand.NewSource(0)))
+	defer uuid.SetRand(nil)
+
+	h.MockKopsVersion("1.21.0-alpha.1")
+	testutils.SetupMockOpenstack()
+
+	expectedFilenames := i.expectTerraformFilenames
+
+	safeClusterName := strings.Replace(i.clusterName, ".", "-", -1)
+	expectedFilenames = append(expectedFilenames,
+		"openstack_compute_instance_v2_master-us-test1-a-1-"+safeClusterName+"_user_data",
+		"openstack_compute_instance_v2_nodes-1-"+safeClusterName+"_user_data",
+		"openstack_compute_instance_v2_nodes-2-"+safeClusterName+"_user_data",
+		"openstack_compute_keypair_v2_kubernetes-"+safeClusterName+"-c4_a6_ed_9a_a8_89_b9_e2_c3_9c_d6_63_eb_9c_71_57_public_key",
+		"aws_s3_bucket_object_cluster-completed.spec_content",
+		"aws_s3_bucket_object_etcd-cluster-spec-events_content",
+		"aws_s3_bucket_object_etcd-cluster-spec-main_content",
+		"aws_s3_bucket_object_kops-version.txt_content",
+		"aws_s3_bucket_object_manifests-etcdmanager-events_content",
+		"aws_s3_bucket_object_manifests-etcdmanager-main_content",
+		"aws_s3_bucket_object_manifests-static-kube-apiserver-healthcheck_content",
+		"aws_s3_bucket_object_nodeupconfig-master-us-test1-a_content",
+		"aws_s3_bucket_object_nodeupconfig-nodes_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-bootstrap_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-core.addons.k8s.io_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-coredns.addons.k8s.io-k8s-1.12_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-dns-controller.addons.k8s.io-k8s-1.12_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-kops-controller.addons.k8s.io-k8s-1.16_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-kubelet-api.rbac.addons.k8s.io-k8s-1.9_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-limit-range.addons.k8s.io_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-openstack.addons.k8s.io-k8s-1.13-ccm_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-rbac.addons.k8s.io-k8s-1.8_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-storage-openstack.addons.k8s.io-k8s-1.16_content")
+
+	i.runTest(t, h, expectedFilenames, "", "", nil)
+}
+
+func (i *integrationTest) runTestTerraformDO(t *testing.T) {
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+
+	h.MockKopsVersion("1.21.0-alpha.1")
+	h.SetupMockDO()
+
+	expectedFilenames := i.expectTerraformFilenames
+
+	expectedFilenames = append(expectedFilenames,
+		"digitalocean_droplet_master-nyc1-1.masters."+i.clusterName+"_user_data",
+		"digitalocean_droplet_nodes."+i.clusterName+"_user_data",
+		"aws_s3_bucket_object_cluster-completed.spec_content",
+		"aws_s3_bucket_object_etcd-cluster-spec-events_content",
+		"aws_s3_bucket_object_etcd-cluster-spec-main_content",
+		"aws_s3_bucket_object_kops-version.txt_content",
+		"aws_s3_bucket_object_manifests-etcdmanager-events_content",
+		"aws_s3_bucket_object_manifests-etcdmanager-main_content",
+		"aws_s3_bucket_object_manifests-static-kube-apiserver-healthcheck_content",
+		"aws_s3_bucket_object_nodeupconfig-master-nyc1-1_content",
+		"aws_s3_bucket_object_nodeupconfig-nodes_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-bootstrap_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-core.addons.k8s.io_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-coredns.addons.k8s.io-k8s-1.12_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-digitalocean-cloud-controller.addons.k8s.io-k8s-1.8_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-dns-controller.addons.k8s.io-k8s-1.12_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-kops-controller.addons.k8s.io-k8s-1.16_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-kubelet-api.rbac.addons.k8s.io-k8s-1.9_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-limit-range.addons.k8s.io_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-rbac.addons.k8s.io-k8s-1.8_content")
+
+	i.runTest(t, h, expectedFilenames, "", "", nil)
+}
+
+func (i *integrationTest) runTestTerraformAzure(t *testing.T) {
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+
+	h.MockKopsVersion("1.21.0-alpha.1")
+	h.SetupMockAzure()
+
+	expectedFilenames := i.expectTerraformFilenames
+
+	expectedFilenames = append(expectedFilenames,
+		"azurerm_linux_virtual_machine_scale_set_master-eastus-1.masters."+i.clusterName+"_custom_data",
+		"azurerm_linux_virtual_machine_scale_set_master-eastus-1.masters."+i.clusterName+"_public_key",
+		"azurerm_linux_virtual_machine_scale_set_nodes."+i.clusterName+"_custom_data",
+		"azurerm_linux_virtual_machine_scale_set_nodes."+i.clusterName+"_public_key",
+		"aws_s3_bucket_object_cluster-completed.spec_content",
+		"aws_s3_bucket_object_etcd-cluster-spec-events_content",
+		"aws_s3_bucket_object_etcd-cluster-spec-main_content",
+		"aws_s3_bucket_object_kops-version.txt_content",
+		"aws_s3_bucket_object_manifests-etcdmanager-events_content",
+		"aws_s3_bucket_object_manifests-etcdmanager-main_content",
+		"aws_s3_bucket_object_manifests-static-kube-apiserver-healthcheck_content",
+		"aws_s3_bucket_object_nodeupconfig-master-eastus-1_content",
+		"aws_s3_bucket_object_nodeupconfig-nodes_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-bootstrap_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-core.addons.k8s.io_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-coredns.addons.k8s.io-k8s-1.12_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-dns-controller.addons.k8s.io-k8s-1.12_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-kops-controller.addons.k8s.io-k8s-1.16_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-kubelet-api.rbac.addons.k8s.io-k8s-1.9_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-limit-range.addons.k8s.io_content",
+		"aws_s3_bucket_object_"+i.clusterName+"-addons-rbac.addons.k8s.io-k8s-1.8_content")
+
+	i.runTest(t, h, expectedFilenames, "", "", nil)
+}
+
 func (i *integrationTest) runTestCloudformation(t *testing.T) {
 	ctx := context.Background()
 
@@ -1150,6 +1298,59 @@ func (i *integrationTest) runTestCloudformation(t *testing.T) {
 	}
 }
 
+func (i *integrationTest) runTestResourceGraph(t *testing.T) {
+	ctx := context.Background()
+
+	i.srcDir = updateClusterTestBase + i.srcDir
+	var stdout bytes.Buffer
+
+	inputYAML := "in-" + i.version + ".yaml"
+	expectedGraphPath := "resourcegraph.json"
+
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+
+	h.MockKopsVersion("1.21.0-alpha.1")
+	h.SetupMockAWS()
+
+	factory := i.setupCluster(t, inputYAML, ctx, stdout)
+
+	{
+		options := &UpdateClusterOptions{}
+		options.InitDefaults()
+		options.Target = cloudup.TargetResourceGraph
+		options.OutDir = path.Join(h.TempDir, "out")
+		options.RunTasksOptions.MaxTaskDuration = 30 * time.Second
+
+		// We don't test it here, and it adds a dependency on kubectl
+		options.CreateKubecfg = false
+		options.ClusterName = i.clusterName
+		options.LifecycleOverrides = i.lifecycleOverrides
+
+		_, err := RunUpdateCluster(ctx, factory, &stdout, options)
+		if err != nil {
+			t.Fatalf("error running update cluster %q: %v", i.clusterName, err)
+		}
+	}
+
+	actualGraph, err := ioutil.ReadFile(path.Join(h.TempDir, "out", resourcegraph.OutputFile))
+	if err != nil {
+		t.Fatalf("unexpected error reading actual resource graph output: %v", err)
+	}
+	golden.AssertMatchesFile(t, string(actualGraph), path.Join(i.srcDir, expectedGraphPath))
+
+	// Every file referenced by the resource graph must have been written
+	var graph resourcegraph.ResourceGraph
+	if err := json.Unmarshal(actualGraph, &graph); err != nil {
+		t.Fatalf("error parsing resource graph: %v", err)
+	}
+	for _, file := range regexp.MustCompile(`"\$file":\s*"([^"]+)"`).FindAllStringSubmatch(string(actualGraph), -1) {
+		if _, err := os.Stat(path.Join(h.TempDir, "out", file[1])); err != nil {
+			t.Errorf("file %q referenced by the resource graph was not written: %v", file[1], err)
+		}
+	}
+}
+
 func MakeSSHKeyPair(publicKeyPath string, privateKeyPath string) error {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
 	if err != nil {
@@ -87,6 +87,14 @@ func TestLifecycleMinimalGCE(t *testing.T) {
 	})
 }
 
+func TestLifecycleMinimalHetzner(t *testing.T) {
+	runLifecycleTestHetzner(&LifecycleTestOptions{
+		t:           t,
+		SrcDir:      "minimal_hetzner",
+		ClusterName: "minimal-hetzner.k8s.local",
+	})
+}
+
 func TestLifecycleFloatingIPOpenstack(t *testing.T) {
 	runLifecycleTestOpenstack(&LifecycleTestOptions{
 		t:           t,
@@ -514,6 +522,59 @@ func runLifecycleTestGCE(o *LifecycleTestOptions) {
 	}
 }
 
+func runLifecycleTestHetzner(o *LifecycleTestOptions) {
+	o.AddDefaults()
+
+	t := o.t
+
+	h := testutils.NewIntegrationTestHarness(o.t)
+	defer h.Close()
+
+	h.MockKopsVersion("1.21.0-alpha.1")
+
+	featureflag.ParseFlags("+Hetzner")
+	defer featureflag.ParseFlags("-Hetzner")
+
+	cloud := h.SetupMockHetzner()
+
+	var beforeIds []string
+	for id := range cloud.All() {
+		beforeIds = append(beforeIds, id)
+	}
+	sort.Strings(beforeIds)
+
+	ctx := context.Background()
+
+	t.Logf("running lifecycle test for cluster %s", o.ClusterName)
+
+	var stdout bytes.Buffer
+	inputYAML := "in-" + o.Version + ".yaml"
+
+	factory := newIntegrationTest(o.ClusterName, o.SrcDir).
+		setupCluster(t, inputYAML, ctx, stdout)
+
+	updateEnsureNoChanges(ctx, t, factory, o.ClusterName, stdout)
+
+	{
+		options := &DeleteClusterOptions{}
+		options.Yes = true
+		options.ClusterName = o.ClusterName
+		if err := RunDeleteCluster(ctx, factory, &stdout, options); err != nil {
+			t.Fatalf("error running delete cluster %q: %v", o.ClusterName, err)
+		}
+	}
+
+	var afterIds []string
+	for id := range cloud.All() {
+		afterIds = append(afterIds, id)
+	}
+	sort.Strings(afterIds)
+
+	if !reflect.DeepEqual(beforeIds, afterIds) {
+		t.Fatalf("resources changed by cluster create / destroy: %v -> %v", beforeIds, afterIds)
+	}
+}
+
 func updateEnsureNoChanges(ctx context.Context, t *testing.T, factory *util.Factory, clusterName string, stdout bytes.Buffer) {
 	t.Helper()
 	options := &UpdateClusterOptions{}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/featureflag"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	lockShort = i18n.T(`Inspect or release the lock on the cluster state.`)
+)
+
+func NewCmdLock(f *util.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: lockShort,
+	}
+
+	// create subcommands
+	cmd.AddCommand(NewCmdLockRelease(f, out))
+	cmd.AddCommand(NewCmdLockStatus(f, out))
+
+	return cmd
+}
+
+// lockClusterState acquires the lock on the state of the cluster for a command that mutates it,
+// returning a function that releases the lock.
+func lockClusterState(clientset simple.Clientset, cluster *kopsapi.Cluster, command string) (func(), error) {
+	if !featureflag.StateStoreLocking.Enabled() {
+		return func() {}, nil
+	}
+	// The lock is a file in the state store, so only VFS state stores support it
+	if _, ok := clientset.(*vfsclientset.VFSClientset); !ok {
+		return func() {}, nil
+	}
+
+	p, err := statelock.PathForCluster(clientset, cluster)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := statelock.Acquire(p, command, statelock.DefaultLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := lock.Release(); err != nil {
+			klog.Warningf("error releasing the lock on the cluster state: %v", err)
+		}
+	}, nil
+}
+
+// lockAndGetCluster acquires the lock on the state of the cluster for a command that mutates it, then reads the cluster,
+// so that the cluster cannot be changed by another command between the read and the write.
+// The cluster is also read before the lock is acquired, as the lock is stored in its ConfigBase.
+func lockAndGetCluster(ctx context.Context, factory commandutils.Factory, clusterName string, command string) (*kopsapi.Cluster, func(), error) {
+	cluster, err := GetCluster(ctx, factory, clusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientset, err := factory.Clientset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unlock, err := lockClusterState(clientset, cluster, command)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err = GetCluster(ctx, factory, clusterName)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	return cluster, unlock, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	lockReleaseLong = templates.LongDesc(i18n.T(`
+	Release the lock on the cluster state.
+
+	A lock whose holder stopped renewing it, for example because the command
+	holding it was killed, expires and is released without --force.
+
+	With --force, the lock is released even if it has not expired. Only do
+	so if the command holding it is no longer running, as concurrent
+	mutations of the cluster can overwrite each other.`))
+
+	lockReleaseExample = templates.Examples(i18n.T(`
+	# Release the lock held by a command that is no longer running.
+	kops lock release --force --name k8s-cluster.example.com --state s3://my-state-store
+	`))
+
+	lockReleaseShort = i18n.T(`Release the lock on the cluster state.`)
+)
+
+type LockReleaseOptions struct {
+	ClusterName string
+	Force       bool
+}
+
+// NewCmdLockRelease returns a lock release command.
+func NewCmdLockRelease(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &LockReleaseOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "release [CLUSTER]",
+		Short:             lockReleaseShort,
+		Long:              lockReleaseLong,
+		Example:           lockReleaseExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunLockRelease(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Force, "force", options.Force, "Release the lock even if it has not expired")
+
+	return cmd
+}
+
+// RunLockRelease releases the lock on the cluster state.
+func RunLockRelease(ctx context.Context, f *util.Factory, out io.Writer, options *LockReleaseOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	p, err := statelock.PathForCluster(clientset, cluster)
+	if err != nil {
+		return err
+	}
+	holder, err := statelock.Read(p)
+	if err != nil {
+		if !options.Force {
+			return fmt.Errorf("%v; specify --force to release the lock anyway", err)
+		}
+	} else if holder == nil {
+		fmt.Fprintf(out, "The cluster state is not locked.\n")
+		return nil
+	} else if !holder.Expired(time.Now()) && !options.Force {
+		return fmt.Errorf("the cluster state is locked by %s until %s; specify --force to release the lock if that command is no longer running",
+			holder, holder.Expires.Local().Format(time.RFC3339))
+	}
+
+	if err := statelock.ForceRelease(p); err != nil {
+		return err
+	}
+	if holder != nil {
+		fmt.Fprintf(out, "Released the lock held by %s.\n", holder)
+	} else {
+		fmt.Fprintf(out, "Released the lock.\n")
+	}
+	return nil
+}
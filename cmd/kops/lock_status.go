@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	lockStatusLong = templates.LongDesc(i18n.T(`
+	Display the holder of the lock on the cluster state.
+
+	Commands that mutate the cluster, such as "kops edit cluster" and
+	"kops update cluster --yes", hold the lock while they run, so that
+	concurrent mutations of the same cluster fail instead of overwriting
+	each other. The lock expires if its holder stops renewing it.`))
+
+	lockStatusExample = templates.Examples(i18n.T(`
+	# Display the holder of the lock on the cluster state.
+	kops lock status --name k8s-cluster.example.com --state s3://my-state-store
+	`))
+
+	lockStatusShort = i18n.T(`Display the holder of the lock on the cluster state.`)
+)
+
+type LockStatusOptions struct {
+	ClusterName string
+	Output      string
+}
+
+// NewCmdLockStatus returns a lock status command.
+func NewCmdLockStatus(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &LockStatusOptions{
+		Output: OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "status [CLUSTER]",
+		Short:             lockStatusShort,
+		Long:              lockStatusLong,
+		Example:           lockStatusExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunLockStatus(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format. One of table, json, or yaml")
+
+	return cmd
+}
+
+// RunLockStatus displays the holder of the lock on the cluster state.
+func RunLockStatus(ctx context.Context, f *util.Factory, out io.Writer, options *LockStatusOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	p, err := statelock.PathForCluster(clientset, cluster)
+	if err != nil {
+		return err
+	}
+	holder, err := statelock.Read(p)
+	if err != nil {
+		return err
+	}
+
+	switch options.Output {
+	case OutputTable:
+		if holder == nil {
+			fmt.Fprintf(out, "The cluster state is not locked.\n")
+			return nil
+		}
+		t := &tables.Table{}
+		t.AddColumn("HOLDER", func(h *statelock.Holder) string {
+			return h.User + "@" + h.Host
+		})
+		t.AddColumn("PID", func(h *statelock.Holder) string {
+			return fmt.Sprintf("%d", h.PID)
+		})
+		t.AddColumn("COMMAND", func(h *statelock.Holder) string {
+			return h.Command
+		})
+		t.AddColumn("ACQUIRED", func(h *statelock.Holder) string {
+			return h.Acquired.Local().Format(time.RFC3339)
+		})
+		t.AddColumn("EXPIRES", func(h *statelock.Holder) string {
+			expires := h.Expires.Local().Format(time.RFC3339)
+			if h.Expired(time.Now()) {
+				expires += " (expired)"
+			}
+			return expires
+		})
+		return t.Render([]*statelock.Holder{holder}, out, "HOLDER", "PID", "COMMAND", "ACQUIRED", "EXPIRES")
+	case OutputYaml:
+		y, err := yaml.Marshal(holder)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(holder)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", options.Output)
+	}
+	return nil
+}
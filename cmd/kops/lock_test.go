@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/featureflag"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kops/pkg/testutils"
+)
+
+func TestLockAndGetCluster(t *testing.T) {
+	ctx := context.Background()
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+	h.SetupMockAWS()
+
+	featureflag.ParseFlags("+StateStoreLocking")
+	defer featureflag.ParseFlags("-StateStoreLocking")
+
+	factory := util.NewFactory(&util.FactoryOptions{RegistryPath: "memfs://tests"})
+	var stdout bytes.Buffer
+	require.NoError(t, RunCreate(ctx, factory, &stdout, &CreateOptions{
+		Filenames: []string{"../../tests/integration/update_cluster/minimal/in-v1alpha2.yaml"},
+	}))
+
+	clientset, err := factory.Clientset()
+	require.NoError(t, err)
+	cluster, err := GetCluster(ctx, factory, "minimal.example.com")
+	require.NoError(t, err)
+	lockPath, err := statelock.PathForCluster(clientset, cluster)
+	require.NoError(t, err)
+
+	// A lock held by another command is not taken over
+	other, err := json.Marshal(&statelock.Holder{ID: "other", Command: "kops edit cluster", Acquired: time.Now(), Expires: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, lockPath.WriteFile(bytes.NewReader(other), nil))
+	_, _, err = lockAndGetCluster(ctx, factory, "minimal.example.com", "kops update cluster")
+	assert.Error(t, err)
+	require.NoError(t, statelock.ForceRelease(lockPath))
+
+	var unlock func()
+	cluster, unlock, err = lockAndGetCluster(ctx, factory, "minimal.example.com", "kops update cluster")
+	require.NoError(t, err)
+	assert.Equal(t, "minimal.example.com", cluster.ObjectMeta.Name)
+
+	holder, err := statelock.Read(lockPath)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.Equal(t, "kops update cluster", holder.Command)
+
+	unlock()
+	_, err = lockPath.ReadFile()
+	assert.True(t, os.IsNotExist(err), "lock was not released: %v", err)
+}
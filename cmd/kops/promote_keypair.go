@@ -128,6 +128,12 @@ func RunPromoteKeypair(ctx context.Context, f *util.Factory, out io.Writer, opti
 		return fmt.Errorf("getting clientset: %v", err)
 	}
 
+	unlock, err := lockClusterState(clientSet, cluster, "kops promote keypair")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	keyStore, err := clientSet.KeyStore(cluster)
 	if err != nil {
 		return fmt.Errorf("getting keystore: %v", err)
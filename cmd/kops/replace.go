@@ -84,8 +84,8 @@ func NewCmdReplace(f *util.Factory, out io.Writer) *cobra.Command {
 }
 
 // RunReplace processes the replace command
-func RunReplace(ctx context.Context, f *util.Factory, out io.Writer, c *ReplaceOptions) error {
-	clientset, err := f.Clientset()
+func RunReplace(ctx context.Context, factory *util.Factory, out io.Writer, c *ReplaceOptions) error {
+	clientset, err := factory.Clientset()
 	if err != nil {
 		return err
 	}
@@ -124,6 +124,13 @@ func RunReplace(ctx context.Context, f *util.Factory, out io.Writer, c *ReplaceO
 						return err
 					}
 
+					// The lock is acquired before checking whether the cluster exists, so that it is not changed before it is replaced
+					unlock, err := lockClusterState(clientset, v, "kops replace")
+					if err != nil {
+						return err
+					}
+					defer unlock()
+
 					// Check if the cluster exists already
 					clusterName := v.Name
 					cluster, err := clientset.GetCluster(ctx, clusterName)
@@ -155,13 +162,12 @@ func RunReplace(ctx context.Context, f *util.Factory, out io.Writer, c *ReplaceO
 				if clusterName == "" {
 					return fmt.Errorf("must specify %q label with cluster name to replace instanceGroup", kopsapi.LabelClusterName)
 				}
-				cluster, err := clientset.GetCluster(ctx, clusterName)
+				cluster, unlock, err := lockAndGetCluster(ctx, factory, clusterName, "kops replace")
 				if err != nil {
-					if errors.IsNotFound(err) {
-						return fmt.Errorf("cluster %q not found", clusterName)
-					}
-					return fmt.Errorf("error fetching cluster %q: %v", clusterName, err)
+					return err
 				}
+				defer unlock()
+
 				// check if the instancegroup exists already
 				igName := v.ObjectMeta.Name
 				ig, err := clientset.InstanceGroupsFor(cluster).Get(ctx, igName, metav1.GetOptions{})
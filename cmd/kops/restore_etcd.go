@@ -0,0 +1,166 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/etcdbackup"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// latestBackup selects the newest valid backup
+const latestBackup = "latest"
+
+type RestoreEtcdOptions struct {
+	ClusterName string
+	EtcdCluster string
+	// Backup is the name of the backup to restore, or "latest"
+	Backup string
+	Yes    bool
+}
+
+var (
+	restoreEtcdLong = templates.LongDesc(i18n.T(`
+	Restore an etcd cluster from a backup.
+
+	The backup is verified, then a restore command is written to the backup store of the etcd cluster.
+	etcd-manager runs the command the next time it starts, so etcd-manager must be restarted on all
+	control plane nodes to restore the backup.
+
+	A restore cannot be undone (unless by restoring again), and resources created after the backup are lost.`))
+
+	restoreEtcdExample = templates.Examples(i18n.T(`
+	# List the backups of the main etcd cluster.
+	kops get etcd-backups --name k8s-cluster.example.com --etcd-cluster main
+
+	# Restore the main etcd cluster from a backup.
+	kops restore etcd --name k8s-cluster.example.com --etcd-cluster main \
+		--backup 2021-05-10T12:00:00Z-000001 --yes
+
+	# Restore the events etcd cluster from its newest valid backup.
+	kops restore etcd --name k8s-cluster.example.com --etcd-cluster events --backup latest --yes
+	`))
+
+	restoreEtcdShort = i18n.T(`Restore an etcd cluster from a backup.`)
+)
+
+// NewCmdRestoreEtcd returns a restore etcd command.
+func NewCmdRestoreEtcd(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RestoreEtcdOptions{
+		EtcdCluster: "main",
+	}
+
+	cmd := &cobra.Command{
+		Use:     "etcd",
+		Short:   restoreEtcdShort,
+		Long:    restoreEtcdLong,
+		Example: restoreEtcdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				return err
+			}
+			options.ClusterName = rootCommand.ClusterName(true)
+			if options.ClusterName == "" {
+				return fmt.Errorf("--name is required")
+			}
+			if options.Backup == "" {
+				return fmt.Errorf("--backup is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRestoreEtcd(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.EtcdCluster, "etcd-cluster", options.EtcdCluster, "Name of the etcd cluster, e.g. main or events")
+	cmd.RegisterFlagCompletionFunc("etcd-cluster", completeEtcdClusterName)
+	cmd.Flags().StringVar(&options.Backup, "backup", options.Backup, "Name of the backup to restore, or \"latest\" for the newest valid backup")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Specify --yes to write the restore command")
+
+	return cmd
+}
+
+// RunRestoreEtcd writes the command that makes etcd-manager restore a backup.
+func RunRestoreEtcd(ctx context.Context, f commandutils.Factory, out io.Writer, options *RestoreEtcdOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	stores, err := etcdBackupStores(cluster, options.EtcdCluster)
+	if err != nil {
+		return err
+	}
+	store := stores[0].store
+
+	backups, err := etcdbackup.ListBackups(options.EtcdCluster, store)
+	if err != nil {
+		return err
+	}
+
+	var backup *etcdbackup.Backup
+	if options.Backup == latestBackup {
+		for i := len(backups) - 1; i >= 0; i-- {
+			etcdbackup.VerifyBackup(store, backups[i])
+			if backups[i].Status == etcdbackup.StatusValid {
+				backup = backups[i]
+				break
+			}
+		}
+		if backup == nil {
+			return fmt.Errorf("no valid backup found for etcd cluster %q", options.EtcdCluster)
+		}
+	} else {
+		backup = etcdbackup.FindBackup(backups, options.Backup)
+		if backup == nil {
+			return fmt.Errorf("backup %q not found for etcd cluster %q", options.Backup, options.EtcdCluster)
+		}
+		etcdbackup.VerifyBackup(store, backup)
+		if backup.Status != etcdbackup.StatusValid {
+			return fmt.Errorf("backup %q is corrupt: %s", backup.Name, backup.Problem)
+		}
+	}
+
+	spec, err := etcdbackup.ReadClusterSpec(store)
+	if err != nil {
+		return err
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "Would restore etcd cluster %q from backup %s, taken at %s\n", options.EtcdCluster, backup.Name, backup.Timestamp.Format(time.RFC3339))
+		fmt.Fprintf(out, "\nMust specify --yes to write the restore command\n")
+		return nil
+	}
+
+	p, err := etcdbackup.AddRestoreCommand(store, backup.Name, spec, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Added restore command %s for etcd cluster %q from backup %s\n", p, options.EtcdCluster, backup.Name)
+	fmt.Fprintf(out, "\nRestart etcd-manager on all control plane nodes to restore the backup.\n")
+	return nil
+}
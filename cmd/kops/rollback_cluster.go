@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/commands"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/util/pkg/vfs"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rollbackClusterLong = templates.LongDesc(i18n.T(`
+	Restore the cluster spec or an instance group spec to a revision in the history of the cluster.
+
+	The object written by the revision is compared with the object in the state store,
+	and is written back to the state store with --yes. The restore is recorded as a
+	new revision. If the revision recorded the deletion of an instance group, the
+	instance group is recreated as it was before the deletion.
+
+	Like "kops edit", the rollback only changes the state store; run
+	"kops update cluster --yes" to apply the change to the cluster.`))
+
+	rollbackClusterExample = templates.Examples(i18n.T(`
+	# Display the changes a rollback to revision 3 would make.
+	kops rollback cluster --revision 3 --name k8s-cluster.example.com --state s3://my-state-store
+
+	# Roll back to revision 3.
+	kops rollback cluster --revision 3 --name k8s-cluster.example.com --state s3://my-state-store --yes
+	`))
+
+	rollbackClusterShort = i18n.T(`Roll back a cluster or instance group spec to a revision.`)
+)
+
+type RollbackClusterOptions struct {
+	ClusterName string
+	Revision    int
+	Yes         bool
+}
+
+// NewCmdRollbackCluster returns a rollback cluster command.
+func NewCmdRollbackCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RollbackClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "cluster [CLUSTER]",
+		Short:             rollbackClusterShort,
+		Long:              rollbackClusterLong,
+		Example:           rollbackClusterExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRollbackCluster(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Revision, "revision", options.Revision, "Revision to roll back to")
+	cmd.MarkFlagRequired("revision")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Roll back without confirmation")
+
+	return cmd
+}
+
+// RunRollbackCluster restores the object written by a revision of a cluster.
+func RunRollbackCluster(ctx context.Context, f *util.Factory, out io.Writer, options *RollbackClusterOptions) error {
+	if options.Yes {
+		// The lock is acquired before the current object is read, so that the change that is shown is the one written
+		_, unlock, err := lockAndGetCluster(ctx, f, options.ClusterName, "kops rollback cluster")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	cluster, clientset, history, err := clusterHistory(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	revision, err := statehistory.Get(history, options.Revision)
+	if err != nil {
+		return err
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+	current, exists, err := readStateObject(configBase, revision.Kind, revision.Name)
+	if err != nil {
+		return err
+	}
+	if exists && current == revision.Object {
+		fmt.Fprintf(out, "%s %q is already at revision %d.\n", revision.Kind, revision.Name, revision.Revision)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Rolling back %s %q to revision %d:\n\n", revision.Kind, revision.Name, revision.Revision)
+	fmt.Fprint(out, diff.FormatDiff(current, revision.Object))
+	fmt.Fprintf(out, "\n")
+
+	if !options.Yes {
+		fmt.Fprintf(out, "Must specify --yes to roll back\n")
+		return nil
+	}
+
+	o, _, err := kopscodecs.Decode([]byte(revision.Object), nil)
+	if err != nil {
+		return fmt.Errorf("error parsing revision %d: %v", revision.Revision, err)
+	}
+
+	switch v := o.(type) {
+	case *kopsapi.Cluster:
+		if v.ObjectMeta.Name != cluster.ObjectMeta.Name {
+			return fmt.Errorf("revision %d is of cluster %q, not %q", revision.Revision, v.ObjectMeta.Name, cluster.ObjectMeta.Name)
+		}
+
+		// The revision is validated as kops edit cluster validates the edited cluster,
+		// as it may have been written by another version of kOps or with other instance groups
+		if err := v.FillDefaults(); err != nil {
+			return err
+		}
+		instanceGroups, err := commands.ReadAllInstanceGroups(ctx, clientset, cluster)
+		if err != nil {
+			return err
+		}
+		failure, err := updateCluster(ctx, clientset, cluster, v, instanceGroups)
+		if err != nil {
+			return fmt.Errorf("error replacing cluster: %v", err)
+		}
+		if failure != "" {
+			return fmt.Errorf("revision %d cannot be restored: %s", revision.Revision, failure)
+		}
+
+	case *kopsapi.InstanceGroup:
+		channel, err := cloudup.ChannelForCluster(cluster)
+		if err != nil {
+			klog.Warningf("%v", err)
+		}
+		fullGroup, failure, err := populateAndValidateInstanceGroup(clientset, channel, cluster, v)
+		if err != nil {
+			return err
+		}
+		if failure != "" {
+			return fmt.Errorf("revision %d cannot be restored: %s", revision.Revision, failure)
+		}
+
+		if exists {
+			_, err = clientset.InstanceGroupsFor(cluster).Update(ctx, fullGroup, metav1.UpdateOptions{})
+		} else {
+			_, err = clientset.InstanceGroupsFor(cluster).Create(ctx, fullGroup, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("error writing InstanceGroup %q: %v", v.ObjectMeta.Name, err)
+		}
+
+	default:
+		return fmt.Errorf("unhandled kind %q in revision %d", revision.Kind, revision.Revision)
+	}
+
+	fmt.Fprintf(out, "Rolled back %s %q to revision %d.\n", revision.Kind, revision.Name, revision.Revision)
+	fmt.Fprintf(out, "Run \"kops update cluster --yes\" to apply the change to the cluster.\n")
+	return nil
+}
+
+// readStateObject reads an object of a kind recorded in the history as it is in the state store
+func readStateObject(configBase vfs.Path, kind string, name string) (string, bool, error) {
+	var p vfs.Path
+	switch kind {
+	case "Cluster":
+		p = configBase.Join(registry.PathCluster)
+	case "InstanceGroup":
+		p = configBase.Join("instancegroup", name)
+	default:
+		return "", false, fmt.Errorf("unhandled kind %q", kind)
+	}
+
+	data, err := p.ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error reading %s %q: %v", kind, name, err)
+	}
+	return string(data), true, nil
+}
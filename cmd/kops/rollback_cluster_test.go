@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/pkg/testutils"
+)
+
+func TestRollbackClusterValidatesRevision(t *testing.T) {
+	ctx := context.Background()
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+	h.SetupMockAWS()
+
+	factory := util.NewFactory(&util.FactoryOptions{RegistryPath: "memfs://tests"})
+	var stdout bytes.Buffer
+	require.NoError(t, RunCreate(ctx, factory, &stdout, &CreateOptions{
+		Filenames: []string{"../../tests/integration/update_cluster/minimal/in-v1alpha2.yaml"},
+	}))
+
+	cluster, clientset, history, err := clusterHistory(ctx, factory, "minimal.example.com")
+	require.NoError(t, err)
+
+	// A revision whose subnet is outside of the network of the cluster
+	invalid := cluster.DeepCopy()
+	invalid.Spec.Subnets[0].CIDR = "192.168.0.0/24"
+	data, err := kopscodecs.ToVersionedYaml(invalid)
+	require.NoError(t, err)
+	revision, err := statehistory.Record(history, statehistory.OperationUpdate, "Cluster", "minimal.example.com", data)
+	require.NoError(t, err)
+
+	err = RunRollbackCluster(ctx, factory, &stdout, &RollbackClusterOptions{
+		ClusterName: "minimal.example.com",
+		Revision:    revision.Revision,
+		Yes:         true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be restored")
+
+	current, err := clientset.GetCluster(ctx, "minimal.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, cluster.Spec.Subnets[0].CIDR, current.Spec.Subnets[0].CIDR)
+
+	// A valid revision is restored
+	valid := cluster.DeepCopy()
+	valid.Spec.KubernetesAPIAccess = []string{"10.0.0.0/8"}
+	data, err = kopscodecs.ToVersionedYaml(valid)
+	require.NoError(t, err)
+	revision, err = statehistory.Record(history, statehistory.OperationUpdate, "Cluster", "minimal.example.com", data)
+	require.NoError(t, err)
+
+	require.NoError(t, RunRollbackCluster(ctx, factory, &stdout, &RollbackClusterOptions{
+		ClusterName: "minimal.example.com",
+		Revision:    revision.Revision,
+		Yes:         true,
+	}))
+	current, err = clientset.GetCluster(ctx, "minimal.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8"}, current.Spec.KubernetesAPIAccess)
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -44,6 +45,7 @@ import (
 	"k8s.io/kops/util/pkg/tables"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -86,6 +88,32 @@ var (
 		# Update only the "nodes-1a" instance group of the k8s-cluster.example.com kOps cluster.
 		kops rolling-update cluster k8s-cluster.example.com --yes \
 		  --instance-group nodes-1a
+
+		# Update the k8s-cluster.example.com kOps cluster, running a script
+		# before draining each instance and calling a webhook after
+		# terminating each instance.
+		kops rolling-update cluster k8s-cluster.example.com --yes \
+		  --pre-drain-hook "./wait-for-rebalance.sh" \
+		  --post-terminate-hook "curl -fsS -X POST https://change.example.com/notify"
+
+		# Update the k8s-cluster.example.com kOps cluster, leaving running any
+		# instance whose node does not drain within 30 minutes.
+		kops rolling-update cluster k8s-cluster.example.com --yes \
+		  --drain-timeout 30m \
+		  --drain-timeout-policy Skip
+
+		# Print the rolling update plan of the k8s-cluster.example.com kOps cluster as JSON.
+		kops rolling-update cluster k8s-cluster.example.com -o json
+
+		# Update the k8s-cluster.example.com kOps cluster, writing a line of JSON
+		# to events.json for each step of the update.
+		kops rolling-update cluster k8s-cluster.example.com --yes \
+		  --events-file events.json
+
+		# Continue a rolling update of the k8s-cluster.example.com kOps cluster
+		# that was interrupted, skipping the instance groups it already updated.
+		kops rolling-update cluster k8s-cluster.example.com --yes \
+		  --resume
 		`))
 
 	rollingupdateShort = i18n.T(`Rolling update a cluster.`)
@@ -110,6 +138,15 @@ type RollingUpdateOptions struct {
 	// PostDrainDelay is the duration of a pause after a drain operation
 	PostDrainDelay time.Duration
 
+	// MaxConcurrentInstanceGroups is the maximum number of node instance groups to update at the same time, overriding the cluster spec
+	MaxConcurrentInstanceGroups int
+
+	// DrainTimeout is the maximum time to wait for a node to drain, overriding the cluster and instance group specs
+	DrainTimeout time.Duration
+
+	// DrainTimeoutPolicy is what to do when a node does not drain within the drain timeout, overriding the cluster and instance group specs
+	DrainTimeoutPolicy string
+
 	// ValidationTimeout is the timeout for validation to succeed after the drain and pause
 	ValidationTimeout time.Duration
 
@@ -137,6 +174,30 @@ type RollingUpdateOptions struct {
 	// InstanceGroupRoles is the list of roles we should rolling-update
 	// if not specified, all instance groups will be updated
 	InstanceGroupRoles []string
+
+	// Resume continues a previous rolling update that did not complete, from the progress it recorded in the state store
+	Resume bool
+
+	// PreDrainHooks are shell commands to run before draining each instance
+	PreDrainHooks []string
+
+	// PostTerminateHooks are shell commands to run after each instance is terminated and the cluster validates
+	PostTerminateHooks []string
+
+	// HookTimeout is the maximum time to wait for each hook given on the command line
+	HookTimeout time.Duration
+
+	// SkipHooks disables all hooks, including those configured in the cluster and instance group specs
+	SkipHooks bool
+
+	// AllowExecHooks permits the running of exec hooks configured in the cluster and instance group specs
+	AllowExecHooks bool
+
+	// Output is the format in which to print the rolling update plan: table, json, or yaml
+	Output string
+
+	// EventsFile is the file to which to write a line of JSON for each step of the rolling update; "-" is stdout
+	EventsFile string
 }
 
 func (o *RollingUpdateOptions) InitDefaults() {
@@ -154,6 +215,10 @@ func (o *RollingUpdateOptions) InitDefaults() {
 	o.PostDrainDelay = 5 * time.Second
 	o.ValidationTimeout = 15 * time.Minute
 	o.ValidateCount = 2
+
+	o.HookTimeout = 5 * time.Minute
+
+	o.Output = OutputTable
 }
 
 func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
@@ -188,7 +253,14 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().DurationVar(&options.NodeInterval, "node-interval", options.NodeInterval, "Time to wait between restarting worker nodes")
 	cmd.Flags().DurationVar(&options.BastionInterval, "bastion-interval", options.BastionInterval, "Time to wait between restarting bastions")
 	cmd.Flags().DurationVar(&options.PostDrainDelay, "post-drain-delay", options.PostDrainDelay, "Time to wait after draining each node")
+	cmd.Flags().DurationVar(&options.DrainTimeout, "drain-timeout", options.DrainTimeout, "Maximum time to wait for each node to drain, overriding the cluster and instance group specs")
+	cmd.Flags().StringVar(&options.DrainTimeoutPolicy, "drain-timeout-policy", options.DrainTimeoutPolicy, "What to do when a node does not drain within the drain timeout, overriding the cluster and instance group specs. One of Fail|Retry|Skip|Force.")
+	cmd.RegisterFlagCompletionFunc("drain-timeout-policy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(kopsapi.DrainTimeoutPolicyFail), string(kopsapi.DrainTimeoutPolicyRetry), string(kopsapi.DrainTimeoutPolicySkip), string(kopsapi.DrainTimeoutPolicyForce)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().IntVar(&options.MaxConcurrentInstanceGroups, "max-concurrent-instance-groups", options.MaxConcurrentInstanceGroups, "Maximum number of instance groups with role node to update at the same time, overriding the cluster spec")
 	cmd.Flags().BoolVarP(&options.Interactive, "interactive", "i", options.Interactive, "Prompt to continue after each instance is updated")
+	cmd.Flags().BoolVar(&options.Resume, "resume", options.Resume, "Continue a previous rolling update that did not complete from where it stopped")
 	cmd.Flags().StringSliceVar(&options.InstanceGroups, "instance-group", options.InstanceGroups, "Instance groups to update (defaults to all if not specified)")
 	cmd.RegisterFlagCompletionFunc("instance-group", completeInstanceGroup(&options.InstanceGroups, &options.InstanceGroupRoles))
 	cmd.Flags().StringSliceVar(&options.InstanceGroupRoles, "instance-group-roles", options.InstanceGroupRoles, "Instance group roles to update ("+strings.Join(allRoles, ",")+")")
@@ -196,6 +268,18 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 		return sets.NewString(allRoles...).Delete(options.InstanceGroupRoles...).List(), cobra.ShellCompDirectiveNoFileComp
 	})
 
+	cmd.Flags().StringArrayVar(&options.PreDrainHooks, "pre-drain-hook", options.PreDrainHooks, "Shell command to run before draining each instance; the rolling update stops if it fails")
+	cmd.Flags().StringArrayVar(&options.PostTerminateHooks, "post-terminate-hook", options.PostTerminateHooks, "Shell command to run after each instance is terminated and the cluster validates; the rolling update stops if it fails")
+	cmd.Flags().DurationVar(&options.HookTimeout, "hook-timeout", options.HookTimeout, "Maximum time to wait for each hook given on the command line")
+	cmd.Flags().BoolVar(&options.SkipHooks, "skip-hooks", options.SkipHooks, "Do not run any hooks, including those configured in the cluster and instance group specs")
+	cmd.Flags().BoolVar(&options.AllowExecHooks, "allow-exec-hooks", options.AllowExecHooks, "Allow exec hooks configured in the cluster and instance group specs to run commands on this machine")
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format for the rolling update plan. One of json|yaml|table.")
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{OutputJSON, OutputYaml, OutputTable}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().StringVar(&options.EventsFile, "events-file", options.EventsFile, "File to which to write a line of JSON for each step of the rolling update (\"-\" for stdout)")
+
 	cmd.Flags().BoolVar(&options.FailOnDrainError, "fail-on-drain-error", true, "Fail if draining a node fails")
 	cmd.Flags().BoolVar(&options.FailOnValidate, "fail-on-validate-error", true, "Fail if the cluster fails to validate")
 
@@ -213,15 +297,44 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 }
 
 func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, options *RollingUpdateOptions) error {
+	switch options.Output {
+	case OutputTable, OutputJSON, OutputYaml:
+	default:
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+
+	if options.MaxConcurrentInstanceGroups < 0 {
+		return fmt.Errorf("--max-concurrent-instance-groups cannot be negative")
+	}
+
+	if options.EventsFile == "-" && options.Output != OutputTable {
+		return fmt.Errorf("--events-file - cannot be used with --output %s, as both would be written to stdout", options.Output)
+	}
+
+	switch kopsapi.DrainTimeoutPolicy(options.DrainTimeoutPolicy) {
+	case "", kopsapi.DrainTimeoutPolicyFail, kopsapi.DrainTimeoutPolicyRetry, kopsapi.DrainTimeoutPolicySkip, kopsapi.DrainTimeoutPolicyForce:
+	default:
+		return fmt.Errorf("unknown drain timeout policy: %q", options.DrainTimeoutPolicy)
+	}
 
 	clientset, err := f.Clientset()
 	if err != nil {
 		return err
 	}
 
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
-	if err != nil {
-		return err
+	var cluster *kopsapi.Cluster
+	if options.Yes {
+		var unlock func()
+		cluster, unlock, err = lockAndGetCluster(ctx, f, options.ClusterName, "kops rolling-update cluster")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	} else {
+		cluster, err = GetCluster(ctx, f, options.ClusterName)
+		if err != nil {
+			return err
+		}
 	}
 
 	contextName := cluster.ObjectMeta.Name
@@ -321,23 +434,30 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	}
 
 	d := &instancegroups.RollingUpdateCluster{
-		Clientset:         clientset,
-		Ctx:               ctx,
-		Cluster:           cluster,
-		MasterInterval:    options.MasterInterval,
-		NodeInterval:      options.NodeInterval,
-		BastionInterval:   options.BastionInterval,
-		Interactive:       options.Interactive,
-		Force:             options.Force,
-		Cloud:             cloud,
-		K8sClient:         k8sClient,
-		FailOnDrainError:  options.FailOnDrainError,
-		FailOnValidate:    options.FailOnValidate,
-		CloudOnly:         options.CloudOnly,
-		ClusterName:       options.ClusterName,
-		PostDrainDelay:    options.PostDrainDelay,
-		ValidationTimeout: options.ValidationTimeout,
-		ValidateCount:     int(options.ValidateCount),
+		Clientset:                   clientset,
+		Ctx:                         ctx,
+		Cluster:                     cluster,
+		MasterInterval:              options.MasterInterval,
+		NodeInterval:                options.NodeInterval,
+		BastionInterval:             options.BastionInterval,
+		Interactive:                 options.Interactive,
+		Force:                       options.Force,
+		Cloud:                       cloud,
+		K8sClient:                   k8sClient,
+		FailOnDrainError:            options.FailOnDrainError,
+		FailOnValidate:              options.FailOnValidate,
+		CloudOnly:                   options.CloudOnly,
+		ClusterName:                 options.ClusterName,
+		PostDrainDelay:              options.PostDrainDelay,
+		MaxConcurrentInstanceGroups: options.MaxConcurrentInstanceGroups,
+		DrainTimeout:                options.DrainTimeout,
+		DrainTimeoutPolicy:          kopsapi.DrainTimeoutPolicy(options.DrainTimeoutPolicy),
+		ValidationTimeout:           options.ValidationTimeout,
+		ValidateCount:               int(options.ValidateCount),
+		Resume:                      options.Resume,
+		Hooks:                       buildRollingUpdateHooks(options),
+		SkipHooks:                   options.SkipHooks,
+		AllowExecHooks:              options.AllowExecHooks,
 		// TODO should we expose this to the UI?
 		ValidateTickDuration:    30 * time.Second,
 		ValidateSuccessDuration: 10 * time.Second,
@@ -348,7 +468,10 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 		return err
 	}
 
-	{
+	// When the plan is machine-readable, other messages go to stderr so as not to corrupt it
+	messages := out
+	switch options.Output {
+	case OutputTable:
 		t := &tables.Table{}
 		t.AddColumn("NAME", func(r *cloudinstances.CloudInstanceGroup) string {
 			return r.InstanceGroup.ObjectMeta.Name
@@ -398,6 +521,24 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 		if err != nil {
 			return err
 		}
+	case OutputYaml:
+		messages = os.Stderr
+		y, err := yaml.Marshal(d.Plan(groups))
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		messages = os.Stderr
+		j, err := json.Marshal(d.Plan(groups))
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
 	}
 
 	needUpdate := false
@@ -408,15 +549,55 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	}
 
 	if !needUpdate && !options.Force {
-		fmt.Printf("\nNo rolling-update required.\n")
-		return nil
+		progress, err := instancegroups.ReadRollingUpdateProgress(clientset, cluster)
+		if err != nil {
+			return err
+		}
+		switch {
+		case progress == nil:
+		case len(progress.Detached) > 0 && options.Resume:
+			// The instances detached by the previous rolling-update still need to be terminated
+			needUpdate = true
+		case len(progress.Detached) > 0:
+			fmt.Fprintf(messages, "\nA previous rolling-update left %d detached instance(s) running; specify --resume to terminate them.\n", len(progress.Detached))
+		case options.Yes:
+			if err := instancegroups.ClearRollingUpdateProgress(clientset, cluster); err != nil {
+				return err
+			}
+			fmt.Fprintf(messages, "\nCleared the progress recorded by a previous rolling-update, which has nothing left to update.\n")
+		default:
+			fmt.Fprintf(messages, "\nA previous rolling-update did not complete, but has nothing left to update; specify --yes to clear its progress.\n")
+		}
+
+		if !needUpdate {
+			fmt.Fprintf(messages, "\nNo rolling-update required.\n")
+			return nil
+		}
 	}
 
 	if !options.Yes {
-		fmt.Printf("\nMust specify --yes to rolling-update.\n")
+		progress, err := instancegroups.ReadRollingUpdateProgress(clientset, cluster)
+		if err != nil {
+			return err
+		}
+		if progress != nil && !options.Resume {
+			fmt.Fprintf(messages, "\nA previous rolling-update did not complete; specify --resume to continue where it stopped.\n")
+		}
+		fmt.Fprintf(messages, "\nMust specify --yes to rolling-update.\n")
 		return nil
 	}
 
+	if options.EventsFile == "-" {
+		d.EventStream = out
+	} else if options.EventsFile != "" {
+		eventsFile, err := os.OpenFile(options.EventsFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening events file: %v", err)
+		}
+		defer eventsFile.Close()
+		d.EventStream = eventsFile
+	}
+
 	var clusterValidator validation.ClusterValidator
 	if !options.CloudOnly {
 		clusterValidator, err = validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
@@ -426,7 +607,45 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	}
 	d.ClusterValidator = clusterValidator
 
-	return d.RollingUpdate(groups, list)
+	err = d.RollingUpdate(groups, list)
+	if blockedDrains := d.BlockedDrains(); len(blockedDrains) > 0 {
+		fmt.Fprintf(messages, "\nThe following nodes did not drain within their drain timeout:\n\n")
+		if err := renderBlockedDrains(blockedDrains, messages); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// renderBlockedDrains prints a table of the nodes that did not drain within their drain timeout
+func renderBlockedDrains(blockedDrains []*instancegroups.BlockedDrain, out io.Writer) error {
+	t := &tables.Table{}
+	t.AddColumn("INSTANCEGROUP", func(b *instancegroups.BlockedDrain) string {
+		return b.InstanceGroup
+	})
+	t.AddColumn("INSTANCE", func(b *instancegroups.BlockedDrain) string {
+		return b.InstanceID
+	})
+	t.AddColumn("NODE", func(b *instancegroups.BlockedDrain) string {
+		return b.NodeName
+	})
+	t.AddColumn("RESULT", func(b *instancegroups.BlockedDrain) string {
+		if b.Detached {
+			return string(b.Result) + " (detached)"
+		}
+		return string(b.Result)
+	})
+	t.AddColumn("ATTEMPTS", func(b *instancegroups.BlockedDrain) string {
+		return strconv.Itoa(b.Attempts)
+	})
+	t.AddColumn("BLOCKING PODS", func(b *instancegroups.BlockedDrain) string {
+		var pods []string
+		for _, pod := range b.Pods {
+			pods = append(pods, pod.String())
+		}
+		return strings.Join(pods, ",")
+	})
+	return t.Render(blockedDrains, out, "INSTANCEGROUP", "INSTANCE", "NODE", "RESULT", "ATTEMPTS", "BLOCKING PODS")
 }
 
 func completeInstanceGroup(selectedInstanceGroups *[]string, selectedInstanceGroupRoles *[]string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -462,3 +681,22 @@ func completeInstanceGroup(selectedInstanceGroups *[]string, selectedInstanceGro
 		return igs, cobra.ShellCompDirectiveNoFileComp
 	}
 }
+
+// buildRollingUpdateHooks builds the hooks given on the command line, which are run in a shell
+func buildRollingUpdateHooks(options *RollingUpdateOptions) []kopsapi.RollingUpdateHook {
+	var hooks []kopsapi.RollingUpdateHook
+	add := func(phase kopsapi.RollingUpdateHookPhase, flag string, commands []string) {
+		for i, command := range commands {
+			hooks = append(hooks, kopsapi.RollingUpdateHook{
+				Name:          fmt.Sprintf("%s[%d]", flag, i),
+				Phase:         phase,
+				Exec:          &kopsapi.ExecRollingUpdateHook{Command: []string{"/bin/sh", "-c", command}},
+				Timeout:       &metav1.Duration{Duration: options.HookTimeout},
+				FailurePolicy: kopsapi.RollingUpdateHookFailurePolicyFail,
+			})
+		}
+	}
+	add(kopsapi.RollingUpdateHookPhasePreDrain, "pre-drain-hook", options.PreDrainHooks)
+	add(kopsapi.RollingUpdateHookPhasePostTerminate, "post-terminate-hook", options.PostTerminateHooks)
+	return hooks
+}
@@ -145,15 +145,21 @@ func NewCmdRoot(f *util.Factory, out io.Writer) *cobra.Command {
 	// create subcommands
 	cmd.AddCommand(NewCmdCreate(f, out))
 	cmd.AddCommand(NewCmdDelete(f, out))
+	cmd.AddCommand(NewCmdDiff(f, out))
 	cmd.AddCommand(NewCmdDistrust(f, out))
 	cmd.AddCommand(NewCmdEdit(f, out))
 	cmd.AddCommand(NewCmdExport(f, out))
 	cmd.AddCommand(NewCmdGenCLIDocs(f, out))
 	cmd.AddCommand(NewCmdGet(f, out))
 	cmd.AddCommand(commands.NewCmdHelpers(f, out))
+	cmd.AddCommand(NewCmdHistory(f, out))
+	cmd.AddCommand(NewCmdLock(f, out))
 	cmd.AddCommand(NewCmdPromote(f, out))
 	cmd.AddCommand(NewCmdReplace(f, out))
+	cmd.AddCommand(NewCmdRestore(f, out))
+	cmd.AddCommand(NewCmdRollback(f, out))
 	cmd.AddCommand(NewCmdRollingUpdate(f, out))
+	cmd.AddCommand(NewCmdRotate(f, out))
 	cmd.AddCommand(NewCmdToolbox(f, out))
 	cmd.AddCommand(NewCmdTrust(f, out))
 	cmd.AddCommand(NewCmdUpdate(f, out))
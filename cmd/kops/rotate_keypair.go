@@ -0,0 +1,324 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/certificates"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/slice"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rotateKeypairLong = templates.LongDesc(i18n.T(`
+	Advance the rotation of a keyset by one phase, then update the cluster
+	and perform a rolling update so that the nodes pick up the change.
+
+	The rotation of a keyset has three phases, each started by a run of this command:
+
+	1. create: a new keypair is added to the keyset. It is trusted, but not yet used for signing.
+	2. promote: the new keypair is made the primary, so that certificates are reissued with it.
+	3. distrust: the keypairs older than the primary are distrusted.
+
+	The phase of each keyset is determined from its keypairs, so the command can be run
+	again after a failure. Between the phases, clients of the Kubernetes API may need a
+	new kubeconfig; the command prints the steps needed once it has run.
+
+	If the keyset is specified as "all", each rotatable keyset is advanced.
+
+	With --older-than, a rotation is only started for a keyset whose primary keypair
+	was issued longer ago than the duration, so that the command can be scheduled.
+	Rotations in progress are always advanced.`))
+
+	rotateKeypairExample = templates.Examples(i18n.T(`
+	# Show the next phase of the rotation of each rotatable keyset.
+	kops rotate keypair all --name k8s-cluster.example.com --state s3://my-state-store
+
+	# Advance the rotation of the kubernetes-ca keyset.
+	kops rotate keypair kubernetes-ca --name k8s-cluster.example.com --state s3://my-state-store --yes
+
+	# Rotate the keysets whose primary keypair was issued more than a year ago.
+	kops rotate keypair all --older-than 8760h \
+		--name k8s-cluster.example.com --state s3://my-state-store --yes`))
+
+	rotateKeypairShort = i18n.T(`Advance the rotation of a keyset and roll the cluster.`)
+)
+
+type RotateKeypairOptions struct {
+	ClusterName string
+	Keyset      string
+	Yes         bool
+	// OlderThan only starts a rotation for the keysets whose primary keypair was issued longer ago than the duration
+	OlderThan time.Duration
+	// SkipRollingUpdate skips the rolling update after updating the cluster
+	SkipRollingUpdate bool
+}
+
+// NewCmdRotateKeypair returns a rotate keypair command.
+func NewCmdRotateKeypair(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RotateKeypairOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "keypair {KEYSET | all}",
+		Short:   rotateKeypairShort,
+		Long:    rotateKeypairLong,
+		Example: rotateKeypairExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			options.ClusterName = rootCommand.ClusterName(true)
+
+			if options.ClusterName == "" {
+				return fmt.Errorf("--name is required")
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("must specify name of keyset to rotate")
+			}
+
+			options.Keyset = args[0]
+
+			if len(args) != 1 {
+				return fmt.Errorf("can only rotate one keyset at a time")
+			}
+
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeRotateKeyset(options, args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRotateKeypair(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Rotate the keysets, update the cluster and perform the rolling update")
+	cmd.Flags().DurationVar(&options.OlderThan, "older-than", options.OlderThan, "Only start the rotation of keysets whose primary keypair was issued longer ago than this duration")
+	cmd.Flags().BoolVar(&options.SkipRollingUpdate, "skip-rolling-update", options.SkipRollingUpdate, "Update the cluster, but do not perform the rolling update")
+
+	return cmd
+}
+
+// keysetRotation is the next phase of the rotation of a keyset
+type keysetRotation struct {
+	Name    string
+	Phase   certificates.Phase
+	Primary *fi.KeysetItem
+}
+
+// RunRotateKeypair advances the rotation of the keysets by one phase.
+func RunRotateKeypair(ctx context.Context, f *util.Factory, out io.Writer, options *RotateKeypairOptions) error {
+	if !rotatableKeysetFilter(options.Keyset, nil) {
+		return fmt.Errorf("rotating keyset %q is not supported", options.Keyset)
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return fmt.Errorf("error getting cluster: %q: %v", options.ClusterName, err)
+	}
+
+	clientSet, err := f.Clientset()
+	if err != nil {
+		return fmt.Errorf("error getting clientset: %v", err)
+	}
+
+	if options.Yes {
+		unlock, err := lockClusterState(clientSet, cluster, "kops rotate keypair")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	keyStore, err := clientSet.KeyStore(cluster)
+	if err != nil {
+		return fmt.Errorf("error getting keystore: %v", err)
+	}
+
+	rotations, err := planKeysetRotations(keyStore, options.Keyset, options.OlderThan)
+	if err != nil {
+		return err
+	}
+	if len(rotations) == 0 {
+		fmt.Fprintf(out, "No keysets are due for rotation.\n")
+		return nil
+	}
+
+	t := &tables.Table{}
+	t.AddColumn("KEYSET", func(r *keysetRotation) string {
+		return r.Name
+	})
+	t.AddColumn("PHASE", func(r *keysetRotation) string {
+		return string(r.Phase)
+	})
+	t.AddColumn("PRIMARY", func(r *keysetRotation) string {
+		return r.Primary.Id
+	})
+	t.AddColumn("ISSUED", func(r *keysetRotation) string {
+		return r.Primary.Certificate.Certificate.NotBefore.Local().Format("2006-01-02")
+	})
+	t.AddColumn("EXPIRES", func(r *keysetRotation) string {
+		return r.Primary.Certificate.Certificate.NotAfter.Local().Format("2006-01-02")
+	})
+	if err := t.Render(rotations, out, "KEYSET", "PHASE", "PRIMARY", "ISSUED", "EXPIRES"); err != nil {
+		return err
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "\nMust specify --yes to rotate\n")
+		return nil
+	}
+
+	phases := make(map[certificates.Phase][]string)
+	for _, rotation := range rotations {
+		switch rotation.Phase {
+		case certificates.PhaseCreate:
+			err = createKeypair(out, &CreateKeypairOptions{ClusterName: options.ClusterName}, rotation.Name, keyStore)
+		case certificates.PhasePromote:
+			err = promoteKeypair(out, rotation.Name, "", keyStore)
+		case certificates.PhaseDistrust:
+			err = distrustKeypair(out, rotation.Name, nil, keyStore)
+		default:
+			err = fmt.Errorf("unknown phase %q", rotation.Phase)
+		}
+		if err != nil {
+			return fmt.Errorf("rotating keyset %s: %v", rotation.Name, err)
+		}
+		phases[rotation.Phase] = append(phases[rotation.Phase], rotation.Name)
+	}
+
+	updateOptions := &UpdateClusterOptions{}
+	updateOptions.InitDefaults()
+	updateOptions.Yes = true
+	updateOptions.ClusterName = options.ClusterName
+	updateOptions.CreateKubecfg = false
+	if _, err := RunUpdateCluster(ctx, f, out, updateOptions); err != nil {
+		return fmt.Errorf("error updating cluster: %v", err)
+	}
+
+	if options.SkipRollingUpdate {
+		fmt.Fprintf(out, "\nThe rolling update was skipped; run \"kops rolling-update cluster --yes\" before the next phase of the rotation.\n")
+	} else {
+		rollingUpdateOptions := &RollingUpdateOptions{}
+		rollingUpdateOptions.InitDefaults()
+		rollingUpdateOptions.Yes = true
+		rollingUpdateOptions.ClusterName = options.ClusterName
+		if err := RunRollingUpdateCluster(ctx, f, out, rollingUpdateOptions); err != nil {
+			return fmt.Errorf("error performing rolling update: %v", err)
+		}
+	}
+
+	printRotationNextSteps(out, phases)
+	return nil
+}
+
+// planKeysetRotations returns the next phase of the rotation of each keyset that is due for rotation
+func planKeysetRotations(keyStore fi.CAStore, name string, olderThan time.Duration) ([]*keysetRotation, error) {
+	keysets := make(map[string]*fi.Keyset)
+	if name == "all" {
+		list, err := keyStore.ListKeysets()
+		if err != nil {
+			return nil, fmt.Errorf("listing keysets: %v", err)
+		}
+		for name, keyset := range list {
+			if rotatableKeysetFilter(name, keyset) {
+				keysets[name] = keyset
+			}
+		}
+	} else {
+		keyset, err := keyStore.FindKeyset(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading keyset: %v", err)
+		} else if keyset == nil {
+			return nil, fmt.Errorf("keyset not found")
+		}
+		keysets[name] = keyset
+	}
+
+	issuedBefore := time.Now().Add(-olderThan)
+	var rotations []*keysetRotation
+	for name, keyset := range keysets {
+		if keyset.Primary == nil || keyset.Primary.Certificate == nil {
+			return nil, fmt.Errorf("keyset %s has no primary keypair", name)
+		}
+		if olderThan != 0 && !certificates.DueForRotation(keyset, issuedBefore) {
+			continue
+		}
+		rotations = append(rotations, &keysetRotation{
+			Name:    name,
+			Phase:   certificates.NextPhase(keyset),
+			Primary: keyset.Primary,
+		})
+	}
+
+	sort.Slice(rotations, func(i, j int) bool {
+		return rotations[i].Name < rotations[j].Name
+	})
+	return rotations, nil
+}
+
+// printRotationNextSteps prints what to do after each phase of the rotation
+func printRotationNextSteps(out io.Writer, phases map[certificates.Phase][]string) {
+	fmt.Fprintf(out, "\n")
+	if names := phases[certificates.PhaseCreate]; len(names) != 0 {
+		if slice.Contains(names, fi.CertificateIDCA) {
+			fmt.Fprintf(out, "The new %s keypair is trusted. Unless the Kubernetes API uses a load balancer with its own certificate,\n", fi.CertificateIDCA)
+			fmt.Fprintf(out, "export a kubeconfig with \"kops export kubecfg\" and distribute its certificate-authority-data to all clients.\n")
+		}
+		fmt.Fprintf(out, "Run \"kops rotate keypair\" again to promote the new keypairs of %v.\n", names)
+	}
+	if names := phases[certificates.PhasePromote]; len(names) != 0 {
+		if slice.Contains(names, fi.CertificateIDCA) {
+			fmt.Fprintf(out, "The new %s keypair is the primary. Export new admin credentials with \"kops export kubecfg --admin=DURATION\"\n", fi.CertificateIDCA)
+			fmt.Fprintf(out, "and distribute them to all clients that require them.\n")
+		}
+		fmt.Fprintf(out, "Run \"kops rotate keypair\" again to distrust the previous keypairs of %v.\n", names)
+	}
+	if names := phases[certificates.PhaseDistrust]; len(names) != 0 {
+		if slice.Contains(names, fi.CertificateIDCA) {
+			fmt.Fprintf(out, "The previous %s keypairs are distrusted. Unless the Kubernetes API uses a load balancer with its own certificate,\n", fi.CertificateIDCA)
+			fmt.Fprintf(out, "export a kubeconfig with \"kops export kubecfg\" and distribute its certificate-authority-data to all clients.\n")
+		}
+		fmt.Fprintf(out, "The rotation of %v is complete.\n", names)
+	}
+}
+
+func completeRotateKeyset(options *RotateKeypairOptions, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	commandutils.ConfigureKlogForCompletion()
+	ctx := context.TODO()
+
+	cluster, clientSet, completions, directive := GetClusterForCompletion(ctx, &rootCommand, nil)
+	if cluster == nil {
+		return completions, directive
+	}
+
+	if len(args) != 0 {
+		return commandutils.CompletionError("too many arguments", nil)
+	}
+
+	_, _, completions, directive = completeKeyset(cluster, clientSet, args, rotatableKeysetFilter)
+	return completions, directive
+}
@@ -37,6 +37,7 @@ func NewCmdToolbox(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdToolboxDump(f, out))
 	cmd.AddCommand(NewCmdToolboxTemplate(f, out))
 	cmd.AddCommand(NewCmdToolboxInstanceSelector(f, out))
+	cmd.AddCommand(NewCmdToolboxMigrateState(f, out))
 
 	return cmd
 }
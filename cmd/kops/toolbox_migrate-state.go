@@ -0,0 +1,274 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/statemigration"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	toolboxMigrateStateLong = templates.LongDesc(i18n.T(`
+	Copies the state of a cluster to another state store.
+
+	The state is read and written through the clients of the state stores, so the source and
+	the destination can each be a VFS state store, e.g. s3:// or gs://, or the Kubernetes API (k8s://).
+	The cluster and instance group specs, the keysets, the secrets, the SSH public keys and the addons
+	are copied, and each copy is verified. The history of the cluster spec and the etcd backups are not copied;
+	the etcd backups stay where they are.
+
+	When the destination is a VFS state store, the ConfigBase, KeyStore and SecretStore of the cluster spec
+	are rewritten to the new state store. When it is the Kubernetes API, the ConfigBase, which the nodes read
+	their configuration from, is kept.
+	The state in the old state store is left in place.
+
+	Nodes read their configuration from the ConfigBase they were created with, until the
+	cluster is updated from the new state store and they are replaced by a rolling update.`))
+
+	toolboxMigrateStateExample = templates.Examples(i18n.T(`
+	# Show what would be copied to a new bucket
+	kops toolbox migrate-state k8s-cluster.example.com --state s3://old-bucket --to s3://new-bucket
+
+	# Copy the state, and update the cluster so that a rolling update replaces the nodes
+	kops toolbox migrate-state k8s-cluster.example.com --state s3://old-bucket --to gs://new-bucket --stage-rolling-update --yes
+
+	# Copy the state to the Kubernetes API of the current kubectl context
+	kops toolbox migrate-state k8s-cluster.example.com --state s3://old-bucket --to k8s:// --yes
+	`))
+
+	toolboxMigrateStateShort = i18n.T(`Copy the state of a cluster to another state store`)
+)
+
+type ToolboxMigrateStateOptions struct {
+	ClusterName string
+
+	// To is the state store to copy the state to
+	To string
+
+	// StageRollingUpdate is whether to update the cluster from the new state store after the copy
+	StageRollingUpdate bool
+
+	Yes bool
+}
+
+func NewCmdToolboxMigrateState(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &ToolboxMigrateStateOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "migrate-state [CLUSTER] --to STATE_STORE",
+		Short:             toolboxMigrateStateShort,
+		Long:              toolboxMigrateStateLong,
+		Example:           toolboxMigrateStateExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunToolboxMigrateState(context.TODO(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.To, "to", options.To, "The state store to copy the state to, e.g. s3://<bucket> or k8s://[<context>]")
+	cmd.MarkFlagRequired("to")
+	cmd.Flags().BoolVar(&options.StageRollingUpdate, "stage-rolling-update", options.StageRollingUpdate, "Update the cluster from the new state store, so that a rolling update replaces the nodes reading the old one")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Copy the state")
+
+	return cmd
+}
+
+func RunToolboxMigrateState(ctx context.Context, f *util.Factory, out io.Writer, options *ToolboxMigrateStateOptions) error {
+	to := strings.TrimSuffix(options.To, "/")
+	if to == "" {
+		return fmt.Errorf("--to is required")
+	}
+	from := strings.TrimSuffix(f.KopsStateStore(), "/")
+	if to == from {
+		return fmt.Errorf("the source and destination of the migration are both %s", from)
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+	destinationFactory := util.NewFactory(&util.FactoryOptions{RegistryPath: to})
+	destination, err := destinationFactory.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	if options.Yes {
+		unlock, err := lockClusterState(clientset, cluster, "kops toolbox migrate-state")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	migrated, references, err := rewriteStateReferences(clientset, cluster, to)
+	if err != nil {
+		return err
+	}
+
+	plan, err := statemigration.BuildPlan(ctx, clientset, destination, cluster, migrated)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Will copy cluster %q from %s to %s: %s\n", cluster.ObjectMeta.Name, from, to, plan.Summary())
+	if err := printStateReferences(out, references); err != nil {
+		return err
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "\nMust specify --yes to copy the state\n")
+		return nil
+	}
+
+	if err := plan.Execute(ctx); err != nil {
+		return fmt.Errorf("error copying the state: %v", err)
+	}
+	fmt.Fprintf(out, "\nCopied the state of cluster %q to %s\n", cluster.ObjectMeta.Name, to)
+
+	if options.StageRollingUpdate {
+		updateOptions := &UpdateClusterOptions{}
+		updateOptions.InitDefaults()
+		updateOptions.Yes = true
+		updateOptions.ClusterName = cluster.ObjectMeta.Name
+		updateOptions.CreateKubecfg = false
+		if _, err := RunUpdateCluster(ctx, destinationFactory, out, updateOptions); err != nil {
+			return fmt.Errorf("error updating cluster from %s: %v", to, err)
+		}
+		fmt.Fprintf(out, "\nThe nodes still read their configuration from %s until they are replaced; run \"kops rolling-update cluster --state %s --yes\" to replace them.\n", cluster.Spec.ConfigBase, to)
+	} else {
+		fmt.Fprintf(out, "\nThe nodes still read their configuration from %s; run \"kops update cluster --state %s --yes\" and \"kops rolling-update cluster --state %s --yes\" to move them to the new state store.\n", cluster.Spec.ConfigBase, to, to)
+	}
+	fmt.Fprintf(out, "The state in %s has been left in place, and can be deleted once the nodes have been replaced.\n", from)
+
+	return nil
+}
+
+// rewriteStateReferences returns a copy of the cluster with the fields that refer to its state in the source state store
+// rewritten to the destination state store, along with those fields.
+// The Kubernetes API cannot hold the files the nodes read, so the ConfigBase is kept when the destination is k8s://.
+func rewriteStateReferences(clientset simple.Clientset, cluster *kopsapi.Cluster, to string) (*kopsapi.Cluster, []*stateReference, error) {
+	sourceConfigBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrated := cluster.DeepCopy()
+	if migrated.Spec.ConfigBase == "" {
+		migrated.Spec.ConfigBase = sourceConfigBase.Path()
+	}
+	if strings.HasPrefix(to, "k8s://") {
+		return migrated, nil, nil
+	}
+	destinationConfigBase := to + "/" + cluster.ObjectMeta.Name
+
+	references := []*stateReference{
+		{Field: "spec.configBase", Value: &migrated.Spec.ConfigBase},
+		{Field: "spec.keyStore", Value: &migrated.Spec.KeyStore},
+		{Field: "spec.secretStore", Value: &migrated.Spec.SecretStore},
+	}
+	for _, reference := range references {
+		if *reference.Value == "" {
+			continue
+		}
+		reference.Old = *reference.Value
+		rewritten, ok, err := statemigration.RewritePath(*reference.Value, sourceConfigBase, destinationConfigBase)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			klog.Warningf("%s refers to %s, which is outside the cluster state and will not be copied", reference.Field, reference.Old)
+			continue
+		}
+		*reference.Value = rewritten
+	}
+
+	// The etcd backups are not copied, so etcd-manager keeps them in the old ConfigBase instead of starting afresh in the new one
+	if migrated.Spec.ConfigBase != cluster.Spec.ConfigBase {
+		for i := range migrated.Spec.EtcdClusters {
+			etcdCluster := &migrated.Spec.EtcdClusters[i]
+			if etcdCluster.Provider != "" && etcdCluster.Provider != kopsapi.EtcdProviderTypeManager {
+				continue
+			}
+			if etcdCluster.Backups == nil {
+				etcdCluster.Backups = &kopsapi.EtcdBackupSpec{}
+			}
+			if etcdCluster.Backups.BackupStore != "" {
+				continue
+			}
+			etcdCluster.Backups.BackupStore = sourceConfigBase.Join("backups", "etcd", etcdCluster.Name).Path()
+			references = append(references, &stateReference{
+				Field: "spec.etcdClusters[" + etcdCluster.Name + "].backups.backupStore",
+				Old:   "(default)",
+				Value: &etcdCluster.Backups.BackupStore,
+			})
+		}
+	}
+
+	return migrated, references, nil
+}
+
+// stateReference is a field of the cluster spec that refers to the state store
+type stateReference struct {
+	Field string
+	Old   string
+	Value *string
+}
+
+func printStateReferences(out io.Writer, references []*stateReference) error {
+	var changed []*stateReference
+	for _, reference := range references {
+		if reference.Old != "" && reference.Old != *reference.Value {
+			changed = append(changed, reference)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(out, "\nWill rewrite the references to the state store in the cluster spec:\n")
+	t := &tables.Table{}
+	t.AddColumn("FIELD", func(r *stateReference) string {
+		return r.Field
+	})
+	t.AddColumn("OLD", func(r *stateReference) string {
+		return r.Old
+	})
+	t.AddColumn("NEW", func(r *stateReference) string {
+		return *r.Value
+	})
+	return t.Render(changed, out, "FIELD", "OLD", "NEW")
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/testutils"
+)
+
+func TestToolboxMigrateState(t *testing.T) {
+	ctx := context.Background()
+	h := testutils.NewIntegrationTestHarness(t)
+	defer h.Close()
+	h.SetupMockAWS()
+
+	factory := util.NewFactory(&util.FactoryOptions{RegistryPath: "memfs://tests"})
+	var stdout bytes.Buffer
+	require.NoError(t, RunCreate(ctx, factory, &stdout, &CreateOptions{
+		Filenames: []string{"../../tests/integration/update_cluster/minimal/in-v1alpha2.yaml"},
+	}))
+
+	options := &ToolboxMigrateStateOptions{
+		ClusterName: "minimal.example.com",
+		To:          "memfs://new/",
+	}
+
+	// Without --yes, the plan is only shown
+	stdout.Reset()
+	require.NoError(t, RunToolboxMigrateState(ctx, factory, &stdout, options))
+	assert.Contains(t, stdout.String(), `Will copy cluster "minimal.example.com" from memfs://tests to memfs://new: 2 instance groups`)
+	assert.Contains(t, stdout.String(), "Must specify --yes to copy the state")
+	destination, err := util.NewFactory(&util.FactoryOptions{RegistryPath: "memfs://new"}).Clientset()
+	require.NoError(t, err)
+	_, err = destination.GetCluster(ctx, "minimal.example.com")
+	require.Error(t, err)
+
+	options.Yes = true
+	require.NoError(t, RunToolboxMigrateState(ctx, factory, &stdout, options))
+	migrated, err := destination.GetCluster(ctx, "minimal.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "memfs://new/minimal.example.com", migrated.Spec.ConfigBase)
+	for _, etcdCluster := range migrated.Spec.EtcdClusters {
+		assert.Equal(t, "memfs://clusters.example.com/minimal.example.com/backups/etcd/"+etcdCluster.Name, etcdCluster.Backups.BackupStore,
+			"the etcd backups should stay in the old state store")
+	}
+
+	// The state is not copied over an existing cluster
+	err = RunToolboxMigrateState(ctx, factory, &stdout, options)
+	assert.EqualError(t, err, `cluster "minimal.example.com" already exists in the destination; refusing to overwrite it`)
+}
@@ -103,6 +103,12 @@ func RunTrustKeypair(ctx context.Context, f *util.Factory, out io.Writer, option
 		return err
 	}
 
+	unlock, err := lockClusterState(clientset, cluster, "kops trust keypair")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	keyStore, err := clientset.KeyStore(cluster)
 	if err != nil {
 		return err
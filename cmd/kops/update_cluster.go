@@ -19,9 +19,11 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -37,12 +39,17 @@ import (
 	"k8s.io/kops/pkg/kubeconfig"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/resourcegraph"
 	"k8s.io/kops/upup/pkg/fi/utils"
 	"k8s.io/kops/upup/pkg/kutil"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
 )
 
+// GraphFormatDOT prints the task graph in the Graphviz DOT language
+const GraphFormatDOT = "dot"
+
 var (
 	updateClusterLong = templates.LongDesc(i18n.T(`
 	Create or update cloud or cluster resources to match the current cluster and instance group definitions.
@@ -55,6 +62,12 @@ var (
 	updateClusterExample = templates.Examples(i18n.T(`
 	# After the cluster has been edited or upgraded, update the cloud resources with:
 	kops update cluster k8s-cluster.example.com --yes --state=s3://my-state-store --yes
+
+	# Print the changes that would be made as JSON, e.g. to check them against policies before applying them.
+	kops update cluster k8s-cluster.example.com -o json
+
+	# Render the graph of the tasks and their dependencies with Graphviz.
+	kops update cluster k8s-cluster.example.com --dump-graph=dot | dot -Tsvg > tasks.svg
 	`))
 
 	updateClusterShort = i18n.T("Update a cluster.")
@@ -69,6 +82,12 @@ type UpdateClusterOptions struct {
 	AllowKopsDowngrade bool
 	// GetAssets is whether this is invoked from the CmdGetAssets.
 	GetAssets bool
+	// DryRunOut is where the dry-run report is printed; it defaults to stdout.
+	DryRunOut io.Writer
+	// Output is the format in which to print the dry-run changes: table, json, or yaml
+	Output string
+	// DumpGraph is the format in which to print the task graph of a dry run instead of the changes: dot or json
+	DumpGraph string
 
 	ClusterName string
 
@@ -89,6 +108,7 @@ func (o *UpdateClusterOptions) InitDefaults() {
 	o.Target = "direct"
 	o.SSHPublicKey = ""
 	o.OutDir = ""
+	o.Output = OutputTable
 
 	// By default we export a kubecfg, but it doesn't have a static/eternal credential in it any more.
 	o.CreateKubecfg = true
@@ -114,7 +134,7 @@ func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Create cloud resources, without --yes update is in dry run mode")
-	cmd.Flags().StringVar(&options.Target, "target", options.Target, "Target - direct, terraform, cloudformation")
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "Target - direct, terraform, cloudformation, resourcegraph")
 	cmd.RegisterFlagCompletionFunc("target", completeUpdateClusterTarget(options))
 	cmd.Flags().StringVar(&options.SSHPublicKey, "ssh-public-key", options.SSHPublicKey, "SSH public key to use (deprecated: use kops create secret instead)")
 	cmd.Flags().StringVar(&options.OutDir, "out", options.OutDir, "Path to write any local output")
@@ -133,7 +153,18 @@ func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().StringSliceVar(&options.LifecycleOverrides, "lifecycle-overrides", options.LifecycleOverrides, "comma separated list of phase overrides, example: SecurityGroups=Ignore,InternetGateway=ExistsAndWarnIfChanges")
 	viper.BindPFlag("lifecycle-overrides", cmd.Flags().Lookup("lifecycle-overrides"))
 	viper.BindEnv("lifecycle-overrides", "KOPS_LIFECYCLE_OVERRIDES")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format for the changes of a dry run. One of json|yaml|table.")
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{OutputJSON, OutputYaml, OutputTable}, cobra.ShellCompDirectiveNoFileComp
+	})
 	cmd.RegisterFlagCompletionFunc("lifecycle-overrides", completeLifecycleOverrides)
+	cmd.Flags().StringVar(&options.DumpGraph, "dump-graph", options.DumpGraph, "Print the graph of the tasks of a dry run, with their dependencies and the changes they would make, instead of the changes. One of dot|json.")
+	cmd.RegisterFlagCompletionFunc("dump-graph", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{GraphFormatDOT, OutputJSON}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().IntVar(&options.RunTasksOptions.MaxConcurrency, "max-concurrency", options.RunTasksOptions.MaxConcurrency, "Maximum number of tasks to run at the same time, to avoid throttling by the cloud API. 0 for no limit")
+	cmd.Flags().StringVar(&options.RunTasksOptions.TraceFile, "trace-file", options.RunTasksOptions.TraceFile, "Path of a file to which to write a trace of the tasks run, in the Chrome trace event format")
+	cmd.MarkFlagFilename("trace-file", "json")
 
 	return cmd
 }
@@ -190,19 +221,62 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 		targetName = cloudup.TargetDryRun
 	}
 
+	structuredOutput := false
+	switch c.Output {
+	case "", OutputTable:
+	case OutputJSON, OutputYaml:
+		if !isDryrun {
+			return nil, fmt.Errorf("--output %s can only be used for a dry run", c.Output)
+		}
+		structuredOutput = true
+	default:
+		return nil, fmt.Errorf("unknown output format: %q", c.Output)
+	}
+	switch c.DumpGraph {
+	case "":
+	case GraphFormatDOT, OutputJSON:
+		if !isDryrun {
+			return nil, fmt.Errorf("--dump-graph can only be used for a dry run")
+		}
+		if structuredOutput {
+			return nil, fmt.Errorf("--dump-graph cannot be used with --output %s", c.Output)
+		}
+		structuredOutput = true
+	default:
+		return nil, fmt.Errorf("unknown graph format: %q", c.DumpGraph)
+	}
+	// With structured output, stdout is reserved for the changes
+	messages := out
+	if structuredOutput {
+		messages = os.Stderr
+	}
+
 	if c.OutDir == "" {
 		if c.Target == cloudup.TargetTerraform {
 			c.OutDir = "out/terraform"
 		} else if c.Target == cloudup.TargetCloudformation {
 			c.OutDir = "out/cloudformation"
+		} else if c.Target == cloudup.TargetResourceGraph {
+			c.OutDir = "out/resourcegraph"
 		} else {
 			c.OutDir = "out"
 		}
 	}
 
-	cluster, err := GetCluster(ctx, f, c.ClusterName)
-	if err != nil {
-		return results, err
+	var cluster *kops.Cluster
+	var err error
+	if isDryrun {
+		cluster, err = GetCluster(ctx, f, c.ClusterName)
+		if err != nil {
+			return results, err
+		}
+	} else {
+		var unlock func()
+		cluster, unlock, err = lockAndGetCluster(ctx, f, c.ClusterName, "kops update cluster")
+		if err != nil {
+			return results, err
+		}
+		defer unlock()
 	}
 
 	clientset, err := f.Clientset()
@@ -226,7 +300,7 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 	}
 
 	if c.SSHPublicKey != "" {
-		fmt.Fprintf(out, "--ssh-public-key on update is deprecated - please use `kops create secret --name %s sshpublickey admin -i ~/.ssh/id_rsa.pub` instead\n", cluster.ObjectMeta.Name)
+		fmt.Fprintf(messages, "--ssh-public-key on update is deprecated - please use `kops create secret --name %s sshpublickey admin -i ~/.ssh/id_rsa.pub` instead\n", cluster.ObjectMeta.Name)
 
 		c.SSHPublicKey = utils.ExpandPath(c.SSHPublicKey)
 		authorized, err := ioutil.ReadFile(c.SSHPublicKey)
@@ -291,6 +365,10 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 		TargetName:         targetName,
 		LifecycleOverrides: lifecycleOverrideMap,
 		GetAssets:          c.GetAssets,
+		DryRunOut:          c.DryRunOut,
+	}
+	if structuredOutput {
+		applyCmd.DryRunOut = io.Discard
 	}
 
 	if err := applyCmd.Run(ctx); err != nil {
@@ -305,6 +383,12 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 
 	if isDryrun && !c.GetAssets {
 		target := applyCmd.Target.(*fi.DryRunTarget)
+		if c.DumpGraph != "" {
+			return results, writeTaskGraph(out, c.DumpGraph, target, applyCmd.TaskMap)
+		}
+		if structuredOutput {
+			return results, writeUpdateClusterPlan(out, c.Output, cluster.ObjectMeta.Name, target, applyCmd.TaskMap)
+		}
 		if target.HasChanges() {
 			fmt.Fprintf(out, "Must specify --yes to apply changes\n")
 		} else {
@@ -376,6 +460,10 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 				fmt.Fprintf(sb, "   aws cloudformation create-stack --capabilities CAPABILITY_NAMED_IAM --stack-name %s --template-body file://%s\n", cfName, cfPath)
 				fmt.Fprintf(sb, "\n")
 			}
+		} else if c.Target == cloudup.TargetResourceGraph {
+			fmt.Fprintf(sb, "\n")
+			fmt.Fprintf(sb, "Resource graph output has been placed into %s\n", filepath.Join(c.OutDir, resourcegraph.OutputFile))
+			fmt.Fprintf(sb, "\n")
 		} else if firstRun {
 			fmt.Fprintf(sb, "\n")
 			fmt.Fprintf(sb, "Cluster is starting.  It should be ready in a few minutes.\n")
@@ -481,12 +569,14 @@ func completeUpdateClusterTarget(options *UpdateClusterOptions) func(cmd *cobra.
 				cloudup.TargetDryRun,
 				cloudup.TargetCloudformation,
 				cloudup.TargetTerraform,
+				cloudup.TargetResourceGraph,
 			}, directive
 		}
 
 		completions := []string{
 			cloudup.TargetDirect,
 			cloudup.TargetDryRun,
+			cloudup.TargetResourceGraph,
 		}
 		for _, cp := range cloudup.TerraformCloudProviders {
 			if cluster.Spec.CloudProvider == string(cp) {
@@ -517,3 +607,74 @@ func completeLifecycleOverrides(cmd *cobra.Command, args []string, toComplete st
 	}
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
+
+// UpdateClusterPlan is the structured output of a dry run
+type UpdateClusterPlan struct {
+	// Cluster is the name of the cluster
+	Cluster string `json:"cluster"`
+	// Changes are the changes that would be made by the update
+	Changes []*fi.TaskChange `json:"changes"`
+}
+
+func writeUpdateClusterPlan(out io.Writer, output string, clusterName string, target *fi.DryRunTarget, taskMap map[string]fi.Task) error {
+	changes, err := target.TaskChanges(taskMap)
+	if err != nil {
+		return err
+	}
+	plan := &UpdateClusterPlan{
+		Cluster: clusterName,
+		Changes: changes,
+	}
+	if plan.Changes == nil {
+		plan.Changes = []*fi.TaskChange{}
+	}
+
+	switch output {
+	case OutputYaml:
+		y, err := yaml.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", output)
+	}
+	return nil
+}
+
+// writeTaskGraph prints the graph of the tasks, marking the tasks that would make changes
+func writeTaskGraph(out io.Writer, format string, target *fi.DryRunTarget, taskMap map[string]fi.Task) error {
+	changes, err := target.TaskChanges(taskMap)
+	if err != nil {
+		return err
+	}
+	graph := fi.BuildTaskGraph(taskMap, changes)
+
+	switch format {
+	case GraphFormatDOT:
+		if err := graph.WriteDOT(out); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(graph)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported graph format: %q", format)
+	}
+	return nil
+}
@@ -95,9 +95,20 @@ type upgradeAction struct {
 }
 
 func RunUpgradeCluster(ctx context.Context, f *util.Factory, out io.Writer, options *UpgradeClusterOptions) error {
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
-	if err != nil {
-		return err
+	var cluster *kopsapi.Cluster
+	var err error
+	if options.Yes {
+		var unlock func()
+		cluster, unlock, err = lockAndGetCluster(ctx, f, options.ClusterName, "kops upgrade cluster")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	} else {
+		cluster, err = GetCluster(ctx, f, options.ClusterName)
+		if err != nil {
+			return err
+		}
 	}
 
 	clientset, err := rootCommand.Clientset()
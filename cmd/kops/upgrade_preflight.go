@@ -0,0 +1,244 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/kops"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	kopsutil "k8s.io/kops/pkg/apis/kops/util"
+	"k8s.io/kops/pkg/commands"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/preflight"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	upgradePreflightLong = templates.LongDesc(i18n.T(`
+	Check whether a cluster can be upgraded to a Kubernetes version, without changing the cluster.
+
+	The report covers the Kubernetes version skew, the validity of the cluster spec with the new version,
+	deprecated and removed flags set in the component configs, objects in the live cluster written with
+	APIs removed by the upgrade, the CNI versions set in the cluster spec and the distributions of the
+	instance group images. Each check passes, warns or fails; the command fails if any check fails.
+
+	The live cluster is checked using the kubeconfig context named after the cluster.
+
+	If no Kubernetes version is specified, the version recommended by the channel is checked.`))
+
+	upgradePreflightExample = templates.Examples(i18n.T(`
+	# Check whether a cluster can be upgraded to Kubernetes 1.22.
+	kops upgrade preflight k8s-cluster.example.com --kubernetes-version 1.22.0
+
+	# Check the cluster spec only, without connecting to the cluster.
+	kops upgrade preflight k8s-cluster.example.com --kubernetes-version 1.22.0 --skip-live-checks
+
+	# Check the upgrade to the version recommended by the channel, with the report in JSON.
+	kops upgrade preflight k8s-cluster.example.com -o json
+	`))
+
+	upgradePreflightShort = i18n.T("Check whether a cluster can be upgraded to a Kubernetes version.")
+)
+
+type UpgradePreflightOptions struct {
+	ClusterName       string
+	KubernetesVersion string
+	Channel           string
+	Kubeconfig        string
+	SkipLiveChecks    bool
+	Output            string
+}
+
+func NewCmdUpgradePreflight(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &UpgradePreflightOptions{
+		Output: OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "preflight [CLUSTER]",
+		Short:             upgradePreflightShort,
+		Long:              upgradePreflightLong,
+		Example:           upgradePreflightExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.TODO()
+
+			return RunUpgradePreflight(ctx, f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.KubernetesVersion, "kubernetes-version", options.KubernetesVersion, "Kubernetes version to check the upgrade to; defaults to the version recommended by the channel")
+	cmd.RegisterFlagCompletionFunc("kubernetes-version", completeKubernetesVersion)
+	cmd.Flags().StringVar(&options.Channel, "channel", options.Channel, "Channel to use for the recommended Kubernetes version")
+	cmd.RegisterFlagCompletionFunc("channel", completeChannel)
+	cmd.Flags().StringVar(&options.Kubeconfig, "kubeconfig", options.Kubeconfig, "Path to the kubeconfig file")
+	cmd.Flags().BoolVar(&options.SkipLiveChecks, "skip-live-checks", options.SkipLiveChecks, "Do not check the live cluster for objects using removed APIs")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format. One of table, yaml, json")
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{OutputTable, OutputJSON, OutputYaml}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+func RunUpgradePreflight(ctx context.Context, f *util.Factory, out io.Writer, options *UpgradePreflightOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	instanceGroupList, err := commands.ReadAllInstanceGroups(ctx, clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	targetVersion := options.KubernetesVersion
+	if targetVersion == "" {
+		channelLocation := options.Channel
+		if channelLocation == "" {
+			channelLocation = cluster.Spec.Channel
+		}
+		if channelLocation == "" {
+			channelLocation = kopsapi.DefaultChannel
+		}
+		channel, err := kopsapi.LoadChannel(channelLocation)
+		if err != nil {
+			return fmt.Errorf("error loading channel %q: %v", channelLocation, err)
+		}
+		recommended := kopsapi.RecommendedKubernetesVersion(channel, kops.Version)
+		if recommended == nil {
+			return fmt.Errorf("channel %q does not recommend a Kubernetes version; specify --kubernetes-version", channelLocation)
+		}
+		targetVersion = recommended.String()
+	}
+	target, err := kopsutil.ParseKubernetesVersion(targetVersion)
+	if err != nil {
+		return fmt.Errorf("unable to parse kubernetes version %q: %v", targetVersion, err)
+	}
+
+	preflightOptions := &preflight.Options{
+		Cluster:        cluster,
+		InstanceGroups: instanceGroupList,
+		TargetVersion:  *target,
+	}
+	if !options.SkipLiveChecks {
+		finder, err := newAPIUsageFinder(cluster, options.Kubeconfig)
+		if err != nil {
+			return fmt.Errorf("%v; use --skip-live-checks to check the cluster spec only", err)
+		}
+		preflightOptions.APIUsage = finder
+	}
+
+	report, err := preflight.BuildReport(ctx, preflightOptions)
+	if err != nil {
+		return err
+	}
+
+	switch options.Output {
+	case OutputTable:
+		fmt.Fprintf(out, "Upgrade of cluster %s from Kubernetes %s to %s\n\n", report.Cluster, report.CurrentVersion, report.TargetVersion)
+		if err := preflightOutputTable(report.Checks, out); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\nResult: %s\n", report.Result)
+	case OutputYaml:
+		y, err := yaml.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(append(j, '\n')); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", options.Output)
+	}
+
+	if report.Result == preflight.ResultFail {
+		return fmt.Errorf("preflight checks failed for the upgrade to Kubernetes %s", report.TargetVersion)
+	}
+	return nil
+}
+
+// newAPIUsageFinder builds an APIUsageFinder for the cluster, using the kubeconfig context named after the cluster
+func newAPIUsageFinder(cluster *kopsapi.Cluster, kubeconfig string) (preflight.APIUsageFinder, error) {
+	contextName := cluster.ObjectMeta.Name
+	configLoadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		configLoadingRules.ExplicitPath = kubeconfig
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		configLoadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
+	}
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build kubernetes metadata client for %q: %v", contextName, err)
+	}
+
+	return preflight.NewKubernetesAPIUsageFinder(k8sClient.Discovery(), metadataClient), nil
+}
+
+func preflightOutputTable(checks []*preflight.Check, out io.Writer) error {
+	t := &tables.Table{}
+	t.AddColumn("CATEGORY", func(c *preflight.Check) string {
+		return c.Category
+	})
+	t.AddColumn("RESULT", func(c *preflight.Check) string {
+		return string(c.Result)
+	})
+	t.AddColumn("NAME", func(c *preflight.Check) string {
+		return c.Name
+	})
+	t.AddColumn("MESSAGE", func(c *preflight.Check) string {
+		return c.Message
+	})
+
+	return t.Render(checks, out, "CATEGORY", "RESULT", "NAME", "MESSAGE")
+}
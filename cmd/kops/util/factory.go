@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 	gceacls "k8s.io/kops/pkg/acls/gce"
@@ -70,8 +71,7 @@ func (f *Factory) Clientset() (simple.Clientset, error) {
 			return nil, field.Required(field.NewPath("State Store"), STATE_ERROR)
 		}
 
-		// We recognize a `k8s` scheme; this might change in future so we won't document it yet
-		// In practice nobody is going to hit this accidentally, so I don't think we need a feature flag.
+		// The `k8s` scheme stores the state in the kops.k8s.io CRDs of the cluster of a kubeconfig context, e.g. k8s://<context>
 		if strings.HasPrefix(registryPath, "k8s://") {
 			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 
@@ -97,11 +97,17 @@ func (f *Factory) Clientset() (simple.Clientset, error) {
 				return nil, fmt.Errorf("error building kops API client: %v", err)
 			}
 
+			kubeClient, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				return nil, fmt.Errorf("error building kubernetes client: %v", err)
+			}
+
 			f.clientset = &api.RESTClientset{
 				BaseURL: &url.URL{
 					Scheme: "k8s",
 				},
 				KopsClient: kopsClient.Kops(),
+				KubeClient: kubeClient,
 			}
 		} else if strings.HasPrefix(registryPath, "vault://") {
 			return nil, field.Invalid(field.NewPath("State Store"), registryPath, "Vault is not supported as registry path")
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -30,11 +31,16 @@ import (
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/cmd/kops/util"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
@@ -56,7 +62,10 @@ var (
 	validateClusterExample = templates.Examples(i18n.T(`
 	# Validate the cluster set as the current context of the kube config.
 	# Kops will try for 10 minutes to validate the cluster 3 times.
-	kops validate cluster --wait 10m --count 3`))
+	kops validate cluster --wait 10m --count 3
+
+	# Validate the cluster every minute, exporting the results as Prometheus metrics on port 9090.
+	kops validate cluster --watch --interval 1m --metrics-listen :9090`))
 
 	validateClusterShort = i18n.T(`Validate a kOps cluster.`)
 )
@@ -67,10 +76,18 @@ type ValidateClusterOptions struct {
 	wait        time.Duration
 	count       int
 	kubeconfig  string
+
+	// watch keeps validating the cluster every interval, exporting the results
+	// as Prometheus metrics on metricsListen and as Kubernetes events
+	watch         bool
+	interval      time.Duration
+	metricsListen string
 }
 
 func (o *ValidateClusterOptions) InitDefaults() {
 	o.output = OutputTable
+	o.interval = time.Minute
+	o.metricsListen = ":9090"
 }
 
 func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
@@ -85,6 +102,10 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
 		ValidArgsFunction: commandutils.CompleteClusterName(&rootCommand, true),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.watch {
+				return RunWatchCluster(context.TODO(), f, options)
+			}
+
 			result, err := RunValidateCluster(context.TODO(), f, out, options)
 			if err != nil {
 				return fmt.Errorf("Validation failed: %v", err)
@@ -106,31 +127,19 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().DurationVar(&options.wait, "wait", options.wait, "Amount of time to wait for the cluster to become ready")
 	cmd.Flags().IntVar(&options.count, "count", options.count, "Number of consecutive successful validations required")
 	cmd.Flags().StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	cmd.Flags().BoolVar(&options.watch, "watch", options.watch, "Keep validating the cluster, exporting the results as Prometheus metrics and Kubernetes events")
+	cmd.Flags().DurationVar(&options.interval, "interval", options.interval, "Time between validations with --watch")
+	cmd.Flags().StringVar(&options.metricsListen, "metrics-listen", options.metricsListen, "The address on which to listen for Prometheus metrics with --watch")
 
 	return cmd
 }
 
 func RunValidateCluster(ctx context.Context, f *util.Factory, out io.Writer, options *ValidateClusterOptions) (*validation.ValidationCluster, error) {
-	clientSet, err := f.Clientset()
-	if err != nil {
-		return nil, err
-	}
-
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	cluster, list, err := getClusterForValidation(ctx, f, options)
 	if err != nil {
 		return nil, err
 	}
 
-	cloud, err := cloudup.BuildCloud(cluster)
-	if err != nil {
-		return nil, err
-	}
-
-	list, err := clientSet.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("cannot get InstanceGroups for %q: %v", cluster.ObjectMeta.Name, err)
-	}
-
 	if options.output == OutputTable {
 		fmt.Fprintf(out, "Validating cluster %v\n\n", cluster.ObjectMeta.Name)
 	}
@@ -145,32 +154,14 @@ func RunValidateCluster(ctx context.Context, f *util.Factory, out io.Writer, opt
 		return nil, fmt.Errorf("no InstanceGroup objects found")
 	}
 
-	// TODO: Refactor into util.Factory
-	contextName := cluster.ObjectMeta.Name
-	configLoadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if options.kubeconfig != "" {
-		configLoadingRules.ExplicitPath = options.kubeconfig
-	}
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		configLoadingRules,
-		&clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
-	if err != nil {
-		return nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
-	}
-
-	k8sClient, err := kubernetes.NewForConfig(config)
+	validator, _, err := newClusterValidator(cluster, list, options)
 	if err != nil {
-		return nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
+		return nil, err
 	}
 
 	timeout := time.Now().Add(options.wait)
 	pollInterval := 10 * time.Second
 
-	validator, err := validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
-	if err != nil {
-		return nil, fmt.Errorf("unexpected error creating validatior: %v", err)
-	}
-
 	consecutive := 0
 	for {
 		if options.wait > 0 && time.Now().After(timeout) {
@@ -240,6 +231,99 @@ func RunValidateCluster(ctx context.Context, f *util.Factory, out io.Writer, opt
 	}
 }
 
+// RunWatchCluster validates the cluster every interval until the context is done, exporting the results
+// as Prometheus metrics and recording Kubernetes events as validation failures appear and are resolved.
+func RunWatchCluster(ctx context.Context, f *util.Factory, options *ValidateClusterOptions) error {
+	cluster, list, err := getClusterForValidation(ctx, f, options)
+	if err != nil {
+		return err
+	}
+
+	validator, k8sClient, err := newClusterValidator(cluster, list, options)
+	if err != nil {
+		return err
+	}
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+	defer eventBroadcaster.Shutdown()
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "kops-validate"})
+
+	watcher, err := validation.NewWatcher(validator, prometheus.DefaultRegisterer, recorder)
+	if err != nil {
+		return err
+	}
+
+	if options.metricsListen != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		server := &http.Server{Addr: options.metricsListen, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				klog.Fatalf("error serving metrics on %q: %v", options.metricsListen, err)
+			}
+		}()
+		defer server.Close()
+		klog.Infof("Serving metrics on %q", options.metricsListen)
+	}
+
+	klog.Infof("Validating cluster %q every %s", cluster.ObjectMeta.Name, options.interval)
+	return watcher.Run(ctx, options.interval)
+}
+
+// getClusterForValidation returns the cluster and its instance groups
+func getClusterForValidation(ctx context.Context, f *util.Factory, options *ValidateClusterOptions) (*kopsapi.Cluster, *kopsapi.InstanceGroupList, error) {
+	clientSet, err := f.Clientset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := clientSet.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get InstanceGroups for %q: %v", cluster.ObjectMeta.Name, err)
+	}
+
+	return cluster, list, nil
+}
+
+// newClusterValidator returns a validator for the cluster, using the kubeconfig context named after the cluster
+func newClusterValidator(cluster *kopsapi.Cluster, list *kopsapi.InstanceGroupList, options *ValidateClusterOptions) (validation.ClusterValidator, kubernetes.Interface, error) {
+	cloud, err := cloudup.BuildCloud(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// TODO: Refactor into util.Factory
+	contextName := cluster.ObjectMeta.Name
+	configLoadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if options.kubeconfig != "" {
+		configLoadingRules.ExplicitPath = options.kubeconfig
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		configLoadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
+	}
+
+	validator, err := validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unexpected error creating validatior: %v", err)
+	}
+
+	return validator, k8sClient, nil
+}
+
 func validateClusterOutputTable(result *validation.ValidationCluster, cluster *kopsapi.Cluster, instanceGroups []kopsapi.InstanceGroup, out io.Writer) error {
 	t := &tables.Table{}
 	t.AddColumn("NAME", func(c kopsapi.InstanceGroup) string {
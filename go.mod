@@ -61,6 +61,7 @@ require (
 	github.com/gophercloud/gophercloud v0.18.0
 	github.com/hashicorp/hcl/v2 v2.10.0
 	github.com/hashicorp/vault/api v1.1.0
+	github.com/hetznercloud/hcloud-go v1.33.1
 	github.com/jacksontj/memberlistmesh v0.0.0-20190905163944-93462b9d2bb7
 	github.com/jetstack/cert-manager v1.3.1
 	github.com/mitchellh/mapstructure v1.4.1
@@ -26,6 +26,8 @@ import (
 	"k8s.io/kops/pkg/wellknownports"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/upup/pkg/fi/nodeup/nodetasks"
 )
 
@@ -44,6 +46,10 @@ func (b BootstrapClientBuilder) Build(c *fi.ModelBuilderContext) error {
 	switch kops.CloudProviderID(b.Cluster.Spec.CloudProvider) {
 	case kops.CloudProviderAWS:
 		authenticator, err = awsup.NewAWSAuthenticator(b.Cloud.Region())
+	case kops.CloudProviderGCE:
+		authenticator, err = gce.NewGCEAuthenticator()
+	case kops.CloudProviderOpenstack:
+		authenticator, err = openstack.NewOpenstackAuthenticator()
 	default:
 		return fmt.Errorf("unsupported cloud provider %s", b.Cluster.Spec.CloudProvider)
 	}
@@ -275,6 +275,7 @@ const (
 	CloudProviderGCE       CloudProviderID = "gce"
 	CloudProviderOpenstack CloudProviderID = "openstack"
 	CloudProviderAzure     CloudProviderID = "azure"
+	CloudProviderHetzner   CloudProviderID = "hetzner"
 )
 
 // FindImage returns the image for the cloudprovider, or nil if none found
@@ -21,8 +21,38 @@ import (
 	"testing"
 
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/featureflag"
 )
 
+func TestUseKopsControllerForNodeBootstrap(t *testing.T) {
+	for _, tc := range []struct {
+		cloudProvider kops.CloudProviderID
+		featureFlag   bool
+		expected      bool
+	}{
+		{cloudProvider: kops.CloudProviderAWS, expected: true},
+		{cloudProvider: kops.CloudProviderGCE, expected: false},
+		{cloudProvider: kops.CloudProviderGCE, featureFlag: true, expected: true},
+		{cloudProvider: kops.CloudProviderOpenstack, featureFlag: true, expected: true},
+		{cloudProvider: kops.CloudProviderDO, featureFlag: true, expected: false},
+	} {
+		if tc.featureFlag {
+			featureflag.ParseFlags("+KopsControllerNodeBootstrap")
+		}
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider:     string(tc.cloudProvider),
+				KubernetesVersion: "1.21.0",
+			},
+		}
+		actual := UseKopsControllerForNodeBootstrap(cluster)
+		featureflag.ParseFlags("-KopsControllerNodeBootstrap")
+		if actual != tc.expected {
+			t.Errorf("expected %v for %s with feature flag %v, but got %v", tc.expected, tc.cloudProvider, tc.featureFlag, actual)
+		}
+	}
+}
+
 func TestUseCiliumEtcd(t *testing.T) {
 	for _, tc := range []struct {
 		cluster  *kops.Cluster
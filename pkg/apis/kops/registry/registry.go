@@ -31,6 +31,8 @@ const (
 	PathClusterCompleted = "cluster-completed.spec"
 	// PathKopsVersionUpdated is the path for the version of kops last used to apply the cluster.
 	PathKopsVersionUpdated = "kops-version.txt"
+	// PathRollingUpdateProgress is the path where the progress of an in-flight rolling update is recorded.
+	PathRollingUpdateProgress = "rolling-update-progress.json"
 )
 
 func ConfigBase(c *api.Cluster) (vfs.Path, error) {
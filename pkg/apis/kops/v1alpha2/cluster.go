@@ -89,6 +89,8 @@ type ClusterSpec struct {
 	KeyStore string `json:"keyStore,omitempty"`
 	// ConfigStore is the VFS path to where the configuration (Cluster, InstanceGroups etc) is stored
 	ConfigStore string `json:"configStore,omitempty"`
+	// StateStoreEncryption configures the envelope encryption of the secrets and private keys in the state store
+	StateStoreEncryption *StateStoreEncryptionSpec `json:"stateStoreEncryption,omitempty"`
 	// DNSZone is the DNS zone we should use when configuring DNS
 	// This is because some clouds let us define a managed zone foo.bar, and then have
 	// kubernetes.dev.foo.bar, without needing to define dev.foo.bar as a hosted zone.
@@ -202,6 +204,9 @@ type ClusterSpec struct {
 	SysctlParameters []string `json:"sysctlParameters,omitempty"`
 	// RollingUpdate defines the default rolling-update settings for instance groups
 	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+	// Validation configures additional checks made when validating the cluster,
+	// both by "kops validate cluster" and during rolling updates.
+	Validation *ClusterValidationSpec `json:"validation,omitempty"`
 	// ClusterAutoscaler defines the cluaster autoscaler configuration.
 	ClusterAutoscaler *ClusterAutoscalerConfig `json:"clusterAutoscaler,omitempty"`
 	// WarmPool defines the default warm pool settings for instance groups (AWS only).
@@ -214,6 +219,17 @@ type ClusterSpec struct {
 	SnapshotController *SnapshotControllerConfig `json:"snapshotController,omitempty"`
 }
 
+// StateStoreEncryptionSpec configures the envelope encryption of the secrets and private keys in the state store.
+// Each secret and keyset is encrypted with its own data key, which is encrypted with a key of a key management service.
+type StateStoreEncryptionSpec struct {
+	// Provider is the key management service: aws-kms, gcp-kms, vault-transit, or local (for testing only)
+	Provider string `json:"provider,omitempty"`
+	// Key identifies the key of the key management service that encrypts the data keys:
+	// the ARN of an AWS KMS key, the resource name of a GCP KMS crypto key,
+	// the mount path and name of a Vault transit key (e.g. transit/kops), or the path of a local key file
+	Key string `json:"key,omitempty"`
+}
+
 // ServiceAccountIssuerDiscoveryConfig configures an OIDC Issuer.
 type ServiceAccountIssuerDiscoveryConfig struct {
 	// DiscoveryStore is the VFS path to where OIDC Issuer Discovery metadata is stored.
@@ -734,6 +750,204 @@ type RollingUpdate struct {
 	// nodes.
 	// +optional
 	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// Hooks are run before draining and after terminating each instance.
+	// If not set on an instance group, the cluster-wide hooks are used.
+	// +optional
+	Hooks []RollingUpdateHook `json:"hooks,omitempty"`
+	// Canary configures updating some instances of the group first, then observing the cluster
+	// for a bake period before updating the rest. If not set on an instance group, the cluster-wide
+	// setting is used.
+	// +optional
+	Canary *CanaryRollingUpdate `json:"canary,omitempty"`
+	// DrainTimeout is the maximum amount of time to wait for a node to drain, for example
+	// when pods cannot be evicted without violating a PodDisruptionBudget.
+	// When it expires, DrainTimeoutPolicy is applied.
+	// Defaults to waiting indefinitely.
+	// +optional
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+	// DrainTimeoutPolicy is what to do when a node does not drain within the DrainTimeout:
+	// "Fail" treats it as a drain error, "Retry" tries draining the node again up to DrainRetries times,
+	// "Skip" leaves the instance running and continues with the rest of the instance group, and
+	// "Force" deletes the remaining pods without using the eviction API, bypassing PodDisruptionBudgets.
+	// Defaults to "Fail".
+	// +optional
+	DrainTimeoutPolicy DrainTimeoutPolicy `json:"drainTimeoutPolicy,omitempty"`
+	// DrainRetries is the number of times to retry draining a node with the "Retry" DrainTimeoutPolicy
+	// before treating it as a drain error.
+	// Defaults to 3.
+	// +optional
+	DrainRetries *int32 `json:"drainRetries,omitempty"`
+	// MaxConcurrentInstanceGroups is the maximum number of instance groups with role "Node"
+	// to update at the same time. Validation failures in other instance groups with role "Node"
+	// do not block the updating of a group. Only used in the cluster-wide setting.
+	// Defaults to 1.
+	// +optional
+	MaxConcurrentInstanceGroups *int32 `json:"maxConcurrentInstanceGroups,omitempty"`
+}
+
+// DrainTimeoutPolicy specifies what to do when a node does not drain within the drain timeout of a rolling update.
+type DrainTimeoutPolicy string
+
+const (
+	// DrainTimeoutPolicyFail treats the timeout as an error draining the node.
+	DrainTimeoutPolicyFail DrainTimeoutPolicy = "Fail"
+	// DrainTimeoutPolicyRetry tries draining the node again.
+	DrainTimeoutPolicyRetry DrainTimeoutPolicy = "Retry"
+	// DrainTimeoutPolicySkip leaves the instance running and continues with the rest of the instance group.
+	DrainTimeoutPolicySkip DrainTimeoutPolicy = "Skip"
+	// DrainTimeoutPolicyForce deletes the pods remaining on the node, bypassing PodDisruptionBudgets.
+	DrainTimeoutPolicyForce DrainTimeoutPolicy = "Force"
+)
+
+// CanaryRollingUpdate configures the canary phase of a rolling update.
+type CanaryRollingUpdate struct {
+	// Instances is the number of instances of the group to update before the bake period.
+	// The value can be an absolute number (for example 1) or a percentage of the
+	// group's instances (for example 10%).
+	// The absolute number is calculated from a percentage by rounding up.
+	// A value of 0 disables the canary phase.
+	// Defaults to 1.
+	// +optional
+	Instances *intstr.IntOrString `json:"instances,omitempty"`
+	// BakeTime is how long to observe the cluster after updating the canary instances.
+	// Defaults to 10m.
+	// +optional
+	BakeTime *metav1.Duration `json:"bakeTime,omitempty"`
+	// PodSelectors select pods that must be ready throughout the bake period,
+	// in addition to the cluster passing validation.
+	// +optional
+	PodSelectors []CanaryPodSelector `json:"podSelectors,omitempty"`
+}
+
+// CanaryPodSelector selects pods that must stay ready during the bake period of a canary rolling update.
+type CanaryPodSelector struct {
+	// Namespace is the namespace of the pods. Defaults to all namespaces.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// LabelSelector selects the pods by label, for example "app=web,tier!=cache".
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// RollingUpdateHookPhase is the point in the updating of an instance at which a RollingUpdateHook is run.
+type RollingUpdateHookPhase string
+
+const (
+	// RollingUpdateHookPhasePreDrain runs the hook before the instance's node is drained.
+	RollingUpdateHookPhasePreDrain RollingUpdateHookPhase = "PreDrain"
+	// RollingUpdateHookPhasePostTerminate runs the hook after the instance has been terminated
+	// and the cluster has passed validation with its replacement.
+	RollingUpdateHookPhasePostTerminate RollingUpdateHookPhase = "PostTerminate"
+)
+
+// RollingUpdateHookFailurePolicy specifies what to do when a RollingUpdateHook fails.
+type RollingUpdateHookFailurePolicy string
+
+const (
+	// RollingUpdateHookFailurePolicyFail stops the rolling update when the hook fails.
+	RollingUpdateHookFailurePolicyFail RollingUpdateHookFailurePolicy = "Fail"
+	// RollingUpdateHookFailurePolicyIgnore logs the failure of the hook and continues the rolling update.
+	RollingUpdateHookFailurePolicyIgnore RollingUpdateHookFailurePolicy = "Ignore"
+)
+
+// RollingUpdateHook describes an action to run around the updating of each instance.
+// Exactly one of Exec or HTTP must be set.
+type RollingUpdateHook struct {
+	// Name identifies the hook in logs and errors.
+	Name string `json:"name,omitempty"`
+	// Phase is when to run the hook: "PreDrain" or "PostTerminate".
+	Phase RollingUpdateHookPhase `json:"phase,omitempty"`
+	// Exec runs a command on the machine performing the rolling update.
+	// Exec hooks are only run when the rolling update is started with --allow-exec-hooks.
+	// +optional
+	Exec *ExecRollingUpdateHook `json:"exec,omitempty"`
+	// HTTP sends a POST request to a webhook.
+	// +optional
+	HTTP *HTTPRollingUpdateHook `json:"http,omitempty"`
+	// Timeout is the maximum amount of time to wait for the hook to complete.
+	// Defaults to 5m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// FailurePolicy is what to do when the hook fails or times out: "Fail" stops the
+	// rolling update and "Ignore" continues it.
+	// Defaults to "Fail".
+	// +optional
+	FailurePolicy RollingUpdateHookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// ExecRollingUpdateHook runs a command for a RollingUpdateHook.
+// The command is given the environment variables KOPS_CLUSTER_NAME, KOPS_INSTANCE_GROUP,
+// KOPS_INSTANCE_ID, KOPS_NODE_NAME, and KOPS_HOOK_PHASE.
+type ExecRollingUpdateHook struct {
+	// Command is the command and arguments to run. It is not run in a shell.
+	Command []string `json:"command,omitempty"`
+}
+
+// HTTPRollingUpdateHook sends a request to a webhook for a RollingUpdateHook.
+// The request body is a JSON object with the fields clusterName, instanceGroup,
+// instanceID, nodeName, and phase. A response status other than 2xx is a failure.
+type HTTPRollingUpdateHook struct {
+	// URL is the URL of the webhook.
+	URL string `json:"url,omitempty"`
+	// Headers are additional headers to send with the request.
+	// Credentials must not be set here; use HeadersFromEnv instead.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// HeadersFromEnv are additional headers to send with the request, mapped to the name of
+	// the environment variable of the machine performing the rolling update that holds the value.
+	// The names of the environment variables must start with KOPS_HOOK_.
+	// +optional
+	HeadersFromEnv map[string]string `json:"headersFromEnv,omitempty"`
+}
+
+// ClusterValidationSpec configures additional checks made when validating the cluster.
+// All checks are disabled unless configured.
+type ClusterValidationSpec struct {
+	// DaemonSets are DaemonSets that must be fully rolled out: every desired pod updated and available.
+	// +optional
+	DaemonSets []ValidationResourceReference `json:"daemonSets,omitempty"`
+	// DeploymentNamespaces are namespaces in which every Deployment must have all of its replicas updated and available.
+	// +optional
+	DeploymentNamespaces []string `json:"deploymentNamespaces,omitempty"`
+	// CoreDNSReady checks that the kube-dns Service has ready endpoints and that each CoreDNS pod reports it is ready.
+	// It does not resolve any names.
+	// +optional
+	CoreDNSReady *bool `json:"coreDNSReady,omitempty"`
+	// APIServerReadyz checks the API server's /readyz endpoint, reporting each failing readiness check.
+	// +optional
+	APIServerReadyz *bool `json:"apiServerReadyz,omitempty"`
+	// Custom are checks of the status conditions of custom resources.
+	// +optional
+	Custom []CustomValidationCheck `json:"custom,omitempty"`
+}
+
+// ValidationResourceReference identifies a namespaced resource to be checked.
+type ValidationResourceReference struct {
+	// Namespace is the namespace of the resource.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the resource.
+	Name string `json:"name,omitempty"`
+}
+
+// CustomValidationCheck requires the selected custom resources to have a status condition.
+type CustomValidationCheck struct {
+	// Name identifies the check in validation failures.
+	Name string `json:"name,omitempty"`
+	// APIVersion is the group and version of the resources, for example "cert-manager.io/v1".
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Resource is the plural resource name, for example "certificates".
+	Resource string `json:"resource,omitempty"`
+	// Namespace is the namespace of the resources. Defaults to all namespaces.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// LabelSelector selects the resources by label. Defaults to all resources.
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// ConditionType is the type of the status condition to check. Defaults to "Ready".
+	// +optional
+	ConditionType string `json:"conditionType,omitempty"`
+	// ConditionStatus is the required status of the condition. Defaults to "True".
+	// +optional
+	ConditionStatus string `json:"conditionStatus,omitempty"`
 }
 
 type PackagesConfig struct {
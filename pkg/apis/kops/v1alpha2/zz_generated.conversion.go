@@ -203,6 +203,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*CanaryPodSelector)(nil), (*kops.CanaryPodSelector)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_CanaryPodSelector_To_kops_CanaryPodSelector(a.(*CanaryPodSelector), b.(*kops.CanaryPodSelector), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.CanaryPodSelector)(nil), (*CanaryPodSelector)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_CanaryPodSelector_To_v1alpha2_CanaryPodSelector(a.(*kops.CanaryPodSelector), b.(*CanaryPodSelector), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CanaryRollingUpdate)(nil), (*kops.CanaryRollingUpdate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_CanaryRollingUpdate_To_kops_CanaryRollingUpdate(a.(*CanaryRollingUpdate), b.(*kops.CanaryRollingUpdate), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.CanaryRollingUpdate)(nil), (*CanaryRollingUpdate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_CanaryRollingUpdate_To_v1alpha2_CanaryRollingUpdate(a.(*kops.CanaryRollingUpdate), b.(*CanaryRollingUpdate), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*CertManagerConfig)(nil), (*kops.CertManagerConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_CertManagerConfig_To_kops_CertManagerConfig(a.(*CertManagerConfig), b.(*kops.CertManagerConfig), scope)
 	}); err != nil {
@@ -303,6 +323,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ClusterValidationSpec)(nil), (*kops.ClusterValidationSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(a.(*ClusterValidationSpec), b.(*kops.ClusterValidationSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ClusterValidationSpec)(nil), (*ClusterValidationSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(a.(*kops.ClusterValidationSpec), b.(*ClusterValidationSpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ContainerdConfig)(nil), (*kops.ContainerdConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_ContainerdConfig_To_kops_ContainerdConfig(a.(*ContainerdConfig), b.(*kops.ContainerdConfig), scope)
 	}); err != nil {
@@ -313,6 +343,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*CustomValidationCheck)(nil), (*kops.CustomValidationCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_CustomValidationCheck_To_kops_CustomValidationCheck(a.(*CustomValidationCheck), b.(*kops.CustomValidationCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.CustomValidationCheck)(nil), (*CustomValidationCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_CustomValidationCheck_To_v1alpha2_CustomValidationCheck(a.(*kops.CustomValidationCheck), b.(*CustomValidationCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*DNSAccessSpec)(nil), (*kops.DNSAccessSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_DNSAccessSpec_To_kops_DNSAccessSpec(a.(*DNSAccessSpec), b.(*kops.DNSAccessSpec), scope)
 	}); err != nil {
@@ -433,6 +473,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ExecRollingUpdateHook)(nil), (*kops.ExecRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(a.(*ExecRollingUpdateHook), b.(*kops.ExecRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ExecRollingUpdateHook)(nil), (*ExecRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(a.(*kops.ExecRollingUpdateHook), b.(*ExecRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ExternalDNSConfig)(nil), (*kops.ExternalDNSConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_ExternalDNSConfig_To_kops_ExternalDNSConfig(a.(*ExternalDNSConfig), b.(*kops.ExternalDNSConfig), scope)
 	}); err != nil {
@@ -513,6 +563,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*HTTPRollingUpdateHook)(nil), (*kops.HTTPRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_HTTPRollingUpdateHook_To_kops_HTTPRollingUpdateHook(a.(*HTTPRollingUpdateHook), b.(*kops.HTTPRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.HTTPRollingUpdateHook)(nil), (*HTTPRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_HTTPRollingUpdateHook_To_v1alpha2_HTTPRollingUpdateHook(a.(*kops.HTTPRollingUpdateHook), b.(*HTTPRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*HookSpec)(nil), (*kops.HookSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_HookSpec_To_kops_HookSpec(a.(*HookSpec), b.(*kops.HookSpec), scope)
 	}); err != nil {
@@ -963,6 +1023,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateHook)(nil), (*kops.RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(a.(*RollingUpdateHook), b.(*kops.RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateHook)(nil), (*RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(a.(*kops.RollingUpdateHook), b.(*RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RomanaNetworkingSpec)(nil), (*kops.RomanaNetworkingSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(a.(*RomanaNetworkingSpec), b.(*kops.RomanaNetworkingSpec), scope)
 	}); err != nil {
@@ -1033,6 +1103,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*StateStoreEncryptionSpec)(nil), (*kops.StateStoreEncryptionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(a.(*StateStoreEncryptionSpec), b.(*kops.StateStoreEncryptionSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.StateStoreEncryptionSpec)(nil), (*StateStoreEncryptionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(a.(*kops.StateStoreEncryptionSpec), b.(*StateStoreEncryptionSpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*TargetSpec)(nil), (*kops.TargetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_TargetSpec_To_kops_TargetSpec(a.(*TargetSpec), b.(*kops.TargetSpec), scope)
 	}); err != nil {
@@ -1073,6 +1153,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ValidationResourceReference)(nil), (*kops.ValidationResourceReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ValidationResourceReference_To_kops_ValidationResourceReference(a.(*ValidationResourceReference), b.(*kops.ValidationResourceReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ValidationResourceReference)(nil), (*ValidationResourceReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ValidationResourceReference_To_v1alpha2_ValidationResourceReference(a.(*kops.ValidationResourceReference), b.(*ValidationResourceReference), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*VolumeMountSpec)(nil), (*kops.VolumeMountSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_VolumeMountSpec_To_kops_VolumeMountSpec(a.(*VolumeMountSpec), b.(*kops.VolumeMountSpec), scope)
 	}); err != nil {
@@ -1712,6 +1802,72 @@ func Convert_kops_CanalNetworkingSpec_To_v1alpha2_CanalNetworkingSpec(in *kops.C
 	return autoConvert_kops_CanalNetworkingSpec_To_v1alpha2_CanalNetworkingSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_CanaryPodSelector_To_kops_CanaryPodSelector(in *CanaryPodSelector, out *kops.CanaryPodSelector, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.LabelSelector = in.LabelSelector
+	return nil
+}
+
+// Convert_v1alpha2_CanaryPodSelector_To_kops_CanaryPodSelector is an autogenerated conversion function.
+func Convert_v1alpha2_CanaryPodSelector_To_kops_CanaryPodSelector(in *CanaryPodSelector, out *kops.CanaryPodSelector, s conversion.Scope) error {
+	return autoConvert_v1alpha2_CanaryPodSelector_To_kops_CanaryPodSelector(in, out, s)
+}
+
+func autoConvert_kops_CanaryPodSelector_To_v1alpha2_CanaryPodSelector(in *kops.CanaryPodSelector, out *CanaryPodSelector, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.LabelSelector = in.LabelSelector
+	return nil
+}
+
+// Convert_kops_CanaryPodSelector_To_v1alpha2_CanaryPodSelector is an autogenerated conversion function.
+func Convert_kops_CanaryPodSelector_To_v1alpha2_CanaryPodSelector(in *kops.CanaryPodSelector, out *CanaryPodSelector, s conversion.Scope) error {
+	return autoConvert_kops_CanaryPodSelector_To_v1alpha2_CanaryPodSelector(in, out, s)
+}
+
+func autoConvert_v1alpha2_CanaryRollingUpdate_To_kops_CanaryRollingUpdate(in *CanaryRollingUpdate, out *kops.CanaryRollingUpdate, s conversion.Scope) error {
+	out.Instances = in.Instances
+	out.BakeTime = in.BakeTime
+	if in.PodSelectors != nil {
+		in, out := &in.PodSelectors, &out.PodSelectors
+		*out = make([]kops.CanaryPodSelector, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_CanaryPodSelector_To_kops_CanaryPodSelector(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.PodSelectors = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_CanaryRollingUpdate_To_kops_CanaryRollingUpdate is an autogenerated conversion function.
+func Convert_v1alpha2_CanaryRollingUpdate_To_kops_CanaryRollingUpdate(in *CanaryRollingUpdate, out *kops.CanaryRollingUpdate, s conversion.Scope) error {
+	return autoConvert_v1alpha2_CanaryRollingUpdate_To_kops_CanaryRollingUpdate(in, out, s)
+}
+
+func autoConvert_kops_CanaryRollingUpdate_To_v1alpha2_CanaryRollingUpdate(in *kops.CanaryRollingUpdate, out *CanaryRollingUpdate, s conversion.Scope) error {
+	out.Instances = in.Instances
+	out.BakeTime = in.BakeTime
+	if in.PodSelectors != nil {
+		in, out := &in.PodSelectors, &out.PodSelectors
+		*out = make([]CanaryPodSelector, len(*in))
+		for i := range *in {
+			if err := Convert_kops_CanaryPodSelector_To_v1alpha2_CanaryPodSelector(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.PodSelectors = nil
+	}
+	return nil
+}
+
+// Convert_kops_CanaryRollingUpdate_To_v1alpha2_CanaryRollingUpdate is an autogenerated conversion function.
+func Convert_kops_CanaryRollingUpdate_To_v1alpha2_CanaryRollingUpdate(in *kops.CanaryRollingUpdate, out *CanaryRollingUpdate, s conversion.Scope) error {
+	return autoConvert_kops_CanaryRollingUpdate_To_v1alpha2_CanaryRollingUpdate(in, out, s)
+}
+
 func autoConvert_v1alpha2_CertManagerConfig_To_kops_CertManagerConfig(in *CertManagerConfig, out *kops.CertManagerConfig, s conversion.Scope) error {
 	out.Enabled = in.Enabled
 	out.Managed = in.Managed
@@ -2293,6 +2449,15 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
 	out.ConfigStore = in.ConfigStore
+	if in.StateStoreEncryption != nil {
+		in, out := &in.StateStoreEncryption, &out.StateStoreEncryption
+		*out = new(kops.StateStoreEncryptionSpec)
+		if err := Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.StateStoreEncryption = nil
+	}
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
 		in, out := &in.DNSControllerGossipConfig, &out.DNSControllerGossipConfig
@@ -2606,6 +2771,15 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	} else {
 		out.RollingUpdate = nil
 	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(kops.ClusterValidationSpec)
+		if err := Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Validation = nil
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(kops.ClusterAutoscalerConfig)
@@ -2705,6 +2879,15 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
 	out.ConfigStore = in.ConfigStore
+	if in.StateStoreEncryption != nil {
+		in, out := &in.StateStoreEncryption, &out.StateStoreEncryption
+		*out = new(StateStoreEncryptionSpec)
+		if err := Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.StateStoreEncryption = nil
+	}
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
 		in, out := &in.DNSControllerGossipConfig, &out.DNSControllerGossipConfig
@@ -3018,6 +3201,15 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	} else {
 		out.RollingUpdate = nil
 	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(ClusterValidationSpec)
+		if err := Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Validation = nil
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(ClusterAutoscalerConfig)
@@ -3098,6 +3290,74 @@ func Convert_kops_ClusterSubnetSpec_To_v1alpha2_ClusterSubnetSpec(in *kops.Clust
 	return autoConvert_kops_ClusterSubnetSpec_To_v1alpha2_ClusterSubnetSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(in *ClusterValidationSpec, out *kops.ClusterValidationSpec, s conversion.Scope) error {
+	if in.DaemonSets != nil {
+		in, out := &in.DaemonSets, &out.DaemonSets
+		*out = make([]kops.ValidationResourceReference, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_ValidationResourceReference_To_kops_ValidationResourceReference(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.DaemonSets = nil
+	}
+	out.DeploymentNamespaces = in.DeploymentNamespaces
+	out.CoreDNSReady = in.CoreDNSReady
+	out.APIServerReadyz = in.APIServerReadyz
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = make([]kops.CustomValidationCheck, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_CustomValidationCheck_To_kops_CustomValidationCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Custom = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec is an autogenerated conversion function.
+func Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(in *ClusterValidationSpec, out *kops.ClusterValidationSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(in, out, s)
+}
+
+func autoConvert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(in *kops.ClusterValidationSpec, out *ClusterValidationSpec, s conversion.Scope) error {
+	if in.DaemonSets != nil {
+		in, out := &in.DaemonSets, &out.DaemonSets
+		*out = make([]ValidationResourceReference, len(*in))
+		for i := range *in {
+			if err := Convert_kops_ValidationResourceReference_To_v1alpha2_ValidationResourceReference(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.DaemonSets = nil
+	}
+	out.DeploymentNamespaces = in.DeploymentNamespaces
+	out.CoreDNSReady = in.CoreDNSReady
+	out.APIServerReadyz = in.APIServerReadyz
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = make([]CustomValidationCheck, len(*in))
+		for i := range *in {
+			if err := Convert_kops_CustomValidationCheck_To_v1alpha2_CustomValidationCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Custom = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec is an autogenerated conversion function.
+func Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(in *kops.ClusterValidationSpec, out *ClusterValidationSpec, s conversion.Scope) error {
+	return autoConvert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_ContainerdConfig_To_kops_ContainerdConfig(in *ContainerdConfig, out *kops.ContainerdConfig, s conversion.Scope) error {
 	out.Address = in.Address
 	out.ConfigOverride = in.ConfigOverride
@@ -3150,6 +3410,38 @@ func Convert_kops_ContainerdConfig_To_v1alpha2_ContainerdConfig(in *kops.Contain
 	return autoConvert_kops_ContainerdConfig_To_v1alpha2_ContainerdConfig(in, out, s)
 }
 
+func autoConvert_v1alpha2_CustomValidationCheck_To_kops_CustomValidationCheck(in *CustomValidationCheck, out *kops.CustomValidationCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	out.APIVersion = in.APIVersion
+	out.Resource = in.Resource
+	out.Namespace = in.Namespace
+	out.LabelSelector = in.LabelSelector
+	out.ConditionType = in.ConditionType
+	out.ConditionStatus = in.ConditionStatus
+	return nil
+}
+
+// Convert_v1alpha2_CustomValidationCheck_To_kops_CustomValidationCheck is an autogenerated conversion function.
+func Convert_v1alpha2_CustomValidationCheck_To_kops_CustomValidationCheck(in *CustomValidationCheck, out *kops.CustomValidationCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_CustomValidationCheck_To_kops_CustomValidationCheck(in, out, s)
+}
+
+func autoConvert_kops_CustomValidationCheck_To_v1alpha2_CustomValidationCheck(in *kops.CustomValidationCheck, out *CustomValidationCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	out.APIVersion = in.APIVersion
+	out.Resource = in.Resource
+	out.Namespace = in.Namespace
+	out.LabelSelector = in.LabelSelector
+	out.ConditionType = in.ConditionType
+	out.ConditionStatus = in.ConditionStatus
+	return nil
+}
+
+// Convert_kops_CustomValidationCheck_To_v1alpha2_CustomValidationCheck is an autogenerated conversion function.
+func Convert_kops_CustomValidationCheck_To_v1alpha2_CustomValidationCheck(in *kops.CustomValidationCheck, out *CustomValidationCheck, s conversion.Scope) error {
+	return autoConvert_kops_CustomValidationCheck_To_v1alpha2_CustomValidationCheck(in, out, s)
+}
+
 func autoConvert_v1alpha2_DNSAccessSpec_To_kops_DNSAccessSpec(in *DNSAccessSpec, out *kops.DNSAccessSpec, s conversion.Scope) error {
 	return nil
 }
@@ -3622,6 +3914,26 @@ func Convert_kops_ExecContainerAction_To_v1alpha2_ExecContainerAction(in *kops.E
 	return autoConvert_kops_ExecContainerAction_To_v1alpha2_ExecContainerAction(in, out, s)
 }
 
+func autoConvert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in *ExecRollingUpdateHook, out *kops.ExecRollingUpdateHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in *ExecRollingUpdateHook, out *kops.ExecRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(in *kops.ExecRollingUpdateHook, out *ExecRollingUpdateHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(in *kops.ExecRollingUpdateHook, out *ExecRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha2_ExternalDNSConfig_To_kops_ExternalDNSConfig(in *ExternalDNSConfig, out *kops.ExternalDNSConfig, s conversion.Scope) error {
 	out.Disable = in.Disable
 	out.WatchIngress = in.WatchIngress
@@ -3838,6 +4150,30 @@ func Convert_kops_HTTPProxy_To_v1alpha2_HTTPProxy(in *kops.HTTPProxy, out *HTTPP
 	return autoConvert_kops_HTTPProxy_To_v1alpha2_HTTPProxy(in, out, s)
 }
 
+func autoConvert_v1alpha2_HTTPRollingUpdateHook_To_kops_HTTPRollingUpdateHook(in *HTTPRollingUpdateHook, out *kops.HTTPRollingUpdateHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Headers = in.Headers
+	out.HeadersFromEnv = in.HeadersFromEnv
+	return nil
+}
+
+// Convert_v1alpha2_HTTPRollingUpdateHook_To_kops_HTTPRollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_HTTPRollingUpdateHook_To_kops_HTTPRollingUpdateHook(in *HTTPRollingUpdateHook, out *kops.HTTPRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_HTTPRollingUpdateHook_To_kops_HTTPRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_HTTPRollingUpdateHook_To_v1alpha2_HTTPRollingUpdateHook(in *kops.HTTPRollingUpdateHook, out *HTTPRollingUpdateHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Headers = in.Headers
+	out.HeadersFromEnv = in.HeadersFromEnv
+	return nil
+}
+
+// Convert_kops_HTTPRollingUpdateHook_To_v1alpha2_HTTPRollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_HTTPRollingUpdateHook_To_v1alpha2_HTTPRollingUpdateHook(in *kops.HTTPRollingUpdateHook, out *HTTPRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_HTTPRollingUpdateHook_To_v1alpha2_HTTPRollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha2_HookSpec_To_kops_HookSpec(in *HookSpec, out *kops.HookSpec, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Disabled = in.Disabled
@@ -6330,6 +6666,30 @@ func autoConvert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate,
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]kops.RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Hooks = nil
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(kops.CanaryRollingUpdate)
+		if err := Convert_v1alpha2_CanaryRollingUpdate_To_kops_CanaryRollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Canary = nil
+	}
+	out.DrainTimeout = in.DrainTimeout
+	out.DrainTimeoutPolicy = kops.DrainTimeoutPolicy(in.DrainTimeoutPolicy)
+	out.DrainRetries = in.DrainRetries
+	out.MaxConcurrentInstanceGroups = in.MaxConcurrentInstanceGroups
 	return nil
 }
 
@@ -6342,6 +6702,30 @@ func autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUp
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Hooks = nil
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollingUpdate)
+		if err := Convert_kops_CanaryRollingUpdate_To_v1alpha2_CanaryRollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Canary = nil
+	}
+	out.DrainTimeout = in.DrainTimeout
+	out.DrainTimeoutPolicy = DrainTimeoutPolicy(in.DrainTimeoutPolicy)
+	out.DrainRetries = in.DrainRetries
+	out.MaxConcurrentInstanceGroups = in.MaxConcurrentInstanceGroups
 	return nil
 }
 
@@ -6350,6 +6734,68 @@ func Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUpdate
 	return autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in, out, s)
 }
 
+func autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = kops.RollingUpdateHookPhase(in.Phase)
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(kops.ExecRollingUpdateHook)
+		if err := Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(kops.HTTPRollingUpdateHook)
+		if err := Convert_v1alpha2_HTTPRollingUpdateHook_To_kops_HTTPRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	out.Timeout = in.Timeout
+	out.FailurePolicy = kops.RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = RollingUpdateHookPhase(in.Phase)
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecRollingUpdateHook)
+		if err := Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPRollingUpdateHook)
+		if err := Convert_kops_HTTPRollingUpdateHook_To_v1alpha2_HTTPRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	out.Timeout = in.Timeout
+	out.FailurePolicy = RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	return nil
+}
+
+// Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(in *RomanaNetworkingSpec, out *kops.RomanaNetworkingSpec, s conversion.Scope) error {
 	out.DaemonServiceIP = in.DaemonServiceIP
 	out.EtcdServiceIP = in.EtcdServiceIP
@@ -6544,6 +6990,28 @@ func Convert_kops_SnapshotControllerConfig_To_v1alpha2_SnapshotControllerConfig(
 	return autoConvert_kops_SnapshotControllerConfig_To_v1alpha2_SnapshotControllerConfig(in, out, s)
 }
 
+func autoConvert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(in *StateStoreEncryptionSpec, out *kops.StateStoreEncryptionSpec, s conversion.Scope) error {
+	out.Provider = in.Provider
+	out.Key = in.Key
+	return nil
+}
+
+// Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec is an autogenerated conversion function.
+func Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(in *StateStoreEncryptionSpec, out *kops.StateStoreEncryptionSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(in, out, s)
+}
+
+func autoConvert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(in *kops.StateStoreEncryptionSpec, out *StateStoreEncryptionSpec, s conversion.Scope) error {
+	out.Provider = in.Provider
+	out.Key = in.Key
+	return nil
+}
+
+// Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec is an autogenerated conversion function.
+func Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(in *kops.StateStoreEncryptionSpec, out *StateStoreEncryptionSpec, s conversion.Scope) error {
+	return autoConvert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_TargetSpec_To_kops_TargetSpec(in *TargetSpec, out *kops.TargetSpec, s conversion.Scope) error {
 	if in.Terraform != nil {
 		in, out := &in.Terraform, &out.Terraform
@@ -6682,6 +7150,28 @@ func Convert_kops_UserData_To_v1alpha2_UserData(in *kops.UserData, out *UserData
 	return autoConvert_kops_UserData_To_v1alpha2_UserData(in, out, s)
 }
 
+func autoConvert_v1alpha2_ValidationResourceReference_To_kops_ValidationResourceReference(in *ValidationResourceReference, out *kops.ValidationResourceReference, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1alpha2_ValidationResourceReference_To_kops_ValidationResourceReference is an autogenerated conversion function.
+func Convert_v1alpha2_ValidationResourceReference_To_kops_ValidationResourceReference(in *ValidationResourceReference, out *kops.ValidationResourceReference, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ValidationResourceReference_To_kops_ValidationResourceReference(in, out, s)
+}
+
+func autoConvert_kops_ValidationResourceReference_To_v1alpha2_ValidationResourceReference(in *kops.ValidationResourceReference, out *ValidationResourceReference, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_kops_ValidationResourceReference_To_v1alpha2_ValidationResourceReference is an autogenerated conversion function.
+func Convert_kops_ValidationResourceReference_To_v1alpha2_ValidationResourceReference(in *kops.ValidationResourceReference, out *ValidationResourceReference, s conversion.Scope) error {
+	return autoConvert_kops_ValidationResourceReference_To_v1alpha2_ValidationResourceReference(in, out, s)
+}
+
 func autoConvert_v1alpha2_VolumeMountSpec_To_kops_VolumeMountSpec(in *VolumeMountSpec, out *kops.VolumeMountSpec, s conversion.Scope) error {
 	out.Device = in.Device
 	out.Filesystem = in.Filesystem
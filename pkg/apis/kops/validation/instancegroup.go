@@ -121,6 +121,9 @@ func ValidateInstanceGroup(g *kops.InstanceGroup, cloud fi.Cloud) field.ErrorLis
 
 	if g.Spec.RollingUpdate != nil {
 		allErrs = append(allErrs, validateRollingUpdate(g.Spec.RollingUpdate, field.NewPath("spec", "rollingUpdate"), g.Spec.Role == kops.InstanceGroupRoleMaster)...)
+		if g.Spec.RollingUpdate.MaxConcurrentInstanceGroups != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "rollingUpdate", "maxConcurrentInstanceGroups"), "Can only be set in the cluster spec"))
+		}
 	}
 
 	if g.Spec.NodeLabels != nil {
@@ -449,3 +449,19 @@ func TestValidInstanceGroup(t *testing.T) {
 		testErrors(t, g.Description, errList, []string{})
 	}
 }
+
+func TestIGRollingUpdateMaxConcurrentInstanceGroups(t *testing.T) {
+	ig := &kops.InstanceGroup{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "some-ig",
+		},
+		Spec: kops.InstanceGroupSpec{
+			Role: kops.InstanceGroupRoleNode,
+			RollingUpdate: &kops.RollingUpdate{
+				MaxConcurrentInstanceGroups: fi.Int32(2),
+			},
+		},
+	}
+	errs := ValidateInstanceGroup(ig, nil)
+	testErrors(t, "maxConcurrentInstanceGroups", errs, []string{"Forbidden::spec.rollingUpdate.maxConcurrentInstanceGroups"})
+}
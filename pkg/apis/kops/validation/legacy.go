@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/util"
+	"k8s.io/kops/pkg/dns"
 	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/pkg/util/subnet"
 	"k8s.io/kops/upup/pkg/fi"
@@ -85,6 +86,13 @@ func ValidateCluster(c *kops.Cluster, strict bool) field.ErrorList {
 	case kops.CloudProviderOpenstack:
 		requiresNetworkCIDR = false
 		requiresSubnetCIDR = false
+	case kops.CloudProviderHetzner:
+		if !dns.IsGossipHostname(c.ObjectMeta.Name) {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "name"), "Hetzner Cloud clusters must use gossip (a name ending in .k8s.local)"))
+		}
+		if c.Spec.NetworkID != "" {
+			allErrs = append(allErrs, field.Forbidden(fieldSpec.Child("networkID"), "networkID is not supported on Hetzner Cloud"))
+		}
 
 	default:
 		allErrs = append(allErrs, field.NotSupported(fieldSpec.Child("cloudProvider"), c.Spec.CloudProvider, []string{
@@ -94,6 +102,7 @@ func ValidateCluster(c *kops.Cluster, strict bool) field.ErrorList {
 			string(kops.CloudProviderAzure),
 			string(kops.CloudProviderAWS),
 			string(kops.CloudProviderOpenstack),
+			string(kops.CloudProviderHetzner),
 		}))
 	}
 
@@ -300,6 +309,8 @@ func ValidateCluster(c *kops.Cluster, strict bool) field.ErrorList {
 			k8sCloudProvider = "gce"
 		case kops.CloudProviderDO:
 			k8sCloudProvider = "external"
+		case kops.CloudProviderHetzner:
+			k8sCloudProvider = "external"
 		case kops.CloudProviderOpenstack:
 			k8sCloudProvider = "openstack"
 		case kops.CloudProviderALI:
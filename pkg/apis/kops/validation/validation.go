@@ -29,12 +29,15 @@ import (
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
 	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
 	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/pkg/model/components"
 	"k8s.io/kops/pkg/model/iam"
 	"k8s.io/kops/upup/pkg/fi"
@@ -104,6 +107,10 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 		allErrs = append(allErrs, validateTopology(spec.Topology, fieldPath.Child("topology"))...)
 	}
 
+	if spec.StateStoreEncryption != nil {
+		allErrs = append(allErrs, validateStateStoreEncryption(spec.StateStoreEncryption, fieldPath.Child("stateStoreEncryption"))...)
+	}
+
 	// UpdatePolicy
 	allErrs = append(allErrs, IsValidValue(fieldPath.Child("updatePolicy"), spec.UpdatePolicy, []string{kops.UpdatePolicyAutomatic, kops.UpdatePolicyExternal})...)
 
@@ -222,6 +229,10 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 		allErrs = append(allErrs, validateRollingUpdate(spec.RollingUpdate, fieldPath.Child("rollingUpdate"), false)...)
 	}
 
+	if spec.Validation != nil {
+		allErrs = append(allErrs, validateClusterValidation(spec.Validation, fieldPath.Child("validation"))...)
+	}
+
 	if spec.API != nil && spec.API.LoadBalancer != nil && spec.CloudProvider == "aws" {
 		value := string(spec.API.LoadBalancer.Class)
 		allErrs = append(allErrs, IsValidValue(fieldPath.Child("class"), &value, kops.SupportedLoadBalancerClasses)...)
@@ -342,6 +353,28 @@ func validateIPv6CIDR(cidr string, fieldPath *field.Path) field.ErrorList {
 	return allErrs
 }
 
+func validateStateStoreEncryption(encryption *kops.StateStoreEncryptionSpec, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if encryption.Provider == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("provider"), ""))
+	} else {
+		allErrs = append(allErrs, IsValidValue(fieldPath.Child("provider"), &encryption.Provider, envelope.Providers)...)
+	}
+
+	if encryption.Key == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("key"), ""))
+	} else if encryption.Provider == envelope.ProviderAWSKMS {
+		// The IAM policies of the nodes grant decryption with the key, which requires the ARN of the key rather than of an alias
+		keyARN, err := arn.Parse(encryption.Key)
+		if err != nil || keyARN.Service != "kms" || !strings.HasPrefix(keyARN.Resource, "key/") {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("key"), encryption.Key, "must be the ARN of an AWS KMS key"))
+		}
+	}
+
+	return allErrs
+}
+
 func validateTopology(topology *kops.TopologySpec, fieldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -1395,9 +1428,185 @@ func validateRollingUpdate(rollingUpdate *kops.RollingUpdate, fldpath *field.Pat
 			allErrs = append(allErrs, field.Forbidden(fldpath.Child("maxSurge"), "Cannot be zero if maxUnavailable is zero"))
 		}
 	}
+	for i, hook := range rollingUpdate.Hooks {
+		allErrs = append(allErrs, validateRollingUpdateHook(&hook, fldpath.Child("hooks").Index(i))...)
+	}
+	if rollingUpdate.Canary != nil {
+		allErrs = append(allErrs, validateCanaryRollingUpdate(rollingUpdate.Canary, fldpath.Child("canary"))...)
+	}
+	if rollingUpdate.DrainTimeout != nil && rollingUpdate.DrainTimeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("drainTimeout"), rollingUpdate.DrainTimeout.Duration.String(), "Must be positive"))
+	}
+	if rollingUpdate.DrainTimeoutPolicy != "" {
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("drainTimeoutPolicy"), fi.String(string(rollingUpdate.DrainTimeoutPolicy)), []string{
+			string(kops.DrainTimeoutPolicyFail),
+			string(kops.DrainTimeoutPolicyRetry),
+			string(kops.DrainTimeoutPolicySkip),
+			string(kops.DrainTimeoutPolicyForce),
+		})...)
+	}
+	if rollingUpdate.DrainRetries != nil && *rollingUpdate.DrainRetries < 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("drainRetries"), *rollingUpdate.DrainRetries, "Cannot be negative"))
+	}
+	if rollingUpdate.MaxConcurrentInstanceGroups != nil && *rollingUpdate.MaxConcurrentInstanceGroups <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("maxConcurrentInstanceGroups"), *rollingUpdate.MaxConcurrentInstanceGroups, "Must be positive"))
+	}
 	return allErrs
 }
 
+func validateCanaryRollingUpdate(canary *kops.CanaryRollingUpdate, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if canary.Instances != nil {
+		instances, err := intstr.GetValueFromIntOrPercent(canary.Instances, 1000, true)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("instances"), canary.Instances,
+				fmt.Sprintf("Unable to parse: %v", err)))
+		} else if instances < 0 {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("instances"), canary.Instances, "Cannot be negative"))
+		}
+	}
+
+	if canary.BakeTime != nil && canary.BakeTime.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("bakeTime"), canary.BakeTime.Duration.String(), "Cannot be negative"))
+	}
+
+	for i, selector := range canary.PodSelectors {
+		fieldSelector := fldpath.Child("podSelectors").Index(i)
+		if selector.Namespace != "" {
+			for _, msg := range validation.ValidateNamespaceName(selector.Namespace, false) {
+				allErrs = append(allErrs, field.Invalid(fieldSelector.Child("namespace"), selector.Namespace, msg))
+			}
+		}
+		if selector.LabelSelector == "" {
+			allErrs = append(allErrs, field.Required(fieldSelector.Child("labelSelector"), ""))
+		} else if _, err := labels.Parse(selector.LabelSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fieldSelector.Child("labelSelector"), selector.LabelSelector, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+func validateClusterValidation(spec *kops.ClusterValidationSpec, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, ds := range spec.DaemonSets {
+		fieldDaemonSet := fldpath.Child("daemonSets").Index(i)
+		if ds.Namespace == "" {
+			allErrs = append(allErrs, field.Required(fieldDaemonSet.Child("namespace"), ""))
+		} else {
+			for _, msg := range validation.ValidateNamespaceName(ds.Namespace, false) {
+				allErrs = append(allErrs, field.Invalid(fieldDaemonSet.Child("namespace"), ds.Namespace, msg))
+			}
+		}
+		if ds.Name == "" {
+			allErrs = append(allErrs, field.Required(fieldDaemonSet.Child("name"), ""))
+		}
+	}
+
+	for i, namespace := range spec.DeploymentNamespaces {
+		for _, msg := range validation.ValidateNamespaceName(namespace, false) {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("deploymentNamespaces").Index(i), namespace, msg))
+		}
+	}
+
+	names := sets.NewString()
+	for i, check := range spec.Custom {
+		fieldCheck := fldpath.Child("custom").Index(i)
+		if check.Name == "" {
+			allErrs = append(allErrs, field.Required(fieldCheck.Child("name"), ""))
+		} else if names.Has(check.Name) {
+			allErrs = append(allErrs, field.Duplicate(fieldCheck.Child("name"), check.Name))
+		}
+		names.Insert(check.Name)
+
+		if check.APIVersion == "" {
+			allErrs = append(allErrs, field.Required(fieldCheck.Child("apiVersion"), ""))
+		} else if _, err := schema.ParseGroupVersion(check.APIVersion); err != nil {
+			allErrs = append(allErrs, field.Invalid(fieldCheck.Child("apiVersion"), check.APIVersion, err.Error()))
+		}
+		if check.Resource == "" {
+			allErrs = append(allErrs, field.Required(fieldCheck.Child("resource"), ""))
+		}
+		if check.Namespace != "" {
+			for _, msg := range validation.ValidateNamespaceName(check.Namespace, false) {
+				allErrs = append(allErrs, field.Invalid(fieldCheck.Child("namespace"), check.Namespace, msg))
+			}
+		}
+		if check.LabelSelector != "" {
+			if _, err := labels.Parse(check.LabelSelector); err != nil {
+				allErrs = append(allErrs, field.Invalid(fieldCheck.Child("labelSelector"), check.LabelSelector, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateRollingUpdateHook(hook *kops.RollingUpdateHook, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, IsValidValue(fldpath.Child("phase"), fi.String(string(hook.Phase)), []string{string(kops.RollingUpdateHookPhasePreDrain), string(kops.RollingUpdateHookPhasePostTerminate)})...)
+
+	if hook.FailurePolicy != "" {
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("failurePolicy"), fi.String(string(hook.FailurePolicy)), []string{string(kops.RollingUpdateHookFailurePolicyFail), string(kops.RollingUpdateHookFailurePolicyIgnore)})...)
+	}
+
+	if hook.Timeout != nil && hook.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("timeout"), hook.Timeout.Duration.String(), "Must be positive"))
+	}
+
+	if hook.Exec == nil && hook.HTTP == nil {
+		allErrs = append(allErrs, field.Required(fldpath, "Either exec or http must be set"))
+	} else if hook.Exec != nil && hook.HTTP != nil {
+		allErrs = append(allErrs, field.Forbidden(fldpath.Child("http"), "Cannot be set if exec is set"))
+	}
+
+	if hook.Exec != nil && len(hook.Exec.Command) == 0 {
+		allErrs = append(allErrs, field.Required(fldpath.Child("exec", "command"), ""))
+	}
+
+	if hook.HTTP != nil {
+		if hook.HTTP.URL == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("http", "url"), ""))
+		} else if u, err := url.Parse(hook.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("http", "url"), hook.HTTP.URL, "Must be an http or https URL"))
+		}
+
+		// The spec is readable by anyone with access to the state store, so credentials are
+		// taken from the environment of the machine performing the rolling update instead.
+		for _, name := range sets.StringKeySet(hook.HTTP.Headers).List() {
+			if isCredentialHeader(name) {
+				allErrs = append(allErrs, field.Forbidden(fldpath.Child("http", "headers").Key(name), "Credentials must be set using headersFromEnv"))
+			}
+		}
+		for _, name := range sets.StringKeySet(hook.HTTP.HeadersFromEnv).List() {
+			env := hook.HTTP.HeadersFromEnv[name]
+			if !strings.HasPrefix(env, "KOPS_HOOK_") {
+				allErrs = append(allErrs, field.Invalid(fldpath.Child("http", "headersFromEnv").Key(name), env, "Environment variable name must start with KOPS_HOOK_"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// isCredentialHeader returns true if the named HTTP header is likely to hold a credential.
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "authorization", "proxy-authorization", "cookie":
+		return true
+	}
+	for _, s := range []string{"token", "secret", "password", "api-key", "apikey"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
 func validateNodeLocalDNS(spec *kops.ClusterSpec, fldpath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
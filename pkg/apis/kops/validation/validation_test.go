@@ -18,7 +18,9 @@ package validation
 
 import (
 	"testing"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -1034,6 +1036,202 @@ func Test_Validate_RollingUpdate(t *testing.T) {
 			},
 			ExpectedErrors: []string{"Forbidden::testField.maxSurge"},
 		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Name:  "exec",
+						Phase: kops.RollingUpdateHookPhasePreDrain,
+						Exec:  &kops.ExecRollingUpdateHook{Command: []string{"/bin/true"}},
+					},
+					{
+						Name:          "http",
+						Phase:         kops.RollingUpdateHookPhasePostTerminate,
+						HTTP:          &kops.HTTPRollingUpdateHook{URL: "https://example.com/hook"},
+						Timeout:       &metav1.Duration{Duration: time.Minute},
+						FailurePolicy: kops.RollingUpdateHookFailurePolicyIgnore,
+					},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase:         "PreTerminate",
+						Exec:          &kops.ExecRollingUpdateHook{Command: []string{"/bin/true"}},
+						FailurePolicy: "Retry",
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Unsupported value::testField.hooks[0].phase",
+				"Unsupported value::testField.hooks[0].failurePolicy",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase: kops.RollingUpdateHookPhasePreDrain,
+					},
+				},
+			},
+			ExpectedErrors: []string{"Required value::testField.hooks[0]"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase: kops.RollingUpdateHookPhasePreDrain,
+						Exec:  &kops.ExecRollingUpdateHook{},
+						HTTP:  &kops.HTTPRollingUpdateHook{URL: "https://example.com/hook"},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Forbidden::testField.hooks[0].http",
+				"Required value::testField.hooks[0].exec.command",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase:   kops.RollingUpdateHookPhasePostTerminate,
+						HTTP:    &kops.HTTPRollingUpdateHook{URL: "ftp://example.com/hook"},
+						Timeout: &metav1.Duration{},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Invalid value::testField.hooks[0].timeout",
+				"Invalid value::testField.hooks[0].http.url",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase: kops.RollingUpdateHookPhasePostTerminate,
+						HTTP: &kops.HTTPRollingUpdateHook{
+							URL:            "https://example.com/hook",
+							Headers:        map[string]string{"X-Source": "kops"},
+							HeadersFromEnv: map[string]string{"Authorization": "KOPS_HOOK_AUTHORIZATION"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase: kops.RollingUpdateHookPhasePostTerminate,
+						HTTP: &kops.HTTPRollingUpdateHook{
+							URL: "https://example.com/hook",
+							Headers: map[string]string{
+								"Authorization":  "Bearer abc",
+								"X-Auth-Token":   "abc",
+								"X-Api-Key":      "abc",
+								"X-Request-Type": "hook",
+							},
+							HeadersFromEnv: map[string]string{"X-Secret": "AWS_SECRET_ACCESS_KEY"},
+						},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Forbidden::testField.hooks[0].http.headers[Authorization]",
+				"Forbidden::testField.hooks[0].http.headers[X-Api-Key]",
+				"Forbidden::testField.hooks[0].http.headers[X-Auth-Token]",
+				"Invalid value::testField.hooks[0].http.headersFromEnv[X-Secret]",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: &kops.CanaryRollingUpdate{
+					Instances: intStr(intstr.FromString("10%")),
+					BakeTime:  &metav1.Duration{Duration: 15 * time.Minute},
+					PodSelectors: []kops.CanaryPodSelector{
+						{LabelSelector: "app=web,tier!=cache"},
+						{Namespace: "kafka", LabelSelector: "app in (broker)"},
+					},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: &kops.CanaryRollingUpdate{
+					Instances: intStr(intstr.FromInt(0)),
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: &kops.CanaryRollingUpdate{
+					Instances: intStr(intstr.FromInt(-1)),
+					BakeTime:  &metav1.Duration{Duration: -time.Minute},
+				},
+			},
+			ExpectedErrors: []string{
+				"Invalid value::testField.canary.instances",
+				"Invalid value::testField.canary.bakeTime",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: &kops.CanaryRollingUpdate{
+					Instances: intStr(intstr.FromString("nope")),
+				},
+			},
+			ExpectedErrors: []string{"Invalid value::testField.canary.instances"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: &kops.CanaryRollingUpdate{
+					PodSelectors: []kops.CanaryPodSelector{
+						{Namespace: "kafka"},
+						{Namespace: "Not_A_Namespace", LabelSelector: "app in ("},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Required value::testField.canary.podSelectors[0].labelSelector",
+				"Invalid value::testField.canary.podSelectors[1].namespace",
+				"Invalid value::testField.canary.podSelectors[1].labelSelector",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				DrainTimeout:       &metav1.Duration{Duration: 10 * time.Minute},
+				DrainTimeoutPolicy: kops.DrainTimeoutPolicyRetry,
+				DrainRetries:       fi.Int32(5),
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				DrainTimeout:       &metav1.Duration{Duration: 0},
+				DrainTimeoutPolicy: "Wait",
+				DrainRetries:       fi.Int32(-1),
+			},
+			ExpectedErrors: []string{
+				"Invalid value::testField.drainTimeout",
+				"Unsupported value::testField.drainTimeoutPolicy",
+				"Invalid value::testField.drainRetries",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxConcurrentInstanceGroups: fi.Int32(3),
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxConcurrentInstanceGroups: fi.Int32(0),
+			},
+			ExpectedErrors: []string{"Invalid value::testField.maxConcurrentInstanceGroups"},
+		},
 	}
 	for _, g := range grid {
 		errs := validateRollingUpdate(&g.Input, field.NewPath("testField"), g.OnMasterIG)
@@ -1041,6 +1239,62 @@ func Test_Validate_RollingUpdate(t *testing.T) {
 	}
 }
 
+func Test_Validate_ClusterValidation(t *testing.T) {
+	grid := []struct {
+		Input          kops.ClusterValidationSpec
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.ClusterValidationSpec{
+				DaemonSets:           []kops.ValidationResourceReference{{Namespace: "kube-system", Name: "cilium"}},
+				DeploymentNamespaces: []string{"kube-system", "ingress"},
+				CoreDNSReady:         fi.Bool(true),
+				APIServerReadyz:      fi.Bool(true),
+				Custom: []kops.CustomValidationCheck{
+					{Name: "certificates", APIVersion: "cert-manager.io/v1", Resource: "certificates", Namespace: "ingress", LabelSelector: "app=web"},
+				},
+			},
+		},
+		{
+			Input: kops.ClusterValidationSpec{
+				DaemonSets: []kops.ValidationResourceReference{
+					{Name: "cilium"},
+					{Namespace: "Not_A_Namespace"},
+				},
+				DeploymentNamespaces: []string{"Not_A_Namespace"},
+			},
+			ExpectedErrors: []string{
+				"Required value::testField.daemonSets[0].namespace",
+				"Invalid value::testField.daemonSets[1].namespace",
+				"Required value::testField.daemonSets[1].name",
+				"Invalid value::testField.deploymentNamespaces[0]",
+			},
+		},
+		{
+			Input: kops.ClusterValidationSpec{
+				Custom: []kops.CustomValidationCheck{
+					{},
+					{Name: "certificates", APIVersion: "a/b/c", Resource: "certificates", Namespace: "Not_A_Namespace", LabelSelector: "app in ("},
+					{Name: "certificates", APIVersion: "v1", Resource: "configmaps"},
+				},
+			},
+			ExpectedErrors: []string{
+				"Required value::testField.custom[0].name",
+				"Required value::testField.custom[0].apiVersion",
+				"Required value::testField.custom[0].resource",
+				"Invalid value::testField.custom[1].apiVersion",
+				"Invalid value::testField.custom[1].namespace",
+				"Invalid value::testField.custom[1].labelSelector",
+				"Duplicate value::testField.custom[2].name",
+			},
+		},
+	}
+	for _, g := range grid {
+		errs := validateClusterValidation(&g.Input, field.NewPath("testField"))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
 func intStr(i intstr.IntOrString) *intstr.IntOrString {
 	return &i
 }
@@ -1311,3 +1565,55 @@ func TestValidateSAExternalPermissions(t *testing.T) {
 	}
 
 }
+
+func Test_Validate_StateStoreEncryption(t *testing.T) {
+	grid := []struct {
+		Description    string
+		Input          kops.StateStoreEncryptionSpec
+		ExpectedErrors []string
+	}{
+		{
+			Description: "AWS KMS key",
+			Input: kops.StateStoreEncryptionSpec{
+				Provider: "aws-kms",
+				Key:      "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			},
+		},
+		{
+			Description: "AWS KMS alias",
+			Input: kops.StateStoreEncryptionSpec{
+				Provider: "aws-kms",
+				Key:      "arn:aws:kms:us-east-1:123456789012:alias/kops",
+			},
+			ExpectedErrors: []string{"Invalid value::spec.stateStoreEncryption.key"},
+		},
+		{
+			Description: "Vault transit key",
+			Input: kops.StateStoreEncryptionSpec{
+				Provider: "vault-transit",
+				Key:      "transit/kops",
+			},
+		},
+		{
+			Description: "Unknown provider",
+			Input: kops.StateStoreEncryptionSpec{
+				Provider: "sops",
+				Key:      "key",
+			},
+			ExpectedErrors: []string{"Unsupported value::spec.stateStoreEncryption.provider"},
+		},
+		{
+			Description:    "Missing provider and key",
+			Input:          kops.StateStoreEncryptionSpec{},
+			ExpectedErrors: []string{"Required value::spec.stateStoreEncryption.provider", "Required value::spec.stateStoreEncryption.key"},
+		},
+	}
+
+	for _, g := range grid {
+		fldPath := field.NewPath("spec", "stateStoreEncryption")
+		t.Run(g.Description, func(t *testing.T) {
+			errs := validateStateStoreEncryption(&g.Input, fldPath)
+			testErrors(t, g.Input, errs, g.ExpectedErrors)
+		})
+	}
+}
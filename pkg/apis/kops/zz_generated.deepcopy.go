@@ -438,6 +438,53 @@ func (in *CanalNetworkingSpec) DeepCopy() *CanalNetworkingSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryPodSelector) DeepCopyInto(out *CanaryPodSelector) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryPodSelector.
+func (in *CanaryPodSelector) DeepCopy() *CanaryPodSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryPodSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRollingUpdate) DeepCopyInto(out *CanaryRollingUpdate) {
+	*out = *in
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.BakeTime != nil {
+		in, out := &in.BakeTime, &out.BakeTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PodSelectors != nil {
+		in, out := &in.PodSelectors, &out.PodSelectors
+		*out = make([]CanaryPodSelector, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRollingUpdate.
+func (in *CanaryRollingUpdate) DeepCopy() *CanaryRollingUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRollingUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertManagerConfig) DeepCopyInto(out *CertManagerConfig) {
 	*out = *in
@@ -975,6 +1022,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(TopologySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.StateStoreEncryption != nil {
+		in, out := &in.StateStoreEncryption, &out.StateStoreEncryption
+		*out = new(StateStoreEncryptionSpec)
+		**out = **in
+	}
 	if in.DNSControllerGossipConfig != nil {
 		in, out := &in.DNSControllerGossipConfig, &out.DNSControllerGossipConfig
 		*out = new(DNSControllerGossipConfig)
@@ -1228,6 +1280,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(RollingUpdate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(ClusterValidationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(ClusterAutoscalerConfig)
@@ -1300,6 +1357,47 @@ func (in *ClusterSubnetSpec) DeepCopy() *ClusterSubnetSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterValidationSpec) DeepCopyInto(out *ClusterValidationSpec) {
+	*out = *in
+	if in.DaemonSets != nil {
+		in, out := &in.DaemonSets, &out.DaemonSets
+		*out = make([]ValidationResourceReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeploymentNamespaces != nil {
+		in, out := &in.DeploymentNamespaces, &out.DeploymentNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CoreDNSReady != nil {
+		in, out := &in.CoreDNSReady, &out.CoreDNSReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.APIServerReadyz != nil {
+		in, out := &in.APIServerReadyz, &out.APIServerReadyz
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = make([]CustomValidationCheck, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterValidationSpec.
+func (in *ClusterValidationSpec) DeepCopy() *ClusterValidationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterValidationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerdConfig) DeepCopyInto(out *ContainerdConfig) {
 	*out = *in
@@ -1366,6 +1464,22 @@ func (in *ContainerdConfig) DeepCopy() *ContainerdConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomValidationCheck) DeepCopyInto(out *CustomValidationCheck) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomValidationCheck.
+func (in *CustomValidationCheck) DeepCopy() *CustomValidationCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomValidationCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSAccessSpec) DeepCopyInto(out *DNSAccessSpec) {
 	*out = *in
@@ -1881,6 +1995,27 @@ func (in *ExecContainerAction) DeepCopy() *ExecContainerAction {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecRollingUpdateHook) DeepCopyInto(out *ExecRollingUpdateHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecRollingUpdateHook.
+func (in *ExecRollingUpdateHook) DeepCopy() *ExecRollingUpdateHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecRollingUpdateHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalDNSConfig) DeepCopyInto(out *ExternalDNSConfig) {
 	*out = *in
@@ -2059,6 +2194,36 @@ func (in *HTTPProxy) DeepCopy() *HTTPProxy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRollingUpdateHook) DeepCopyInto(out *HTTPRollingUpdateHook) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HeadersFromEnv != nil {
+		in, out := &in.HeadersFromEnv, &out.HeadersFromEnv
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRollingUpdateHook.
+func (in *HTTPRollingUpdateHook) DeepCopy() *HTTPRollingUpdateHook {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRollingUpdateHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HookSpec) DeepCopyInto(out *HookSpec) {
 	*out = *in
@@ -4570,6 +4735,33 @@ func (in *RollingUpdate) DeepCopyInto(out *RollingUpdate) {
 		*out = new(intstr.IntOrString)
 		**out = **in
 	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollingUpdate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DrainRetries != nil {
+		in, out := &in.DrainRetries, &out.DrainRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConcurrentInstanceGroups != nil {
+		in, out := &in.MaxConcurrentInstanceGroups, &out.MaxConcurrentInstanceGroups
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -4583,6 +4775,37 @@ func (in *RollingUpdate) DeepCopy() *RollingUpdate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateHook) DeepCopyInto(out *RollingUpdateHook) {
+	*out = *in
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecRollingUpdateHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPRollingUpdateHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateHook.
+func (in *RollingUpdateHook) DeepCopy() *RollingUpdateHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RomanaNetworkingSpec) DeepCopyInto(out *RomanaNetworkingSpec) {
 	*out = *in
@@ -4733,6 +4956,22 @@ func (in *SnapshotControllerConfig) DeepCopy() *SnapshotControllerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateStoreEncryptionSpec) DeepCopyInto(out *StateStoreEncryptionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateStoreEncryptionSpec.
+func (in *StateStoreEncryptionSpec) DeepCopy() *StateStoreEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StateStoreEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetSpec) DeepCopyInto(out *TargetSpec) {
 	*out = *in
@@ -4823,6 +5062,22 @@ func (in *UserData) DeepCopy() *UserData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationResourceReference) DeepCopyInto(out *ValidationResourceReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationResourceReference.
+func (in *ValidationResourceReference) DeepCopy() *ValidationResourceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationResourceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeMountSpec) DeepCopyInto(out *VolumeMountSpec) {
 	*out = *in
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/model"
+	"k8s.io/kops/pkg/nodelabels"
+	"k8s.io/kops/pkg/rbac"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// Sources of certificates
+const (
+	// SourceKeystore is the keystore of the cluster
+	SourceKeystore = "keystore"
+	// SourceKopsController is kops-controller, which issues certificates to nodes when they boot
+	SourceKopsController = "kops-controller"
+)
+
+// Statuses of certificates
+const (
+	// StatusPrimary is the status of the primary keypair of a keyset, used for signing
+	StatusPrimary = "primary"
+	// StatusTrusted is the status of a secondary keypair that is in the trust stores
+	StatusTrusted = "trusted"
+	// StatusDistrusted is the status of a secondary keypair that was removed from the trust stores
+	StatusDistrusted = "distrusted"
+	// StatusIssued is the status of a certificate issued to a node
+	StatusIssued = "issued"
+)
+
+const (
+	// KopsControllerCertValidity is the minimum validity of the certificates kops-controller issues to nodes
+	KopsControllerCertValidity = 455 * 24 * time.Hour
+	// KopsControllerCertValidityJitter is the maximum validity added to KopsControllerCertValidity,
+	// so that the certificates of different nodes don't all expire at the same time
+	KopsControllerCertValidityJitter = 30 * 24 * time.Hour
+)
+
+// Certificate is a certificate of the cluster
+type Certificate struct {
+	Source string `json:"source"`
+	// Name is the name of the keyset of a certificate in the keystore, or of a certificate issued to a node
+	Name string `json:"name"`
+	// ID is the ID of the keypair of a certificate in the keystore
+	ID string `json:"id,omitempty"`
+	// Node is the name of the node a certificate was issued to
+	Node    string `json:"node,omitempty"`
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+	Status  string `json:"status"`
+
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// Estimated is true if NotBefore and NotAfter are estimated rather than read from the certificate.
+	// NotAfter is then the earliest time at which the certificate can expire.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// ExpiresBefore returns true if the certificate expires before the time
+func (c *Certificate) ExpiresBefore(t time.Time) bool {
+	return c.NotAfter.Before(t)
+}
+
+// ListKeystoreCertificates lists the certificates of the keypairs in the keystore
+func ListKeystoreCertificates(keyStore fi.CAStore, includeDistrusted bool) ([]*Certificate, error) {
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("error listing keysets: %v", err)
+	}
+
+	var certificates []*Certificate
+	for name, keyset := range keysets {
+		for _, item := range keyset.Items {
+			if item.Certificate == nil || (item.DistrustTimestamp != nil && !includeDistrusted) {
+				continue
+			}
+
+			status := StatusTrusted
+			if item.DistrustTimestamp != nil {
+				status = StatusDistrusted
+			} else if keyset.Primary != nil && item.Id == keyset.Primary.Id {
+				status = StatusPrimary
+			}
+
+			cert := item.Certificate.Certificate
+			certificates = append(certificates, &Certificate{
+				Source:    SourceKeystore,
+				Name:      name,
+				ID:        item.Id,
+				Subject:   cert.Subject.String(),
+				Issuer:    cert.Issuer.String(),
+				Status:    status,
+				NotBefore: cert.NotBefore,
+				NotAfter:  cert.NotAfter,
+			})
+		}
+	}
+
+	sortCertificates(certificates)
+	return certificates, nil
+}
+
+// KopsControllerCert is a certificate kops-controller issues to nodes
+type KopsControllerCert struct {
+	Name string
+	// Signer is the name of the keyset of the CA that signs the certificate
+	Signer string
+}
+
+// KopsControllerCerts returns the certificates kops-controller issues to the nodes of the cluster
+func KopsControllerCerts(cluster *kops.Cluster) []KopsControllerCert {
+	certs := []KopsControllerCert{
+		{Name: "kubelet", Signer: fi.CertificateIDCA},
+		{Name: "kubelet-server", Signer: fi.CertificateIDCA},
+	}
+	if cluster.Spec.Networking != nil && model.UseCiliumEtcd(cluster) {
+		certs = append(certs, KopsControllerCert{Name: "etcd-client-cilium", Signer: "etcd-clients-ca-cilium"})
+	}
+	if cluster.Spec.KubeProxy == nil || cluster.Spec.KubeProxy.Enabled == nil || *cluster.Spec.KubeProxy.Enabled {
+		certs = append(certs, KopsControllerCert{Name: "kube-proxy", Signer: fi.CertificateIDCA})
+	}
+	if cluster.Spec.Networking != nil && cluster.Spec.Networking.Kuberouter != nil {
+		certs = append(certs, KopsControllerCert{Name: "kube-router", Signer: fi.CertificateIDCA})
+	}
+	return certs
+}
+
+// kopsControllerCertSubject returns the subject of the certificate kops-controller issues to the node
+func kopsControllerCertSubject(name string, nodeName string) string {
+	switch name {
+	case "kubelet":
+		return "CN=system:node:" + nodeName + ",O=" + rbac.NodesGroup
+	case "kubelet-server":
+		return "CN=" + nodeName
+	case "kube-proxy":
+		return "CN=" + rbac.KubeProxy
+	case "kube-router":
+		return "CN=" + rbac.KubeRouter
+	case "etcd-client-cilium":
+		return "CN=cilium"
+	default:
+		return ""
+	}
+}
+
+// ListKopsControllerCertificates lists the certificates kops-controller issued to the nodes.
+// The certificates are only stored on the nodes, so they are estimated from the creation of the nodes
+// and the primary keypairs of their signers.
+func ListKopsControllerCertificates(cluster *kops.Cluster, nodes []corev1.Node, keyStore fi.CAStore) ([]*Certificate, error) {
+	if !model.UseKopsControllerForNodeBootstrap(cluster) {
+		return nil, nil
+	}
+
+	certs := KopsControllerCerts(cluster)
+	issuers := make(map[string]string)
+	for _, cert := range certs {
+		if _, found := issuers[cert.Signer]; found {
+			continue
+		}
+		keyset, err := keyStore.FindKeyset(cert.Signer)
+		if err != nil {
+			return nil, fmt.Errorf("error reading keyset %q: %v", cert.Signer, err)
+		}
+		if keyset == nil || keyset.Primary == nil || keyset.Primary.Certificate == nil {
+			return nil, fmt.Errorf("keyset %q not found", cert.Signer)
+		}
+		issuers[cert.Signer] = keyset.Primary.Certificate.Certificate.Subject.String()
+	}
+
+	var certificates []*Certificate
+	for i := range nodes {
+		node := &nodes[i]
+		// Control plane nodes issue their own certificates
+		if _, found := node.Labels[nodelabels.RoleLabelMaster16]; found {
+			continue
+		}
+		if _, found := node.Labels[nodelabels.RoleLabelControlPlane20]; found {
+			continue
+		}
+
+		issued := node.CreationTimestamp.Time
+		for _, cert := range certs {
+			certificates = append(certificates, &Certificate{
+				Source:    SourceKopsController,
+				Name:      cert.Name,
+				Node:      node.Name,
+				Subject:   kopsControllerCertSubject(cert.Name, node.Name),
+				Issuer:    issuers[cert.Signer],
+				Status:    StatusIssued,
+				NotBefore: issued,
+				NotAfter:  issued.Add(KopsControllerCertValidity),
+				Estimated: true,
+			})
+		}
+	}
+
+	sortCertificates(certificates)
+	return certificates, nil
+}
+
+// sortCertificates sorts the certificates by name, node, issue time and ID
+func sortCertificates(certificates []*Certificate) {
+	sort.SliceStable(certificates, func(i, j int) bool {
+		a, b := certificates[i], certificates[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		if !a.NotBefore.Equal(b.NotBefore) {
+			return a.NotBefore.Before(b.NotBefore)
+		}
+		// IDs are serial numbers, so compare them numerically
+		if len(a.ID) != len(b.ID) {
+			return len(a.ID) < len(b.ID)
+		}
+		return a.ID < b.ID
+	})
+}
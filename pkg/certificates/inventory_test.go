@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/nodelabels"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/pkg/testutils"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// issueCA issues a self-signed CA certificate with the serial
+func issueCA(t *testing.T, name string, serial int64) (*pki.Certificate, *pki.PrivateKey) {
+	privateKey, err := pki.GeneratePrivateKey()
+	require.NoError(t, err)
+	pkiSerial := pki.BuildPKISerial(serial)
+	cert, _, _, err := pki.IssueCert(&pki.IssueCertRequest{
+		Type:       "ca",
+		Subject:    pkix.Name{CommonName: name, SerialNumber: pkiSerial.String()},
+		Serial:     pkiSerial,
+		PrivateKey: privateKey,
+	}, nil)
+	require.NoError(t, err)
+	return cert, privateKey
+}
+
+func buildKeyStore(t *testing.T, cluster *kops.Cluster) fi.CAStore {
+	keyStore := fi.NewVFSCAStore(cluster, vfs.NewMemFSPath(vfs.NewMemFSContext(), "pki"))
+
+	cert, privateKey := issueCA(t, fi.CertificateIDCA, 2)
+	keyset, err := fi.NewKeyset(cert, privateKey)
+	require.NoError(t, err)
+	cert, privateKey = issueCA(t, fi.CertificateIDCA, 1)
+	old, err := keyset.AddItem(cert, privateKey, false)
+	require.NoError(t, err)
+	now := time.Now().UTC().Round(0)
+	old.DistrustTimestamp = &now
+	cert, privateKey = issueCA(t, fi.CertificateIDCA, 3)
+	_, err = keyset.AddItem(cert, privateKey, false)
+	require.NoError(t, err)
+	require.NoError(t, keyStore.StoreKeyset(fi.CertificateIDCA, keyset))
+
+	cert, privateKey = issueCA(t, "service-account", 1)
+	keyset, err = fi.NewKeyset(cert, privateKey)
+	require.NoError(t, err)
+	require.NoError(t, keyStore.StoreKeyset("service-account", keyset))
+
+	return keyStore
+}
+
+func TestListKeystoreCertificates(t *testing.T) {
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	keyStore := buildKeyStore(t, cluster)
+
+	certs, err := ListKeystoreCertificates(keyStore, false)
+	require.NoError(t, err)
+
+	var statuses []string
+	for _, cert := range certs {
+		assert.Equal(t, SourceKeystore, cert.Source)
+		assert.Equal(t, cert.Subject, cert.Issuer)
+		statuses = append(statuses, cert.Name+" "+cert.Status)
+	}
+	assert.Equal(t, []string{
+		"kubernetes-ca primary",
+		"kubernetes-ca trusted",
+		"service-account primary",
+	}, statuses)
+
+	certs, err = ListKeystoreCertificates(keyStore, true)
+	require.NoError(t, err)
+	statuses = nil
+	for _, cert := range certs {
+		statuses = append(statuses, cert.Status)
+	}
+	assert.ElementsMatch(t, []string{StatusPrimary, StatusDistrusted, StatusTrusted, StatusPrimary}, statuses)
+}
+
+func TestListKopsControllerCertificates(t *testing.T) {
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	cluster.Spec.KubernetesVersion = "1.21.0"
+	keyStore := buildKeyStore(t, cluster)
+
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "master-1",
+				CreationTimestamp: metav1.NewTime(created),
+				Labels:            map[string]string{nodelabels.RoleLabelMaster16: ""},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "node-1",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		},
+	}
+
+	keyset, err := keyStore.FindKeyset(fi.CertificateIDCA)
+	require.NoError(t, err)
+	issuer := keyset.Primary.Certificate.Subject.String()
+
+	certs, err := ListKopsControllerCertificates(cluster, nodes, keyStore)
+	require.NoError(t, err)
+
+	var subjects []string
+	for _, cert := range certs {
+		assert.Equal(t, SourceKopsController, cert.Source)
+		assert.Equal(t, "node-1", cert.Node)
+		assert.Equal(t, issuer, cert.Issuer)
+		assert.Equal(t, StatusIssued, cert.Status)
+		assert.Equal(t, created.Add(KopsControllerCertValidity), cert.NotAfter)
+		assert.True(t, cert.Estimated)
+		subjects = append(subjects, cert.Name+" "+cert.Subject)
+	}
+	assert.Equal(t, []string{
+		"kube-proxy CN=system:kube-proxy",
+		"kubelet CN=system:node:node-1,O=system:nodes",
+		"kubelet-server CN=node-1",
+	}, subjects)
+
+	cluster.Spec.KubernetesVersion = "1.18.0"
+	certs, err = ListKopsControllerCertificates(cluster, nodes, keyStore)
+	require.NoError(t, err)
+	assert.Empty(t, certs)
+}
+
+func TestKopsControllerCerts(t *testing.T) {
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	cluster.Spec.KubeProxy = &kops.KubeProxyConfig{Enabled: fi.Bool(false)}
+	cluster.Spec.Networking = &kops.NetworkingSpec{Kuberouter: &kops.KuberouterNetworkingSpec{}}
+
+	assert.Equal(t, []KopsControllerCert{
+		{Name: "kubelet", Signer: fi.CertificateIDCA},
+		{Name: "kubelet-server", Signer: fi.CertificateIDCA},
+		{Name: "kube-router", Signer: fi.CertificateIDCA},
+	}, KopsControllerCerts(cluster))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// Phase is the next step of the rotation of a keyset
+type Phase string
+
+const (
+	// PhaseCreate creates a new keypair, which is trusted but not yet used for signing.
+	// The cluster must then be updated so that the new keypair is in the trust stores of all nodes.
+	PhaseCreate Phase = "create"
+	// PhasePromote makes the new keypair the primary, so that it is used for signing.
+	// The cluster must then be updated so that all certificates are reissued with the new keypair.
+	PhasePromote Phase = "promote"
+	// PhaseDistrust removes the keypairs older than the primary from the trust stores.
+	// The cluster must then be updated so that the old keypairs are no longer trusted.
+	PhaseDistrust Phase = "distrust"
+)
+
+// NextPhase returns the next phase of the rotation of the keyset.
+// A keyset with a trusted keypair newer than the primary is promoted; a keyset with trusted keypairs
+// older than the primary has them distrusted; otherwise a new keypair is created.
+func NextPhase(keyset *fi.Keyset) Phase {
+	if keyset.Primary == nil {
+		return PhaseCreate
+	}
+	primarySerial := keyset.Primary.Certificate.Certificate.SerialNumber
+
+	older := false
+	for _, item := range keyset.Items {
+		if item.DistrustTimestamp != nil || item.Id == keyset.Primary.Id || item.Certificate == nil {
+			continue
+		}
+		switch item.Certificate.Certificate.SerialNumber.Cmp(primarySerial) {
+		case 1:
+			if item.PrivateKey != nil {
+				return PhasePromote
+			}
+		case -1:
+			older = true
+		}
+	}
+	if older {
+		return PhaseDistrust
+	}
+	return PhaseCreate
+}
+
+// DueForRotation returns true if the primary keypair of the keyset was issued before the time,
+// or if a rotation of the keyset is already in progress
+func DueForRotation(keyset *fi.Keyset, issuedBefore time.Time) bool {
+	if NextPhase(keyset) != PhaseCreate {
+		return true
+	}
+	if keyset.Primary == nil || keyset.Primary.Certificate == nil {
+		return false
+	}
+	return keyset.Primary.Certificate.Certificate.NotBefore.Before(issuedBefore)
+}
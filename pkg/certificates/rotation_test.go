@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestNextPhase(t *testing.T) {
+	cert, privateKey := issueCA(t, "ca", 2)
+	keyset, err := fi.NewKeyset(cert, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, PhaseCreate, NextPhase(keyset))
+
+	// A newer keypair without a private key cannot be promoted
+	cert, _ = issueCA(t, "ca", 3)
+	_, err = keyset.AddItem(cert, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, PhaseCreate, NextPhase(keyset))
+
+	cert, privateKey = issueCA(t, "ca", 4)
+	newer, err := keyset.AddItem(cert, privateKey, false)
+	require.NoError(t, err)
+	assert.Equal(t, PhasePromote, NextPhase(keyset))
+
+	old := keyset.Primary
+	keyset.Primary = newer
+	assert.Equal(t, PhaseDistrust, NextPhase(keyset))
+
+	now := time.Now()
+	for _, item := range keyset.Items {
+		if item != newer {
+			item.DistrustTimestamp = &now
+		}
+	}
+	assert.Equal(t, PhaseCreate, NextPhase(keyset))
+
+	assert.False(t, DueForRotation(keyset, old.Certificate.Certificate.NotBefore.Add(-time.Hour)))
+	assert.True(t, DueForRotation(keyset, time.Now().Add(time.Hour)))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/kubemanifest"
+)
+
+const (
+	// addonsConfigMapName is the name of the ConfigMap holding the addons of a cluster
+	addonsConfigMapName = "kops-clusteraddons"
+	// addonsConfigMapKey is the key of the addons in the ConfigMap
+	addonsConfigMapKey = "default"
+)
+
+// configMapAddonsClient stores the addons of a cluster in a ConfigMap in the namespace of the cluster
+type configMapAddonsClient struct {
+	client corev1client.ConfigMapInterface
+}
+
+var _ simple.AddonsClient = &configMapAddonsClient{}
+
+// Replace implements simple.AddonsClient::Replace
+func (c *configMapAddonsClient) Replace(addons kubemanifest.ObjectList) error {
+	ctx := context.TODO()
+
+	b, err := addons.ToYAML()
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: addonsConfigMapName},
+		Data:       map[string]string{addonsConfigMapKey: string(b)},
+	}
+
+	existing, err := c.client.Get(ctx, addonsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("error reading addons: %v", err)
+		}
+		if _, err := c.client.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating addons: %v", err)
+		}
+		return nil
+	}
+
+	configMap.ObjectMeta.ResourceVersion = existing.ObjectMeta.ResourceVersion
+	if _, err := c.client.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating addons: %v", err)
+	}
+	return nil
+}
+
+// List implements simple.AddonsClient::List
+func (c *configMapAddonsClient) List() (kubemanifest.ObjectList, error) {
+	ctx := context.TODO()
+
+	configMap, err := c.client.Get(ctx, addonsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading addons: %v", err)
+	}
+
+	return kubemanifest.LoadObjectsFrom([]byte(configMap.Data[addonsConfigMapKey]))
+}
@@ -22,8 +22,11 @@ import (
 	"net/url"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/registry"
@@ -36,12 +39,19 @@ import (
 	"k8s.io/kops/util/pkg/vfs"
 )
 
-// RESTClientset is an implementation of clientset that uses a "real" generated REST client
+// RESTClientset is an implementation of clientset that uses a "real" generated REST client,
+// storing the kOps objects of each cluster in a namespace of a Kubernetes API server that serves the kops.k8s.io CRDs.
 type RESTClientset struct {
 	BaseURL    *url.URL
 	KopsClient kopsinternalversion.KopsInterface
+	// KubeClient stores the secrets and the private keys in Kubernetes Secrets, and the addons in a ConfigMap.
+	// If nil, the secrets and the private keys are stored in Keysets, and addons are not supported.
+	KubeClient kubernetes.Interface
 }
 
+// annotationClusterName is set on the namespaces created for clusters, as their names cannot hold the dots of cluster names
+const annotationClusterName = "kops.k8s.io/cluster"
+
 // GetCluster implements the GetCluster method of Clientset for a kubernetes-API state store
 func (c *RESTClientset) GetCluster(ctx context.Context, name string) (*kops.Cluster, error) {
 	namespace := restNamespaceForClusterName(name)
@@ -50,17 +60,48 @@ func (c *RESTClientset) GetCluster(ctx context.Context, name string) (*kops.Clus
 
 // AddonsFor fetches the AddonsClient for the cluster
 func (c *RESTClientset) AddonsFor(cluster *kops.Cluster) simple.AddonsClient {
-	// We should manage these directly in the cluster
-	klog.Fatalf("AddonsFor not implemented for RESTClientset")
-	return nil
+	if c.KubeClient == nil {
+		klog.Fatalf("AddonsFor not implemented for RESTClientset without a Kubernetes client")
+	}
+	namespace := restNamespaceForClusterName(cluster.Name)
+	return &configMapAddonsClient{client: c.KubeClient.CoreV1().ConfigMaps(namespace)}
 }
 
 // CreateCluster implements the CreateCluster method of Clientset for a kubernetes-API state store
 func (c *RESTClientset) CreateCluster(ctx context.Context, cluster *kops.Cluster) (*kops.Cluster, error) {
 	namespace := restNamespaceForClusterName(cluster.Name)
+	if err := c.ensureNamespace(ctx, namespace, cluster.Name); err != nil {
+		return nil, err
+	}
 	return c.KopsClient.Clusters(namespace).Create(ctx, cluster, metav1.CreateOptions{})
 }
 
+// ensureNamespace creates the namespace for the cluster if it does not exist
+func (c *RESTClientset) ensureNamespace(ctx context.Context, namespace string, clusterName string) error {
+	if c.KubeClient == nil {
+		return nil
+	}
+
+	_, err := c.KubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("error reading namespace %q: %v", namespace, err)
+	}
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        namespace,
+			Annotations: map[string]string{annotationClusterName: clusterName},
+		},
+	}
+	if _, err := c.KubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating namespace %q: %v", namespace, err)
+	}
+	return nil
+}
+
 // UpdateCluster implements the UpdateCluster method of Clientset for a kubernetes-API state store
 func (c *RESTClientset) UpdateCluster(ctx context.Context, cluster *kops.Cluster, status *kops.ClusterStatus) (*kops.Cluster, error) {
 	klog.Warningf("validating cluster update client side; needs to move to server")
@@ -78,12 +119,11 @@ func (c *RESTClientset) UpdateCluster(ctx context.Context, cluster *kops.Cluster
 
 // ConfigBaseFor implements the ConfigBaseFor method of Clientset for a kubernetes-API state store
 func (c *RESTClientset) ConfigBaseFor(cluster *kops.Cluster) (vfs.Path, error) {
-	if cluster.Spec.ConfigBase != "" {
-		return vfs.Context.BuildVfsPath(cluster.Spec.ConfigBase)
+	// The nodes read their configuration from the ConfigBase, so it must be a VFS path they can read
+	if cluster.Spec.ConfigBase == "" {
+		return nil, fmt.Errorf("configBase must be set to a path the cluster can read, e.g. s3://<bucket>/%s, when the state store is in Kubernetes; set it with --config-base and the EnableSeparateConfigBase feature flag", cluster.Name)
 	}
-	// URL for clusters looks like https://<server>/apis/kops/v1alpha2/namespaces/<cluster>/clusters/<cluster>
-	// We probably want to add a subresource for full resources
-	return vfs.Context.BuildVfsPath(c.BaseURL.String())
+	return vfs.Context.BuildVfsPath(cluster.Spec.ConfigBase)
 }
 
 // ListClusters implements the ListClusters method of Clientset for a kubernetes-API state store
@@ -99,12 +139,19 @@ func (c *RESTClientset) InstanceGroupsFor(cluster *kops.Cluster) kopsinternalver
 
 func (c *RESTClientset) SecretStore(cluster *kops.Cluster) (fi.SecretStore, error) {
 	namespace := restNamespaceForClusterName(cluster.Name)
+	if c.KubeClient != nil {
+		return secrets.NewKubernetesSecretStore(cluster, c.KubeClient.CoreV1(), namespace), nil
+	}
 	return secrets.NewClientsetSecretStore(cluster, c.KopsClient, namespace), nil
 }
 
 func (c *RESTClientset) KeyStore(cluster *kops.Cluster) (fi.CAStore, error) {
 	namespace := restNamespaceForClusterName(cluster.Name)
-	return fi.NewClientsetCAStore(cluster, c.KopsClient, namespace), nil
+	var secretsClient corev1client.SecretsGetter
+	if c.KubeClient != nil {
+		secretsClient = c.KubeClient.CoreV1()
+	}
+	return fi.NewClientsetCAStore(cluster, c.KopsClient, secretsClient, namespace), nil
 }
 
 func (c *RESTClientset) SSHCredentialStore(cluster *kops.Cluster) (fi.SSHCredentialStore, error) {
@@ -146,6 +193,45 @@ func (c *RESTClientset) DeleteCluster(ctx context.Context, cluster *kops.Cluster
 		}
 	}
 
+	{
+		sshCredentials, err := c.KopsClient.SSHCredentials(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error listing SSHCredentials: %v", err)
+		}
+
+		for i := range sshCredentials.Items {
+			sshCredential := &sshCredentials.Items[i]
+			err = c.KopsClient.SSHCredentials(namespace).Delete(ctx, sshCredential.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("error deleting SSHCredential %q: %v", sshCredential.Name, err)
+			}
+		}
+	}
+
+	if c.KubeClient != nil {
+		for _, secretType := range []string{fi.KubernetesSecretTypeKeyset, fi.KubernetesSecretTypeSecret} {
+			secretList, err := c.KubeClient.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: fi.LabelKubernetesSecretType + "=" + secretType,
+			})
+			if err != nil {
+				return fmt.Errorf("error listing secrets: %v", err)
+			}
+
+			for i := range secretList.Items {
+				secret := &secretList.Items[i]
+				err = c.KubeClient.CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+				if err != nil && !errors.IsNotFound(err) {
+					return fmt.Errorf("error deleting secret %q: %v", secret.Name, err)
+				}
+			}
+		}
+
+		err = c.KubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, addonsConfigMapName, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting addons: %v", err)
+		}
+	}
+
 	{
 		igs, err := c.KopsClient.InstanceGroups(namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	kopsfake "k8s.io/kops/pkg/client/clientset_generated/clientset/fake"
+	"k8s.io/kops/pkg/kubemanifest"
+	"k8s.io/kops/pkg/testutils"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func newTestRESTClientset() *RESTClientset {
+	return &RESTClientset{
+		BaseURL:    &url.URL{Scheme: "k8s"},
+		KopsClient: kopsfake.NewSimpleClientset().Kops(),
+		KubeClient: kubefake.NewSimpleClientset(),
+	}
+}
+
+func TestRESTClientsetClusterLifecycle(t *testing.T) {
+	ctx := context.Background()
+	vfs.Context.ResetMemfsContext(true)
+	c := newTestRESTClientset()
+
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	_, err := c.CreateCluster(ctx, cluster)
+	require.NoError(t, err)
+
+	ns, err := c.KubeClient.CoreV1().Namespaces().Get(ctx, "minimal-example-com", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "minimal.example.com", ns.Annotations[annotationClusterName])
+
+	configBase, err := c.ConfigBaseFor(cluster)
+	require.NoError(t, err)
+	assert.Equal(t, "memfs://unittest-bucket/minimal.example.com", configBase.Path())
+
+	secretStore, err := c.SecretStore(cluster)
+	require.NoError(t, err)
+	_, _, err = secretStore.GetOrCreateSecret("admin", &fi.Secret{Data: []byte("password")})
+	require.NoError(t, err)
+
+	addons, err := kubemanifest.LoadObjectsFrom([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: addon\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.AddonsFor(cluster).Replace(addons))
+	loaded, err := c.AddonsFor(cluster).List()
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, "ConfigMap", loaded[0].Kind())
+
+	require.NoError(t, c.DeleteCluster(ctx, cluster))
+
+	_, err = c.GetCluster(ctx, "minimal.example.com")
+	assert.True(t, errors.IsNotFound(err), "cluster was not deleted: %v", err)
+	secrets, err := c.KubeClient.CoreV1().Secrets("minimal-example-com").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, secrets.Items)
+	loaded, err = c.AddonsFor(cluster).List()
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+}
+
+func TestRESTClientsetConfigBaseRequired(t *testing.T) {
+	c := newTestRESTClientset()
+
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	cluster.Spec.ConfigBase = ""
+	_, err := c.ConfigBaseFor(cluster)
+	assert.Error(t, err)
+}
@@ -28,6 +28,8 @@ import (
 	"k8s.io/kops/pkg/apis/kops/registry"
 	kopsinternalversion "k8s.io/kops/pkg/client/clientset_generated/clientset/typed/kops/internalversion"
 	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/pkg/statelock"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/secrets"
 	"k8s.io/kops/util/pkg/vfs"
@@ -147,6 +149,14 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 		if relativePath == "config" || relativePath == "cluster.spec" || relativePath == "cluster-completed.spec" || relativePath == registry.PathKopsVersionUpdated {
 			continue
 		}
+		// The lock is held by the command deleting the cluster
+		if relativePath == statelock.PathLock {
+			continue
+		}
+		// The progress of a rolling update that was interrupted
+		if relativePath == registry.PathRollingUpdateProgress {
+			continue
+		}
 		if strings.HasPrefix(relativePath, "addons/") {
 			continue
 		}
@@ -168,6 +178,9 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 		if strings.HasPrefix(relativePath, "manifests/") {
 			continue
 		}
+		if strings.HasPrefix(relativePath, statehistory.PathHistory+"/") {
+			continue
+		}
 		// TODO: offer an option _not_ to delete backups?
 		if strings.HasPrefix(relativePath, "backups/") {
 			continue
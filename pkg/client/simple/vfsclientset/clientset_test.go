@@ -17,9 +17,18 @@ limitations under the License.
 package vfsclientset
 
 import (
+	"bytes"
+	"context"
+	"os"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -70,3 +79,69 @@ func TestSSHCredentialStoreOnOwnCFS(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, actual)
 	}
 }
+
+func TestInstanceGroupHistory(t *testing.T) {
+	ctx := context.TODO()
+	vfs.Context.ResetMemfsContext(true)
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	require.NoError(t, err)
+	clientset := NewVFSClientset(basePath)
+
+	cluster := &kops.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster.example.com"}}
+	ig := &kops.InstanceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodes"},
+		Spec: kops.InstanceGroupSpec{
+			Role:    kops.InstanceGroupRoleNode,
+			MinSize: fi.Int32(1),
+			MaxSize: fi.Int32(1),
+		},
+	}
+	igs := clientset.InstanceGroupsFor(cluster)
+
+	_, err = igs.Create(ctx, ig, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = igs.Update(ctx, ig, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	ig.Spec.MaxSize = fi.Int32(2)
+	_, err = igs.Update(ctx, ig, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	err = igs.Delete(ctx, "nodes", metav1.DeleteOptions{})
+	require.NoError(t, err)
+
+	revisions, err := statehistory.List(basePath.Join("cluster.example.com", statehistory.PathHistory))
+	require.NoError(t, err)
+	var operations []statehistory.Operation
+	for _, r := range revisions {
+		assert.Equal(t, "InstanceGroup", r.Kind)
+		assert.Equal(t, "nodes", r.Name)
+		operations = append(operations, r.Operation)
+	}
+	assert.Equal(t, []statehistory.Operation{statehistory.OperationCreate, statehistory.OperationUpdate, statehistory.OperationDelete}, operations)
+	assert.Contains(t, revisions[2].Object, "maxSize: 2")
+}
+
+func TestDeleteClusterWithRollingUpdateProgress(t *testing.T) {
+	ctx := context.TODO()
+	vfs.Context.ResetMemfsContext(true)
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	require.NoError(t, err)
+	clientset := NewVFSClientset(basePath)
+
+	configBase := basePath.Join("cluster.example.com")
+	cluster := &kops.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster.example.com"},
+		Spec:       kops.ClusterSpec{ConfigBase: configBase.Path()},
+	}
+	require.NoError(t, configBase.Join("config").WriteFile(bytes.NewReader([]byte("{}")), nil))
+	progress := configBase.Join(registry.PathRollingUpdateProgress)
+	require.NoError(t, progress.WriteFile(bytes.NewReader([]byte("{}")), nil))
+
+	require.NoError(t, clientset.DeleteCluster(ctx, cluster))
+
+	_, err = progress.ReadFile()
+	assert.True(t, os.IsNotExist(err), "rolling update progress was not deleted: %v", err)
+
+	unknown := configBase.Join("unknown.json")
+	require.NoError(t, unknown.WriteFile(bytes.NewReader([]byte("{}")), nil))
+	assert.Error(t, clientset.DeleteCluster(ctx, cluster))
+}
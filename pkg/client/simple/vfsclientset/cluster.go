@@ -33,6 +33,7 @@ import (
 	api "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/registry"
 	"k8s.io/kops/pkg/apis/kops/validation"
+	"k8s.io/kops/pkg/statehistory"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -115,6 +116,7 @@ func (r *ClusterVFS) Create(c *api.Cluster) (*api.Cluster, error) {
 		}
 		return nil, fmt.Errorf("error writing Cluster %q: %v", c.ObjectMeta.Name, err)
 	}
+	r.recordHistory(r.basePath.Join(clusterName, statehistory.PathHistory), statehistory.OperationCreate, c)
 
 	return c, nil
 }
@@ -148,6 +150,7 @@ func (r *ClusterVFS) Update(c *api.Cluster, status *api.ClusterStatus) (*api.Clu
 		}
 		return nil, fmt.Errorf("error writing Cluster: %v", err)
 	}
+	r.recordHistory(r.basePath.Join(clusterName, statehistory.PathHistory), statehistory.OperationUpdate, c)
 
 	return c, nil
 }
@@ -33,6 +33,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/v1alpha2"
 	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/statehistory"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -45,6 +46,8 @@ type commonVFS struct {
 	basePath vfs.Path
 	encoder  runtime.Encoder
 	validate ValidationFunction
+	// history is where the writes of objects are recorded, if not nil
+	history vfs.Path
 }
 
 func (c *commonVFS) init(kind string, basePath vfs.Path, storeVersion runtime.GroupVersioner) {
@@ -99,10 +102,32 @@ func (c *commonVFS) create(ctx context.Context, cluster *kops.Cluster, i runtime
 		}
 		return fmt.Errorf("error writing %s: %v", c.kind, err)
 	}
+	c.recordHistory(c.history, statehistory.OperationCreate, i)
 
 	return nil
 }
 
+// recordHistory records a write of an object in the history.
+// The write has already happened, so a failure to record it is only logged.
+func (c *commonVFS) recordHistory(history vfs.Path, operation statehistory.Operation, o runtime.Object) {
+	if history == nil {
+		return
+	}
+	objectMeta, err := meta.Accessor(o)
+	if err != nil {
+		klog.Warningf("unable to record %s in history: %v", c.kind, err)
+		return
+	}
+	data, err := c.serialize(o)
+	if err != nil {
+		klog.Warningf("unable to record %s %q in history: %v", c.kind, objectMeta.GetName(), err)
+		return
+	}
+	if _, err := statehistory.Record(history, operation, c.kind, objectMeta.GetName(), data); err != nil {
+		klog.Warningf("unable to record %s %q in history: %v", c.kind, objectMeta.GetName(), err)
+	}
+}
+
 func (c *commonVFS) serialize(o runtime.Object) ([]byte, error) {
 	var b bytes.Buffer
 	err := c.encoder.Encode(o, &b)
@@ -196,11 +221,21 @@ func (c *commonVFS) update(ctx context.Context, cluster *kops.Cluster, i runtime
 	if err != nil {
 		return fmt.Errorf("error writing %s: %v", c.kind, err)
 	}
+	c.recordHistory(c.history, statehistory.OperationUpdate, i)
 
 	return nil
 }
 
 func (c *commonVFS) delete(ctx context.Context, name string, options metav1.DeleteOptions) error {
+	var deleted runtime.Object
+	if c.history != nil {
+		o, err := c.find(ctx, name)
+		if err != nil {
+			return err
+		}
+		deleted = o
+	}
+
 	p := c.basePath.Join(name)
 	err := p.Remove()
 	if err != nil {
@@ -209,6 +244,9 @@ func (c *commonVFS) delete(ctx context.Context, name string, options metav1.Dele
 		}
 		return fmt.Errorf("error deleting %s configuration %q: %v", c.kind, name, err)
 	}
+	if deleted != nil {
+		c.recordHistory(c.history, statehistory.OperationDelete, deleted)
+	}
 	return nil
 }
 
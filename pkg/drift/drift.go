@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/kops/pkg/resources"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// Status is the kind of drift between the cluster spec and the cloud
+type Status string
+
+const (
+	// StatusChanged is a resource that exists but differs from the cluster spec
+	StatusChanged Status = "Changed"
+	// StatusMissing is a resource of the cluster spec that does not exist
+	StatusMissing Status = "Missing"
+	// StatusOrphaned is a resource that exists but is no longer part of the cluster spec
+	StatusOrphaned Status = "Orphaned"
+)
+
+// Drift is a resource that differs between the cluster spec and the cloud
+type Drift struct {
+	Status Status `json:"status"`
+	// Type is the task type, or the cloud resource type for resources not known to any task
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// ID is the cloud ID of orphaned resources, if known
+	ID string `json:"id,omitempty"`
+	// Fields are the fields that would be set or changed to correct the drift
+	Fields []fi.FieldChange `json:"fields,omitempty"`
+}
+
+// BuildReport combines the changes found by a dry run of the tasks with the resources found in the cloud.
+// Cloud resources that are owned by the cluster but are not matched by the name or ID of any task are reported as orphaned;
+// instances are never reported, as they are managed by their instance groups rather than by tasks.
+func BuildReport(changes []*fi.TaskChange, tasks map[string]fi.Task, cloudResources map[string]*resources.Resource) []*Drift {
+	var report []*Drift
+
+	for _, change := range changes {
+		d := &Drift{
+			Type:   change.Type,
+			Name:   change.Name,
+			Fields: change.Fields,
+		}
+		switch change.Action {
+		case fi.ChangeActionCreate:
+			d.Status = StatusMissing
+		case fi.ChangeActionUpdate:
+			d.Status = StatusChanged
+		case fi.ChangeActionDelete:
+			d.Status = StatusOrphaned
+		}
+		report = append(report, d)
+	}
+
+	known := make(map[string]bool)
+	for _, task := range tasks {
+		for _, id := range taskIdentifiers(task) {
+			known[id] = true
+		}
+	}
+
+	var orphaned []*Drift
+	for _, r := range cloudResources {
+		if r.Shared || strings.EqualFold(r.Type, "instance") {
+			continue
+		}
+		if known[r.ID] || known[r.Name] {
+			continue
+		}
+		orphaned = append(orphaned, &Drift{
+			Status: StatusOrphaned,
+			Type:   r.Type,
+			Name:   r.Name,
+			ID:     r.ID,
+		})
+	}
+	sort.Slice(orphaned, func(i, j int) bool {
+		if orphaned[i].Type != orphaned[j].Type {
+			return orphaned[i].Type < orphaned[j].Type
+		}
+		if orphaned[i].Name != orphaned[j].Name {
+			return orphaned[i].Name < orphaned[j].Name
+		}
+		return orphaned[i].ID < orphaned[j].ID
+	})
+
+	return append(report, orphaned...)
+}
+
+// taskIdentifiers returns the name of the task and, if Find has populated it, the cloud ID of its resource
+func taskIdentifiers(task fi.Task) []string {
+	var ids []string
+	if hasName, ok := task.(fi.HasName); ok {
+		if name := fi.StringValue(hasName.GetName()); name != "" {
+			ids = append(ids, name)
+		}
+	}
+
+	v := reflect.ValueOf(task)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ids
+	}
+	f := v.FieldByName("ID")
+	if !f.IsValid() {
+		return ids
+	}
+	switch f.Kind() {
+	case reflect.String:
+		if f.String() != "" {
+			ids = append(ids, f.String())
+		}
+	case reflect.Ptr:
+		if !f.IsNil() && f.Elem().Kind() == reflect.String && f.Elem().String() != "" {
+			ids = append(ids, f.Elem().String())
+		}
+	}
+	return ids
+}
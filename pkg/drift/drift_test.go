@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/kops/pkg/resources"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type testTask struct {
+	Name *string
+	ID   *string
+}
+
+func (t *testTask) GetName() *string {
+	return t.Name
+}
+
+func (t *testTask) Run(_ *fi.Context) error {
+	panic("not implemented")
+}
+
+func TestBuildReport(t *testing.T) {
+	changes := []*fi.TaskChange{
+		{Key: "testTask/subnet", Type: "testTask", Name: "subnet", Action: fi.ChangeActionUpdate, Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.0.0.0/24 -> 10.0.1.0/24"}}},
+		{Key: "testTask/gateway", Type: "testTask", Name: "gateway", Action: fi.ChangeActionCreate},
+		{Key: "Keypair/old", Type: "Keypair", Name: "old", Action: fi.ChangeActionDelete},
+	}
+	tasks := map[string]fi.Task{
+		"testTask/vpc":    &testTask{Name: fi.String("vpc"), ID: fi.String("vpc-1234")},
+		"testTask/subnet": &testTask{Name: fi.String("subnet")},
+	}
+	cloudResources := map[string]*resources.Resource{
+		"vpc:vpc-1234":           {Type: "vpc", ID: "vpc-1234", Name: "my-cluster"},
+		"subnet:subnet-1":        {Type: "subnet", ID: "subnet-1", Name: "subnet"},
+		"security-group:sg-1":    {Type: "security-group", ID: "sg-1", Name: "leftover"},
+		"security-group:sg-2":    {Type: "security-group", ID: "sg-2", Name: "shared", Shared: true},
+		"instance:i-1":           {Type: "instance", ID: "i-1", Name: "node"},
+		"load-balancer:elb-1234": {Type: "load-balancer", ID: "elb-1234", Name: "a1234"},
+	}
+
+	report := BuildReport(changes, tasks, cloudResources)
+
+	expected := []*Drift{
+		{Status: StatusChanged, Type: "testTask", Name: "subnet", Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.0.0.0/24 -> 10.0.1.0/24"}}},
+		{Status: StatusMissing, Type: "testTask", Name: "gateway"},
+		{Status: StatusOrphaned, Type: "Keypair", Name: "old"},
+		{Status: StatusOrphaned, Type: "load-balancer", Name: "a1234", ID: "elb-1234"},
+		{Status: StatusOrphaned, Type: "security-group", Name: "leftover", ID: "sg-1"},
+	}
+	assert.Equal(t, expected, report)
+}
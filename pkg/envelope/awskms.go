@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// awsKMS wraps the data keys with an AWS KMS key
+type awsKMS struct {
+	key    string
+	client *kms.KMS
+}
+
+var _ KeyService = &awsKMS{}
+
+// newAWSKMS builds the key service for the ARN of a KMS key.
+// The client is built for the region of the key, so that nodes need no region configuration.
+func newAWSKMS(key string) (*awsKMS, error) {
+	keyARN, err := arn.Parse(key)
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS key %q is not an ARN: %v", key, err)
+	}
+
+	config := aws.NewConfig().WithCredentialsChainVerboseErrors(true).WithRegion(keyARN.Region)
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error starting AWS session: %v", err)
+	}
+
+	return &awsKMS{
+		key:    key,
+		client: kms.New(sess, config),
+	}, nil
+}
+
+func (k *awsKMS) Provider() string {
+	return ProviderAWSKMS
+}
+
+func (k *awsKMS) Key() string {
+	return k.key
+}
+
+func (k *awsKMS) WrapKey(dataKey []byte) ([]byte, error) {
+	response, err := k.client.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(k.key),
+		Plaintext: dataKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.CiphertextBlob, nil
+}
+
+func (k *awsKMS) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	response, err := k.client.Decrypt(&kms.DecryptInput{
+		KeyId:          aws.String(k.key),
+		CiphertextBlob: wrappedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Plaintext, nil
+}
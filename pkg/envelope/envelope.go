@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package envelope implements the envelope encryption of the secrets and private keys in the state store.
+//
+// Each object is encrypted with AES-256-GCM using a new random data key. The data key is encrypted ("wrapped")
+// by a key management service and stored alongside the encrypted object, together with the provider and the key
+// that wrapped it. Decryption therefore needs no configuration, only access to the key management service.
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// Providers of key management services
+const (
+	ProviderAWSKMS       = "aws-kms"
+	ProviderGCPKMS       = "gcp-kms"
+	ProviderVaultTransit = "vault-transit"
+	// ProviderLocal wraps the data keys with a key read from a local file; it is intended for testing
+	ProviderLocal = "local"
+)
+
+// Providers are the supported providers of key management services
+var Providers = []string{ProviderAWSKMS, ProviderGCPKMS, ProviderVaultTransit, ProviderLocal}
+
+// header prefixes an encrypted object, so that it can be told apart from a plain one
+var header = []byte("kops-envelope/v1\n")
+
+// dataKeySize is the size of the data keys, for AES-256
+const dataKeySize = 32
+
+// KeyService wraps and unwraps data keys with a key of a key management service
+type KeyService interface {
+	// Provider is the provider of the key management service
+	Provider() string
+	// Key identifies the key that wraps the data keys
+	Key() string
+	// WrapKey encrypts a data key
+	WrapKey(dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped by WrapKey
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+// envelope is the serialized form of an encrypted object
+type envelope struct {
+	Provider   string `json:"provider"`
+	Key        string `json:"key"`
+	WrappedKey []byte `json:"wrappedKey"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// additionalData binds the ciphertext to the key that wrapped its data key
+func (e *envelope) additionalData() []byte {
+	return []byte(e.Provider + "\n" + e.Key)
+}
+
+var (
+	keyServicesMutex sync.Mutex
+	// keyServices caches the key services by provider and key, as building their clients can be expensive
+	keyServices = make(map[string]KeyService)
+)
+
+// NewKeyService returns the key service for a key of a provider
+func NewKeyService(provider string, key string) (KeyService, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required for provider %q", provider)
+	}
+
+	keyServicesMutex.Lock()
+	defer keyServicesMutex.Unlock()
+
+	id := provider + "\n" + key
+	if ks := keyServices[id]; ks != nil {
+		return ks, nil
+	}
+
+	var ks KeyService
+	var err error
+	switch provider {
+	case ProviderAWSKMS:
+		ks, err = newAWSKMS(key)
+	case ProviderGCPKMS:
+		ks, err = newGCPKMS(key)
+	case ProviderVaultTransit:
+		ks, err = newVaultTransit(key)
+	case ProviderLocal:
+		ks, err = newLocalKeyFile(key)
+	default:
+		return nil, fmt.Errorf("unknown key management service provider %q", provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyServices[id] = ks
+	return ks, nil
+}
+
+// IsEncrypted returns true if the data is an encrypted object
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, header)
+}
+
+// Encrypt encrypts an object with a new data key wrapped by the key service
+func Encrypt(ks KeyService, plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("error generating data key: %v", err)
+	}
+
+	wrappedKey, err := ks.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data key with %s key %q: %v", ks.Provider(), ks.Key(), err)
+	}
+
+	e := &envelope{
+		Provider:   ks.Provider(),
+		Key:        ks.Key(),
+		WrappedKey: wrappedKey,
+	}
+	e.Nonce, e.Ciphertext, err = seal(dataKey, plaintext, e.additionalData())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing envelope: %v", err)
+	}
+	return append(append([]byte{}, header...), data...), nil
+}
+
+// EncryptForCluster encrypts an object with the state store encryption of the cluster.
+// The object is returned as is if the state store of the cluster is not encrypted.
+func EncryptForCluster(cluster *kops.Cluster, plaintext []byte) ([]byte, error) {
+	if cluster == nil || cluster.Spec.StateStoreEncryption == nil {
+		return plaintext, nil
+	}
+	ks, err := NewKeyService(cluster.Spec.StateStoreEncryption.Provider, cluster.Spec.StateStoreEncryption.Key)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(ks, plaintext)
+}
+
+// Decrypt decrypts an encrypted object. Data that is not encrypted is returned as is,
+// so that objects written before the encryption was enabled can still be read.
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	e := &envelope{}
+	if err := json.Unmarshal(data[len(header):], e); err != nil {
+		return nil, fmt.Errorf("error parsing envelope: %v", err)
+	}
+
+	ks, err := NewKeyService(e.Provider, e.Key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := ks.UnwrapKey(e.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key with %s key %q: %v", e.Provider, e.Key, err)
+	}
+
+	return open(dataKey, e.Nonce, e.Ciphertext, e.additionalData())
+}
+
+// seal encrypts the plaintext with AES-GCM, returning the random nonce and the ciphertext
+func seal(key []byte, plaintext []byte, additionalData []byte) ([]byte, []byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext encrypted by seal
+func open(key []byte, nonce []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(nonce))
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting: %v", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error building cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error building cipher: %v", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// writeKeyFile writes a local key file with a key of the byte repeated
+func writeKeyFile(t *testing.T, name string, b byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, dataKeySize))
+	require.NoError(t, ioutil.WriteFile(path, []byte(key+"\n"), 0600))
+	return path
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	ks, err := NewKeyService(ProviderLocal, writeKeyFile(t, "key", 1))
+	require.NoError(t, err)
+
+	plaintext := []byte("apiVersion: kops.k8s.io/v1alpha2\nkind: Keyset\n")
+	encrypted, err := Encrypt(ks, plaintext)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "Keyset")
+
+	decrypted, err := Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+func TestDecryptPlain(t *testing.T) {
+	plaintext := []byte("apiVersion: kops.k8s.io/v1alpha2\nkind: Keyset\n")
+	assert.False(t, IsEncrypted(plaintext))
+
+	decrypted, err := Decrypt(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	path := writeKeyFile(t, "key", 1)
+	ks, err := NewKeyService(ProviderLocal, path)
+	require.NoError(t, err)
+
+	encrypted, err := Encrypt(ks, []byte("secret"))
+	require.NoError(t, err)
+
+	// Build a key service for the same key id, but holding another key
+	other, err := newLocalKeyFile(writeKeyFile(t, "other", 2))
+	require.NoError(t, err)
+	keyServicesMutex.Lock()
+	keyServices[ProviderLocal+"\n"+path] = other
+	keyServicesMutex.Unlock()
+	defer func() {
+		keyServicesMutex.Lock()
+		delete(keyServices, ProviderLocal+"\n"+path)
+		keyServicesMutex.Unlock()
+	}()
+
+	_, err = Decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestDecryptTampered(t *testing.T) {
+	ks, err := NewKeyService(ProviderLocal, writeKeyFile(t, "key", 1))
+	require.NoError(t, err)
+
+	e := &envelope{Provider: ks.Provider(), Key: ks.Key()}
+	dataKey := bytes.Repeat([]byte{3}, dataKeySize)
+	e.WrappedKey, err = ks.WrapKey(dataKey)
+	require.NoError(t, err)
+	e.Nonce, e.Ciphertext, err = seal(dataKey, []byte("secret"), e.additionalData())
+	require.NoError(t, err)
+
+	plaintext, err := open(dataKey, e.Nonce, e.Ciphertext, e.additionalData())
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	// The ciphertext is bound to the key that wrapped its data key
+	_, err = open(dataKey, e.Nonce, e.Ciphertext, []byte(ProviderLocal+"\nother"))
+	assert.Error(t, err)
+
+	e.Ciphertext[0] ^= 0xff
+	_, err = open(dataKey, e.Nonce, e.Ciphertext, e.additionalData())
+	assert.Error(t, err)
+}
+
+func TestEncryptForCluster(t *testing.T) {
+	cluster := &kops.Cluster{}
+	plaintext := []byte("secret")
+
+	data, err := EncryptForCluster(cluster, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, data)
+
+	cluster.Spec.StateStoreEncryption = &kops.StateStoreEncryptionSpec{
+		Provider: ProviderLocal,
+		Key:      writeKeyFile(t, "key", 1),
+	}
+	data, err = EncryptForCluster(cluster, plaintext)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(data))
+
+	decrypted, err := Decrypt(data)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+func TestNewKeyServiceErrors(t *testing.T) {
+	_, err := NewKeyService("unknown", "key")
+	assert.EqualError(t, err, `unknown key management service provider "unknown"`)
+
+	_, err = NewKeyService(ProviderLocal, "")
+	assert.EqualError(t, err, `key is required for provider "local"`)
+
+	_, err = NewKeyService(ProviderAWSKMS, "alias/kops")
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "short")
+	require.NoError(t, ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0600))
+	_, err = NewKeyService(ProviderLocal, path)
+	assert.Error(t, err)
+}
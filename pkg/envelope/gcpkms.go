@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/oauth2/google"
+)
+
+// gcpKMSEndpoint is the endpoint of the Cloud KMS REST API
+const gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+
+// gcpKMS wraps the data keys with a GCP Cloud KMS crypto key, through the Cloud KMS REST API
+type gcpKMS struct {
+	key    string
+	client *http.Client
+}
+
+var _ KeyService = &gcpKMS{}
+
+// newGCPKMS builds the key service for the resource name of a crypto key,
+// e.g. projects/my-project/locations/global/keyRings/my-ring/cryptoKeys/my-key
+func newGCPKMS(key string) (*gcpKMS, error) {
+	client, err := google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/cloudkms")
+	if err != nil {
+		return nil, fmt.Errorf("error building GCP client: %v", err)
+	}
+	return &gcpKMS{
+		key:    key,
+		client: client,
+	}, nil
+}
+
+func (k *gcpKMS) Provider() string {
+	return ProviderGCPKMS
+}
+
+func (k *gcpKMS) Key() string {
+	return k.key
+}
+
+func (k *gcpKMS) WrapKey(dataKey []byte) ([]byte, error) {
+	request := struct {
+		Plaintext []byte `json:"plaintext"`
+	}{Plaintext: dataKey}
+	response := struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}{}
+	if err := k.call("encrypt", &request, &response); err != nil {
+		return nil, err
+	}
+	return response.Ciphertext, nil
+}
+
+func (k *gcpKMS) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	request := struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}{Ciphertext: wrappedKey}
+	response := struct {
+		Plaintext []byte `json:"plaintext"`
+	}{}
+	if err := k.call("decrypt", &request, &response); err != nil {
+		return nil, err
+	}
+	return response.Plaintext, nil
+}
+
+// call calls a method of the crypto key
+func (k *gcpKMS) call(method string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("error serializing request: %v", err)
+	}
+
+	httpResponse, err := k.client.Post(gcpKMSEndpoint+k.key+":"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error calling Cloud KMS: %v", err)
+	}
+	defer httpResponse.Body.Close()
+
+	data, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return fmt.Errorf("error reading Cloud KMS response: %v", err)
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("cloud KMS %s returned %s: %s", method, httpResponse.Status, string(data))
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("error parsing Cloud KMS response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+)
+
+// localKeyFile wraps the data keys with an AES-256 key read from a local file.
+// Anyone with the file can decrypt the state store, so it is intended for testing.
+type localKeyFile struct {
+	path string
+	key  []byte
+}
+
+var _ KeyService = &localKeyFile{}
+
+// newLocalKeyFile reads the key from the file, which holds a base64-encoded 32 byte key
+func newLocalKeyFile(path string) (*localKeyFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding key file %q: %v", path, err)
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("key file %q holds a key of %d bytes, expected %d", path, len(key), dataKeySize)
+	}
+	return &localKeyFile{path: path, key: key}, nil
+}
+
+func (k *localKeyFile) Provider() string {
+	return ProviderLocal
+}
+
+func (k *localKeyFile) Key() string {
+	return k.path
+}
+
+func (k *localKeyFile) WrapKey(dataKey []byte) ([]byte, error) {
+	nonce, ciphertext, err := seal(k.key, dataKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+func (k *localKeyFile) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped key is too short")
+	}
+	return open(k.key, wrappedKey[:gcm.NonceSize()], wrappedKey[gcm.NonceSize():], nil)
+}
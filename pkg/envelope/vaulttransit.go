@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// vaultTransit wraps the data keys with a key of the Vault transit secrets engine.
+// The client is configured from the environment, e.g. VAULT_ADDR and VAULT_TOKEN.
+type vaultTransit struct {
+	key    string
+	mount  string
+	name   string
+	client *vault.Client
+}
+
+var _ KeyService = &vaultTransit{}
+
+// newVaultTransit builds the key service for a transit key, given as <mount path>/<key name>, e.g. transit/kops
+func newVaultTransit(key string) (*vaultTransit, error) {
+	i := strings.LastIndex(key, "/")
+	if i <= 0 || i == len(key)-1 {
+		return nil, fmt.Errorf("vault transit key %q must be of the form <mount path>/<key name>", key)
+	}
+
+	client, err := vault.NewClient(vault.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("error building vault client: %v", err)
+	}
+
+	return &vaultTransit{
+		key:    key,
+		mount:  key[:i],
+		name:   key[i+1:],
+		client: client,
+	}, nil
+}
+
+func (k *vaultTransit) Provider() string {
+	return ProviderVaultTransit
+}
+
+func (k *vaultTransit) Key() string {
+	return k.key
+}
+
+func (k *vaultTransit) WrapKey(dataKey []byte) ([]byte, error) {
+	secret, err := k.client.Logical().Write(k.mount+"/encrypt/"+k.name, map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(dataKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data["ciphertext"] == nil {
+		return nil, fmt.Errorf("vault returned no ciphertext")
+	}
+	ciphertext, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault returned an unexpected ciphertext of type %T", secret.Data["ciphertext"])
+	}
+	return []byte(ciphertext), nil
+}
+
+func (k *vaultTransit) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	secret, err := k.client.Logical().Write(k.mount+"/decrypt/"+k.name, map[string]interface{}{
+		"ciphertext": string(wrappedKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data["plaintext"] == nil {
+		return nil, fmt.Errorf("vault returned no plaintext")
+	}
+	plaintext, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault returned an unexpected plaintext of type %T", secret.Data["plaintext"])
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package etcdbackup reads the backups that etcd-manager writes to the backup store of an etcd cluster,
+// and writes the commands that make etcd-manager restore them.
+// The files are those read and written by etcd-manager and etcd-manager-ctl:
+//
+//	<backupStore>/<backup>/_etcd_backup.meta     the metadata of the backup
+//	<backupStore>/<backup>/etcd.backup.gz        the gzipped etcd snapshot
+//	<backupStore>/control/etcd-cluster-spec      the spec of the etcd cluster, written by kops
+//	<backupStore>/control/<time>/_command.json   a command for etcd-manager
+package etcdbackup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/urls"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+const (
+	// MetaFilename is the name of the file with the metadata of a backup
+	MetaFilename = "_etcd_backup.meta"
+	// DataFilename is the name of the file with the gzipped etcd snapshot of a backup
+	DataFilename = "etcd.backup.gz"
+	// CommandFilename is the name of the file of a command for etcd-manager
+	CommandFilename = "_command.json"
+
+	controlDir = "control"
+)
+
+// Status is the result of the verification of a backup
+type Status string
+
+const (
+	// StatusUnverified is a backup whose data has not been read
+	StatusUnverified Status = "Unverified"
+	// StatusValid is a backup whose metadata and data were read successfully
+	StatusValid Status = "Valid"
+	// StatusCorrupt is a backup whose metadata or data could not be read
+	StatusCorrupt Status = "Corrupt"
+)
+
+// Backup is a backup of an etcd cluster
+type Backup struct {
+	// EtcdCluster is the name of the etcd cluster, e.g. main
+	EtcdCluster string `json:"etcdCluster"`
+	// Name identifies the backup in the backup store
+	Name        string    `json:"name"`
+	Timestamp   time.Time `json:"timestamp"`
+	EtcdVersion string    `json:"etcdVersion,omitempty"`
+	// Size is the size of the gzipped snapshot, in bytes; it is only set once verified
+	Size   int64  `json:"size,omitempty"`
+	Status Status `json:"status"`
+	// Problem describes why the backup is corrupt
+	Problem string `json:"problem,omitempty"`
+}
+
+// ClusterSpec is the spec of an etcd cluster, as stored in the control directory of the backup store
+type ClusterSpec struct {
+	MemberCount int32  `json:"memberCount,omitempty"`
+	EtcdVersion string `json:"etcdVersion,omitempty"`
+}
+
+// backupInfo is the content of the metadata file of a backup
+type backupInfo struct {
+	EtcdVersion string `json:"etcdVersion,omitempty"`
+	// Timestamp is the time of the backup, in seconds since the epoch; etcd-manager writes it as a string
+	Timestamp   json.Number  `json:"timestamp,omitempty"`
+	ClusterSpec *ClusterSpec `json:"clusterSpec,omitempty"`
+}
+
+// command is a command for etcd-manager
+type command struct {
+	// Timestamp is the time of the command, in nanoseconds since the epoch
+	Timestamp     string                `json:"timestamp"`
+	RestoreBackup *restoreBackupCommand `json:"restoreBackup,omitempty"`
+}
+
+type restoreBackupCommand struct {
+	ClusterSpec *ClusterSpec `json:"clusterSpec"`
+	Backup      string       `json:"backup"`
+}
+
+// BackupStore returns the backup store of the etcd cluster, defaulting it as the cluster spec does
+func BackupStore(cluster *kops.Cluster, etcdCluster *kops.EtcdClusterSpec) (vfs.Path, error) {
+	backupStore := ""
+	if etcdCluster.Backups != nil {
+		backupStore = etcdCluster.Backups.BackupStore
+	}
+	if backupStore == "" {
+		if cluster.Spec.ConfigBase == "" {
+			return nil, fmt.Errorf("configBase is not set for cluster %q", cluster.ObjectMeta.Name)
+		}
+		backupStore = urls.Join(cluster.Spec.ConfigBase, "backups", "etcd", etcdCluster.Name)
+	}
+
+	p, err := vfs.Context.BuildVfsPath(backupStore)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing backup store %q: %v", backupStore, err)
+	}
+	return p, nil
+}
+
+// ListBackups returns the backups in the backup store of the etcd cluster, oldest first.
+// Backups whose metadata cannot be read are returned as corrupt.
+func ListBackups(etcdCluster string, backupStore vfs.Path) ([]*Backup, error) {
+	files, err := backupStore.ReadTree()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing backup store %q: %v", backupStore, err)
+	}
+
+	var backups []*Backup
+	for _, file := range files {
+		name := backupName(backupStore, file)
+		if name == "" {
+			continue
+		}
+
+		backup := &Backup{
+			EtcdCluster: etcdCluster,
+			Name:        name,
+			Status:      StatusUnverified,
+		}
+		if t, ok := timeFromName(name); ok {
+			backup.Timestamp = t
+		}
+
+		info, err := readBackupInfo(file)
+		if err != nil {
+			backup.Status = StatusCorrupt
+			backup.Problem = err.Error()
+		} else {
+			backup.EtcdVersion = info.EtcdVersion
+			if seconds, err := strconv.ParseInt(info.Timestamp.String(), 10, 64); err == nil && seconds != 0 {
+				backup.Timestamp = time.Unix(seconds, 0).UTC()
+			}
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Timestamp.Equal(backups[j].Timestamp) {
+			return backups[i].Timestamp.Before(backups[j].Timestamp)
+		}
+		return backups[i].Name < backups[j].Name
+	})
+	return backups, nil
+}
+
+// backupName returns the name of the backup if the file is the metadata file of a backup, otherwise ""
+func backupName(backupStore vfs.Path, file vfs.Path) string {
+	if file.Base() != MetaFilename {
+		return ""
+	}
+	relative := strings.TrimPrefix(file.Path(), strings.TrimSuffix(backupStore.Path(), "/")+"/")
+	tokens := strings.Split(relative, "/")
+	if len(tokens) != 2 || tokens[0] == controlDir {
+		return ""
+	}
+	return tokens[0]
+}
+
+// timeFromName parses the time from the name of a backup, which etcd-manager names <RFC3339 time>-<sequence>
+func timeFromName(name string) (time.Time, bool) {
+	i := strings.LastIndex(name, "-")
+	if i == -1 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, name[:i])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func readBackupInfo(p vfs.Path) (*backupInfo, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", MetaFilename, err)
+	}
+	info := &backupInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", MetaFilename, err)
+	}
+	return info, nil
+}
+
+// VerifyBackup reads the snapshot of the backup, checking that it decompresses to a non-empty snapshot.
+// It sets the Status, Size and Problem of the backup.
+func VerifyBackup(backupStore vfs.Path, backup *Backup) {
+	if backup.Status == StatusCorrupt {
+		return
+	}
+
+	size, err := verifySnapshot(backupStore.Join(backup.Name, DataFilename))
+	if err != nil {
+		backup.Status = StatusCorrupt
+		backup.Problem = err.Error()
+		return
+	}
+	backup.Status = StatusValid
+	backup.Size = size
+	backup.Problem = ""
+}
+
+// verifySnapshot decompresses the snapshot as it is read, returning its compressed size.
+// gzip verifies the checksum and length of the data when it reaches the end of the stream.
+func verifySnapshot(p vfs.Path) (int64, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := p.WriteTo(pw)
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	compressed := &countingReader{r: pr}
+	r, err := gzip.NewReader(compressed)
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %v", DataFilename, err)
+	}
+	defer r.Close()
+
+	n, err := io.Copy(ioutil.Discard, r)
+	if err != nil {
+		return 0, fmt.Errorf("error decompressing %s: %v", DataFilename, err)
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%s is empty", DataFilename)
+	}
+	return compressed.n, nil
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// FindBackup returns the backup with the name, or nil if it is not found
+func FindBackup(backups []*Backup, name string) *Backup {
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup
+		}
+	}
+	return nil
+}
+
+// ReadClusterSpec reads the spec of the etcd cluster from the control directory of the backup store
+func ReadClusterSpec(backupStore vfs.Path) (*ClusterSpec, error) {
+	p := backupStore.Join(controlDir, "etcd-cluster-spec")
+	data, err := p.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("error reading etcd cluster spec %q: %v", p, err)
+	}
+	spec := &ClusterSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("error parsing etcd cluster spec %q: %v", p, err)
+	}
+	return spec, nil
+}
+
+// AddRestoreCommand writes the command that makes etcd-manager restore the backup when it next starts.
+// It returns the path of the command.
+func AddRestoreCommand(backupStore vfs.Path, backup string, spec *ClusterSpec, now time.Time) (vfs.Path, error) {
+	cmd := &command{
+		Timestamp: strconv.FormatInt(now.UnixNano(), 10),
+		RestoreBackup: &restoreBackupCommand{
+			ClusterSpec: spec,
+			Backup:      backup,
+		},
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling restore command: %v", err)
+	}
+
+	p := backupStore.Join(controlDir, now.UTC().Format(time.RFC3339Nano), CommandFilename)
+	if err := p.WriteFile(bytes.NewReader(data), nil); err != nil {
+		return nil, fmt.Errorf("error writing restore command %q: %v", p, err)
+	}
+	return p, nil
+}
+
+// StaleProblem returns a description of the problem if the newest valid or unverified backup is older than maxAge, otherwise ""
+func StaleProblem(backups []*Backup, maxAge time.Duration, now time.Time) string {
+	var newest *Backup
+	for _, backup := range backups {
+		if backup.Status == StatusCorrupt {
+			continue
+		}
+		if newest == nil || backup.Timestamp.After(newest.Timestamp) {
+			newest = backup
+		}
+	}
+	if newest == nil {
+		return "no backups found"
+	}
+	if age := now.Sub(newest.Timestamp); age > maxAge {
+		return fmt.Sprintf("newest backup %s is %s old", newest.Name, age.Round(time.Minute))
+	}
+	return ""
+}
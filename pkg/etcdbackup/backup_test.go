@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdbackup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func writeFile(t *testing.T, p vfs.Path, data []byte) {
+	require.NoError(t, p.WriteFile(bytes.NewReader(data), nil))
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func newBackupStore(t *testing.T) vfs.Path {
+	store := vfs.NewMemFSPath(vfs.NewMemFSContext(), "backups/etcd/main")
+
+	writeFile(t, store.Join("control", "etcd-cluster-spec"), []byte(`{"memberCount":3,"etcdVersion":"3.4.13"}`))
+
+	// A valid backup, with the timestamp written as a string as etcd-manager does
+	writeFile(t, store.Join("2021-05-10T12:00:00Z-000001", MetaFilename), []byte(`{"etcdVersion":"3.4.13","timestamp":"1620648000"}`))
+	writeFile(t, store.Join("2021-05-10T12:00:00Z-000001", DataFilename), gzipped(t, []byte("snapshot")))
+
+	// A newer backup whose snapshot is truncated
+	data := gzipped(t, []byte("snapshot"))
+	writeFile(t, store.Join("2021-05-10T13:00:00Z-000002", MetaFilename), []byte(`{"etcdVersion":"3.4.13","timestamp":1620651600}`))
+	writeFile(t, store.Join("2021-05-10T13:00:00Z-000002", DataFilename), data[:len(data)-4])
+
+	// A backup whose metadata is not valid
+	writeFile(t, store.Join("2021-05-10T14:00:00Z-000003", MetaFilename), []byte(`{`))
+
+	return store
+}
+
+func TestBackupStore(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+
+	cluster := &kops.Cluster{}
+	cluster.Spec.ConfigBase = "memfs://state/example.com"
+
+	p, err := BackupStore(cluster, &kops.EtcdClusterSpec{Name: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, "memfs://state/example.com/backups/etcd/main", p.Path())
+
+	p, err = BackupStore(cluster, &kops.EtcdClusterSpec{
+		Name:    "events",
+		Backups: &kops.EtcdBackupSpec{BackupStore: "memfs://backups/events"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "memfs://backups/events", p.Path())
+}
+
+func TestListAndVerifyBackups(t *testing.T) {
+	store := newBackupStore(t)
+
+	backups, err := ListBackups("main", store)
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+
+	assert.Equal(t, "2021-05-10T12:00:00Z-000001", backups[0].Name)
+	assert.Equal(t, time.Date(2021, 5, 10, 12, 0, 0, 0, time.UTC), backups[0].Timestamp)
+	assert.Equal(t, "3.4.13", backups[0].EtcdVersion)
+	assert.Equal(t, StatusUnverified, backups[0].Status)
+	assert.Equal(t, time.Date(2021, 5, 10, 13, 0, 0, 0, time.UTC), backups[1].Timestamp)
+	assert.Equal(t, StatusCorrupt, backups[2].Status)
+	assert.Contains(t, backups[2].Problem, "error parsing _etcd_backup.meta")
+	assert.Equal(t, time.Date(2021, 5, 10, 14, 0, 0, 0, time.UTC), backups[2].Timestamp, "timestamp parsed from the name")
+
+	for _, backup := range backups {
+		VerifyBackup(store, backup)
+	}
+	assert.Equal(t, StatusValid, backups[0].Status)
+	assert.Equal(t, int64(len(gzipped(t, []byte("snapshot")))), backups[0].Size, "compressed size")
+	assert.Equal(t, StatusCorrupt, backups[1].Status)
+	assert.Contains(t, backups[1].Problem, "error decompressing etcd.backup.gz")
+
+	now := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "", StaleProblem(backups, 48*time.Hour, now))
+	assert.Equal(t, "newest backup 2021-05-10T12:00:00Z-000001 is 24h0m0s old", StaleProblem(backups, 12*time.Hour, now))
+	assert.Equal(t, "no backups found", StaleProblem(nil, time.Hour, now))
+}
+
+func TestVerifyBackupMissingSnapshot(t *testing.T) {
+	store := newBackupStore(t)
+
+	backup := &Backup{Name: "2021-05-10T15:00:00Z-000004"}
+	VerifyBackup(store, backup)
+	assert.Equal(t, StatusCorrupt, backup.Status)
+	assert.Contains(t, backup.Problem, "error reading etcd.backup.gz")
+}
+
+func TestListBackupsEmptyStore(t *testing.T) {
+	store := vfs.NewMemFSPath(vfs.NewMemFSContext(), "backups/etcd/main")
+
+	backups, err := ListBackups("main", store)
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestAddRestoreCommand(t *testing.T) {
+	store := newBackupStore(t)
+
+	spec, err := ReadClusterSpec(store)
+	require.NoError(t, err)
+	assert.Equal(t, &ClusterSpec{MemberCount: 3, EtcdVersion: "3.4.13"}, spec)
+
+	now := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	p, err := AddRestoreCommand(store, "2021-05-10T12:00:00Z-000001", spec, now)
+	require.NoError(t, err)
+	assert.Equal(t, "memfs://backups/etcd/main/control/2021-05-11T12:00:00Z/_command.json", p.Path())
+
+	data, err := p.ReadFile()
+	require.NoError(t, err)
+	var actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, map[string]interface{}{
+		"timestamp": "1620734400000000000",
+		"restoreBackup": map[string]interface{}{
+			"clusterSpec": map[string]interface{}{"memberCount": float64(3), "etcdVersion": "3.4.13"},
+			"backup":      "2021-05-10T12:00:00Z-000001",
+		},
+	}, actual)
+
+	backups, err := ListBackups("main", store)
+	require.NoError(t, err)
+	assert.Len(t, backups, 3, "commands are not listed as backups")
+}
@@ -91,6 +91,12 @@ var (
 	UseServiceAccountIAM = new("UseServiceAccountIAM", Bool(false))
 	// Azure toggles the Azure support.
 	Azure = new("Azure", Bool(false))
+	// Hetzner toggles the Hetzner Cloud support.
+	Hetzner = new("Hetzner", Bool(false))
+	// KopsControllerNodeBootstrap enables nodes on GCE and OpenStack to bootstrap using kops-controller.
+	KopsControllerNodeBootstrap = new("KopsControllerNodeBootstrap", Bool(false))
+	// StateStoreLocking enables the lock on the cluster state held by the commands that mutate the cluster.
+	StateStoreLocking = new("StateStoreLocking", Bool(true))
 	// KopsControllerStateStore enables fetching the kops state from kops-controller, instead of requiring access to S3/GCS/etc.
 	KopsControllerStateStore = new("KopsControllerStateStore", Bool(false))
 	// APIServerNodes enables ability to provision nodes that only run the kube-apiserver.
@@ -0,0 +1,243 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// defaultCanaryBakeTime is how long to observe the cluster after updating canary instances, if not specified
+const defaultCanaryBakeTime = 10 * time.Minute
+
+// canaryInstances returns the number of instances to update before the bake period,
+// or 0 if there is to be no canary phase.
+func canaryInstances(canary *api.CanaryRollingUpdate, numInstances int, numUpdate int) int {
+	if canary == nil {
+		return 0
+	}
+
+	instances := intstr.FromInt(1)
+	if canary.Instances != nil {
+		instances = *canary.Instances
+	}
+	count, err := intstr.GetValueFromIntOrPercent(&instances, numInstances, true)
+	if err != nil || count <= 0 {
+		return 0
+	}
+
+	// A canary phase is pointless if it would update every instance anyway
+	if count >= numUpdate {
+		return 0
+	}
+	return count
+}
+
+// updateCanaries updates the canary instances of a group one at a time, then observes the cluster
+// for the bake period. It returns an error, leaving the group's remaining instances untouched,
+// if the cluster does not stay healthy. If the group surges, each canary is detached so that its
+// replacement is created before it is drained.
+func (c *RollingUpdateCluster) updateCanaries(group *cloudinstances.CloudInstanceGroup, canaries []*cloudinstances.CloudInstance, canary *api.CanaryRollingUpdate, maxSurge int, sleepAfterTerminate time.Duration) error {
+	bakeTime := defaultCanaryBakeTime
+	if canary.BakeTime != nil {
+		bakeTime = canary.BakeTime.Duration
+	}
+
+	klog.Infof("Updating %d canary instance(s) in group %q.", len(canaries), group.InstanceGroup.Name)
+	c.emitGroupEvent(EventCanaryStarted, group, fmt.Sprintf("updating %d instance(s)", len(canaries)))
+
+	for _, u := range canaries {
+		if err := c.updateCanary(group, u, maxSurge, sleepAfterTerminate); err != nil {
+			c.emitGroupEvent(EventCanaryFailed, group, err.Error())
+			return err
+		}
+
+		if c.Interactive {
+			nodeName := ""
+			if u.Node != nil {
+				nodeName = u.Node.Name
+			}
+
+			stopPrompting, err := promptInteractive(u.ID, nodeName)
+			if err != nil {
+				return err
+			}
+			if stopPrompting {
+				c.Interactive = false
+			}
+		}
+	}
+
+	if err := c.bake(group, canary, bakeTime); err != nil {
+		c.emitGroupEvent(EventCanaryFailed, group, err.Error())
+		return fmt.Errorf("canary instances of group %q failed, not updating its remaining instances: %v", group.InstanceGroup.Name, err)
+	}
+
+	c.emitGroupEvent(EventCanaryPassed, group, "")
+	return nil
+}
+
+// updateCanary replaces a single canary instance, surging first if the group surges
+func (c *RollingUpdateCluster) updateCanary(group *cloudinstances.CloudInstanceGroup, u *cloudinstances.CloudInstance, maxSurge int, sleepAfterTerminate time.Duration) error {
+	if maxSurge > 0 && !c.CloudOnly && u.Status != cloudinstances.CloudInstanceStatusDetached {
+		if err := c.detachInstance(u); err != nil {
+			// As when surging the rest of the group, proceed without surging instead of bubbling up the error.
+			klog.Warningf("Not surging canary instance: %v", err)
+		} else {
+			klog.Infof("waiting for %v after detaching instance", sleepAfterTerminate)
+			time.Sleep(sleepAfterTerminate)
+
+			if err := c.maybeValidate(" after detaching canary instance", c.ValidateCount, group); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.drainTerminateAndWait(u, sleepAfterTerminate); err != nil {
+		return err
+	}
+	if err := c.maybeValidate(" after terminating canary instance", c.ValidateCount, group); err != nil {
+		return err
+	}
+	return c.runPostTerminateHooks([]*cloudinstances.CloudInstance{u})
+}
+
+// bake observes the cluster for the bake period, failing if it does not recover from a failed
+// validation or a pod selected by the canary's pod selectors is not ready. As nothing can be
+// observed with the cloudonly flag, the bake period is then skipped.
+func (c *RollingUpdateCluster) bake(group *cloudinstances.CloudInstanceGroup, canary *api.CanaryRollingUpdate, bakeTime time.Duration) error {
+	if c.CloudOnly {
+		klog.Warningf("Skipping canary bake time of group %q as cloudonly flag is set.", group.InstanceGroup.Name)
+		return nil
+	}
+
+	// Pods evicted from the canary instances may take a while to be rescheduled,
+	// so wait for the selected pods to become ready before the bake period starts.
+	if len(canary.PodSelectors) > 0 {
+		if err := c.waitForCanaryPods(canary.PodSelectors); err != nil {
+			return err
+		}
+	}
+
+	klog.Infof("Observing the cluster for %s before updating the remaining instances of group %q.", bakeTime, group.InstanceGroup.Name)
+	deadline := time.Now().Add(bakeTime)
+	for {
+		if err := c.checkCanaryHealth(group, canary.PodSelectors); err != nil {
+			return err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		if remaining > c.ValidateTickDuration {
+			remaining = c.ValidateTickDuration
+		}
+		time.Sleep(remaining)
+	}
+
+	klog.Infof("Cluster stayed healthy for the canary bake time of group %q.", group.InstanceGroup.Name)
+	return nil
+}
+
+// waitForCanaryPods waits for the pods selected by the selectors to be ready, up to the validation timeout
+func (c *RollingUpdateCluster) waitForCanaryPods(selectors []api.CanaryPodSelector) error {
+	deadline := time.Now().Add(c.ValidationTimeout)
+	for {
+		err := c.checkCanaryPods(selectors)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pods did not become ready within %s: %v", c.ValidationTimeout, err)
+		}
+		klog.Infof("Waiting for pods to become ready before the canary bake time: %v.", err)
+		time.Sleep(c.ValidateTickDuration)
+	}
+}
+
+// checkCanaryHealth returns an error if the cluster does not pass validation or the selected pods
+// are not ready. Unlike the validation after terminating an instance, any failure halts the rolling
+// update, whether or not it is transient and regardless of fail-on-validate, so that a regression
+// caused by the canary instances does not spread to the rest of the group.
+func (c *RollingUpdateCluster) checkCanaryHealth(group *cloudinstances.CloudInstanceGroup, selectors []api.CanaryPodSelector) error {
+	result, err := c.ClusterValidator.Validate()
+	if err != nil {
+		c.emitGroupEvent(EventValidationFailed, group, err.Error())
+		return fmt.Errorf("error validating cluster during canary bake time: %v", err)
+	}
+	if hasFailureRelevantToGroup(result.Failures, group) {
+		var messages []string
+		for _, failure := range result.Failures {
+			messages = append(messages, failure.Message)
+		}
+		failure := strings.Join(messages, ", ")
+		c.emitGroupEvent(EventValidationFailed, group, failure)
+		return fmt.Errorf("cluster did not pass validation during canary bake time: %s", failure)
+	}
+
+	return c.checkCanaryPods(selectors)
+}
+
+// checkCanaryPods returns an error if any running pod selected by the selectors is not ready
+func (c *RollingUpdateCluster) checkCanaryPods(selectors []api.CanaryPodSelector) error {
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for _, selector := range selectors {
+		pods, err := c.K8sClient.CoreV1().Pods(selector.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.LabelSelector})
+		if err != nil {
+			return fmt.Errorf("error listing pods matching %q: %v", selector.LabelSelector, err)
+		}
+
+		var notReady []string
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded {
+				continue
+			}
+			if !isPodReady(pod) {
+				notReady = append(notReady, pod.Namespace+"/"+pod.Name)
+			}
+		}
+		if len(notReady) > 0 {
+			return fmt.Errorf("pods matching %q are not ready: %s", selector.LabelSelector, strings.Join(notReady, ", "))
+		}
+	}
+
+	return nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+)
+
+func TestCanaryInstances(t *testing.T) {
+	for _, tc := range []struct {
+		instances    *intstr.IntOrString
+		numInstances int
+		numUpdate    int
+		expected     int
+	}{
+		{numInstances: 3, numUpdate: 3, expected: 1},
+		{instances: intStr(intstr.FromInt(2)), numInstances: 3, numUpdate: 3, expected: 2},
+		{instances: intStr(intstr.FromString("50%")), numInstances: 5, numUpdate: 5, expected: 3},
+		{instances: intStr(intstr.FromString("1%")), numInstances: 5, numUpdate: 5, expected: 1},
+		{instances: intStr(intstr.FromInt(0)), numInstances: 3, numUpdate: 3, expected: 0},
+		{instances: intStr(intstr.FromInt(2)), numInstances: 3, numUpdate: 2, expected: 0},
+		{numInstances: 1, numUpdate: 1, expected: 0},
+	} {
+		name := fmt.Sprintf("%v of %d/%d", tc.instances, tc.numUpdate, tc.numInstances)
+		t.Run(name, func(t *testing.T) {
+			canary := &kopsapi.CanaryRollingUpdate{Instances: tc.instances}
+			assert.Equal(t, tc.expected, canaryInstances(canary, tc.numInstances, tc.numUpdate))
+		})
+	}
+
+	assert.Equal(t, 0, canaryInstances(nil, 3, 3), "no canary")
+}
+
+func intStr(i intstr.IntOrString) *intstr.IntOrString {
+	return &i
+}
+
+func setCanary(c *RollingUpdateCluster, canary *kopsapi.CanaryRollingUpdate) {
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{Canary: canary}
+}
+
+func TestRollingUpdateCanary(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	setCanary(c, &kopsapi.CanaryRollingUpdate{BakeTime: &v1meta.Duration{Duration: 5 * time.Millisecond}})
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assert.Contains(t, events.String(), `"type":"CanaryStarted"`)
+	assert.Contains(t, events.String(), `"type":"CanaryPassed"`)
+}
+
+// failAfterCallsClusterValidator passes validation a number of times, then fails
+type failAfterCallsClusterValidator struct {
+	passes int
+}
+
+func (v *failAfterCallsClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	if v.passes > 0 {
+		v.passes--
+		return &validation.ValidationCluster{}, nil
+	}
+	return &validation.ValidationCluster{
+		Failures: []*validation.ValidationError{
+			{
+				Kind:    "testing",
+				Name:    "testingfailure",
+				Message: "testing failure",
+			},
+		},
+	}, nil
+}
+
+func TestRollingUpdateCanaryHaltsWhenValidationFails(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	setCanary(c, &kopsapi.CanaryRollingUpdate{BakeTime: &v1meta.Duration{Duration: time.Minute}})
+
+	// Passes the validation before the group and the validations after terminating the canary
+	c.ClusterValidator = &failAfterCallsClusterValidator{passes: 1 + c.ValidateCount}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	if assert.Error(t, err, "rolling update") {
+		assert.Contains(t, err.Error(), `canary instances of group "node-1" failed`)
+		assert.Contains(t, err.Error(), "testing failure")
+	}
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	assert.Contains(t, events.String(), `"type":"CanaryFailed"`)
+}
+
+func TestRollingUpdateCanarySurges(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	cloud.MockAutoscaling = &disabledSurgeTest{AutoScalingAPI: cloud.MockAutoscaling, t: t}
+	cloud.MockEC2 = &ec2IgnoreTags{EC2API: cloud.MockEC2}
+	one := intstr.FromInt(1)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxSurge: &one,
+		Canary:   &kopsapi.CanaryRollingUpdate{BakeTime: &v1meta.Duration{Duration: 5 * time.Millisecond}},
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+
+	// The canary is the first instance drained, and must have been detached before it
+	detached := map[string]bool{}
+	decoder := json.NewDecoder(&events)
+	for decoder.More() {
+		var event RollingUpdateEvent
+		if !assert.NoError(t, decoder.Decode(&event), "decoding event") {
+			return
+		}
+		if event.Type == EventInstanceDetached {
+			detached[event.InstanceID] = true
+		}
+		if event.Type == EventInstanceDrained {
+			assert.True(t, detached[event.InstanceID], "canary instance %q detached before it was drained", event.InstanceID)
+			return
+		}
+	}
+	t.Fatal("no instance was drained")
+}
+
+func TestCanaryBakeSkippedWhenCloudOnly(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.CloudOnly = true
+	c.ClusterValidator = &assertNotCalledClusterValidator{T: t}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+
+	// Would time out the test if the bake time were not skipped
+	assert.NoError(t, c.bake(groups["node-1"], &kopsapi.CanaryRollingUpdate{}, time.Hour), "bake")
+}
+
+// failOnceClusterValidator fails validation the first time it is called, then passes
+type failOnceClusterValidator struct {
+	failed bool
+}
+
+func (v *failOnceClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	if v.failed {
+		return &validation.ValidationCluster{}, nil
+	}
+	v.failed = true
+	return (&failingClusterValidator{}).Validate()
+}
+
+func TestCanaryBakeHaltsOnTransientValidationFailure(t *testing.T) {
+	c, cloud := getTestSetup()
+	validator := &failOnceClusterValidator{}
+	c.ClusterValidator = validator
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+
+	err := c.bake(groups["node-1"], &kopsapi.CanaryRollingUpdate{}, 5*time.Millisecond)
+	if assert.Error(t, err, "bake") {
+		assert.Contains(t, err.Error(), "testing failure")
+	}
+}
+
+func TestCanaryBakeHaltsWithoutFailOnValidate(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.FailOnValidate = false
+	var events bytes.Buffer
+	c.EventStream = &events
+	setCanary(c, &kopsapi.CanaryRollingUpdate{BakeTime: &v1meta.Duration{Duration: time.Minute}})
+
+	// Passes the validation before the group and the validations after terminating the canary
+	c.ClusterValidator = &failAfterCallsClusterValidator{passes: 1 + c.ValidateCount}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	if assert.Error(t, err, "rolling update") {
+		assert.Contains(t, err.Error(), `canary instances of group "node-1" failed`)
+	}
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	assert.Contains(t, events.String(), `"type":"CanaryFailed"`)
+}
+
+func makeCanaryPod(name string, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      name,
+			Namespace: "kafka",
+			Labels:    map[string]string{"app": "broker"},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: status},
+			},
+		},
+	}
+}
+
+func TestCanaryBakeWaitsForPods(t *testing.T) {
+	c, cloud := getTestSetup()
+	canary := &kopsapi.CanaryRollingUpdate{
+		PodSelectors: []kopsapi.CanaryPodSelector{
+			{Namespace: "kafka", LabelSelector: "app=broker"},
+		},
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+
+	tracker := c.K8sClient.(*fake.Clientset).Tracker()
+	assert.NoError(t, tracker.Add(makeCanaryPod("broker-0", true)))
+	assert.NoError(t, tracker.Add(makeCanaryPod("broker-1", false)))
+
+	err := c.bake(groups["node-1"], canary, 5*time.Millisecond)
+	if assert.Error(t, err, "bake") {
+		assert.True(t, strings.Contains(err.Error(), "kafka/broker-1"), "error %q names the pod that is not ready", err)
+		assert.False(t, strings.Contains(err.Error(), "kafka/broker-0"), "error %q names a ready pod", err)
+	}
+
+	assert.NoError(t, tracker.Update(v1.SchemeGroupVersion.WithResource("pods"), makeCanaryPod("broker-1", true), "kafka"))
+	assert.NoError(t, c.bake(groups["node-1"], canary, 5*time.Millisecond), "bake")
+}
+
+func TestCheckCanaryPods(t *testing.T) {
+	c, _ := getTestSetup()
+	tracker := c.K8sClient.(*fake.Clientset).Tracker()
+	assert.NoError(t, tracker.Add(makeCanaryPod("broker-0", true)))
+
+	notReady := makeCanaryPod("broker-1", false)
+	notReady.Status.Phase = v1.PodSucceeded
+	assert.NoError(t, tracker.Add(notReady))
+
+	other := makeCanaryPod("other", false)
+	other.Labels = map[string]string{"app": "other"}
+	assert.NoError(t, tracker.Add(other))
+
+	assert.NoError(t, c.checkCanaryPods([]kopsapi.CanaryPodSelector{{LabelSelector: "app=broker"}}), "ready and completed pods")
+	assert.Error(t, c.checkCanaryPods([]kopsapi.CanaryPodSelector{{LabelSelector: "app in (broker,other)"}}), "pod not ready")
+	assert.NoError(t, c.checkCanaryPods([]kopsapi.CanaryPodSelector{{Namespace: "default", LabelSelector: "app=other"}}), "other namespace")
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// errDrainSkipped is returned by drainNode when the node did not drain within its timeout
+// and the instance is to be left running.
+var errDrainSkipped = errors.New("drain skipped")
+
+// DrainResult is the outcome of draining a node that did not drain within its drain timeout.
+type DrainResult string
+
+const (
+	// DrainResultDrained means the node drained when the drain was retried.
+	DrainResultDrained DrainResult = "Drained"
+	// DrainResultSkipped means the instance was left running.
+	DrainResultSkipped DrainResult = "Skipped"
+	// DrainResultForced means the remaining pods were deleted, bypassing PodDisruptionBudgets.
+	DrainResultForced DrainResult = "Forced"
+	// DrainResultFailed means the node could not be drained.
+	DrainResultFailed DrainResult = "Failed"
+)
+
+// BlockedDrain records a node that did not drain within its drain timeout.
+type BlockedDrain struct {
+	// InstanceGroup is the name of the instance group of the instance.
+	InstanceGroup string `json:"instanceGroup"`
+	// InstanceID is the ID of the instance.
+	InstanceID string `json:"instanceID"`
+	// NodeName is the name of the instance's node.
+	NodeName string `json:"nodeName"`
+	// Policy is the drain timeout policy that was applied.
+	Policy api.DrainTimeoutPolicy `json:"policy"`
+	// Result is the outcome of the policy.
+	Result DrainResult `json:"result"`
+	// Attempts is the number of times the node was drained.
+	Attempts int `json:"attempts"`
+	// Detached is whether a skipped instance had been detached from its instance group for surging,
+	// so is left running outside of it.
+	Detached bool `json:"detached,omitempty"`
+	// Pods are the pods that had not been evicted when the drain last timed out.
+	Pods []BlockingPod `json:"pods,omitempty"`
+}
+
+// BlockingPod is a pod that had not been evicted from a node when its drain timed out.
+type BlockingPod struct {
+	// Namespace is the namespace of the pod.
+	Namespace string `json:"namespace"`
+	// Name is the name of the pod.
+	Name string `json:"name"`
+	// PodDisruptionBudgets are the names of the PodDisruptionBudgets selecting the pod, if any.
+	PodDisruptionBudgets []string `json:"podDisruptionBudgets,omitempty"`
+}
+
+func (p BlockingPod) String() string {
+	s := p.Namespace + "/" + p.Name
+	if len(p.PodDisruptionBudgets) > 0 {
+		s += " (PodDisruptionBudget " + strings.Join(p.PodDisruptionBudgets, ", ") + ")"
+	}
+	return s
+}
+
+// BlockedDrains returns the nodes that did not drain within their drain timeout during the rolling update.
+func (c *RollingUpdateCluster) BlockedDrains() []*BlockedDrain {
+	c.drainMutex.Lock()
+	defer c.drainMutex.Unlock()
+	return append([]*BlockedDrain(nil), c.blockedDrains...)
+}
+
+func (c *RollingUpdateCluster) recordBlockedDrain(blocked *BlockedDrain) {
+	c.drainMutex.Lock()
+	defer c.drainMutex.Unlock()
+	c.blockedDrains = append(c.blockedDrains, blocked)
+}
+
+// skippedInstances returns whether any instance of the group was skipped because its node did not drain.
+func (c *RollingUpdateCluster) skippedInstances(group *cloudinstances.CloudInstanceGroup) bool {
+	for _, blocked := range c.BlockedDrains() {
+		if blocked.InstanceGroup == group.InstanceGroup.Name && blocked.Result == DrainResultSkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceSkipped returns whether the instance was left running because its node did not drain.
+func (c *RollingUpdateCluster) instanceSkipped(u *cloudinstances.CloudInstance) bool {
+	for _, blocked := range c.BlockedDrains() {
+		if blocked.InstanceID == u.ID && blocked.Result == DrainResultSkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceDetached returns whether the instance has been detached from its instance group for surging.
+func (c *RollingUpdateCluster) instanceDetached(u *cloudinstances.CloudInstance) bool {
+	return u.Status == cloudinstances.CloudInstanceStatusDetached || c.progress.isDetached(u.ID)
+}
+
+// isDrainTimeout returns whether the error is from the drain helper giving up on the pods
+// being evicted or deleted within its timeout. The helper reports this either as its own
+// "global timeout reached" error or, while waiting for pods to be deleted, as wait.ErrWaitTimeout,
+// neither of which it wraps in a way errors.Is could detect.
+func isDrainTimeout(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "global timeout reached") || strings.Contains(message, wait.ErrWaitTimeout.Error())
+}
+
+// drainSettings returns the drain timeout, timeout policy, and retries for the instance,
+// with those given to the RollingUpdateCluster overriding those of the instance group and cluster.
+func (c *RollingUpdateCluster) drainSettings(u *cloudinstances.CloudInstance) (time.Duration, api.DrainTimeoutPolicy, int) {
+	group := u.CloudInstanceGroup
+	settings := resolveSettings(c.Cluster, group.InstanceGroup, len(group.Ready)+len(group.NeedUpdate))
+
+	var timeout time.Duration
+	if settings.DrainTimeout != nil {
+		timeout = settings.DrainTimeout.Duration
+	}
+	if c.DrainTimeout != 0 {
+		timeout = c.DrainTimeout
+	}
+
+	policy := settings.DrainTimeoutPolicy
+	if c.DrainTimeoutPolicy != "" {
+		policy = c.DrainTimeoutPolicy
+	}
+
+	return timeout, policy, int(*settings.DrainRetries)
+}
+
+// runNodeDrain evicts the pods from the node, applying the drain timeout policy if they are not evicted in time.
+func (c *RollingUpdateCluster) runNodeDrain(helper *drain.Helper, u *cloudinstances.CloudInstance, excludedFromLB bool) error {
+	timeout, policy, retries := c.drainSettings(u)
+	helper.Timeout = timeout
+
+	nodeName := u.Node.Name
+	var blocked *BlockedDrain
+	for attempt := 1; ; attempt++ {
+		err := drain.RunNodeDrain(helper, nodeName)
+		if err == nil || apierrors.IsNotFound(err) {
+			if blocked != nil {
+				blocked.Result = DrainResultDrained
+				blocked.Attempts = attempt
+				c.recordBlockedDrain(blocked)
+			}
+			return nil
+		}
+		if timeout == 0 || !isDrainTimeout(err) {
+			return fmt.Errorf("error draining node: %v", err)
+		}
+
+		blocked = &BlockedDrain{
+			InstanceGroup: u.CloudInstanceGroup.InstanceGroup.Name,
+			InstanceID:    u.ID,
+			NodeName:      nodeName,
+			Policy:        policy,
+			Attempts:      attempt,
+			Pods:          c.blockingPods(helper, nodeName),
+		}
+		message := fmt.Sprintf("node did not drain within %s", timeout)
+		if len(blocked.Pods) > 0 {
+			var pods []string
+			for _, pod := range blocked.Pods {
+				pods = append(pods, pod.String())
+			}
+			message += "; blocked by " + strings.Join(pods, ", ")
+		}
+		klog.Warningf("Instance %q: %s.", u.ID, message)
+		c.emitInstanceEvent(EventInstanceDrainTimedOut, u, message)
+
+		switch policy {
+		case api.DrainTimeoutPolicyRetry:
+			if attempt <= retries {
+				klog.Infof("Retrying drain of node %q (retry %d of %d).", nodeName, attempt, retries)
+				continue
+			}
+			blocked.Result = DrainResultFailed
+			c.recordBlockedDrain(blocked)
+			return fmt.Errorf("%s after %d attempts", message, attempt)
+
+		case api.DrainTimeoutPolicySkip:
+			if c.instanceDetached(u) {
+				// The instance group has already replaced it, so it is left running outside of the group
+				// until a later rolling update terminates it.
+				klog.Warningf("Skipping instance %q, leaving it running detached from its instance group.", u.ID)
+				blocked.Detached = true
+			} else {
+				klog.Warningf("Skipping instance %q, leaving it running.", u.ID)
+			}
+			if err := c.restoreNode(helper, u.Node, excludedFromLB); err != nil {
+				klog.Warningf("Error restoring skipped node %q: %v", nodeName, err)
+			}
+			blocked.Result = DrainResultSkipped
+			c.recordBlockedDrain(blocked)
+			return errDrainSkipped
+
+		case api.DrainTimeoutPolicyForce:
+			klog.Warningf("Deleting the pods remaining on node %q, bypassing PodDisruptionBudgets.", nodeName)
+			helper.DisableEviction = true
+			if err := drain.RunNodeDrain(helper, nodeName); err != nil && !apierrors.IsNotFound(err) {
+				blocked.Result = DrainResultFailed
+				c.recordBlockedDrain(blocked)
+				return fmt.Errorf("error deleting pods from node: %v", err)
+			}
+			blocked.Result = DrainResultForced
+			c.recordBlockedDrain(blocked)
+			return nil
+
+		default:
+			blocked.Result = DrainResultFailed
+			c.recordBlockedDrain(blocked)
+			return errors.New(message)
+		}
+	}
+}
+
+// blockingPods returns the pods that remain to be evicted from the node, with the PodDisruptionBudgets selecting them
+func (c *RollingUpdateCluster) blockingPods(helper *drain.Helper, nodeName string) []BlockingPod {
+	list, errs := helper.GetPodsForDeletion(nodeName)
+	if list == nil {
+		klog.Warningf("Error listing the pods remaining on node %q: %v", nodeName, errs)
+		return nil
+	}
+
+	budgets := make(map[string][]policyv1beta1.PodDisruptionBudget)
+	var pods []BlockingPod
+	for _, pod := range list.Pods() {
+		if _, found := budgets[pod.Namespace]; !found {
+			pdbs, err := c.K8sClient.PolicyV1beta1().PodDisruptionBudgets(pod.Namespace).List(c.Ctx, metav1.ListOptions{})
+			if err != nil {
+				klog.Warningf("Error listing PodDisruptionBudgets in namespace %q: %v", pod.Namespace, err)
+				budgets[pod.Namespace] = nil
+			} else {
+				budgets[pod.Namespace] = pdbs.Items
+			}
+		}
+
+		blocking := BlockingPod{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+		}
+		for _, pdb := range budgets[pod.Namespace] {
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil || selector.Empty() {
+				continue
+			}
+			if selector.Matches(labels.Set(pod.Labels)) {
+				blocking.PodDisruptionBudgets = append(blocking.PodDisruptionBudgets, pdb.Name)
+			}
+		}
+		pods = append(pods, blocking)
+	}
+	return pods
+}
+
+// restoreNode makes a node that is being left running schedulable again, and
+// includes it in load balancers again unless it was excluded before the drain.
+func (c *RollingUpdateCluster) restoreNode(helper *drain.Helper, node *corev1.Node, excludedFromLB bool) error {
+	if err := drain.RunCordonOrUncordon(helper, node, false); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error uncordoning node: %v", err)
+	}
+
+	if excludedFromLB {
+		return nil
+	}
+	if _, ok := node.Labels[corev1.LabelNodeExcludeBalancers]; !ok {
+		return nil
+	}
+
+	oldData, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	delete(node.Labels, corev1.LabelNodeExcludeBalancers)
+
+	newData, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, node)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.K8sClient.CoreV1().Nodes().Patch(c.Ctx, node.Name, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// logDrainSummary logs the nodes that did not drain within their drain timeout
+func (c *RollingUpdateCluster) logDrainSummary() {
+	blockedDrains := c.BlockedDrains()
+	if len(blockedDrains) == 0 {
+		return
+	}
+
+	klog.Infof("%d node(s) did not drain within their drain timeout:", len(blockedDrains))
+	for _, blocked := range blockedDrains {
+		var pods []string
+		for _, pod := range blocked.Pods {
+			pods = append(pods, pod.String())
+		}
+		result := string(blocked.Result)
+		if blocked.Detached {
+			result += " (left running detached from its instance group)"
+		}
+		klog.Infof("  instance %q (node %q) in group %q: %s after %d attempt(s); blocking pods: %s",
+			blocked.InstanceID, blocked.NodeName, blocked.InstanceGroup, result, blocked.Attempts, strings.Join(pods, ", "))
+	}
+}
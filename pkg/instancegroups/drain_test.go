@@ -0,0 +1,229 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	testingclient "k8s.io/client-go/testing"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// setupBlockingPod adds a pod covered by a PodDisruptionBudget, whose deletion is
+// ignored the given number of times, as happens when its eviction is refused
+func setupBlockingPod(t *testing.T, c *RollingUpdateCluster, blockedDeletes int) {
+	fakeClient := c.K8sClient.(*fake.Clientset)
+	assert.NoError(t, fakeClient.Tracker().Add(&v1.Pod{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      "web-0",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
+		},
+	}))
+	assert.NoError(t, fakeClient.Tracker().Add(&policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &v1meta.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}))
+
+	var mutex sync.Mutex
+	fakeClient.PrependReactor("delete", "pods", func(action testingclient.Action) (bool, runtime.Object, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if action.(testingclient.DeleteAction).GetName() != "web-0" || blockedDeletes == 0 {
+			return false, nil, nil
+		}
+		blockedDeletes--
+		return true, nil, nil
+	})
+}
+
+func setDrainTimeout(c *RollingUpdateCluster, policy kopsapi.DrainTimeoutPolicy) {
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxSurge:           intStr(intstr.FromInt(0)),
+		DrainTimeout:       &v1meta.Duration{Duration: 20 * time.Millisecond},
+		DrainTimeoutPolicy: policy,
+		DrainRetries:       fi.Int32(1),
+	}
+}
+
+func TestDrainTimeoutSkip(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicySkip)
+	setupBlockingPod(t, c, -1)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	blockedDrains := c.BlockedDrains()
+	if assert.Len(t, blockedDrains, 2) {
+		assert.Equal(t, DrainResultSkipped, blockedDrains[0].Result)
+		assert.Equal(t, 1, blockedDrains[0].Attempts)
+		assert.Equal(t, []BlockingPod{
+			{Namespace: "default", Name: "web-0", PodDisruptionBudgets: []string{"web"}},
+		}, blockedDrains[0].Pods)
+	}
+	assert.Contains(t, events.String(), `"type":"InstanceDrainTimedOut"`)
+	assert.Contains(t, events.String(), `"type":"InstanceSkipped"`)
+	assert.NotContains(t, events.String(), `"type":"InstanceTerminated"`)
+
+	node, err := c.K8sClient.CoreV1().Nodes().Get(c.Ctx, "node-1a.local", v1meta.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.False(t, node.Spec.Unschedulable, "skipped node is schedulable")
+		_, excluded := node.Labels[v1.LabelNodeExcludeBalancers]
+		assert.False(t, excluded, "skipped node is included in load balancers")
+	}
+}
+
+func TestDrainTimeoutSkipDetached(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicySkip)
+	setupBlockingPod(t, c, -1)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	detached := groups["node-1"].NeedUpdate[0]
+	detached.Status = cloudinstances.CloudInstanceStatusDetached
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	for _, blocked := range c.BlockedDrains() {
+		assert.Equal(t, DrainResultSkipped, blocked.Result)
+		assert.Equal(t, blocked.InstanceID == detached.ID, blocked.Detached, "instance %q detached", blocked.InstanceID)
+	}
+	assert.Contains(t, events.String(), `"instanceID":"`+detached.ID+`","nodeName":"`+detached.Node.Name+`","message":"left running detached from its instance group"`)
+}
+
+func TestDrainErrorIsNotTimeout(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicySkip)
+	setupBlockingPod(t, c, 0)
+
+	// Fails to delete the pod only after the drain timeout has elapsed
+	c.K8sClient.(*fake.Clientset).PrependReactor("delete", "pods", func(action testingclient.Action) (bool, runtime.Object, error) {
+		time.Sleep(30 * time.Millisecond)
+		return true, nil, fmt.Errorf("delete failed")
+	})
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 1, 1)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Empty(t, c.BlockedDrains())
+	assert.Contains(t, events.String(), `"type":"InstanceDrainFailed"`)
+	assert.NotContains(t, events.String(), `"type":"InstanceDrainTimedOut"`)
+	assert.NotContains(t, events.String(), `"type":"InstanceSkipped"`)
+}
+
+func TestDrainTimeoutRetry(t *testing.T) {
+	c, cloud := getTestSetup()
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicyRetry)
+	setupBlockingPod(t, c, 1)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	blockedDrains := c.BlockedDrains()
+	if assert.Len(t, blockedDrains, 1) {
+		assert.Equal(t, DrainResultDrained, blockedDrains[0].Result)
+		assert.Equal(t, 2, blockedDrains[0].Attempts)
+	}
+}
+
+func TestDrainTimeoutRetryExhausted(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.FailOnDrainError = true
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicyRetry)
+	setupBlockingPod(t, c, -1)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	if assert.Error(t, err, "rolling update") {
+		assert.Contains(t, err.Error(), "node did not drain within 20ms; blocked by default/web-0 (PodDisruptionBudget web) after 2 attempts")
+	}
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	blockedDrains := c.BlockedDrains()
+	if assert.Len(t, blockedDrains, 1) {
+		assert.Equal(t, DrainResultFailed, blockedDrains[0].Result)
+	}
+}
+
+func TestDrainTimeoutForce(t *testing.T) {
+	c, cloud := getTestSetup()
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicyForce)
+	setupBlockingPod(t, c, 1)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	blockedDrains := c.BlockedDrains()
+	if assert.Len(t, blockedDrains, 1) {
+		assert.Equal(t, DrainResultForced, blockedDrains[0].Result)
+	}
+
+	_, err = c.K8sClient.CoreV1().Pods("default").Get(c.Ctx, "web-0", v1meta.GetOptions{})
+	assert.Error(t, err, "pod was deleted")
+}
+
+func TestDrainTimeoutOverride(t *testing.T) {
+	c, cloud := getTestSetup()
+	setDrainTimeout(c, kopsapi.DrainTimeoutPolicyFail)
+	c.DrainTimeout = time.Minute
+	c.DrainTimeoutPolicy = kopsapi.DrainTimeoutPolicySkip
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 1, 1)
+	timeout, policy, retries := c.drainSettings(groups["node-1"].NeedUpdate[0])
+	assert.Equal(t, time.Minute, timeout)
+	assert.Equal(t, kopsapi.DrainTimeoutPolicySkip, policy)
+	assert.Equal(t, 1, retries)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"encoding/json"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// RollingUpdateEventType identifies a step of a rolling update.
+type RollingUpdateEventType string
+
+const (
+	// EventRollingUpdateStarted is written when a rolling update starts.
+	EventRollingUpdateStarted RollingUpdateEventType = "RollingUpdateStarted"
+	// EventRollingUpdateCompleted is written when a rolling update completes successfully.
+	EventRollingUpdateCompleted RollingUpdateEventType = "RollingUpdateCompleted"
+	// EventRollingUpdateFailed is written when a rolling update stops with an error.
+	EventRollingUpdateFailed RollingUpdateEventType = "RollingUpdateFailed"
+	// EventInstanceGroupStarted is written when the updating of an instance group starts.
+	EventInstanceGroupStarted RollingUpdateEventType = "InstanceGroupStarted"
+	// EventInstanceGroupCompleted is written when all instances of an instance group have been updated.
+	EventInstanceGroupCompleted RollingUpdateEventType = "InstanceGroupCompleted"
+	// EventInstanceGroupFailed is written when the updating of an instance group stops with an error.
+	EventInstanceGroupFailed RollingUpdateEventType = "InstanceGroupFailed"
+	// EventInstanceTainted is written when an instance's node is tainted to discourage scheduling.
+	EventInstanceTainted RollingUpdateEventType = "InstanceTainted"
+	// EventInstanceDetached is written when an instance is detached for surging.
+	EventInstanceDetached RollingUpdateEventType = "InstanceDetached"
+	// EventInstanceDrained is written when an instance's node has been drained.
+	EventInstanceDrained RollingUpdateEventType = "InstanceDrained"
+	// EventInstanceDrainFailed is written when draining an instance's node fails.
+	EventInstanceDrainFailed RollingUpdateEventType = "InstanceDrainFailed"
+	// EventInstanceDrainTimedOut is written when an instance's node does not drain within its drain timeout.
+	EventInstanceDrainTimedOut RollingUpdateEventType = "InstanceDrainTimedOut"
+	// EventInstanceSkipped is written when an instance is left running because its node did not drain.
+	EventInstanceSkipped RollingUpdateEventType = "InstanceSkipped"
+	// EventInstanceTerminated is written when an instance has been terminated.
+	EventInstanceTerminated RollingUpdateEventType = "InstanceTerminated"
+	// EventHookFailed is written when a rolling update hook fails.
+	EventHookFailed RollingUpdateEventType = "HookFailed"
+	// EventCanaryStarted is written when the updating of the canary instances of an instance group starts.
+	EventCanaryStarted RollingUpdateEventType = "CanaryStarted"
+	// EventCanaryPassed is written when the cluster stayed healthy for the canary bake time.
+	EventCanaryPassed RollingUpdateEventType = "CanaryPassed"
+	// EventCanaryFailed is written when the canary phase of an instance group fails, halting its update.
+	EventCanaryFailed RollingUpdateEventType = "CanaryFailed"
+	// EventValidationPassed is written when the cluster passes validation.
+	EventValidationPassed RollingUpdateEventType = "ValidationPassed"
+	// EventValidationFailed is written when the cluster does not pass validation within the timeout.
+	EventValidationFailed RollingUpdateEventType = "ValidationFailed"
+)
+
+// RollingUpdateEvent is a machine-readable record of a step of a rolling update.
+type RollingUpdateEvent struct {
+	// Time is when the step happened.
+	Time time.Time `json:"time"`
+	// Type identifies the step.
+	Type RollingUpdateEventType `json:"type"`
+	// Cluster is the name of the cluster being updated.
+	Cluster string `json:"cluster,omitempty"`
+	// InstanceGroup is the name of the instance group the step applies to, if any.
+	InstanceGroup string `json:"instanceGroup,omitempty"`
+	// InstanceID is the ID of the instance the step applies to, if any.
+	InstanceID string `json:"instanceID,omitempty"`
+	// NodeName is the name of the node of the instance the step applies to, if known.
+	NodeName string `json:"nodeName,omitempty"`
+	// Message contains further detail, such as the error for failures.
+	Message string `json:"message,omitempty"`
+}
+
+// emitEvent writes the event as a line of JSON to the EventStream, if one is set.
+func (c *RollingUpdateCluster) emitEvent(event RollingUpdateEvent) {
+	if c.EventStream == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.Cluster == "" {
+		event.Cluster = c.ClusterName
+	}
+
+	b, err := json.Marshal(&event)
+	if err != nil {
+		klog.Warningf("error encoding rolling update event: %v", err)
+		return
+	}
+	b = append(b, '\n')
+
+	c.eventMutex.Lock()
+	defer c.eventMutex.Unlock()
+	if _, err := c.EventStream.Write(b); err != nil {
+		klog.Warningf("error writing rolling update event: %v", err)
+	}
+}
+
+// emitGroupEvent writes an event about an instance group.
+func (c *RollingUpdateCluster) emitGroupEvent(eventType RollingUpdateEventType, group *cloudinstances.CloudInstanceGroup, message string) {
+	event := RollingUpdateEvent{
+		Type:    eventType,
+		Message: message,
+	}
+	if group != nil && group.InstanceGroup != nil {
+		event.InstanceGroup = group.InstanceGroup.Name
+	}
+	c.emitEvent(event)
+}
+
+// emitInstanceEvent writes an event about an instance.
+func (c *RollingUpdateCluster) emitInstanceEvent(eventType RollingUpdateEventType, u *cloudinstances.CloudInstance, message string) {
+	event := RollingUpdateEvent{
+		Type:       eventType,
+		InstanceID: u.ID,
+		Message:    message,
+	}
+	if u.CloudInstanceGroup != nil && u.CloudInstanceGroup.InstanceGroup != nil {
+		event.InstanceGroup = u.CloudInstanceGroup.InstanceGroup.Name
+	}
+	if u.Node != nil {
+		event.NodeName = u.Node.Name
+	}
+	c.emitEvent(event)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// defaultHookTimeout is the maximum time a hook may run if it does not specify a timeout
+const defaultHookTimeout = 5 * time.Minute
+
+// hookRequest is the body of the request sent to an HTTP hook
+type hookRequest struct {
+	ClusterName   string `json:"clusterName"`
+	InstanceGroup string `json:"instanceGroup"`
+	InstanceID    string `json:"instanceID"`
+	NodeName      string `json:"nodeName,omitempty"`
+	Phase         string `json:"phase"`
+}
+
+// hooksFor returns the hooks to run for the instance in the given phase
+func (c *RollingUpdateCluster) hooksFor(u *cloudinstances.CloudInstance, phase api.RollingUpdateHookPhase) []api.RollingUpdateHook {
+	if c.SkipHooks {
+		return nil
+	}
+
+	var hooks []api.RollingUpdateHook
+	if u.CloudInstanceGroup != nil && u.CloudInstanceGroup.InstanceGroup != nil {
+		settings := resolveSettings(c.Cluster, u.CloudInstanceGroup.InstanceGroup, 1)
+		hooks = append(hooks, settings.Hooks...)
+	}
+	hooks = append(hooks, c.Hooks...)
+
+	var matching []api.RollingUpdateHook
+	for _, hook := range hooks {
+		if hook.Phase == phase {
+			matching = append(matching, hook)
+		}
+	}
+	return matching
+}
+
+// checkExecHooks returns an error if an instance group to be updated has exec hooks in the
+// cluster or instance group spec and these have not been allowed. Exec hooks run on the machine
+// performing the rolling update, and anyone who can write to the state store can set them.
+func (c *RollingUpdateCluster) checkExecHooks(groups map[string]*cloudinstances.CloudInstanceGroup) error {
+	if c.SkipHooks || c.AllowExecHooks {
+		return nil
+	}
+
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ig := groups[name].InstanceGroup
+		if ig == nil {
+			continue
+		}
+		for _, hook := range resolveSettings(c.Cluster, ig, 1).Hooks {
+			if hook.Exec != nil {
+				return fmt.Errorf("instance group %q has exec hook %q configured in the spec, which is only run on this machine with --allow-exec-hooks", ig.Name, hook.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// runPostTerminateHooks runs the PostTerminate hooks of instances that have been terminated,
+// once the cluster has validated after their termination. Instances that were left running
+// because their node did not drain are passed over.
+func (c *RollingUpdateCluster) runPostTerminateHooks(instances []*cloudinstances.CloudInstance) error {
+	for _, u := range instances {
+		if c.instanceSkipped(u) {
+			continue
+		}
+		if err := c.runHooks(u, api.RollingUpdateHookPhasePostTerminate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPostTerminateHooks returns whether any of the terminated instances has PostTerminate hooks to run
+func (c *RollingUpdateCluster) hasPostTerminateHooks(instances []*cloudinstances.CloudInstance) bool {
+	for _, u := range instances {
+		if !c.instanceSkipped(u) && len(c.hooksFor(u, api.RollingUpdateHookPhasePostTerminate)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// runHooks runs the hooks for the instance in the given phase, in order.
+// It returns an error if a hook with a failure policy of "Fail" fails.
+func (c *RollingUpdateCluster) runHooks(u *cloudinstances.CloudInstance, phase api.RollingUpdateHookPhase) error {
+	for i, hook := range c.hooksFor(u, phase) {
+		hook := hook
+		name := hook.Name
+		if name == "" {
+			name = fmt.Sprintf("%s[%d]", phase, i)
+		}
+
+		klog.Infof("Running %s hook %q for instance %q", phase, name, u.ID)
+		err := c.runHook(&hook, u, phase)
+		if err == nil {
+			continue
+		}
+
+		c.emitInstanceEvent(EventHookFailed, u, fmt.Sprintf("%s hook %q: %v", phase, name, err))
+		if hook.FailurePolicy == api.RollingUpdateHookFailurePolicyIgnore {
+			klog.Warningf("Ignoring failure of %s hook %q for instance %q: %v", phase, name, u.ID, err)
+			continue
+		}
+		return fmt.Errorf("%s hook %q failed for instance %q: %v", phase, name, u.ID, err)
+	}
+	return nil
+}
+
+func (c *RollingUpdateCluster) runHook(hook *api.RollingUpdateHook, u *cloudinstances.CloudInstance, phase api.RollingUpdateHookPhase) error {
+	timeout := defaultHookTimeout
+	if hook.Timeout != nil {
+		timeout = hook.Timeout.Duration
+	}
+
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request := hookRequest{
+		ClusterName: c.ClusterName,
+		InstanceID:  u.ID,
+		Phase:       string(phase),
+	}
+	if u.CloudInstanceGroup != nil && u.CloudInstanceGroup.InstanceGroup != nil {
+		request.InstanceGroup = u.CloudInstanceGroup.InstanceGroup.Name
+	}
+	if u.Node != nil {
+		request.NodeName = u.Node.Name
+	}
+
+	var err error
+	switch {
+	case hook.Exec != nil:
+		err = runExecHook(ctx, hook.Exec, &request)
+	case hook.HTTP != nil:
+		err = runHTTPHook(ctx, hook.HTTP, &request)
+	default:
+		return fmt.Errorf("hook has neither exec nor http set")
+	}
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v: %v", timeout, err)
+	}
+	return err
+}
+
+func runExecHook(ctx context.Context, hook *api.ExecRollingUpdateHook, request *hookRequest) error {
+	if len(hook.Command) == 0 {
+		return fmt.Errorf("exec hook has no command")
+	}
+
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"KOPS_CLUSTER_NAME="+request.ClusterName,
+		"KOPS_INSTANCE_GROUP="+request.InstanceGroup,
+		"KOPS_INSTANCE_ID="+request.InstanceID,
+		"KOPS_NODE_NAME="+request.NodeName,
+		"KOPS_HOOK_PHASE="+request.Phase,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if len(output) != 0 {
+		klog.Infof("hook output:\n%s", strings.TrimRight(string(output), "\n"))
+	}
+	if err != nil {
+		return fmt.Errorf("error running %q: %v", strings.Join(hook.Command, " "), err)
+	}
+	return nil
+}
+
+func runHTTPHook(ctx context.Context, hook *api.HTTPRollingUpdateHook, request *hookRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("error building hook request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error building hook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, env := range hook.HeadersFromEnv {
+		v, found := os.LookupEnv(env)
+		if !found {
+			return fmt.Errorf("environment variable %q for header %q is not set", env, k)
+		}
+		req.Header.Set(k, v)
+	}
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %q: %v", hook.URL, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("unexpected status %q from %q: %s", response.Status, hook.URL, strings.TrimSpace(string(responseBody)))
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+)
+
+func makeHookInstance(clusterHooks, groupHooks []kops.RollingUpdateHook) (*kops.Cluster, *cloudinstances.CloudInstance) {
+	cluster := &kops.Cluster{}
+	cluster.Name = "test.k8s.local"
+	if clusterHooks != nil {
+		cluster.Spec.RollingUpdate = &kops.RollingUpdate{Hooks: clusterHooks}
+	}
+
+	ig := &kops.InstanceGroup{}
+	ig.Name = "nodes"
+	if groupHooks != nil {
+		ig.Spec.RollingUpdate = &kops.RollingUpdate{Hooks: groupHooks}
+	}
+
+	group := &cloudinstances.CloudInstanceGroup{HumanName: "nodes", InstanceGroup: ig}
+	instance := &cloudinstances.CloudInstance{
+		ID:                 "i-1",
+		Node:               &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		CloudInstanceGroup: group,
+	}
+	return cluster, instance
+}
+
+func execHook(name string, phase kops.RollingUpdateHookPhase, command ...string) kops.RollingUpdateHook {
+	return kops.RollingUpdateHook{
+		Name:  name,
+		Phase: phase,
+		Exec:  &kops.ExecRollingUpdateHook{Command: command},
+	}
+}
+
+func hookNames(hooks []kops.RollingUpdateHook) []string {
+	names := []string{}
+	for _, hook := range hooks {
+		names = append(names, hook.Name)
+	}
+	return names
+}
+
+func TestHooksFor(t *testing.T) {
+	clusterHooks := []kops.RollingUpdateHook{
+		execHook("cluster-pre", kops.RollingUpdateHookPhasePreDrain, "true"),
+		execHook("cluster-post", kops.RollingUpdateHookPhasePostTerminate, "true"),
+	}
+	groupHooks := []kops.RollingUpdateHook{
+		execHook("group-pre", kops.RollingUpdateHookPhasePreDrain, "true"),
+	}
+	extraHooks := []kops.RollingUpdateHook{
+		execHook("extra-pre", kops.RollingUpdateHookPhasePreDrain, "true"),
+	}
+
+	cluster, instance := makeHookInstance(clusterHooks, nil)
+	c := &RollingUpdateCluster{Cluster: cluster, Hooks: extraHooks}
+	assert.Equal(t, []string{"cluster-pre", "extra-pre"}, hookNames(c.hooksFor(instance, kops.RollingUpdateHookPhasePreDrain)), "cluster hooks")
+	assert.Equal(t, []string{"cluster-post"}, hookNames(c.hooksFor(instance, kops.RollingUpdateHookPhasePostTerminate)), "cluster hooks")
+
+	cluster, instance = makeHookInstance(clusterHooks, groupHooks)
+	c = &RollingUpdateCluster{Cluster: cluster, Hooks: extraHooks}
+	assert.Equal(t, []string{"group-pre", "extra-pre"}, hookNames(c.hooksFor(instance, kops.RollingUpdateHookPhasePreDrain)), "group hooks")
+	assert.Empty(t, c.hooksFor(instance, kops.RollingUpdateHookPhasePostTerminate), "group hooks")
+
+	c.SkipHooks = true
+	assert.Empty(t, c.hooksFor(instance, kops.RollingUpdateHookPhasePreDrain), "skipped hooks")
+}
+
+func TestCheckExecHooks(t *testing.T) {
+	cluster, instance := makeHookInstance(nil, []kops.RollingUpdateHook{
+		execHook("drain-lb", kops.RollingUpdateHookPhasePreDrain, "true"),
+	})
+	groups := map[string]*cloudinstances.CloudInstanceGroup{"nodes": instance.CloudInstanceGroup}
+
+	c := &RollingUpdateCluster{Cluster: cluster}
+	err := c.checkExecHooks(groups)
+	if assert.Error(t, err, "exec hook from the spec") {
+		assert.Contains(t, err.Error(), `instance group "nodes" has exec hook "drain-lb" configured in the spec`)
+	}
+	assert.Error(t, c.RollingUpdate(groups, &kops.InstanceGroupList{}), "rolling update with exec hook from the spec")
+	assert.Error(t, c.UpdateSingleInstance(instance, false), "deleting instance with exec hook from the spec")
+
+	c = &RollingUpdateCluster{Cluster: cluster, AllowExecHooks: true}
+	assert.NoError(t, c.checkExecHooks(groups), "allowed exec hooks")
+
+	c = &RollingUpdateCluster{Cluster: cluster, SkipHooks: true}
+	assert.NoError(t, c.checkExecHooks(groups), "skipped hooks")
+
+	cluster, instance = makeHookInstance(nil, nil)
+	groups = map[string]*cloudinstances.CloudInstanceGroup{"nodes": instance.CloudInstanceGroup}
+	c = &RollingUpdateCluster{
+		Cluster: cluster,
+		Hooks:   []kops.RollingUpdateHook{execHook("pre-drain-hook", kops.RollingUpdateHookPhasePreDrain, "true")},
+	}
+	assert.NoError(t, c.checkExecHooks(groups), "exec hook from the command line")
+}
+
+func TestExecHookEnvironment(t *testing.T) {
+	cluster, instance := makeHookInstance(nil, []kops.RollingUpdateHook{
+		execHook("env", kops.RollingUpdateHookPhasePreDrain, "/bin/sh", "-c",
+			`test "$KOPS_CLUSTER_NAME/$KOPS_INSTANCE_GROUP/$KOPS_INSTANCE_ID/$KOPS_NODE_NAME/$KOPS_HOOK_PHASE" = "test.k8s.local/nodes/i-1/node-1/PreDrain"`),
+	})
+	c := &RollingUpdateCluster{Cluster: cluster, ClusterName: cluster.Name}
+
+	assert.NoError(t, c.runHooks(instance, kops.RollingUpdateHookPhasePreDrain))
+}
+
+func TestHookFailurePolicy(t *testing.T) {
+	failing := execHook("failing", kops.RollingUpdateHookPhasePostTerminate, "/bin/sh", "-c", "exit 1")
+
+	cluster, instance := makeHookInstance(nil, []kops.RollingUpdateHook{failing})
+	c := &RollingUpdateCluster{Cluster: cluster}
+	err := c.runHooks(instance, kops.RollingUpdateHookPhasePostTerminate)
+	if assert.Error(t, err, "default failure policy") {
+		assert.Contains(t, err.Error(), `PostTerminate hook "failing" failed for instance "i-1"`)
+	}
+
+	failing.FailurePolicy = kops.RollingUpdateHookFailurePolicyIgnore
+	cluster, instance = makeHookInstance(nil, []kops.RollingUpdateHook{failing})
+	c = &RollingUpdateCluster{Cluster: cluster}
+	assert.NoError(t, c.runHooks(instance, kops.RollingUpdateHookPhasePostTerminate), "Ignore failure policy")
+}
+
+func TestHookTimeout(t *testing.T) {
+	hook := execHook("slow", kops.RollingUpdateHookPhasePreDrain, "sleep", "10")
+	hook.Timeout = &metav1.Duration{Duration: 100 * time.Millisecond}
+
+	cluster, instance := makeHookInstance(nil, []kops.RollingUpdateHook{hook})
+	c := &RollingUpdateCluster{Cluster: cluster}
+	start := time.Now()
+	err := c.runHooks(instance, kops.RollingUpdateHookPhasePreDrain)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timed out after 100ms")
+	}
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second), "hook was not stopped")
+}
+
+func TestHTTPHook(t *testing.T) {
+	var received hookRequest
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		assert.Equal(t, "kops", r.Header.Get("X-Source"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	cluster, instance := makeHookInstance(nil, []kops.RollingUpdateHook{
+		{
+			Name:  "webhook",
+			Phase: kops.RollingUpdateHookPhasePostTerminate,
+			HTTP: &kops.HTTPRollingUpdateHook{
+				URL:            server.URL,
+				Headers:        map[string]string{"X-Source": "kops"},
+				HeadersFromEnv: map[string]string{"X-Token": "KOPS_HOOK_TEST_TOKEN"},
+			},
+		},
+	})
+	c := &RollingUpdateCluster{Cluster: cluster, ClusterName: cluster.Name}
+
+	err := c.runHooks(instance, kops.RollingUpdateHookPhasePostTerminate)
+	if assert.Error(t, err, "unset environment variable") {
+		assert.Contains(t, err.Error(), `environment variable "KOPS_HOOK_TEST_TOKEN" for header "X-Token" is not set`)
+	}
+
+	os.Setenv("KOPS_HOOK_TEST_TOKEN", "secret")
+	defer os.Unsetenv("KOPS_HOOK_TEST_TOKEN")
+
+	assert.NoError(t, c.runHooks(instance, kops.RollingUpdateHookPhasePostTerminate))
+	assert.Equal(t, hookRequest{
+		ClusterName:   "test.k8s.local",
+		InstanceGroup: "nodes",
+		InstanceID:    "i-1",
+		NodeName:      "node-1",
+		Phase:         "PostTerminate",
+	}, received)
+
+	status = http.StatusServiceUnavailable
+	err = c.runHooks(instance, kops.RollingUpdateHookPhasePostTerminate)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "503 Service Unavailable")
+	}
+}
+
+// validationRecorder counts validations, recording how many had happened
+// when each instance was terminated and when its PostTerminate hook ran
+type validationRecorder struct {
+	mutex        sync.Mutex
+	validations  int
+	terminatedAt map[string]int
+	hookAt       map[string]int
+}
+
+func (r *validationRecorder) Validate() (*validation.ValidationCluster, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.validations++
+	return &validation.ValidationCluster{}, nil
+}
+
+// Write receives the rolling update's event stream
+func (r *validationRecorder) Write(b []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var event RollingUpdateEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err == nil && event.Type == EventInstanceTerminated {
+			r.terminatedAt[event.InstanceID] = r.validations
+		}
+	}
+	return len(b), nil
+}
+
+func TestPostTerminateHooksRunAfterValidation(t *testing.T) {
+	recorder := &validationRecorder{terminatedAt: map[string]int{}, hookAt: map[string]int{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request hookRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		recorder.mutex.Lock()
+		recorder.hookAt[request.InstanceID] = recorder.validations
+		recorder.mutex.Unlock()
+	}))
+	defer server.Close()
+
+	c, cloud := getTestSetup()
+	c.ClusterValidator = recorder
+	c.EventStream = recorder
+	c.Hooks = []kops.RollingUpdateHook{
+		{Name: "webhook", Phase: kops.RollingUpdateHookPhasePostTerminate, HTTP: &kops.HTTPRollingUpdateHook{URL: server.URL}},
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kops.InstanceGroupRoleNode, 3, 3)
+	assert.NoError(t, c.RollingUpdate(groups, &kops.InstanceGroupList{}), "rolling update")
+
+	assert.Len(t, recorder.terminatedAt, 3, "terminated instances")
+	assert.Len(t, recorder.hookAt, 3, "hooks run")
+	for id, terminatedAt := range recorder.terminatedAt {
+		assert.GreaterOrEqual(t, recorder.hookAt[id], terminatedAt+c.ValidateCount, "hook of %q ran after the cluster validated", id)
+	}
+}
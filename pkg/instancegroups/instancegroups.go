@@ -91,6 +91,19 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 		return nil
 	}
 
+	c.emitGroupEvent(EventInstanceGroupStarted, group, "")
+	defer func() {
+		if err == nil {
+			// Leave a group with skipped instances to be resumed
+			if !c.skippedInstances(group) {
+				c.progress.completeGroup(group)
+			}
+			c.emitGroupEvent(EventInstanceGroupCompleted, group, "")
+		} else {
+			c.emitGroupEvent(EventInstanceGroupFailed, group, err.Error())
+		}
+	}()
+
 	if isBastion {
 		klog.V(3).Info("Not validating the cluster as instance is a bastion.")
 	} else if err = c.maybeValidate("", 1, group); err != nil {
@@ -148,6 +161,17 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 
 	update = prioritizeUpdate(update)
 
+	if numCanaries := canaryInstances(settings.Canary, numInstances, len(update)); numCanaries > 0 && *settings.DrainAndTerminate {
+		if err := c.updateCanaries(group, update[:numCanaries], settings.Canary, maxSurge, sleepAfterTerminate); err != nil {
+			return err
+		}
+		update = update[numCanaries:]
+		noneReady = false
+		if maxSurge > len(update) {
+			maxSurge = len(update)
+		}
+	}
+
 	if maxSurge > 0 && !c.CloudOnly {
 		skippedNodes := 0
 		for numSurge := 1; numSurge <= maxSurge; numSurge++ {
@@ -181,11 +205,15 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 		return nil
 	}
 
-	terminateChan := make(chan error, maxConcurrency)
+	terminateChan := make(chan terminateResult, maxConcurrency)
+
+	// terminated are the instances terminated since the cluster last validated,
+	// whose PostTerminate hooks are run once it has
+	var terminated []*cloudinstances.CloudInstance
 
 	for uIdx, u := range update {
 		go func(m *cloudinstances.CloudInstance) {
-			terminateChan <- c.drainTerminateAndWait(m, sleepAfterTerminate)
+			terminateChan <- terminateResult{instance: m, err: c.drainTerminateAndWait(m, sleepAfterTerminate)}
 		}(u)
 		runningDrains++
 
@@ -195,16 +223,23 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 			continue
 		}
 
-		err = <-terminateChan
+		result := <-terminateChan
 		runningDrains--
+		if result.err != nil {
+			return waitForPendingBeforeReturningError(runningDrains, terminateChan, result.err)
+		}
+		terminated = append(terminated, result.instance)
+
+		err = c.maybeValidate(" after terminating instance", c.ValidateCount, group)
 		if err != nil {
 			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
 		}
 
-		err = c.maybeValidate(" after terminating instance", c.ValidateCount, group)
+		err = c.runPostTerminateHooks(terminated)
 		if err != nil {
 			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
 		}
+		terminated = nil
 
 		if c.Interactive {
 			nodeName := ""
@@ -227,35 +262,45 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 	sweep:
 		for runningDrains > 0 {
 			select {
-			case err = <-terminateChan:
+			case result := <-terminateChan:
 				runningDrains--
-				if err != nil {
-					return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
+				if result.err != nil {
+					return waitForPendingBeforeReturningError(runningDrains, terminateChan, result.err)
 				}
+				terminated = append(terminated, result.instance)
 			default:
 				break sweep
 			}
 		}
 	}
 
-	if runningDrains > 0 {
+	if runningDrains > 0 || c.hasPostTerminateHooks(terminated) {
 		for runningDrains > 0 {
-			err = <-terminateChan
+			result := <-terminateChan
 			runningDrains--
-			if err != nil {
-				return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
+			if result.err != nil {
+				return waitForPendingBeforeReturningError(runningDrains, terminateChan, result.err)
 			}
+			terminated = append(terminated, result.instance)
 		}
 
 		err = c.maybeValidate(" after terminating instance", c.ValidateCount, group)
 		if err != nil {
 			return err
 		}
+
+		return c.runPostTerminateHooks(terminated)
 	}
 
 	return nil
 }
 
+// terminateResult is the outcome of draining and terminating an instance
+type terminateResult struct {
+	instance *cloudinstances.CloudInstance
+	err      error
+}
+
 func prioritizeUpdate(update []*cloudinstances.CloudInstance) []*cloudinstances.CloudInstance {
 	// The priorities are, in order:
 	//   attached before detached
@@ -275,7 +320,7 @@ func prioritizeUpdate(update []*cloudinstances.CloudInstance) []*cloudinstances.
 	return result
 }
 
-func waitForPendingBeforeReturningError(runningDrains int, terminateChan chan error, err error) error {
+func waitForPendingBeforeReturningError(runningDrains int, terminateChan chan terminateResult, err error) error {
 	for runningDrains > 0 {
 		<-terminateChan
 		runningDrains--
@@ -284,7 +329,7 @@ func waitForPendingBeforeReturningError(runningDrains int, terminateChan chan er
 }
 
 func (c *RollingUpdateCluster) taintAllNeedUpdate(group *cloudinstances.CloudInstanceGroup, update []*cloudinstances.CloudInstance) error {
-	var toTaint []*corev1.Node
+	var toTaint []*cloudinstances.CloudInstance
 	for _, u := range update {
 		if u.Node != nil && !u.Node.Spec.Unschedulable {
 			foundTaint := false
@@ -294,7 +339,7 @@ func (c *RollingUpdateCluster) taintAllNeedUpdate(group *cloudinstances.CloudIns
 				}
 			}
 			if !foundTaint {
-				toTaint = append(toTaint, u.Node)
+				toTaint = append(toTaint, u)
 			}
 		}
 	}
@@ -304,13 +349,16 @@ func (c *RollingUpdateCluster) taintAllNeedUpdate(group *cloudinstances.CloudIns
 			noun = "node"
 		}
 		klog.Infof("Tainting %d %s in %q instancegroup.", len(toTaint), noun, group.InstanceGroup.Name)
-		for _, n := range toTaint {
+		for _, u := range toTaint {
+			n := u.Node
 			if err := c.patchTaint(n); err != nil {
 				if c.FailOnDrainError {
 					return fmt.Errorf("failed to taint node %q: %v", n, err)
 				}
 				klog.Infof("Ignoring error tainting node %q: %v", n, err)
+				continue
 			}
+			c.emitInstanceEvent(EventInstanceTainted, u, "")
 		}
 	}
 	return nil
@@ -379,6 +427,12 @@ func (c *RollingUpdateCluster) patchExcludeFromLB(node *corev1.Node) error {
 func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInstance, sleepAfterTerminate time.Duration) error {
 	instanceID := u.ID
 
+	if err := c.runHooks(u, api.RollingUpdateHookPhasePreDrain); err != nil {
+		return err
+	}
+
+	c.progress.startInstance(u)
+
 	nodeName := ""
 	if u.Node != nil {
 		nodeName = u.Node.Name
@@ -397,11 +451,22 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 		if u.Node != nil {
 			klog.Infof("Draining the node: %q.", nodeName)
 
-			if err := c.drainNode(u); err != nil {
+			if err := c.drainNode(u); err == errDrainSkipped {
+				c.progress.skipInstance(u)
+				message := ""
+				if c.instanceDetached(u) {
+					message = "left running detached from its instance group"
+				}
+				c.emitInstanceEvent(EventInstanceSkipped, u, message)
+				return nil
+			} else if err != nil {
+				c.emitInstanceEvent(EventInstanceDrainFailed, u, err.Error())
 				if c.FailOnDrainError {
 					return fmt.Errorf("failed to drain node %q: %v", nodeName, err)
 				}
 				klog.Infof("Ignoring error draining node %q: %v", nodeName, err)
+			} else {
+				c.emitInstanceEvent(EventInstanceDrained, u, "")
 			}
 		} else {
 			klog.Warningf("Skipping drain of instance %q, because it is not registered in kubernetes", instanceID)
@@ -426,6 +491,9 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 		return err
 	}
 
+	c.progress.finishInstance(u)
+	c.emitInstanceEvent(EventInstanceTerminated, u, "")
+
 	if err := c.reconcileInstanceGroup(); err != nil {
 		klog.Errorf("error reconciling instance group %q: %v", u.CloudInstanceGroup.HumanName, err)
 		return err
@@ -438,9 +506,16 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 	return nil
 }
 
+// reconcilesInstanceGroups returns whether the cloud provider requires the cluster's configuration
+// to be applied after terminating an instance, for its instance group to replace it.
+func (c *RollingUpdateCluster) reconcilesInstanceGroups() bool {
+	return api.CloudProviderID(c.Cluster.Spec.CloudProvider) == api.CloudProviderOpenstack ||
+		api.CloudProviderID(c.Cluster.Spec.CloudProvider) == api.CloudProviderDO ||
+		api.CloudProviderID(c.Cluster.Spec.CloudProvider) == api.CloudProviderHetzner
+}
+
 func (c *RollingUpdateCluster) reconcileInstanceGroup() error {
-	if api.CloudProviderID(c.Cluster.Spec.CloudProvider) != api.CloudProviderOpenstack &&
-		api.CloudProviderID(c.Cluster.Spec.CloudProvider) != api.CloudProviderDO {
+	if !c.reconcilesInstanceGroups() {
 		return nil
 	}
 	rto := fi.RunTasksOptions{}
@@ -470,6 +545,7 @@ func (c *RollingUpdateCluster) maybeValidate(operation string, validateCount int
 		klog.Info("Validating the cluster.")
 
 		if err := c.validateClusterWithTimeout(validateCount, group); err != nil {
+			c.emitGroupEvent(EventValidationFailed, group, err.Error())
 
 			if c.FailOnValidate {
 				klog.Errorf("Cluster did not validate within %s", c.ValidationTimeout)
@@ -477,6 +553,8 @@ func (c *RollingUpdateCluster) maybeValidate(operation string, validateCount int
 			}
 
 			klog.Warningf("Cluster validation failed%s, proceeding since fail-on-validate is set to false: %v", operation, err)
+		} else {
+			c.emitGroupEvent(EventValidationPassed, group, "")
 		}
 	}
 	return nil
@@ -577,6 +655,9 @@ func (c *RollingUpdateCluster) detachInstance(u *cloudinstances.CloudInstance) e
 		return fmt.Errorf("error detaching instance %q: %v", id, err)
 	}
 
+	c.progress.detachInstance(u)
+	c.emitInstanceEvent(EventInstanceDetached, u, "")
+
 	return nil
 }
 
@@ -628,9 +709,6 @@ func (c *RollingUpdateCluster) drainNode(u *cloudinstances.CloudInstance) error
 
 		// We want to proceed even when pods are using emptyDir volumes
 		DeleteEmptyDirData: true,
-
-		// Other options we might want to set:
-		// Timeout?
 	}
 
 	if err := drain.RunCordonOrUncordon(helper, u.Node, true); err != nil {
@@ -640,6 +718,7 @@ func (c *RollingUpdateCluster) drainNode(u *cloudinstances.CloudInstance) error
 		return fmt.Errorf("error cordoning node: %v", err)
 	}
 
+	_, excludedFromLB := u.Node.Labels[corev1.LabelNodeExcludeBalancers]
 	if err := c.patchExcludeFromLB(u.Node); err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil
@@ -647,11 +726,8 @@ func (c *RollingUpdateCluster) drainNode(u *cloudinstances.CloudInstance) error
 		return fmt.Errorf("error excluding node from load balancer: %v", err)
 	}
 
-	if err := drain.RunNodeDrain(helper, u.Node.Name); err != nil {
-		if apierrors.IsNotFound(err) {
-			return nil
-		}
-		return fmt.Errorf("error draining node: %v", err)
+	if err := c.runNodeDrain(helper, u, excludedFromLB); err != nil {
+		return err
 	}
 
 	if c.PostDrainDelay > 0 {
@@ -679,6 +755,10 @@ func (c *RollingUpdateCluster) deleteNode(node *corev1.Node) error {
 
 // UpdateSingleInstance performs a rolling update on a single instance
 func (c *RollingUpdateCluster) UpdateSingleInstance(cloudMember *cloudinstances.CloudInstance, detach bool) error {
+	if err := c.checkExecHooks(map[string]*cloudinstances.CloudInstanceGroup{cloudMember.CloudInstanceGroup.HumanName: cloudMember.CloudInstanceGroup}); err != nil {
+		return err
+	}
+
 	if detach {
 		if cloudMember.CloudInstanceGroup.InstanceGroup.IsMaster() {
 			klog.Warning("cannot detach master instances. Assuming --surge=false")
@@ -694,5 +774,8 @@ func (c *RollingUpdateCluster) UpdateSingleInstance(cloudMember *cloudinstances.
 		}
 	}
 
-	return c.drainTerminateAndWait(cloudMember, 0)
+	if err := c.drainTerminateAndWait(cloudMember, 0); err != nil {
+		return err
+	}
+	return c.runPostTerminateHooks([]*cloudinstances.CloudInstance{cloudMember})
 }
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// InstanceUpdateReason is why an instance is to be updated.
+type InstanceUpdateReason string
+
+const (
+	// InstanceUpdateReasonSpecChanged means the instance was created with an outdated specification.
+	InstanceUpdateReasonSpecChanged InstanceUpdateReason = "SpecChanged"
+	// InstanceUpdateReasonDetached means the instance was detached for surging by a previous rolling update.
+	InstanceUpdateReasonDetached InstanceUpdateReason = "Detached"
+	// InstanceUpdateReasonAnnotation means the node has the kops.k8s.io/needs-update annotation.
+	InstanceUpdateReasonAnnotation InstanceUpdateReason = "NeedsUpdateAnnotation"
+	// InstanceUpdateReasonForced means the instance is up to date but the update was forced.
+	InstanceUpdateReasonForced InstanceUpdateReason = "Forced"
+)
+
+// RollingUpdatePlan describes what a rolling update would do.
+type RollingUpdatePlan struct {
+	// Cluster is the name of the cluster.
+	Cluster string `json:"cluster"`
+	// NeedUpdate is true if any instance is to be updated.
+	NeedUpdate bool `json:"needUpdate"`
+	// InstanceGroups are the instance groups, in the order they would be updated.
+	InstanceGroups []*InstanceGroupPlan `json:"instanceGroups"`
+}
+
+// InstanceGroupPlan describes what a rolling update would do to an instance group.
+type InstanceGroupPlan struct {
+	// Name is the name of the instance group.
+	Name string `json:"name"`
+	// Role is the role of the instance group.
+	Role api.InstanceGroupRole `json:"role"`
+	// Status is "NeedsUpdate" if any instance of the group is to be updated, otherwise "Ready".
+	Status string `json:"status"`
+	// MinSize, TargetSize and MaxSize are the sizes of the cloud group.
+	MinSize    int `json:"minSize"`
+	TargetSize int `json:"targetSize"`
+	MaxSize    int `json:"maxSize"`
+	// MaxSurge is the resolved maximum number of extra instances to create during the update.
+	MaxSurge int `json:"maxSurge"`
+	// MaxUnavailable is the resolved maximum number of instances that may be unavailable during the update.
+	MaxUnavailable int `json:"maxUnavailable"`
+	// DrainAndTerminate is false if instances will not be drained and terminated.
+	DrainAndTerminate bool `json:"drainAndTerminate"`
+	// CanaryInstances is the number of instances to update before the canary bake time, if any.
+	CanaryInstances int `json:"canaryInstances,omitempty"`
+	// Instances are the instances of the group.
+	Instances []*InstancePlan `json:"instances,omitempty"`
+}
+
+// InstancePlan describes what a rolling update would do to an instance.
+type InstancePlan struct {
+	// ID is the cloud identifier of the instance.
+	ID string `json:"id"`
+	// NodeName is the name of the instance's node, if it has registered.
+	NodeName string `json:"nodeName,omitempty"`
+	// Status is the status of the instance, as for "kops get instances".
+	Status string `json:"status"`
+	// NeedUpdate is true if the instance is to be updated.
+	NeedUpdate bool `json:"needUpdate"`
+	// Reason is why the instance is to be updated.
+	Reason InstanceUpdateReason `json:"reason,omitempty"`
+}
+
+// Plan describes what RollingUpdate would do to the groups, without changing anything.
+// AdjustNeedUpdate should be called on the groups first.
+func (c *RollingUpdateCluster) Plan(groups map[string]*cloudinstances.CloudInstanceGroup) *RollingUpdatePlan {
+	plan := &RollingUpdatePlan{
+		Cluster:        c.ClusterName,
+		InstanceGroups: []*InstanceGroupPlan{},
+	}
+
+	byRole := make(map[api.InstanceGroupRole]map[string]*cloudinstances.CloudInstanceGroup)
+	for k, group := range groups {
+		role := group.InstanceGroup.Spec.Role
+		if byRole[role] == nil {
+			byRole[role] = make(map[string]*cloudinstances.CloudInstanceGroup)
+		}
+		byRole[role][k] = group
+	}
+
+	// The order in which rollingUpdateGroups updates the roles
+	roles := []api.InstanceGroupRole{
+		api.InstanceGroupRoleBastion,
+		api.InstanceGroupRoleMaster,
+		api.InstanceGroupRoleAPIServer,
+		api.InstanceGroupRoleNode,
+	}
+	for _, role := range roles {
+		for _, k := range sortGroups(byRole[role]) {
+			groupPlan := c.planInstanceGroup(byRole[role][k])
+			if groupPlan.Status != "Ready" {
+				plan.NeedUpdate = true
+			}
+			plan.InstanceGroups = append(plan.InstanceGroups, groupPlan)
+		}
+	}
+
+	return plan
+}
+
+func (c *RollingUpdateCluster) planInstanceGroup(group *cloudinstances.CloudInstanceGroup) *InstanceGroupPlan {
+	numInstances := len(group.Ready) + len(group.NeedUpdate)
+	settings := resolveSettings(c.Cluster, group.InstanceGroup, numInstances)
+
+	groupPlan := &InstanceGroupPlan{
+		Name:              group.InstanceGroup.Name,
+		Role:              group.InstanceGroup.Spec.Role,
+		Status:            group.Status(),
+		MinSize:           group.MinSize,
+		TargetSize:        group.TargetSize,
+		MaxSize:           group.MaxSize,
+		MaxSurge:          settings.MaxSurge.IntValue(),
+		MaxUnavailable:    settings.MaxUnavailable.IntValue(),
+		DrainAndTerminate: *settings.DrainAndTerminate,
+	}
+
+	if group.InstanceGroup.Spec.Role == api.InstanceGroupRoleMaster && groupPlan.MaxSurge != 0 {
+		// As in rollingUpdateInstanceGroup, masters do not surge
+		groupPlan.MaxSurge = 0
+		if groupPlan.MaxUnavailable == 0 {
+			groupPlan.MaxUnavailable = 1
+		}
+	}
+
+	numUpdate := len(group.NeedUpdate)
+	if c.Force {
+		numUpdate = numInstances
+	}
+	if groupPlan.DrainAndTerminate {
+		groupPlan.CanaryInstances = canaryInstances(settings.Canary, numInstances, numUpdate)
+	}
+
+	for _, u := range group.NeedUpdate {
+		groupPlan.Instances = append(groupPlan.Instances, planInstance(u, true, updateReason(u)))
+	}
+	for _, u := range group.Ready {
+		if c.Force {
+			groupPlan.Instances = append(groupPlan.Instances, planInstance(u, true, InstanceUpdateReasonForced))
+		} else {
+			groupPlan.Instances = append(groupPlan.Instances, planInstance(u, false, ""))
+		}
+	}
+	if c.Force && len(group.Ready) > 0 {
+		groupPlan.Status = "NeedsUpdate"
+	}
+
+	return groupPlan
+}
+
+func planInstance(u *cloudinstances.CloudInstance, needUpdate bool, reason InstanceUpdateReason) *InstancePlan {
+	instancePlan := &InstancePlan{
+		ID:         u.ID,
+		Status:     u.Status,
+		NeedUpdate: needUpdate,
+		Reason:     reason,
+	}
+	if u.Node != nil {
+		instancePlan.NodeName = u.Node.Name
+	}
+	return instancePlan
+}
+
+// updateReason determines why an instance in a group's NeedUpdate was selected for update
+func updateReason(u *cloudinstances.CloudInstance) InstanceUpdateReason {
+	if u.Status == cloudinstances.CloudInstanceStatusDetached {
+		return InstanceUpdateReasonDetached
+	}
+	if u.Node != nil {
+		if _, ok := u.Node.Annotations["kops.k8s.io/needs-update"]; ok {
+			return InstanceUpdateReasonAnnotation
+		}
+	}
+	return InstanceUpdateReasonSpecChanged
+}
@@ -0,0 +1,408 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// RollingUpdateProgress is a checkpoint of a rolling update, persisted in the state store so
+// that an interrupted rolling update can be resumed.
+type RollingUpdateProgress struct {
+	// CompletedGroups are the names of the instance groups that have been fully updated.
+	CompletedGroups []string `json:"completedGroups,omitempty"`
+	// InFlight are the instances that were being drained or terminated.
+	InFlight []ProgressInstance `json:"inFlight,omitempty"`
+	// Detached are the instances that were detached from their instance group in order to surge.
+	Detached []ProgressInstance `json:"detached,omitempty"`
+}
+
+// ProgressInstance identifies an instance recorded in a RollingUpdateProgress.
+type ProgressInstance struct {
+	// ID is the cloud identifier of the instance.
+	ID string `json:"id"`
+	// InstanceGroup is the name of the instance group the instance belongs to.
+	InstanceGroup string `json:"instanceGroup"`
+	// NodeName is the name of the kubernetes node of the instance, if known.
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// ReadRollingUpdateProgress returns the progress recorded by a rolling update of the cluster that did not complete,
+// or nil if there is none.
+func ReadRollingUpdateProgress(clientset simple.Clientset, cluster *api.Cluster) (*RollingUpdateProgress, error) {
+	p, err := progressPath(clientset, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return readProgress(p)
+}
+
+// ClearRollingUpdateProgress removes the progress recorded by a rolling update of the cluster that did not complete.
+func ClearRollingUpdateProgress(clientset simple.Clientset, cluster *api.Cluster) error {
+	p, err := progressPath(clientset, cluster)
+	if err != nil {
+		return err
+	}
+	return (&progressTracker{path: p}).clear()
+}
+
+func progressPath(clientset simple.Clientset, cluster *api.Cluster) (vfs.Path, error) {
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+	return configBase.Join(registry.PathRollingUpdateProgress), nil
+}
+
+func readProgress(p vfs.Path) (*RollingUpdateProgress, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading rolling update progress %q: %v", p, err)
+	}
+
+	progress := &RollingUpdateProgress{}
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("error parsing rolling update progress %q: %v", p, err)
+	}
+	return progress, nil
+}
+
+// progressTracker records the progress of a rolling update as it happens.
+// A nil progressTracker records nothing.
+type progressTracker struct {
+	mutex    sync.Mutex
+	path     vfs.Path
+	progress RollingUpdateProgress
+}
+
+func progressInstance(u *cloudinstances.CloudInstance) ProgressInstance {
+	p := ProgressInstance{
+		ID:            u.ID,
+		InstanceGroup: u.CloudInstanceGroup.InstanceGroup.Name,
+	}
+	if u.Node != nil {
+		p.NodeName = u.Node.Name
+	}
+	return p
+}
+
+func removeProgressInstance(instances []ProgressInstance, id string) []ProgressInstance {
+	var result []ProgressInstance
+	for _, i := range instances {
+		if i.ID != id {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func addProgressInstance(instances []ProgressInstance, u *cloudinstances.CloudInstance) []ProgressInstance {
+	return append(removeProgressInstance(instances, u.ID), progressInstance(u))
+}
+
+// startInstance records that the instance is about to be drained and terminated.
+func (t *progressTracker) startInstance(u *cloudinstances.CloudInstance) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.InFlight = addProgressInstance(t.progress.InFlight, u)
+	t.saveLocked()
+}
+
+// finishInstance records that the instance has been terminated.
+func (t *progressTracker) finishInstance(u *cloudinstances.CloudInstance) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.InFlight = removeProgressInstance(t.progress.InFlight, u.ID)
+	t.progress.Detached = removeProgressInstance(t.progress.Detached, u.ID)
+	t.saveLocked()
+}
+
+// skipInstance records that the instance is no longer being drained, but has not been terminated.
+func (t *progressTracker) skipInstance(u *cloudinstances.CloudInstance) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.InFlight = removeProgressInstance(t.progress.InFlight, u.ID)
+	t.saveLocked()
+}
+
+// detachInstance records that the instance has been detached from its instance group.
+func (t *progressTracker) detachInstance(u *cloudinstances.CloudInstance) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.Detached = addProgressInstance(t.progress.Detached, u)
+	t.saveLocked()
+}
+
+// isDetached returns whether the instance has been recorded as detached from its instance group.
+func (t *progressTracker) isDetached(id string) bool {
+	if t == nil {
+		return false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, detached := range t.progress.Detached {
+		if detached.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// completeGroup records that all instances of the instance group have been updated.
+func (t *progressTracker) completeGroup(group *cloudinstances.CloudInstanceGroup) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	name := group.InstanceGroup.Name
+	for _, completed := range t.progress.CompletedGroups {
+		if completed == name {
+			return
+		}
+	}
+	t.progress.CompletedGroups = append(t.progress.CompletedGroups, name)
+	t.saveLocked()
+}
+
+// saveLocked writes the progress to the state store. The mutex must be held.
+// Failures are not fatal to the rolling update; they only limit how precisely it can be resumed.
+func (t *progressTracker) saveLocked() {
+	if err := t.write(); err != nil {
+		klog.Warningf("unable to record rolling update progress: %v", err)
+	}
+}
+
+func (t *progressTracker) write() error {
+	if t.path == nil {
+		return nil
+	}
+	data, err := json.Marshal(&t.progress)
+	if err != nil {
+		return fmt.Errorf("error serializing rolling update progress: %v", err)
+	}
+	if err := t.path.WriteFile(bytes.NewReader(data), nil); err != nil {
+		return fmt.Errorf("error writing rolling update progress %q: %v", t.path, err)
+	}
+	return nil
+}
+
+// clear removes the recorded progress, once the rolling update has completed.
+func (t *progressTracker) clear() error {
+	if t == nil || t.path == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err := t.path.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing rolling update progress %q: %v", t.path, err)
+	}
+	return nil
+}
+
+// initProgress sets up recording of the rolling update's progress in the state store and, if
+// Resume is set, loads the progress recorded by a previous rolling update that did not complete.
+// If Resume is not set, only the instances the previous rolling update detached are loaded.
+func (c *RollingUpdateCluster) initProgress() (*RollingUpdateProgress, error) {
+	c.progress = &progressTracker{}
+
+	if c.Clientset == nil {
+		if c.Resume {
+			return nil, fmt.Errorf("cannot resume rolling update without a state store")
+		}
+		return nil, nil
+	}
+
+	p, err := progressPath(c.Clientset, c.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	c.progress.path = p
+
+	previous, err := readProgress(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil && !c.Resume {
+		klog.Warningf("A previous rolling update of cluster %q did not complete; starting over. Use --resume to continue where it stopped.", c.ClusterName)
+		if len(previous.Detached) > 0 {
+			// Some clouds keep no other record of the instances detached to surge, so they are
+			// still terminated when starting over.
+			klog.Infof("The %d instance(s) detached by the previous rolling update will be terminated.", len(previous.Detached))
+			previous = &RollingUpdateProgress{Detached: previous.Detached}
+		} else {
+			previous = nil
+		}
+	} else if previous == nil && c.Resume {
+		klog.Infof("No progress was recorded by a previous rolling update; starting from the beginning.")
+	}
+
+	if previous != nil {
+		c.progress.progress = *previous
+	}
+
+	if err := c.progress.write(); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// resumeGroups reconciles the cloud groups with the progress recorded by an interrupted rolling update.
+// Groups that were completed are skipped, instances that were in flight are updated first, and instances
+// that were detached to surge are drained and terminated even if the cloud no longer tracks them in their group.
+func (c *RollingUpdateCluster) resumeGroups(previous *RollingUpdateProgress, groups map[string]*cloudinstances.CloudInstanceGroup) (map[string]*cloudinstances.CloudInstanceGroup, error) {
+	completed := sets.NewString(previous.CompletedGroups...)
+
+	result := make(map[string]*cloudinstances.CloudInstanceGroup)
+	byName := make(map[string]*cloudinstances.CloudInstanceGroup)
+	for k, group := range groups {
+		name := group.InstanceGroup.Name
+		if completed.Has(name) {
+			klog.Infof("Skipping instance group %q, which was completed by the interrupted rolling update.", name)
+			continue
+		}
+		result[k] = group
+		byName[name] = group
+	}
+
+	for _, d := range previous.Detached {
+		group := byName[d.InstanceGroup]
+		if group == nil {
+			klog.Warningf("Instance %q was detached from instance group %q by the interrupted rolling update, but that group is not being updated; it must be cleaned up manually.", d.ID, d.InstanceGroup)
+			continue
+		}
+
+		if u := findCloudInstance(group, d.ID); u != nil {
+			// Make sure the instance is not detached a second time, and is not counted as up to date
+			u.Status = cloudinstances.CloudInstanceStatusDetached
+			moveToNeedUpdate(group, u)
+			continue
+		}
+
+		klog.Infof("Adopting instance %q, which was detached from instance group %q by the interrupted rolling update.", d.ID, d.InstanceGroup)
+		node, err := c.findNode(d.NodeName)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := group.NewCloudInstance(d.ID, cloudinstances.CloudInstanceStatusDetached, node); err != nil {
+			return nil, fmt.Errorf("error adopting detached instance %q: %v", d.ID, err)
+		}
+	}
+
+	// Instances that were being drained when the rolling update stopped are finished first,
+	// rather than leaving them cordoned while other instances are drained.
+	for i := len(previous.InFlight) - 1; i >= 0; i-- {
+		f := previous.InFlight[i]
+		group := byName[f.InstanceGroup]
+		if group == nil {
+			continue
+		}
+		u := findCloudInstance(group, f.ID)
+		if u == nil {
+			klog.V(2).Infof("Instance %q was terminated by the interrupted rolling update.", f.ID)
+			continue
+		}
+		moveToNeedUpdate(group, u)
+		for j, n := range group.NeedUpdate {
+			if n == u {
+				copy(group.NeedUpdate[1:j+1], group.NeedUpdate[0:j])
+				group.NeedUpdate[0] = u
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// findNode returns the kubernetes node with the given name, or nil if it does not exist.
+func (c *RollingUpdateCluster) findNode(name string) (*v1.Node, error) {
+	if name == "" || c.CloudOnly || c.K8sClient == nil {
+		return nil, nil
+	}
+	node, err := c.K8sClient.CoreV1().Nodes().Get(c.Ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting node %q: %v", name, err)
+	}
+	return node, nil
+}
+
+func findCloudInstance(group *cloudinstances.CloudInstanceGroup, id string) *cloudinstances.CloudInstance {
+	for _, u := range group.NeedUpdate {
+		if u.ID == id {
+			return u
+		}
+	}
+	for _, u := range group.Ready {
+		if u.ID == id {
+			return u
+		}
+	}
+	return nil
+}
+
+// moveToNeedUpdate makes sure the instance is updated even if the cloud reports it as up to date.
+func moveToNeedUpdate(group *cloudinstances.CloudInstanceGroup, u *cloudinstances.CloudInstance) {
+	for i, r := range group.Ready {
+		if r == u {
+			group.Ready = append(group.Ready[:i], group.Ready[i+1:]...)
+			group.NeedUpdate = append(group.NeedUpdate, u)
+			return
+		}
+	}
+}
@@ -19,6 +19,7 @@ package instancegroups
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"sync"
 	"time"
@@ -77,6 +78,45 @@ type RollingUpdateCluster struct {
 
 	// ValidateCount is the amount of time that a cluster needs to be validated after single node update
 	ValidateCount int
+
+	// Hooks are run around the updating of each instance, in addition to those configured in the
+	// rolling update settings of the cluster and instance groups
+	Hooks []api.RollingUpdateHook
+
+	// SkipHooks disables the running of all hooks
+	SkipHooks bool
+
+	// AllowExecHooks permits the running of exec hooks configured in the cluster and instance group specs.
+	// Exec hooks in Hooks are always allowed.
+	AllowExecHooks bool
+
+	// MaxConcurrentInstanceGroups overrides the maximum number of node instance groups to update
+	// at the same time set in the cluster spec, if not zero
+	MaxConcurrentInstanceGroups int
+
+	// DrainTimeout overrides the drain timeout of the cluster and instance groups, if not zero
+	DrainTimeout time.Duration
+
+	// DrainTimeoutPolicy overrides the drain timeout policy of the cluster and instance groups, if set
+	DrainTimeoutPolicy api.DrainTimeoutPolicy
+
+	// EventStream receives a line of JSON describing each step of the rolling update, if set
+	EventStream io.Writer
+
+	// Resume continues a rolling update that did not complete, using the progress it recorded in the state store
+	Resume bool
+
+	// progress records the progress of the rolling update in the state store
+	progress *progressTracker
+
+	// eventMutex serializes writes to the EventStream
+	eventMutex sync.Mutex
+
+	// blockedDrains are the nodes that did not drain within their drain timeout
+	blockedDrains []*BlockedDrain
+
+	// drainMutex guards blockedDrains
+	drainMutex sync.Mutex
 }
 
 // AdjustNeedUpdate adjusts the set of instances that need updating, using factors outside those known by the cloud implementation
@@ -94,6 +134,44 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 		return nil
 	}
 
+	if err := c.checkExecHooks(groups); err != nil {
+		return err
+	}
+
+	previous, err := c.initProgress()
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		groups, err = c.resumeGroups(previous, groups)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.emitEvent(RollingUpdateEvent{Type: EventRollingUpdateStarted})
+
+	err = c.rollingUpdateGroups(groups)
+	c.logDrainSummary()
+	if err != nil {
+		if c.progress.path != nil {
+			klog.Infof("Rolling update progress was recorded in %q; use --resume to continue where it stopped.", c.progress.path)
+		}
+		c.emitEvent(RollingUpdateEvent{Type: EventRollingUpdateFailed, Message: err.Error()})
+		return err
+	}
+
+	if err := c.progress.clear(); err != nil {
+		return err
+	}
+
+	c.emitEvent(RollingUpdateEvent{Type: EventRollingUpdateCompleted})
+
+	klog.Infof("Rolling update completed for cluster %q!", c.ClusterName)
+	return nil
+}
+
+func (c *RollingUpdateCluster) rollingUpdateGroups(groups map[string]*cloudinstances.CloudInstanceGroup) error {
 	var resultsMutex sync.Mutex
 	results := make(map[string]error)
 
@@ -180,23 +258,42 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 
 	// Upgrade nodes
 	{
-		// We run nodes in series, even if they are in separate instance groups
+		// By default we run nodes in series, even if they are in separate instance groups
 		// typically they will not being separate instance groups. If you roll the nodes in parallel
 		// you can get into a scenario where you can evict multiple statefulset pods from the same
-		// statefulset at the same time. Further improvements needs to be made to protect from this as
-		// well.
+		// statefulset at the same time, so updating multiple groups at once must be enabled
+		// explicitly. Further improvements needs to be made to protect from this as well.
+		concurrency := c.nodeGroupConcurrency()
+		if concurrency > 1 {
+			klog.Infof("Updating up to %d node instance groups at the same time.", concurrency)
+		}
 
 		for k := range nodeGroups {
 			results[k] = fmt.Errorf("function panic nodes")
 		}
 
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, concurrency)
 		for _, k := range sortGroups(nodeGroups) {
-			err := c.rollingUpdateInstanceGroup(nodeGroups[k], c.NodeInterval)
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(k string) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
 
-			results[k] = err
+				err := c.rollingUpdateInstanceGroup(nodeGroups[k], c.NodeInterval)
 
-			// TODO: Bail on error?
+				resultsMutex.Lock()
+				results[k] = err
+				resultsMutex.Unlock()
+
+				// TODO: Bail on error?
+			}(k)
 		}
+
+		wg.Wait()
 	}
 
 	for _, err := range results {
@@ -205,10 +302,33 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 		}
 	}
 
-	klog.Infof("Rolling update completed for cluster %q!", c.ClusterName)
 	return nil
 }
 
+// nodeGroupConcurrency returns the maximum number of node instance groups to update at the same time
+func (c *RollingUpdateCluster) nodeGroupConcurrency() int {
+	if c.Interactive {
+		return 1
+	}
+
+	concurrency := 1
+	if c.Cluster.Spec.RollingUpdate != nil && c.Cluster.Spec.RollingUpdate.MaxConcurrentInstanceGroups != nil {
+		concurrency = int(*c.Cluster.Spec.RollingUpdate.MaxConcurrentInstanceGroups)
+	}
+	if c.MaxConcurrentInstanceGroups != 0 {
+		concurrency = c.MaxConcurrentInstanceGroups
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > 1 && c.reconcilesInstanceGroups() {
+		// Each drain applies the cluster's configuration, which must not run more than once at a time
+		klog.Warningf("Updating one node instance group at a time, as the %s cloud provider does not support updating several at the same time.", c.Cluster.Spec.CloudProvider)
+		concurrency = 1
+	}
+	return concurrency
+}
+
 func sortGroups(groupMap map[string]*cloudinstances.CloudInstanceGroup) []string {
 	groups := make([]string, 0, len(groupMap))
 	for group := range groupMap {
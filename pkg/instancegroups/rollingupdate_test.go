@@ -17,7 +17,9 @@ limitations under the License.
 package instancegroups
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 	"sync"
@@ -38,10 +40,12 @@ import (
 	testingclient "k8s.io/client-go/testing"
 	"k8s.io/kops/cloudmock/aws/mockautoscaling"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
 	"k8s.io/kops/pkg/cloudinstances"
 	"k8s.io/kops/pkg/validation"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/util/pkg/vfs"
 )
 
 const (
@@ -1503,6 +1507,473 @@ func TestRollingUpdateMaxSurgeAllNeedUpdateMaxAlreadyDetached(t *testing.T) {
 	concurrentTest.AssertComplete()
 }
 
+func addStateStore(c *RollingUpdateCluster) {
+	vfs.Context.ResetMemfsContext(true)
+	basePath, _ := vfs.Context.BuildVfsPath("memfs://tests")
+	c.Clientset = vfsclientset.NewVFSClientset(basePath)
+	c.Cluster.Spec.ConfigBase = "memfs://tests/" + c.Cluster.Name
+}
+
+func writeProgress(t *testing.T, c *RollingUpdateCluster, progress *RollingUpdateProgress) {
+	p, err := progressPath(c.Clientset, c.Cluster)
+	if err != nil {
+		t.Fatalf("error building progress path: %v", err)
+	}
+	tracker := &progressTracker{path: p, progress: *progress}
+	if err := tracker.write(); err != nil {
+		t.Fatalf("error writing progress: %v", err)
+	}
+}
+
+func TestRollingUpdateRecordsProgressOnFailure(t *testing.T) {
+	c, cloud := getTestSetup()
+	addStateStore(c)
+
+	c.ClusterValidator = &failAfterOneNodeClusterValidator{
+		Cloud:       cloud,
+		Group:       "master-1",
+		ReturnError: false,
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	progress, err := ReadRollingUpdateProgress(c.Clientset, c.Cluster)
+	if assert.NoError(t, err, "reading progress") && assert.NotNil(t, progress, "progress") {
+		assert.Equal(t, []string{"bastion-1"}, progress.CompletedGroups, "completed groups")
+		assert.Empty(t, progress.InFlight, "in-flight instances")
+		assert.Empty(t, progress.Detached, "detached instances")
+	}
+}
+
+func TestRollingUpdateClearsProgressOnSuccess(t *testing.T) {
+	c, cloud := getTestSetup()
+	addStateStore(c)
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	progress, err := ReadRollingUpdateProgress(c.Clientset, c.Cluster)
+	assert.NoError(t, err, "reading progress")
+	assert.Nil(t, progress, "progress")
+}
+
+func TestRollingUpdateResumeSkipsCompletedGroups(t *testing.T) {
+	c, cloud := getTestSetup()
+	addStateStore(c)
+
+	writeProgress(t, c, &RollingUpdateProgress{
+		CompletedGroups: []string{"bastion-1", "master-1"},
+	})
+	c.Resume = true
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 2)
+	assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+
+	progress, err := ReadRollingUpdateProgress(c.Clientset, c.Cluster)
+	assert.NoError(t, err, "reading progress")
+	assert.Nil(t, progress, "progress")
+}
+
+func TestRollingUpdateWithoutResumeIgnoresProgress(t *testing.T) {
+	c, cloud := getTestSetup()
+	addStateStore(c)
+
+	writeProgress(t, c, &RollingUpdateProgress{
+		CompletedGroups: []string{"bastion-1", "master-1"},
+	})
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 0)
+	assertGroupInstanceCount(t, cloud, "bastion-1", 0)
+}
+
+func TestRollingUpdateWithoutResumeKeepsDetached(t *testing.T) {
+	c, _ := getTestSetup()
+	addStateStore(c)
+
+	detached := []ProgressInstance{{ID: "node-1z", InstanceGroup: "node-1", NodeName: "node-1z.local"}}
+	writeProgress(t, c, &RollingUpdateProgress{
+		CompletedGroups: []string{"bastion-1", "master-1"},
+		InFlight:        []ProgressInstance{{ID: "node-1a", InstanceGroup: "node-1"}},
+		Detached:        detached,
+	})
+
+	previous, err := c.initProgress()
+	if assert.NoError(t, err, "initProgress") && assert.NotNil(t, previous, "previous progress") {
+		assert.Equal(t, &RollingUpdateProgress{Detached: detached}, previous)
+	}
+
+	progress, err := ReadRollingUpdateProgress(c.Clientset, c.Cluster)
+	if assert.NoError(t, err, "reading progress") && assert.NotNil(t, progress, "progress") {
+		assert.Empty(t, progress.CompletedGroups, "completed groups")
+		assert.Empty(t, progress.InFlight, "in-flight instances")
+		assert.Equal(t, detached, progress.Detached, "detached instances")
+	}
+}
+
+func TestClearRollingUpdateProgress(t *testing.T) {
+	c, _ := getTestSetup()
+	addStateStore(c)
+
+	writeProgress(t, c, &RollingUpdateProgress{CompletedGroups: []string{"bastion-1"}})
+	assert.NoError(t, ClearRollingUpdateProgress(c.Clientset, c.Cluster), "clearing progress")
+	progress, err := ReadRollingUpdateProgress(c.Clientset, c.Cluster)
+	assert.NoError(t, err, "reading progress")
+	assert.Nil(t, progress, "progress")
+
+	assert.NoError(t, ClearRollingUpdateProgress(c.Clientset, c.Cluster), "clearing missing progress")
+}
+
+func TestRollingUpdateResumeWithoutStateStore(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.Resume = true
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 3)
+	assertGroupInstanceCount(t, cloud, "master-1", 2)
+	assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+}
+
+func TestResumeGroupsInFlightAndDetached(t *testing.T) {
+	c, cloud := getTestSetup()
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 2)
+
+	resumed, err := c.resumeGroups(&RollingUpdateProgress{
+		InFlight: []ProgressInstance{
+			{ID: "node-1b", InstanceGroup: "node-1"},
+		},
+		Detached: []ProgressInstance{
+			{ID: "node-1c", InstanceGroup: "node-1"},
+			{ID: "node-1z", InstanceGroup: "node-1"},
+		},
+	}, groups)
+	if !assert.NoError(t, err, "resuming groups") {
+		return
+	}
+
+	group := resumed["node-1"]
+	if assert.Len(t, group.NeedUpdate, 4, "instances needing update") {
+		assert.Equal(t, "node-1b", group.NeedUpdate[0].ID, "in-flight instance is updated first")
+		assert.Equal(t, "node-1a", group.NeedUpdate[1].ID)
+		assert.Equal(t, "node-1c", group.NeedUpdate[2].ID)
+		assert.Equal(t, cloudinstances.CloudInstanceStatusDetached, group.NeedUpdate[2].Status)
+		assert.Equal(t, "node-1z", group.NeedUpdate[3].ID, "missing detached instance is adopted")
+		assert.Equal(t, cloudinstances.CloudInstanceStatusDetached, group.NeedUpdate[3].Status)
+	}
+	assert.Empty(t, group.Ready, "ready instances")
+}
+
+func TestRollingUpdatePlan(t *testing.T) {
+	c, cloud := getTestSetup()
+	surge := intstr.FromInt(2)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{MaxSurge: &surge}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 2, 0)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 1)
+	makeGroup(groups, c.K8sClient, cloud, "master-1", kopsapi.InstanceGroupRoleMaster, 2, 1)
+	makeGroup(groups, c.K8sClient, cloud, "bastion-1", kopsapi.InstanceGroupRoleBastion, 1, 0)
+	addNeedsUpdateAnnotation(groups["node-1"], "node-1c")
+	assert.NoError(t, c.AdjustNeedUpdate(groups), "AdjustNeedUpdate")
+
+	plan := c.Plan(groups)
+	assert.True(t, plan.NeedUpdate, "plan needs update")
+
+	var names []string
+	for _, group := range plan.InstanceGroups {
+		names = append(names, group.Name)
+	}
+	assert.Equal(t, []string{"bastion-1", "master-1", "node-1", "node-2"}, names, "instance group order")
+
+	master := plan.InstanceGroups[1]
+	assert.Equal(t, "NeedsUpdate", master.Status, "master status")
+	assert.Equal(t, 0, master.MaxSurge, "master maxSurge")
+	assert.Equal(t, 1, master.MaxUnavailable, "master maxUnavailable")
+
+	node1 := plan.InstanceGroups[2]
+	assert.Equal(t, 2, node1.MaxSurge, "node-1 maxSurge")
+	assert.Equal(t, 0, node1.MaxUnavailable, "node-1 maxUnavailable")
+	assert.True(t, node1.DrainAndTerminate, "node-1 drainAndTerminate")
+	assert.Equal(t, []*InstancePlan{
+		{ID: "node-1a", NodeName: "node-1a.local", Status: cloudinstances.CloudInstanceStatusNeedsUpdate, NeedUpdate: true, Reason: InstanceUpdateReasonSpecChanged},
+		{ID: "node-1c", NodeName: "node-1c.local", Status: cloudinstances.CloudInstanceStatusNeedsUpdate, NeedUpdate: true, Reason: InstanceUpdateReasonAnnotation},
+		{ID: "node-1b", NodeName: "node-1b.local", Status: cloudinstances.CloudInstanceStatusUpToDate},
+	}, node1.Instances, "node-1 instances")
+
+	assert.Equal(t, "Ready", plan.InstanceGroups[3].Status, "node-2 status")
+
+	c.Force = true
+	plan = c.Plan(groups)
+	assert.Equal(t, "NeedsUpdate", plan.InstanceGroups[3].Status, "node-2 status when forced")
+	assert.Equal(t, InstanceUpdateReasonForced, plan.InstanceGroups[3].Instances[0].Reason, "node-2 reason when forced")
+}
+
+func TestRollingUpdateEventStream(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.ClusterName = "test.k8s.local"
+	var events bytes.Buffer
+	c.EventStream = &events
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 1)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	var types []RollingUpdateEventType
+	decoder := json.NewDecoder(&events)
+	for decoder.More() {
+		var event RollingUpdateEvent
+		if !assert.NoError(t, decoder.Decode(&event), "decoding event") {
+			return
+		}
+		assert.Equal(t, "test.k8s.local", event.Cluster, "event cluster")
+		assert.False(t, event.Time.IsZero(), "event time")
+		switch event.Type {
+		case EventInstanceTainted, EventInstanceDrained, EventInstanceTerminated:
+			assert.Equal(t, "node-1", event.InstanceGroup, "%s instance group", event.Type)
+			assert.Equal(t, "node-1a", event.InstanceID, "%s instance", event.Type)
+			assert.Equal(t, "node-1a.local", event.NodeName, "%s node", event.Type)
+		}
+		types = append(types, event.Type)
+	}
+
+	assert.Equal(t, []RollingUpdateEventType{
+		EventRollingUpdateStarted,
+		EventInstanceGroupStarted,
+		EventValidationPassed,
+		EventInstanceTainted,
+		EventInstanceDrained,
+		EventInstanceTerminated,
+		EventValidationPassed,
+		EventInstanceGroupCompleted,
+		EventRollingUpdateCompleted,
+	}, types, "event types")
+}
+
+func TestRollingUpdateEventStreamFailure(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	c.ClusterValidator = &failingClusterValidator{}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 1)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	var types []RollingUpdateEventType
+	for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+		var event RollingUpdateEvent
+		if !assert.NoError(t, json.Unmarshal([]byte(line), &event), "decoding event") {
+			return
+		}
+		types = append(types, event.Type)
+	}
+
+	assert.Equal(t, []RollingUpdateEventType{
+		EventRollingUpdateStarted,
+		EventInstanceGroupStarted,
+		EventValidationFailed,
+		EventInstanceGroupFailed,
+		EventRollingUpdateFailed,
+	}, types, "event types")
+}
+
+// maxConcurrentGroups returns the largest number of instance groups being updated at the same time, according to the events
+func maxConcurrentGroups(t *testing.T, events *bytes.Buffer) int {
+	active, max := 0, 0
+	decoder := json.NewDecoder(events)
+	for decoder.More() {
+		var event RollingUpdateEvent
+		if !assert.NoError(t, decoder.Decode(&event), "decoding event") {
+			return 0
+		}
+		switch event.Type {
+		case EventInstanceGroupStarted:
+			active++
+			if active > max {
+				max = active
+			}
+		case EventInstanceGroupCompleted, EventInstanceGroupFailed:
+			active--
+		}
+	}
+	return max
+}
+
+func TestRollingUpdateNodeGroupsSequentialByDefault(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 2, 2)
+	makeGroup(groups, c.K8sClient, cloud, "node-3", kopsapi.InstanceGroupRoleNode, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "node-3", 0)
+	assert.Equal(t, 1, maxConcurrentGroups(t, &events), "concurrent instance groups")
+}
+
+// barrierClusterValidator holds up validation until the given number of callers are validating at
+// the same time. As each instance group validates before updating any instances and does not
+// validate concurrently with itself, this holds up the update of each group until that many
+// groups are being updated at the same time.
+type barrierClusterValidator struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	waiting int
+	barrier int
+	release chan struct{}
+}
+
+func newBarrierClusterValidator(t *testing.T, barrier int) *barrierClusterValidator {
+	return &barrierClusterValidator{
+		t:       t,
+		barrier: barrier,
+		release: make(chan struct{}),
+	}
+}
+
+func (v *barrierClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	v.mutex.Lock()
+	v.waiting++
+	if v.waiting == v.barrier {
+		close(v.release)
+	}
+	v.mutex.Unlock()
+
+	select {
+	case <-v.release:
+	case <-time.After(10 * time.Second):
+		v.t.Errorf("fewer than %d instance groups were updated at the same time", v.barrier)
+		return nil, errors.New("timed out waiting for other instance groups")
+	}
+	return &validation.ValidationCluster{}, nil
+}
+
+func TestRollingUpdateConcurrentNodeGroups(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	c.ClusterValidator = newBarrierClusterValidator(t, 2)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxConcurrentInstanceGroups: fi.Int32(2),
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-3", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "node-3", 0)
+	assert.Equal(t, 2, maxConcurrentGroups(t, &events), "concurrent instance groups")
+}
+
+func TestRollingUpdateConcurrentNodeGroupsAfterMasters(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxConcurrentInstanceGroups: fi.Int32(2),
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "master-1", kopsapi.InstanceGroupRoleMaster, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 0)
+	assert.Equal(t, 1, maxConcurrentGroups(t, &events), "concurrent instance groups")
+}
+
+func TestRollingUpdateConcurrentNodeGroupsOverride(t *testing.T) {
+	c, cloud := getTestSetup()
+	var events bytes.Buffer
+	c.EventStream = &events
+	c.ClusterValidator = newBarrierClusterValidator(t, 3)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxConcurrentInstanceGroups: fi.Int32(2),
+	}
+	c.MaxConcurrentInstanceGroups = 3
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-3", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, 3, maxConcurrentGroups(t, &events), "concurrent instance groups")
+}
+
+func TestRollingUpdateConcurrentNodeGroupsInteractive(t *testing.T) {
+	c, _ := getTestSetup()
+	c.MaxConcurrentInstanceGroups = 3
+	c.Interactive = true
+	assert.Equal(t, 1, c.nodeGroupConcurrency(), "interactive concurrency")
+}
+
+func TestRollingUpdateConcurrentNodeGroupsReconciling(t *testing.T) {
+	for _, provider := range []kopsapi.CloudProviderID{kopsapi.CloudProviderOpenstack, kopsapi.CloudProviderDO} {
+		c, _ := getTestSetup()
+		c.Cluster.Spec.CloudProvider = string(provider)
+		c.MaxConcurrentInstanceGroups = 3
+		assert.Equal(t, 1, c.nodeGroupConcurrency(), "%s concurrency", provider)
+	}
+}
+
+func TestRollingUpdateConcurrentNodeGroupsScopesValidation(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.MaxConcurrentInstanceGroups = 3
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-3", kopsapi.InstanceGroupRoleNode, 3, 3)
+
+	// node-2 never validates, which must not hold up the other groups being updated at the same time
+	c.ClusterValidator = &instanceGroupNodeSpecificErrorClusterValidator{
+		InstanceGroup: groups["node-2"].InstanceGroup,
+	}
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 3)
+	assertGroupInstanceCount(t, cloud, "node-3", 0)
+}
+
 func assertCordon(t *testing.T, action testingclient.PatchAction) {
 	assert.Equal(t, "nodes", action.GetResource().Resource)
 	assert.Equal(t, cordonPatch, string(action.GetPatch()))
@@ -39,12 +39,35 @@ func resolveSettings(cluster *kops.Cluster, group *kops.InstanceGroup, numInstan
 		if rollingUpdate.MaxSurge == nil {
 			rollingUpdate.MaxSurge = def.MaxSurge
 		}
+		if rollingUpdate.Hooks == nil {
+			rollingUpdate.Hooks = def.Hooks
+		}
+		if rollingUpdate.Canary == nil {
+			rollingUpdate.Canary = def.Canary
+		}
+		if rollingUpdate.DrainTimeout == nil {
+			rollingUpdate.DrainTimeout = def.DrainTimeout
+		}
+		if rollingUpdate.DrainTimeoutPolicy == "" {
+			rollingUpdate.DrainTimeoutPolicy = def.DrainTimeoutPolicy
+		}
+		if rollingUpdate.DrainRetries == nil {
+			rollingUpdate.DrainRetries = def.DrainRetries
+		}
 	}
 
 	if rollingUpdate.DrainAndTerminate == nil {
 		rollingUpdate.DrainAndTerminate = fi.Bool(true)
 	}
 
+	if rollingUpdate.DrainTimeoutPolicy == "" {
+		rollingUpdate.DrainTimeoutPolicy = kops.DrainTimeoutPolicyFail
+	}
+
+	if rollingUpdate.DrainRetries == nil {
+		rollingUpdate.DrainRetries = fi.Int32(3)
+	}
+
 	if rollingUpdate.MaxSurge == nil {
 		val := intstr.FromInt(0)
 		if kops.CloudProviderID(cluster.Spec.CloudProvider) == kops.CloudProviderAWS && !featureflag.Spotinst.Enabled() {
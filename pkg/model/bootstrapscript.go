@@ -173,6 +173,13 @@ func (b *BootstrapScript) buildEnvironmentVariables(cluster *kops.Cluster) (map[
 		}
 	}
 
+	if kops.CloudProviderID(cluster.Spec.CloudProvider) == kops.CloudProviderHetzner {
+		hcloudToken := os.Getenv("HCLOUD_TOKEN")
+		if hcloudToken != "" {
+			env["HCLOUD_TOKEN"] = hcloudToken
+		}
+	}
+
 	if kops.CloudProviderID(cluster.Spec.CloudProvider) == kops.CloudProviderAWS {
 		region, err := awsup.FindRegion(cluster)
 		if err != nil {
@@ -88,6 +88,9 @@ func (b *KubeControllerManagerOptionsBuilder) BuildOptions(o interface{}) error
 	case kops.CloudProviderDO:
 		kcm.CloudProvider = "external"
 
+	case kops.CloudProviderHetzner:
+		kcm.CloudProvider = "external"
+
 	case kops.CloudProviderOpenstack:
 		kcm.CloudProvider = "openstack"
 
@@ -139,6 +139,10 @@ func (b *KubeletOptionsBuilder) BuildOptions(o interface{}) error {
 		clusterSpec.Kubelet.HostnameOverride = "@digitalocean"
 	}
 
+	if cloudProvider == kops.CloudProviderHetzner {
+		clusterSpec.Kubelet.CloudProvider = "external"
+	}
+
 	if cloudProvider == kops.CloudProviderGCE {
 		clusterSpec.Kubelet.CloudProvider = "gce"
 		clusterSpec.Kubelet.HairpinMode = "promiscuous-bridge"
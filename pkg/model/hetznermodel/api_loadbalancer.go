@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznermodel
+
+import (
+	"fmt"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetznertasks"
+)
+
+// APILoadBalancerModelBuilder builds a LoadBalancer for accessing the API
+type APILoadBalancerModelBuilder struct {
+	*HetznerModelContext
+	Lifecycle fi.Lifecycle
+}
+
+var _ fi.ModelBuilder = &APILoadBalancerModelBuilder{}
+
+func (b *APILoadBalancerModelBuilder) Build(c *fi.ModelBuilderContext) error {
+	// Configuration where a load balancer fronts the API
+	if !b.UseLoadBalancerForAPI() {
+		return nil
+	}
+
+	lbSpec := b.Cluster.Spec.API.LoadBalancer
+	switch lbSpec.Type {
+	case kops.LoadBalancerTypePublic:
+		// OK
+	default:
+		return fmt.Errorf("unhandled LoadBalancer type %q", lbSpec.Type)
+	}
+
+	masters := b.MasterInstanceGroups()
+	if len(masters) == 0 {
+		return fmt.Errorf("no master instance groups found")
+	}
+	zones, err := b.FindZonesForInstanceGroup(masters[0])
+	if err != nil {
+		return err
+	}
+	if len(zones) == 0 {
+		return fmt.Errorf("no zones found for instance group %q", masters[0].Name)
+	}
+
+	selector := b.CloudLabels()
+	selector[hetzner.TagKubernetesInstanceRole] = RoleLabel(kops.InstanceGroupRoleMaster)
+
+	loadBalancer := &hetznertasks.LoadBalancer{
+		Name:      fi.String("api." + b.ClusterName()),
+		Lifecycle: b.Lifecycle,
+		Network:   b.LinkToNetwork(),
+		Location:  zones[0],
+		Type:      "lb11",
+		Services: []*hetznertasks.LoadBalancerService{
+			{
+				Protocol:        "tcp",
+				ListenerPort:    fi.Int(443),
+				DestinationPort: fi.Int(443),
+			},
+		},
+		Target: hetzner.LabelSelector(selector),
+		Labels: b.CloudLabels(),
+	}
+	c.AddTask(loadBalancer)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznermodel
+
+import (
+	"strings"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/model"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetznertasks"
+)
+
+// HetznerModelContext is the Hetzner Cloud model context
+type HetznerModelContext struct {
+	*model.KopsModelContext
+}
+
+// CloudLabels returns the labels of the resources owned by the cluster
+func (b *HetznerModelContext) CloudLabels() map[string]string {
+	return map[string]string{
+		hetzner.TagKubernetesClusterName: b.ClusterName(),
+	}
+}
+
+// RoleLabel returns the value of the instance role label for servers with the role
+func RoleLabel(role kops.InstanceGroupRole) string {
+	return strings.ToLower(string(role))
+}
+
+// LinkToNetwork returns the network of the cluster
+func (b *HetznerModelContext) LinkToNetwork() *hetznertasks.Network {
+	return &hetznertasks.Network{Name: fi.String(b.ClusterName())}
+}
+
+// LinkToSSHKey returns the SSH key of the cluster
+func (b *HetznerModelContext) LinkToSSHKey() *hetznertasks.SSHKey {
+	return &hetznertasks.SSHKey{Name: fi.String(b.ClusterName())}
+}
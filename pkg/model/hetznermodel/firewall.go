@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznermodel
+
+import (
+	"strconv"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetznertasks"
+)
+
+// FirewallModelBuilder configures the firewalls of the public interfaces of the servers.
+// Traffic over the private network is not filtered by Hetzner Cloud firewalls.
+type FirewallModelBuilder struct {
+	*HetznerModelContext
+	Lifecycle fi.Lifecycle
+}
+
+var _ fi.ModelBuilder = &FirewallModelBuilder{}
+
+func (b *FirewallModelBuilder) Build(c *fi.ModelBuilderContext) error {
+	masterFirewall := b.buildFirewall(kops.InstanceGroupRoleMaster)
+	masterFirewall.Rules = append(masterFirewall.Rules, &hetznertasks.FirewallRule{
+		SourceIPs: b.Cluster.Spec.SSHAccess,
+		Protocol:  "tcp",
+		Port:      fi.String("22"),
+	})
+	masterFirewall.Rules = append(masterFirewall.Rules, &hetznertasks.FirewallRule{
+		SourceIPs: b.Cluster.Spec.KubernetesAPIAccess,
+		Protocol:  "tcp",
+		Port:      fi.String("443"),
+	})
+	c.AddTask(masterFirewall)
+
+	nodeFirewall := b.buildFirewall(kops.InstanceGroupRoleNode)
+	nodeFirewall.Rules = append(nodeFirewall.Rules, &hetznertasks.FirewallRule{
+		SourceIPs: b.Cluster.Spec.SSHAccess,
+		Protocol:  "tcp",
+		Port:      fi.String("22"),
+	})
+	if len(b.Cluster.Spec.NodePortAccess) > 0 {
+		nodePortRange, err := b.NodePortRange()
+		if err != nil {
+			return err
+		}
+		nodeFirewall.Rules = append(nodeFirewall.Rules, &hetznertasks.FirewallRule{
+			SourceIPs: b.Cluster.Spec.NodePortAccess,
+			Protocol:  "tcp",
+			Port:      fi.String(strconv.Itoa(nodePortRange.Base) + "-" + strconv.Itoa(nodePortRange.Base+nodePortRange.Size-1)),
+		})
+	}
+	c.AddTask(nodeFirewall)
+
+	return nil
+}
+
+// buildFirewall returns a firewall without rules, applied to the servers with the role
+func (b *FirewallModelBuilder) buildFirewall(role kops.InstanceGroupRole) *hetznertasks.Firewall {
+	selector := b.CloudLabels()
+	selector[hetzner.TagKubernetesInstanceRole] = RoleLabel(role)
+
+	labels := b.CloudLabels()
+	labels[hetzner.TagKubernetesFirewallRole] = RoleLabel(role)
+
+	return &hetznertasks.Firewall{
+		Name:      fi.String(RoleLabel(role) + "." + b.ClusterName()),
+		Lifecycle: b.Lifecycle,
+		Selector:  hetzner.LabelSelector(selector),
+		Labels:    labels,
+	}
+}
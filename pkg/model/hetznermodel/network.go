@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznermodel
+
+import (
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetznertasks"
+)
+
+// NetworkModelBuilder configures the private network of the cluster
+type NetworkModelBuilder struct {
+	*HetznerModelContext
+	Lifecycle fi.Lifecycle
+}
+
+var _ fi.ModelBuilder = &NetworkModelBuilder{}
+
+func (b *NetworkModelBuilder) Build(c *fi.ModelBuilderContext) error {
+	region, err := hetzner.FindRegion(b.Cluster)
+	if err != nil {
+		return err
+	}
+
+	network := &hetznertasks.Network{
+		Name:      fi.String(b.ClusterName()),
+		Lifecycle: b.Lifecycle,
+		Region:    region,
+		IPRange:   b.Cluster.Spec.NetworkCIDR,
+		Labels:    b.CloudLabels(),
+	}
+	for _, subnet := range b.Cluster.Spec.Subnets {
+		network.Subnets = append(network.Subnets, subnet.CIDR)
+	}
+	c.AddTask(network)
+
+	return nil
+}
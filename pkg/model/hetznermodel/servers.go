@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznermodel
+
+import (
+	"fmt"
+
+	"k8s.io/kops/pkg/model"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetznertasks"
+)
+
+// ServerGroupModelBuilder configures the SSH key and the servers of the instance groups
+type ServerGroupModelBuilder struct {
+	*HetznerModelContext
+
+	BootstrapScriptBuilder *model.BootstrapScriptBuilder
+	Lifecycle              fi.Lifecycle
+}
+
+var _ fi.ModelBuilder = &ServerGroupModelBuilder{}
+
+func (b *ServerGroupModelBuilder) Build(c *fi.ModelBuilderContext) error {
+	var sshKeys []*hetznertasks.SSHKey
+	if len(b.SSHPublicKeys) > 0 {
+		sshKey := &hetznertasks.SSHKey{
+			Name:      fi.String(b.ClusterName()),
+			Lifecycle: b.Lifecycle,
+			PublicKey: string(b.SSHPublicKeys[0]),
+			Labels:    b.CloudLabels(),
+		}
+		c.AddTask(sshKey)
+		sshKeys = append(sshKeys, sshKey)
+	}
+
+	for _, ig := range b.InstanceGroups {
+		zones, err := b.FindZonesForInstanceGroup(ig)
+		if err != nil {
+			return err
+		}
+		if len(zones) != 1 {
+			return fmt.Errorf("instance group %q must be in exactly one location, found %d", ig.Name, len(zones))
+		}
+
+		userData, err := b.BootstrapScriptBuilder.ResourceNodeUp(c, ig)
+		if err != nil {
+			return err
+		}
+
+		labels := b.CloudLabels()
+		labels[hetzner.TagKubernetesInstanceGroup] = ig.Name
+		labels[hetzner.TagKubernetesInstanceRole] = RoleLabel(ig.Spec.Role)
+
+		serverGroup := &hetznertasks.ServerGroup{
+			Name:      fi.String(ig.Name),
+			Lifecycle: b.Lifecycle,
+			SSHKeys:   sshKeys,
+			Network:   b.LinkToNetwork(),
+			Count:     int(fi.Int32Value(ig.Spec.MinSize)),
+			Location:  zones[0],
+			Size:      ig.Spec.MachineType,
+			Image:     ig.Spec.Image,
+			UserData:  userData,
+			Labels:    labels,
+		}
+		c.AddTask(serverGroup)
+	}
+
+	return nil
+}
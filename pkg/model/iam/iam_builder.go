@@ -38,6 +38,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/model"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/pkg/util/stringorslice"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awstasks"
@@ -279,6 +280,7 @@ func (r *NodeRoleAPIServer) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
 	}
+	addStateStoreEncryptionPermissions(p, b.Cluster)
 
 	if b.KMSKeys != nil && len(b.KMSKeys) != 0 {
 		addKMSIAMPolicies(p, stringorslice.Slice(b.KMSKeys))
@@ -322,6 +324,7 @@ func (r *NodeRoleMaster) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
 	}
+	addStateStoreEncryptionPermissions(p, b.Cluster)
 
 	if b.KMSKeys != nil && len(b.KMSKeys) != 0 {
 		addKMSIAMPolicies(p, stringorslice.Slice(b.KMSKeys))
@@ -379,6 +382,7 @@ func (r *NodeRoleNode) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
 	}
+	addStateStoreEncryptionPermissions(p, b.Cluster)
 
 	if b.Cluster.Spec.IAM.AllowContainerRegistry {
 		addECRPermissions(p)
@@ -1077,6 +1081,20 @@ func addKMSIAMPolicies(p *Policy, resource stringorslice.StringOrSlice) {
 	)
 }
 
+// addStateStoreEncryptionPermissions allows decrypting the secrets and private keys in the state store,
+// if they are encrypted with an AWS KMS key
+func addStateStoreEncryptionPermissions(p *Policy, cluster *kops.Cluster) {
+	encryption := cluster.Spec.StateStoreEncryption
+	if encryption == nil || encryption.Provider != envelope.ProviderAWSKMS {
+		return
+	}
+	p.Statement = append(p.Statement, &Statement{
+		Effect:   StatementEffectAllow,
+		Action:   stringorslice.Of("kms:Decrypt"),
+		Resource: stringorslice.Of(encryption.Key),
+	})
+}
+
 func addKMSGenerateRandomPolicies(p *Policy) {
 	// For nodeup to seed the instance's random number generator.
 	p.unconditionalAction.Insert(
@@ -107,11 +107,38 @@ func (i *nodeIdentifier) IdentifyNode(ctx context.Context, node *corev1.Node) (*
 		return nil, fmt.Errorf("found instance %q, but status is %q", instanceName, instanceStatus)
 	}
 
+	instanceTemplate, err := i.getManagedInstanceTemplate(zone, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	igName := GetMetadataValue(instanceTemplate.Properties.Metadata, MetadataKeyInstanceGroupName)
+	if igName == "" {
+		return nil, fmt.Errorf("ig name not set on instance template %s", instanceTemplate.Name)
+	}
+
+	info := &nodeidentity.LegacyInfo{}
+	info.InstanceGroup = igName
+	return info, nil
+}
+
+// InstanceTemplateForInstance returns the instance template of the managed instance group that manages the instance.
+// Unlike the metadata of the instance, the instance template can't be changed from the instance.
+func InstanceTemplateForInstance(computeService *compute.Service, project string, zone string, instance *compute.Instance) (*compute.InstanceTemplate, error) {
+	i := &nodeIdentifier{
+		computeService: computeService,
+		project:        project,
+	}
+	return i.getManagedInstanceTemplate(zone, instance)
+}
+
+// getManagedInstanceTemplate queries GCE for the instance template of the MIG managing the instance
+func (i *nodeIdentifier) getManagedInstanceTemplate(zone string, instance *compute.Instance) (*compute.InstanceTemplate, error) {
 	// The metadata itself is potentially mutable from the instance
 	// We instead look at the MIG configuration
-	createdBy := getMetadataValue(instance.Metadata, "created-by")
+	createdBy := GetMetadataValue(instance.Metadata, "created-by")
 	if createdBy == "" {
-		return nil, fmt.Errorf("instance %q did not have created-by metadata label set", instanceName)
+		return nil, fmt.Errorf("instance %q did not have created-by metadata label set", instance.Name)
 	}
 
 	// We need to double-check the MIG configuration, in case created-by was changed
@@ -133,19 +160,7 @@ func (i *nodeIdentifier) IdentifyNode(ctx context.Context, node *corev1.Node) (*
 		return nil, fmt.Errorf("instance %s did not have Version set", instance.Name)
 	}
 
-	instanceTemplate, err := i.getInstanceTemplate(lastComponent(migMember.Version.InstanceTemplate))
-	if err != nil {
-		return nil, err
-	}
-
-	igName := getMetadataValue(instanceTemplate.Properties.Metadata, MetadataKeyInstanceGroupName)
-	if igName == "" {
-		return nil, fmt.Errorf("ig name not set on instance template %s", instanceTemplate.Name)
-	}
-
-	info := &nodeidentity.LegacyInfo{}
-	info.InstanceGroup = igName
-	return info, nil
+	return i.getInstanceTemplate(lastComponent(migMember.Version.InstanceTemplate))
 }
 
 // getInstance queries GCE for the instance with the specified name, returning an error if not found
@@ -218,7 +233,8 @@ func lastComponent(s string) string {
 	return s
 }
 
-func getMetadataValue(metadata *compute.Metadata, key string) string {
+// GetMetadataValue returns the value of the metadata item with the key, or the empty string if it is not set
+func GetMetadataValue(metadata *compute.Metadata, key string) string {
 	value := ""
 	if metadata != nil {
 		for _, item := range metadata.Items {
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kops"
+	"k8s.io/kops/pkg/nodeidentity"
+)
+
+const (
+	providerIDPrefix         = "hcloud://"
+	serverLabelInstanceGroup = "kops.k8s.io/instance-group"
+)
+
+// nodeIdentifier identifies a node from Hetzner Cloud
+type nodeIdentifier struct {
+	client *hcloud.Client
+}
+
+// New creates and returns a nodeidentity.LegacyIdentifier for Nodes running on Hetzner Cloud,
+// expecting the env var HCLOUD_TOKEN
+func New() (nodeidentity.LegacyIdentifier, error) {
+	accessToken := os.Getenv("HCLOUD_TOKEN")
+	if accessToken == "" {
+		return nil, errors.New("HCLOUD_TOKEN is required")
+	}
+
+	client := hcloud.NewClient(
+		hcloud.WithToken(accessToken),
+		hcloud.WithApplication("kops", kops.Version),
+	)
+
+	return &nodeIdentifier{
+		client: client,
+	}, nil
+}
+
+// IdentifyNode queries Hetzner Cloud for the node identity information
+func (i *nodeIdentifier) IdentifyNode(ctx context.Context, node *corev1.Node) (*nodeidentity.LegacyInfo, error) {
+	providerID := node.Spec.ProviderID
+	if providerID == "" {
+		return nil, errors.New("provider ID cannot be empty")
+	}
+
+	if !strings.HasPrefix(providerID, providerIDPrefix) {
+		return nil, fmt.Errorf("provider ID %q is missing prefix %q", providerID, providerIDPrefix)
+	}
+
+	serverID, err := strconv.Atoi(strings.TrimPrefix(providerID, providerIDPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert provider ID %q: %v", providerID, err)
+	}
+
+	server, _, err := i.client.Server.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server %d: %v", serverID, err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server %d not found", serverID)
+	}
+
+	instanceGroup, ok := server.Labels[serverLabelInstanceGroup]
+	if !ok {
+		return nil, fmt.Errorf("could not find label %q on server %d", serverLabelInstanceGroup, serverID)
+	}
+
+	info := &nodeidentity.LegacyInfo{}
+	info.InstanceGroup = instanceGroup
+
+	return info, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/metadata"
+)
+
+// maxObjectsReported is the number of objects named in the report of a removed API
+const maxObjectsReported = 5
+
+// removedAPI is an API version of a resource that is removed in a Kubernetes version
+type removedAPI struct {
+	gvr       schema.GroupVersionResource
+	removedIn string
+	// replacement is the API version that replaces it
+	replacement string
+}
+
+func newRemovedAPI(group, version, resource, removedIn, replacement string) removedAPI {
+	return removedAPI{
+		gvr:         schema.GroupVersionResource{Group: group, Version: version, Resource: resource},
+		removedIn:   removedIn,
+		replacement: replacement,
+	}
+}
+
+// removedAPIs are the API versions of resources removed from Kubernetes, from the Kubernetes deprecated API migration guide
+var removedAPIs = []removedAPI{
+	newRemovedAPI("extensions", "v1beta1", "daemonsets", "1.16", "apps/v1"),
+	newRemovedAPI("extensions", "v1beta1", "deployments", "1.16", "apps/v1"),
+	newRemovedAPI("extensions", "v1beta1", "replicasets", "1.16", "apps/v1"),
+	newRemovedAPI("extensions", "v1beta1", "networkpolicies", "1.16", "networking.k8s.io/v1"),
+	newRemovedAPI("extensions", "v1beta1", "podsecuritypolicies", "1.16", "policy/v1beta1"),
+	newRemovedAPI("apps", "v1beta1", "deployments", "1.16", "apps/v1"),
+	newRemovedAPI("apps", "v1beta1", "statefulsets", "1.16", "apps/v1"),
+	newRemovedAPI("apps", "v1beta2", "daemonsets", "1.16", "apps/v1"),
+	newRemovedAPI("apps", "v1beta2", "deployments", "1.16", "apps/v1"),
+	newRemovedAPI("apps", "v1beta2", "replicasets", "1.16", "apps/v1"),
+	newRemovedAPI("apps", "v1beta2", "statefulsets", "1.16", "apps/v1"),
+
+	newRemovedAPI("admissionregistration.k8s.io", "v1beta1", "mutatingwebhookconfigurations", "1.22", "admissionregistration.k8s.io/v1"),
+	newRemovedAPI("admissionregistration.k8s.io", "v1beta1", "validatingwebhookconfigurations", "1.22", "admissionregistration.k8s.io/v1"),
+	newRemovedAPI("apiextensions.k8s.io", "v1beta1", "customresourcedefinitions", "1.22", "apiextensions.k8s.io/v1"),
+	newRemovedAPI("apiregistration.k8s.io", "v1beta1", "apiservices", "1.22", "apiregistration.k8s.io/v1"),
+	newRemovedAPI("certificates.k8s.io", "v1beta1", "certificatesigningrequests", "1.22", "certificates.k8s.io/v1"),
+	newRemovedAPI("coordination.k8s.io", "v1beta1", "leases", "1.22", "coordination.k8s.io/v1"),
+	newRemovedAPI("extensions", "v1beta1", "ingresses", "1.22", "networking.k8s.io/v1"),
+	newRemovedAPI("networking.k8s.io", "v1beta1", "ingresses", "1.22", "networking.k8s.io/v1"),
+	newRemovedAPI("networking.k8s.io", "v1beta1", "ingressclasses", "1.22", "networking.k8s.io/v1"),
+	newRemovedAPI("rbac.authorization.k8s.io", "v1beta1", "clusterroles", "1.22", "rbac.authorization.k8s.io/v1"),
+	newRemovedAPI("rbac.authorization.k8s.io", "v1beta1", "clusterrolebindings", "1.22", "rbac.authorization.k8s.io/v1"),
+	newRemovedAPI("rbac.authorization.k8s.io", "v1beta1", "roles", "1.22", "rbac.authorization.k8s.io/v1"),
+	newRemovedAPI("rbac.authorization.k8s.io", "v1beta1", "rolebindings", "1.22", "rbac.authorization.k8s.io/v1"),
+	newRemovedAPI("scheduling.k8s.io", "v1beta1", "priorityclasses", "1.22", "scheduling.k8s.io/v1"),
+	newRemovedAPI("storage.k8s.io", "v1beta1", "csidrivers", "1.22", "storage.k8s.io/v1"),
+	newRemovedAPI("storage.k8s.io", "v1beta1", "csinodes", "1.22", "storage.k8s.io/v1"),
+	newRemovedAPI("storage.k8s.io", "v1beta1", "storageclasses", "1.22", "storage.k8s.io/v1"),
+	newRemovedAPI("storage.k8s.io", "v1beta1", "volumeattachments", "1.22", "storage.k8s.io/v1"),
+
+	newRemovedAPI("batch", "v1beta1", "cronjobs", "1.25", "batch/v1"),
+	newRemovedAPI("discovery.k8s.io", "v1beta1", "endpointslices", "1.25", "discovery.k8s.io/v1"),
+	newRemovedAPI("events.k8s.io", "v1beta1", "events", "1.25", "events.k8s.io/v1"),
+	newRemovedAPI("autoscaling", "v2beta1", "horizontalpodautoscalers", "1.25", "autoscaling/v2"),
+	newRemovedAPI("policy", "v1beta1", "poddisruptionbudgets", "1.25", "policy/v1"),
+	newRemovedAPI("policy", "v1beta1", "podsecuritypolicies", "1.25", "Pod Security Admission"),
+	newRemovedAPI("node.k8s.io", "v1beta1", "runtimeclasses", "1.25", "node.k8s.io/v1"),
+
+	newRemovedAPI("flowcontrol.apiserver.k8s.io", "v1beta1", "flowschemas", "1.26", "flowcontrol.apiserver.k8s.io/v1beta2"),
+	newRemovedAPI("flowcontrol.apiserver.k8s.io", "v1beta1", "prioritylevelconfigurations", "1.26", "flowcontrol.apiserver.k8s.io/v1beta2"),
+	newRemovedAPI("autoscaling", "v2beta2", "horizontalpodautoscalers", "1.26", "autoscaling/v2"),
+}
+
+// APIUsageFinder finds the objects in the live cluster that are written with an API version
+type APIUsageFinder interface {
+	// FindObjectsWrittenWith returns the names of the objects of the resource that were written with its API version.
+	// served is false if the API server does not serve the API version of the resource.
+	FindObjectsWrittenWith(ctx context.Context, gvr schema.GroupVersionResource) (names []string, served bool, err error)
+}
+
+// checkAPIs checks the live cluster for objects written with API versions that are removed by the upgrade
+func checkAPIs(ctx context.Context, finder APIUsageFinder, current semver.Version, target semver.Version) []*Check {
+	c := &checker{category: CategoryAPIs}
+
+	if finder == nil {
+		c.add("", ResultWarn, "the live cluster was not checked for objects using removed APIs")
+		return c.checks
+	}
+
+	for _, api := range removedAPIs {
+		// APIs removed before the current version cannot be in use, and APIs removed after the target version are not removed by the upgrade
+		if isMinorGTE(current, api.removedIn) || !isMinorGTE(target, api.removedIn) {
+			continue
+		}
+
+		name := api.gvr.GroupVersion().String() + " " + api.gvr.Resource
+		names, served, err := finder.FindObjectsWrittenWith(ctx, api.gvr)
+		if err != nil {
+			c.add(name, ResultWarn, "unable to check %s: %v", api.gvr.Resource, err)
+			continue
+		}
+		if !served || len(names) == 0 {
+			continue
+		}
+
+		objects := strings.Join(names, ", ")
+		if len(names) > maxObjectsReported {
+			objects = fmt.Sprintf("%s and %d more", strings.Join(names[:maxObjectsReported], ", "), len(names)-maxObjectsReported)
+		}
+		c.add(name, ResultFail, "%s is removed in Kubernetes %s; migrate the manifests of %s to %s", name, api.removedIn, objects, api.replacement)
+	}
+
+	c.passIfEmpty("no objects in the live cluster were written with APIs removed in %d.%d", target.Major, target.Minor)
+	return c.checks
+}
+
+// kubernetesAPIUsageFinder finds the objects written with an API version through the Kubernetes API.
+// An object is considered to be written with an API version if a manager of its fields,
+// or the last configuration applied with kubectl, used that API version.
+type kubernetesAPIUsageFinder struct {
+	discovery discovery.DiscoveryInterface
+	metadata  metadata.Interface
+}
+
+var _ APIUsageFinder = &kubernetesAPIUsageFinder{}
+
+// NewKubernetesAPIUsageFinder builds an APIUsageFinder for a live cluster
+func NewKubernetesAPIUsageFinder(discovery discovery.DiscoveryInterface, metadata metadata.Interface) APIUsageFinder {
+	return &kubernetesAPIUsageFinder{
+		discovery: discovery,
+		metadata:  metadata,
+	}
+}
+
+func (f *kubernetesAPIUsageFinder) FindObjectsWrittenWith(ctx context.Context, gvr schema.GroupVersionResource) ([]string, bool, error) {
+	apiVersion := gvr.GroupVersion().String()
+
+	resources, err := f.discovery.ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("error discovering resources of %s: %v", apiVersion, err)
+	}
+	served := false
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			served = true
+		}
+	}
+	if !served {
+		return nil, false, nil
+	}
+
+	list, err := f.metadata.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, true, fmt.Errorf("error listing %s: %v", gvr.Resource, err)
+	}
+
+	var names []string
+	for i := range list.Items {
+		item := &list.Items[i].ObjectMeta
+		if writtenWith(item, apiVersion) {
+			name := item.Name
+			if item.Namespace != "" {
+				name = item.Namespace + "/" + item.Name
+			}
+			names = append(names, name)
+		}
+	}
+	return names, true, nil
+}
+
+// writtenWith returns true if a manager of the fields of the object, or the last configuration applied with kubectl, used the API version
+func writtenWith(meta *metav1.ObjectMeta, apiVersion string) bool {
+	for _, managedFields := range meta.ManagedFields {
+		if managedFields.APIVersion == apiVersion {
+			return true
+		}
+	}
+
+	if lastApplied := meta.Annotations[corev1.LastAppliedConfigAnnotation]; lastApplied != "" {
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal([]byte(lastApplied), &typeMeta); err == nil && typeMeta.APIVersion == apiVersion {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/validation"
+	"k8s.io/kops/util/pkg/distributions"
+)
+
+// checkValidation validates the cluster spec with the target version.
+// Errors that do not depend on the version are reported as warnings.
+func checkValidation(cluster *kops.Cluster, target semver.Version) []*Check {
+	c := &checker{category: CategoryValidation}
+
+	currentErrs := sets.NewString()
+	for _, err := range validation.ValidateCluster(cluster, false) {
+		currentErrs.Insert(err.Error())
+	}
+
+	upgraded := cluster.DeepCopy()
+	upgraded.Spec.KubernetesVersion = target.String()
+	for _, err := range validation.ValidateCluster(upgraded, false) {
+		if currentErrs.Has(err.Error()) {
+			c.add(err.Field, ResultWarn, "the cluster spec is not valid regardless of the upgrade: %s", err.ErrorBody())
+		} else {
+			c.add(err.Field, ResultFail, "the cluster spec is not valid for %s: %s", target, err.ErrorBody())
+		}
+	}
+
+	c.passIfEmpty("the cluster spec is valid for %s", target)
+	return c.checks
+}
+
+// cniSupport is the newest Kubernetes version supported by a minor version of a CNI
+type cniSupport struct {
+	cni     string
+	version string
+	// maxKubernetesVersion is the newest minor Kubernetes version the CNI version supports
+	maxKubernetesVersion string
+}
+
+var cniSupportMatrix = []cniSupport{
+	{cni: "cilium", version: "1.8", maxKubernetesVersion: "1.18"},
+	{cni: "cilium", version: "1.9", maxKubernetesVersion: "1.19"},
+	{cni: "cilium", version: "1.10", maxKubernetesVersion: "1.21"},
+	{cni: "calico", version: "3.16", maxKubernetesVersion: "1.18"},
+	{cni: "calico", version: "3.17", maxKubernetesVersion: "1.19"},
+	{cni: "calico", version: "3.18", maxKubernetesVersion: "1.20"},
+	{cni: "calico", version: "3.19", maxKubernetesVersion: "1.21"},
+}
+
+// checkNetworking checks that the CNI versions set in the cluster spec support the target version.
+// The versions kOps uses when the cluster spec does not set one are assumed to be supported.
+func checkNetworking(cluster *kops.Cluster, target semver.Version) []*Check {
+	c := &checker{category: CategoryNetworking}
+
+	networking := cluster.Spec.Networking
+	if networking != nil {
+		if networking.Cilium != nil && networking.Cilium.Version != "" {
+			checkCNIVersion(c, "cilium", networking.Cilium.Version, target)
+		}
+		if networking.Calico != nil && networking.Calico.Version != "" {
+			checkCNIVersion(c, "calico", networking.Calico.Version, target)
+		}
+	}
+
+	c.passIfEmpty("the networking uses the CNI versions bundled with kOps")
+	return c.checks
+}
+
+func checkCNIVersion(c *checker, cni string, version string, target semver.Version) {
+	name := cni + " " + version
+	sv, err := semver.ParseTolerant(version)
+	if err != nil {
+		c.add(name, ResultWarn, "unable to parse %s version %q: %v", cni, version, err)
+		return
+	}
+
+	minor := fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
+	for _, support := range cniSupportMatrix {
+		if support.cni != cni || support.version != minor {
+			continue
+		}
+		if isMinorNewer(target, support.maxKubernetesVersion) {
+			c.add(name, ResultFail, "%s %s supports Kubernetes up to %s", cni, version, support.maxKubernetesVersion)
+		} else {
+			c.add(name, ResultPass, "%s %s supports Kubernetes %d.%d", cni, version, target.Major, target.Minor)
+		}
+		return
+	}
+	c.add(name, ResultWarn, "unable to determine whether %s %s supports Kubernetes %d.%d", cni, version, target.Major, target.Minor)
+}
+
+// kopeioImageVersion matches the Kubernetes version the kope.io images were built for
+var kopeioImageVersion = regexp.MustCompile(`^kope\.io/k8s-(\d+\.\d+)-`)
+
+// checkDistributions checks that the distributions of the images of the instance groups are supported
+func checkDistributions(instanceGroups []*kops.InstanceGroup, target semver.Version) []*Check {
+	c := &checker{category: CategoryDistributions}
+
+	for _, ig := range instanceGroups {
+		image := ig.Spec.Image
+		if image == "" {
+			continue
+		}
+		name := "InstanceGroup/" + ig.ObjectMeta.Name
+
+		if match := kopeioImageVersion.FindStringSubmatch(image); match != nil && isMinorNewer(target, match[1]) {
+			c.add(name, ResultWarn, "image %q is built for Kubernetes %s; kope.io images are deprecated", image, match[1])
+			continue
+		}
+
+		distribution, found := distributions.FindDistributionForImage(image)
+		switch {
+		case !found:
+			c.add(name, ResultWarn, "unable to determine the distribution of image %q", image)
+		case distribution.IsRemoved():
+			c.add(name, ResultFail, "the distribution of image %q is no longer supported", image)
+		case distribution.IsDeprecated():
+			c.add(name, ResultWarn, "support for the distribution of image %q is deprecated", image)
+		}
+	}
+
+	c.passIfEmpty("the distributions of the images are supported")
+	return c.checks
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// Components whose flags are checked, named after the fields of the cluster spec
+const (
+	componentKubeAPIServer         = "kubeAPIServer"
+	componentKubelet               = "kubelet"
+	componentKubeControllerManager = "kubeControllerManager"
+)
+
+// flagDeprecation is a flag of a component that is deprecated or removed in a Kubernetes version
+type flagDeprecation struct {
+	component string
+	// flag is the name of the flag, as in the flag tags of the componentconfig structs
+	flag         string
+	deprecatedIn string
+	// removedIn is the version in which the flag is removed, or empty if it is not yet scheduled for removal
+	removedIn   string
+	replacement string
+}
+
+var flagDeprecations = []flagDeprecation{
+	{component: componentKubeAPIServer, flag: "insecure-port", deprecatedIn: "1.20", removedIn: "1.24", replacement: "the secure port"},
+	{component: componentKubeAPIServer, flag: "insecure-bind-address", deprecatedIn: "1.20", removedIn: "1.24", replacement: "bindAddress"},
+	{component: componentKubeAPIServer, flag: "address", deprecatedIn: "1.20", removedIn: "1.24", replacement: "bindAddress"},
+	{component: componentKubeAPIServer, flag: "admission-control", deprecatedIn: "1.10", replacement: "enableAdmissionPlugins"},
+	{component: componentKubeAPIServer, flag: "experimental-encryption-provider-config", deprecatedIn: "1.13", removedIn: "1.14", replacement: "encryptionProviderConfig"},
+	{component: componentKubelet, flag: "network-plugin", deprecatedIn: "1.20", removedIn: "1.24", replacement: "the CNI configuration of the container runtime"},
+	{component: componentKubelet, flag: "network-plugin-mtu", deprecatedIn: "1.20", removedIn: "1.24", replacement: "the CNI configuration of the container runtime"},
+	{component: componentKubelet, flag: "image-pull-progress-deadline", deprecatedIn: "1.20", removedIn: "1.24"},
+	{component: componentKubelet, flag: "enable-cadvisor-json-endpoints", deprecatedIn: "1.18", removedIn: "1.21"},
+	{component: componentKubelet, flag: "seccomp-profile-root", deprecatedIn: "1.19"},
+	{component: componentKubelet, flag: "register-schedulable", deprecatedIn: "1.10", replacement: "taints"},
+	{component: componentKubeControllerManager, flag: "experimental-cluster-signing-duration", deprecatedIn: "1.19", removedIn: "1.25", replacement: "clusterSigningDuration"},
+}
+
+// checkFlags checks the flags set in the component configs of the cluster spec and the instance groups
+func checkFlags(cluster *kops.Cluster, instanceGroups []*kops.InstanceGroup, target semver.Version) []*Check {
+	c := &checker{category: CategoryFlags}
+
+	configs := []struct {
+		path      string
+		component string
+		config    interface{}
+	}{
+		{"spec.kubeAPIServer", componentKubeAPIServer, cluster.Spec.KubeAPIServer},
+		{"spec.kubelet", componentKubelet, cluster.Spec.Kubelet},
+		{"spec.masterKubelet", componentKubelet, cluster.Spec.MasterKubelet},
+		{"spec.kubeControllerManager", componentKubeControllerManager, cluster.Spec.KubeControllerManager},
+	}
+	for _, ig := range instanceGroups {
+		configs = append(configs, struct {
+			path      string
+			component string
+			config    interface{}
+		}{"InstanceGroup/" + ig.ObjectMeta.Name + " spec.kubelet", componentKubelet, ig.Spec.Kubelet})
+	}
+
+	for _, config := range configs {
+		for _, deprecation := range flagDeprecations {
+			if deprecation.component != config.component || !isMinorGTE(target, deprecation.deprecatedIn) {
+				continue
+			}
+			field, set := flagField(config.config, deprecation.flag)
+			if !set {
+				continue
+			}
+
+			name := config.path + "." + field
+			suffix := ""
+			if deprecation.replacement != "" {
+				suffix = "; use " + deprecation.replacement + " instead"
+			}
+			if deprecation.removedIn != "" && isMinorGTE(target, deprecation.removedIn) {
+				c.add(name, ResultFail, "%s flag --%s was removed in Kubernetes %s%s", config.component, deprecation.flag, deprecation.removedIn, suffix)
+			} else {
+				c.add(name, ResultWarn, "%s flag --%s is deprecated since Kubernetes %s%s", config.component, deprecation.flag, deprecation.deprecatedIn, suffix)
+			}
+		}
+	}
+
+	c.passIfEmpty("no deprecated or removed flags are set")
+	return c.checks
+}
+
+// flagField returns the json name of the field of the component config with the flag, and whether it is set
+func flagField(config interface{}, flag string) (string, bool) {
+	v := reflect.ValueOf(config)
+	if !v.IsValid() || v.IsNil() {
+		return "", false
+	}
+	v = v.Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("flag") != flag {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		return name, !v.Field(i).IsZero()
+	}
+	return "", false
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight checks whether a cluster can be upgraded to a Kubernetes version,
+// reporting the parts of the cluster spec and the live cluster that are not compatible with it.
+package preflight
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/util"
+)
+
+// Result is the result of a check
+type Result string
+
+const (
+	// ResultPass means the check found no problem
+	ResultPass Result = "Pass"
+	// ResultWarn means the check found a problem that does not prevent the upgrade, e.g. a deprecation
+	ResultWarn Result = "Warn"
+	// ResultFail means the check found a problem that must be fixed before the upgrade
+	ResultFail Result = "Fail"
+)
+
+// severity orders the results from best to worst
+var severity = map[Result]int{
+	ResultPass: 0,
+	ResultWarn: 1,
+	ResultFail: 2,
+}
+
+// Categories of checks
+const (
+	CategoryVersion       = "Version"
+	CategoryValidation    = "Validation"
+	CategoryFlags         = "Flags"
+	CategoryAPIs          = "APIs"
+	CategoryNetworking    = "Networking"
+	CategoryDistributions = "Distributions"
+)
+
+// Check is the result of a check of the cluster
+type Check struct {
+	Category string `json:"category"`
+	// Name identifies what was checked, e.g. a flag or an API
+	Name    string `json:"name"`
+	Result  Result `json:"result"`
+	Message string `json:"message"`
+}
+
+// Report is the result of the preflight checks of the upgrade of a cluster
+type Report struct {
+	Cluster        string `json:"cluster"`
+	CurrentVersion string `json:"currentVersion"`
+	TargetVersion  string `json:"targetVersion"`
+	// Result is the worst result of the checks
+	Result Result   `json:"result"`
+	Checks []*Check `json:"checks"`
+}
+
+// Options are the inputs of the preflight checks
+type Options struct {
+	Cluster        *kops.Cluster
+	InstanceGroups []*kops.InstanceGroup
+	// TargetVersion is the Kubernetes version the cluster is to be upgraded to
+	TargetVersion semver.Version
+	// APIUsage finds the objects written with removed APIs in the live cluster; the live cluster is not checked if nil
+	APIUsage APIUsageFinder
+}
+
+// checker accumulates the checks of a category
+type checker struct {
+	category string
+	checks   []*Check
+}
+
+func (c *checker) add(name string, result Result, format string, args ...interface{}) {
+	c.checks = append(c.checks, &Check{
+		Category: c.category,
+		Name:     name,
+		Result:   result,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// passIfEmpty adds a passing check if no problem was found
+func (c *checker) passIfEmpty(format string, args ...interface{}) {
+	if len(c.checks) == 0 {
+		c.add("", ResultPass, format, args...)
+	}
+}
+
+// BuildReport runs the preflight checks
+func BuildReport(ctx context.Context, options *Options) (*Report, error) {
+	cluster := options.Cluster
+	current, err := util.ParseKubernetesVersion(cluster.Spec.KubernetesVersion)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse kubernetes version %q: %v", cluster.Spec.KubernetesVersion, err)
+	}
+	target := options.TargetVersion
+
+	report := &Report{
+		Cluster:        cluster.ObjectMeta.Name,
+		CurrentVersion: cluster.Spec.KubernetesVersion,
+		TargetVersion:  target.String(),
+		Result:         ResultPass,
+	}
+
+	report.Checks = append(report.Checks, checkVersion(*current, target)...)
+	report.Checks = append(report.Checks, checkValidation(cluster, target)...)
+	report.Checks = append(report.Checks, checkFlags(cluster, options.InstanceGroups, target)...)
+	report.Checks = append(report.Checks, checkAPIs(ctx, options.APIUsage, *current, target)...)
+	report.Checks = append(report.Checks, checkNetworking(cluster, target)...)
+	report.Checks = append(report.Checks, checkDistributions(options.InstanceGroups, target)...)
+
+	for _, check := range report.Checks {
+		if severity[check.Result] > severity[report.Result] {
+			report.Result = check.Result
+		}
+	}
+	return report, nil
+}
+
+// minorVersion parses a version of the form 1.22
+func minorVersion(s string) semver.Version {
+	return semver.MustParse(s + ".0")
+}
+
+// isMinorGTE returns true if the minor version of v is the same as or newer than the minor version
+func isMinorGTE(v semver.Version, minor string) bool {
+	m := minorVersion(minor)
+	if v.Major != m.Major {
+		return v.Major > m.Major
+	}
+	return v.Minor >= m.Minor
+}
+
+// isMinorNewer returns true if the minor version of v is newer than the minor version
+func isMinorNewer(v semver.Version, minor string) bool {
+	m := minorVersion(minor)
+	if v.Major != m.Major {
+		return v.Major > m.Major
+	}
+	return v.Minor > m.Minor
+}
+
+// checkVersion checks that the target version is an upgrade of at most one minor version
+func checkVersion(current semver.Version, target semver.Version) []*Check {
+	c := &checker{category: CategoryVersion}
+	switch {
+	case target.LT(current):
+		c.add("kubernetesVersion", ResultFail, "%s is older than the current version %s; downgrades are not supported", target, current)
+	case target.Major != current.Major || target.Minor > current.Minor+1:
+		c.add("kubernetesVersion", ResultFail, "upgrading from %s to %s skips a minor version; Kubernetes must be upgraded one minor version at a time", current, target)
+	case target.EQ(current):
+		c.add("kubernetesVersion", ResultPass, "the cluster is already at %s", target)
+	default:
+		c.add("kubernetesVersion", ResultPass, "upgrade from %s to %s", current, target)
+	}
+	return c.checks
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/testutils"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// fakeAPIUsageFinder serves the API versions of resources in its objects
+type fakeAPIUsageFinder struct {
+	objects map[schema.GroupVersionResource][]string
+}
+
+func (f *fakeAPIUsageFinder) FindObjectsWrittenWith(ctx context.Context, gvr schema.GroupVersionResource) ([]string, bool, error) {
+	names, served := f.objects[gvr]
+	return names, served, nil
+}
+
+func findCheck(report *Report, category string, name string) *Check {
+	for _, check := range report.Checks {
+		if check.Category == category && check.Name == name {
+			return check
+		}
+	}
+	return nil
+}
+
+func TestBuildReport(t *testing.T) {
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	cluster.Spec.KubernetesVersion = "1.21.2"
+	cluster.Spec.Networking = &kops.NetworkingSpec{
+		Cilium: &kops.CiliumNetworkingSpec{Version: "v1.9.8"},
+	}
+	cluster.Spec.KubeAPIServer = &kops.KubeAPIServerConfig{InsecurePort: 8080}
+	cluster.Spec.KubeControllerManager = &kops.KubeControllerManagerConfig{
+		ExperimentalClusterSigningDuration: &metav1.Duration{},
+	}
+
+	master := testutils.BuildMinimalMasterInstanceGroup("subnet-us-mock-1a")
+	master.Spec.Image = "099720109477/ubuntu/images/hvm-ssd/ubuntu-focal-20.04-amd64-server-20210503"
+	nodes := testutils.BuildMinimalNodeInstanceGroup("nodes", "subnet-us-mock-1a")
+	nodes.Spec.Image = "099720109477/ubuntu/images/hvm-ssd/ubuntu-xenial-16.04-amd64-server-20210429"
+	nodes.Spec.Kubelet = &kops.KubeletConfigSpec{NetworkPluginMTU: fi.Int32(9001)}
+
+	finder := &fakeAPIUsageFinder{
+		objects: map[schema.GroupVersionResource][]string{
+			{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}:   {"default/a", "default/b", "default/c", "default/d", "default/e", "default/f"},
+			{Group: "storage.k8s.io", Version: "v1beta1", Resource: "storageclasses"}: nil,
+		},
+	}
+
+	report, err := BuildReport(context.Background(), &Options{
+		Cluster:        cluster,
+		InstanceGroups: []*kops.InstanceGroup{&master, &nodes},
+		TargetVersion:  semver.MustParse("1.22.0"),
+		APIUsage:       finder,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ResultFail, report.Result)
+	assert.Equal(t, "1.21.2", report.CurrentVersion)
+	assert.Equal(t, "1.22.0", report.TargetVersion)
+
+	assert.Equal(t, &Check{
+		Category: CategoryVersion,
+		Name:     "kubernetesVersion",
+		Result:   ResultPass,
+		Message:  "upgrade from 1.21.2 to 1.22.0",
+	}, findCheck(report, CategoryVersion, "kubernetesVersion"))
+
+	assert.Equal(t, &Check{
+		Category: CategoryFlags,
+		Name:     "spec.kubeAPIServer.insecurePort",
+		Result:   ResultWarn,
+		Message:  "kubeAPIServer flag --insecure-port is deprecated since Kubernetes 1.20; use the secure port instead",
+	}, findCheck(report, CategoryFlags, "spec.kubeAPIServer.insecurePort"))
+	assert.Equal(t, ResultWarn, findCheck(report, CategoryFlags, "spec.kubeControllerManager.experimentalClusterSigningDuration").Result)
+	assert.Equal(t, ResultWarn, findCheck(report, CategoryFlags, "InstanceGroup/nodes spec.kubelet.networkPluginMTU").Result)
+
+	assert.Equal(t, &Check{
+		Category: CategoryAPIs,
+		Name:     "networking.k8s.io/v1beta1 ingresses",
+		Result:   ResultFail,
+		Message:  "networking.k8s.io/v1beta1 ingresses is removed in Kubernetes 1.22; migrate the manifests of default/a, default/b, default/c, default/d, default/e and 1 more to networking.k8s.io/v1",
+	}, findCheck(report, CategoryAPIs, "networking.k8s.io/v1beta1 ingresses"))
+	assert.Nil(t, findCheck(report, CategoryAPIs, "storage.k8s.io/v1beta1 storageclasses"))
+
+	assert.Equal(t, &Check{
+		Category: CategoryNetworking,
+		Name:     "cilium v1.9.8",
+		Result:   ResultFail,
+		Message:  "cilium v1.9.8 supports Kubernetes up to 1.19",
+	}, findCheck(report, CategoryNetworking, "cilium v1.9.8"))
+
+	assert.Nil(t, findCheck(report, CategoryDistributions, "InstanceGroup/master-subnet-us-mock-1a"))
+	assert.Equal(t, ResultFail, findCheck(report, CategoryDistributions, "InstanceGroup/nodes").Result)
+}
+
+func TestBuildReportWithoutLiveCluster(t *testing.T) {
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	cluster.Spec.KubernetesVersion = "1.21.2"
+	cluster.Spec.Networking = &kops.NetworkingSpec{Kubenet: &kops.KubenetNetworkingSpec{}}
+
+	report, err := BuildReport(context.Background(), &Options{
+		Cluster:       cluster,
+		TargetVersion: semver.MustParse("1.21.5"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ResultWarn, report.Result)
+	assert.Equal(t, []*Check{
+		{Category: CategoryVersion, Name: "kubernetesVersion", Result: ResultPass, Message: "upgrade from 1.21.2 to 1.21.5"},
+		{Category: CategoryValidation, Result: ResultPass, Message: "the cluster spec is valid for 1.21.5"},
+		{Category: CategoryFlags, Result: ResultPass, Message: "no deprecated or removed flags are set"},
+		{Category: CategoryAPIs, Result: ResultWarn, Message: "the live cluster was not checked for objects using removed APIs"},
+		{Category: CategoryNetworking, Result: ResultPass, Message: "the networking uses the CNI versions bundled with kOps"},
+		{Category: CategoryDistributions, Result: ResultPass, Message: "the distributions of the images are supported"},
+	}, report.Checks)
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		current  string
+		target   string
+		expected Result
+	}{
+		{current: "1.21.2", target: "1.21.2", expected: ResultPass},
+		{current: "1.21.2", target: "1.22.0-beta.1", expected: ResultPass},
+		{current: "1.21.2", target: "1.21.1", expected: ResultFail},
+		{current: "1.20.8", target: "1.22.0", expected: ResultFail},
+		{current: "1.21.2", target: "2.0.0", expected: ResultFail},
+	}
+	for _, test := range tests {
+		t.Run(test.current+"-"+test.target, func(t *testing.T) {
+			checks := checkVersion(semver.MustParse(test.current), semver.MustParse(test.target))
+			require.Len(t, checks, 1)
+			assert.Equal(t, test.expected, checks[0].Result, checks[0].Message)
+		})
+	}
+}
+
+func TestWrittenWith(t *testing.T) {
+	meta := &metav1.ObjectMeta{
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kube-controller-manager", APIVersion: "networking.k8s.io/v1"},
+		},
+	}
+	assert.True(t, writtenWith(meta, "networking.k8s.io/v1"))
+	assert.False(t, writtenWith(meta, "networking.k8s.io/v1beta1"))
+
+	meta.Annotations = map[string]string{
+		corev1.LastAppliedConfigAnnotation: `{"apiVersion":"networking.k8s.io/v1beta1","kind":"Ingress"}`,
+	}
+	assert.True(t, writtenWith(meta, "networking.k8s.io/v1beta1"))
+}
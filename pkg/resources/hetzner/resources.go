@@ -0,0 +1,306 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/kops/pkg/resources"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+)
+
+const (
+	resourceTypeServer         = "server"
+	resourceTypePlacementGroup = "placement-group"
+	resourceTypeVolume         = "volume"
+	resourceTypeLoadBalancer   = "load-balancer"
+	resourceTypeFirewall       = "firewall"
+	resourceTypeNetwork        = "network"
+	resourceTypeSSHKey         = "ssh-key"
+)
+
+type listFn func(hetzner.HetznerCloud, map[string]string) ([]*resources.Resource, error)
+
+// ListResources returns the resources labelled as owned by the cluster
+func ListResources(cloud hetzner.HetznerCloud, clusterName string) (map[string]*resources.Resource, error) {
+	resourceTrackers := make(map[string]*resources.Resource)
+
+	labels := map[string]string{
+		hetzner.TagKubernetesClusterName: clusterName,
+	}
+
+	listFunctions := []listFn{
+		listServers,
+		listPlacementGroups,
+		listVolumes,
+		listLoadBalancers,
+		listFirewalls,
+		listNetworks,
+		listSSHKeys,
+	}
+
+	for _, fn := range listFunctions {
+		rt, err := fn(cloud, labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range rt {
+			resourceTrackers[t.Type+":"+t.ID] = t
+		}
+	}
+
+	return resourceTrackers, nil
+}
+
+func listServers(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	servers, err := c.GetServers(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, server := range servers {
+		resourceTracker := &resources.Resource{
+			Name:    server.Name,
+			ID:      strconv.Itoa(server.ID),
+			Type:    resourceTypeServer,
+			Deleter: deleteServer,
+			Obj:     server,
+		}
+
+		// Servers must be gone before the resources they use can be deleted
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network != nil {
+				resourceTracker.Blocks = append(resourceTracker.Blocks, resourceTypeNetwork+":"+strconv.Itoa(privateNet.Network.ID))
+			}
+		}
+		for _, volume := range server.Volumes {
+			resourceTracker.Blocks = append(resourceTracker.Blocks, resourceTypeVolume+":"+strconv.Itoa(volume.ID))
+		}
+		for _, firewall := range server.PublicNet.Firewalls {
+			if firewall.Firewall.ID != 0 {
+				resourceTracker.Blocks = append(resourceTracker.Blocks, resourceTypeFirewall+":"+strconv.Itoa(firewall.Firewall.ID))
+			}
+		}
+		if server.PlacementGroup != nil {
+			resourceTracker.Blocks = append(resourceTracker.Blocks, resourceTypePlacementGroup+":"+strconv.Itoa(server.PlacementGroup.ID))
+		}
+
+		resourceTrackers = append(resourceTrackers, resourceTracker)
+	}
+
+	return resourceTrackers, nil
+}
+
+func deleteServer(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	if _, err := c.ServerClient().Delete(context.TODO(), r.Obj.(*hcloud.Server)); err != nil {
+		return fmt.Errorf("failed to delete server %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+func listPlacementGroups(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	placementGroups, err := c.GetPlacementGroups(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list placement groups: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, placementGroup := range placementGroups {
+		resourceTrackers = append(resourceTrackers, &resources.Resource{
+			Name:    placementGroup.Name,
+			ID:      strconv.Itoa(placementGroup.ID),
+			Type:    resourceTypePlacementGroup,
+			Deleter: deletePlacementGroup,
+			Obj:     placementGroup,
+		})
+	}
+
+	return resourceTrackers, nil
+}
+
+func deletePlacementGroup(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	if _, err := c.PlacementGroupClient().Delete(context.TODO(), r.Obj.(*hcloud.PlacementGroup)); err != nil {
+		return fmt.Errorf("failed to delete placement group %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+func listVolumes(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	volumes, err := c.GetVolumes(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, volume := range volumes {
+		resourceTrackers = append(resourceTrackers, &resources.Resource{
+			Name:    volume.Name,
+			ID:      strconv.Itoa(volume.ID),
+			Type:    resourceTypeVolume,
+			Deleter: deleteVolume,
+			Obj:     volume,
+		})
+	}
+
+	return resourceTrackers, nil
+}
+
+func deleteVolume(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	if _, err := c.VolumeClient().Delete(context.TODO(), r.Obj.(*hcloud.Volume)); err != nil {
+		return fmt.Errorf("failed to delete volume %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+func listLoadBalancers(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	loadBalancers, err := c.GetLoadBalancers(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list load balancers: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, loadBalancer := range loadBalancers {
+		resourceTracker := &resources.Resource{
+			Name:    loadBalancer.Name,
+			ID:      strconv.Itoa(loadBalancer.ID),
+			Type:    resourceTypeLoadBalancer,
+			Deleter: deleteLoadBalancer,
+			Obj:     loadBalancer,
+		}
+		for _, privateNet := range loadBalancer.PrivateNet {
+			if privateNet.Network != nil {
+				resourceTracker.Blocks = append(resourceTracker.Blocks, resourceTypeNetwork+":"+strconv.Itoa(privateNet.Network.ID))
+			}
+		}
+		resourceTrackers = append(resourceTrackers, resourceTracker)
+	}
+
+	return resourceTrackers, nil
+}
+
+func deleteLoadBalancer(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	if _, err := c.LoadBalancerClient().Delete(context.TODO(), r.Obj.(*hcloud.LoadBalancer)); err != nil {
+		return fmt.Errorf("failed to delete load balancer %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+func listFirewalls(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	firewalls, err := c.GetFirewalls(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewalls: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, firewall := range firewalls {
+		resourceTrackers = append(resourceTrackers, &resources.Resource{
+			Name:    firewall.Name,
+			ID:      strconv.Itoa(firewall.ID),
+			Type:    resourceTypeFirewall,
+			Deleter: deleteFirewall,
+			Obj:     firewall,
+		})
+	}
+
+	return resourceTrackers, nil
+}
+
+func deleteFirewall(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	firewall := r.Obj.(*hcloud.Firewall)
+
+	// Firewalls that are still applied to resources cannot be deleted
+	if len(firewall.AppliedTo) > 0 {
+		actions, _, err := c.FirewallClient().RemoveResources(context.TODO(), firewall, firewall.AppliedTo)
+		if err != nil {
+			return fmt.Errorf("failed to remove firewall %q from its resources: %v", r.Name, err)
+		}
+		if err := c.WaitForActions(actions...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := c.FirewallClient().Delete(context.TODO(), firewall); err != nil {
+		return fmt.Errorf("failed to delete firewall %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+func listNetworks(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	networks, err := c.GetNetworks(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, network := range networks {
+		resourceTrackers = append(resourceTrackers, &resources.Resource{
+			Name:    network.Name,
+			ID:      strconv.Itoa(network.ID),
+			Type:    resourceTypeNetwork,
+			Deleter: deleteNetwork,
+			Obj:     network,
+		})
+	}
+
+	return resourceTrackers, nil
+}
+
+func deleteNetwork(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	if _, err := c.NetworkClient().Delete(context.TODO(), r.Obj.(*hcloud.Network)); err != nil {
+		return fmt.Errorf("failed to delete network %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+func listSSHKeys(c hetzner.HetznerCloud, labels map[string]string) ([]*resources.Resource, error) {
+	sshKeys, err := c.GetSSHKeys(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSH keys: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, sshKey := range sshKeys {
+		resourceTrackers = append(resourceTrackers, &resources.Resource{
+			Name:    sshKey.Name,
+			ID:      strconv.Itoa(sshKey.ID),
+			Type:    resourceTypeSSHKey,
+			Deleter: deleteSSHKey,
+			Obj:     sshKey,
+		})
+	}
+
+	return resourceTrackers, nil
+}
+
+func deleteSSHKey(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(hetzner.HetznerCloud)
+	if _, err := c.SSHKeyClient().Delete(context.TODO(), r.Obj.(*hcloud.SSHKey)); err != nil {
+		return fmt.Errorf("failed to delete SSH key %q: %v", r.Name, err)
+	}
+	return nil
+}
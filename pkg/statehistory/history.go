@@ -0,0 +1,276 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statehistory keeps an append-only history of the changes to the cluster and instance group specs
+// in the state store, so that changes can be audited and undone.
+//
+// Each write of a spec is recorded as a revision in the history directory of the cluster's ConfigBase.
+// Revisions are numbered per cluster, and each revision holds the object as it was written.
+package statehistory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// PathHistory is the path, relative to the cluster's ConfigBase, of the history
+const PathHistory = "history"
+
+// maxRecordAttempts is the number of times recording a revision is attempted when another writer takes its number
+const maxRecordAttempts = 5
+
+// Operation is the operation recorded by a revision
+type Operation string
+
+const (
+	OperationCreate Operation = "Create"
+	OperationUpdate Operation = "Update"
+	// OperationDelete records the deletion of an object; the revision holds the object that was deleted
+	OperationDelete Operation = "Delete"
+)
+
+// Revision is a recorded write of an object to the state store
+type Revision struct {
+	Revision    int       `json:"revision"`
+	Timestamp   time.Time `json:"timestamp"`
+	User        string    `json:"user,omitempty"`
+	KopsVersion string    `json:"kopsVersion,omitempty"`
+	Operation   Operation `json:"operation"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	// Hash is the sha256 hash of the object
+	Hash string `json:"hash"`
+	// Object is the object as it was written to the state store
+	Object string `json:"object"`
+}
+
+// ShortHash returns the abbreviated hash of the object
+func (r *Revision) ShortHash() string {
+	if len(r.Hash) > 12 {
+		return r.Hash[:12]
+	}
+	return r.Hash
+}
+
+// fileName returns the name of the file holding the revision.
+// The kind and name are part of the file name, so the revisions of an object can be found without reading them.
+func (r *Revision) fileName() string {
+	return fmt.Sprintf("%08d-%s-%s.json", r.Revision, strings.ToLower(r.Kind), r.Name)
+}
+
+// revisionFile is the parsed name of the file of a revision
+type revisionFile struct {
+	path     vfs.Path
+	revision int
+	kind     string
+	name     string
+}
+
+func parseFileName(p vfs.Path) (*revisionFile, bool) {
+	tokens := strings.SplitN(strings.TrimSuffix(p.Base(), ".json"), "-", 3)
+	if len(tokens) != 3 || !strings.HasSuffix(p.Base(), ".json") {
+		return nil, false
+	}
+	revision, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return nil, false
+	}
+	return &revisionFile{path: p, revision: revision, kind: tokens[1], name: tokens[2]}, true
+}
+
+// listFiles returns the files of the revisions in the history, ordered by revision
+func listFiles(history vfs.Path) ([]*revisionFile, error) {
+	paths, err := history.ReadDir()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing history %q: %v", history, err)
+	}
+
+	var files []*revisionFile
+	for _, p := range paths {
+		f, ok := parseFileName(p)
+		if !ok {
+			klog.Warningf("ignoring unexpected file %q in history", p)
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].revision < files[j].revision
+	})
+	return files, nil
+}
+
+// Hash returns the hash of an object as recorded in a revision
+func Hash(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// Record records a write of an object to the state store as the next revision in the history.
+// An update that does not change the object since its last revision is not recorded.
+func Record(history vfs.Path, operation Operation, kind string, name string, data []byte) (*Revision, error) {
+	r := &Revision{
+		Timestamp:   time.Now().UTC().Round(time.Second),
+		KopsVersion: kops.Version,
+		Operation:   operation,
+		Kind:        kind,
+		Name:        name,
+		Hash:        Hash(data),
+		Object:      string(data),
+	}
+	if u, err := user.Current(); err == nil {
+		r.User = u.Username
+	} else {
+		r.User = os.Getenv("USER")
+	}
+
+	for attempt := 1; ; attempt++ {
+		files, err := listFiles(history)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Revision = 1
+		if len(files) != 0 {
+			r.Revision = files[len(files)-1].revision + 1
+		}
+
+		if operation == OperationUpdate {
+			last, err := lastRevisionOf(files, kind, name)
+			if err != nil {
+				return nil, err
+			}
+			if last != nil && last.Operation != OperationDelete && last.Hash == r.Hash {
+				klog.V(2).Infof("%s %q is unchanged since revision %d", kind, name, last.Revision)
+				return nil, nil
+			}
+		}
+
+		b, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing revision: %v", err)
+		}
+		p := history.Join(r.fileName())
+		err = p.CreateFile(bytes.NewReader(b), nil)
+		if err == nil {
+			return r, nil
+		}
+		if !os.IsExist(err) || attempt >= maxRecordAttempts {
+			return nil, fmt.Errorf("error writing revision %q: %v", p, err)
+		}
+		klog.V(2).Infof("revision %d was recorded by another writer, retrying", r.Revision)
+	}
+}
+
+// lastRevisionOf reads the last revision of an object, or returns nil if the object has none
+func lastRevisionOf(files []*revisionFile, kind string, name string) (*Revision, error) {
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		if f.kind == strings.ToLower(kind) && f.name == name {
+			return readRevision(f.path)
+		}
+	}
+	return nil, nil
+}
+
+func readRevision(p vfs.Path) (*Revision, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reading revision %q: %v", p, err)
+	}
+	r := &Revision{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("error parsing revision %q: %v", p, err)
+	}
+	return r, nil
+}
+
+// List returns the revisions in the history, ordered by revision
+func List(history vfs.Path) ([]*Revision, error) {
+	files, err := listFiles(history)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []*Revision
+	for _, f := range files {
+		r, err := readRevision(f.path)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
+
+// Get returns a revision from the history
+func Get(history vfs.Path, revision int) (*Revision, error) {
+	files, err := listFiles(history)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.revision == revision {
+			return readRevision(f.path)
+		}
+	}
+	return nil, fmt.Errorf("revision %d not found in history", revision)
+}
+
+// Previous returns the revision of the same object that precedes a revision, or nil if it is the first
+func Previous(history vfs.Path, r *Revision) (*Revision, error) {
+	files, err := listFiles(history)
+	if err != nil {
+		return nil, err
+	}
+	var previous []*revisionFile
+	for _, f := range files {
+		if f.revision < r.Revision {
+			previous = append(previous, f)
+		}
+	}
+	return lastRevisionOf(previous, r.Kind, r.Name)
+}
+
+// PathForCluster returns the path of the history of the cluster
+func PathForCluster(clientset simple.Clientset, cluster *api.Cluster) (vfs.Path, error) {
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+	return configBase.Join(PathHistory), nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statehistory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestRecord(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+	history, err := vfs.Context.BuildVfsPath("memfs://tests/cluster.example.com/history")
+	require.NoError(t, err)
+
+	r, err := Record(history, OperationCreate, "InstanceGroup", "nodes", []byte("maxSize: 1\n"))
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, 1, r.Revision)
+	assert.Equal(t, Hash([]byte("maxSize: 1\n")), r.Hash)
+
+	_, err = Record(history, OperationCreate, "Cluster", "cluster.example.com", []byte("kubernetesVersion: 1.21.0\n"))
+	require.NoError(t, err)
+
+	r, err = Record(history, OperationUpdate, "InstanceGroup", "nodes", []byte("maxSize: 1\n"))
+	require.NoError(t, err)
+	assert.Nil(t, r, "an update that does not change the object is not recorded")
+
+	r, err = Record(history, OperationUpdate, "InstanceGroup", "nodes", []byte("maxSize: 2\n"))
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, 3, r.Revision)
+
+	_, err = Record(history, OperationDelete, "InstanceGroup", "nodes", []byte("maxSize: 2\n"))
+	require.NoError(t, err)
+
+	revisions, err := List(history)
+	require.NoError(t, err)
+	var summary []string
+	for _, r := range revisions {
+		summary = append(summary, string(r.Operation)+" "+r.Kind+"/"+r.Name)
+	}
+	assert.Equal(t, []string{
+		"Create InstanceGroup/nodes",
+		"Create Cluster/cluster.example.com",
+		"Update InstanceGroup/nodes",
+		"Delete InstanceGroup/nodes",
+	}, summary)
+
+	r, err = Get(history, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "maxSize: 2\n", r.Object)
+
+	previous, err := Previous(history, r)
+	require.NoError(t, err)
+	require.NotNil(t, previous)
+	assert.Equal(t, 1, previous.Revision)
+
+	_, err = Get(history, 5)
+	assert.Error(t, err)
+}
+
+func TestListEmpty(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+	history, err := vfs.Context.BuildVfsPath("memfs://tests/cluster.example.com/history")
+	require.NoError(t, err)
+
+	revisions, err := List(history)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
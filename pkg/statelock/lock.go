@@ -0,0 +1,317 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statelock implements an advisory lock on the state of a cluster in the state store.
+// The commands that mutate a cluster hold the lock, so that two users mutating the same cluster
+// at the same time fail fast instead of silently overwriting each other's changes.
+//
+// The lock is a file in the cluster's ConfigBase, created with vfs.Path.CreateFile, which uses
+// conditional writes on the backends that support them. The holder renews the lease of the lock
+// while it is held, so that the lock of a process that died expires and can be taken over.
+package statelock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// PathLock is the path, relative to the cluster's ConfigBase, of the lock
+const PathLock = "lock.json"
+
+// DefaultLeaseDuration is how long a lock is held without being renewed
+const DefaultLeaseDuration = 10 * time.Minute
+
+// Holder describes the holder of a lock
+type Holder struct {
+	// ID identifies the acquisition of the lock
+	ID          string `json:"id"`
+	User        string `json:"user,omitempty"`
+	Host        string `json:"host,omitempty"`
+	PID         int    `json:"pid,omitempty"`
+	Command     string `json:"command,omitempty"`
+	KopsVersion string `json:"kopsVersion,omitempty"`
+
+	Acquired time.Time `json:"acquired"`
+	// Expires is when the lock expires unless it is renewed
+	Expires time.Time `json:"expires"`
+}
+
+// Expired returns true if the lease of the lock has expired
+func (h *Holder) Expired(now time.Time) bool {
+	return !now.Before(h.Expires)
+}
+
+func (h *Holder) String() string {
+	return fmt.Sprintf("%s@%s (%s, pid %d)", h.User, h.Host, h.Command, h.PID)
+}
+
+// LockedError is returned when the lock is held by someone else
+type LockedError struct {
+	Path   string
+	Holder *Holder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("the cluster state is locked by %s since %s, until %s; if that command is no longer running, release the lock with \"kops lock release --force\"",
+		e.Holder, e.Holder.Acquired.Local().Format(time.RFC3339), e.Holder.Expires.Local().Format(time.RFC3339))
+}
+
+// Lock is a lock held on the state of a cluster
+type Lock struct {
+	path  vfs.Path
+	lease time.Duration
+
+	mutex  sync.Mutex
+	holder Holder
+	// refs counts the acquisitions of the lock by this process, as commands call each other
+	refs int
+	stop chan struct{}
+	done chan struct{}
+}
+
+var (
+	heldMutex sync.Mutex
+	// held are the locks held by this process, by path
+	held = make(map[string]*Lock)
+)
+
+// Acquire acquires the lock at the path for the command, and renews its lease until it is released.
+// The lock is reentrant: acquiring a lock already held by this process returns the same lock.
+func Acquire(p vfs.Path, command string, lease time.Duration) (*Lock, error) {
+	if lease <= 0 {
+		lease = DefaultLeaseDuration
+	}
+
+	heldMutex.Lock()
+	defer heldMutex.Unlock()
+
+	if l := held[p.Path()]; l != nil {
+		l.refs++
+		return l, nil
+	}
+
+	l := &Lock{
+		path:   p,
+		lease:  lease,
+		holder: newHolder(command, lease),
+		refs:   1,
+	}
+	if err := l.acquire(); err != nil {
+		return nil, err
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.renewUntilStopped()
+
+	held[p.Path()] = l
+	return l, nil
+}
+
+func newHolder(command string, lease time.Duration) Holder {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		klog.Fatalf("error generating random number: %v", err)
+	}
+
+	h := Holder{
+		ID:          hex.EncodeToString(id),
+		PID:         os.Getpid(),
+		Command:     command,
+		KopsVersion: kops.Version,
+	}
+	if u, err := user.Current(); err == nil {
+		h.User = u.Username
+	} else {
+		h.User = os.Getenv("USER")
+	}
+	if host, err := os.Hostname(); err == nil {
+		h.Host = host
+	}
+
+	now := time.Now().UTC().Round(time.Second)
+	h.Acquired = now
+	h.Expires = now.Add(lease)
+	return h
+}
+
+func (l *Lock) acquire() error {
+	data, err := json.Marshal(&l.holder)
+	if err != nil {
+		return fmt.Errorf("error serializing lock: %v", err)
+	}
+
+	// Attempt to create the lock, then read it back to check who holds it:
+	// on backends without conditional writes, the last of two concurrent creates wins.
+	// An expired lock is removed and the creation retried once.
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := l.path.CreateFile(bytes.NewReader(data), nil); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("error creating lock %q: %v", l.path, err)
+		}
+
+		current, err := Read(l.path)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			// Released after we attempted to create it
+			continue
+		}
+		if current.ID == l.holder.ID {
+			klog.V(2).Infof("acquired lock %q", l.path)
+			return nil
+		}
+		if !current.Expired(time.Now()) {
+			return &LockedError{Path: l.path.Path(), Holder: current}
+		}
+
+		klog.Warningf("taking over the lock of the cluster state held by %s, which expired at %s", current, current.Expires.Local().Format(time.RFC3339))
+		if err := l.path.Remove(); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing expired lock %q: %v", l.path, err)
+		}
+	}
+	return fmt.Errorf("unable to acquire lock %q", l.path)
+}
+
+func (l *Lock) renewUntilStopped() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.renew(); err != nil {
+				klog.Warningf("error renewing lock %q: %v", l.path, err)
+			}
+		}
+	}
+}
+
+// renew extends the lease of the lock, if it is still held
+func (l *Lock) renew() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	current, err := Read(l.path)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.ID != l.holder.ID {
+		return fmt.Errorf("the lock is no longer held; it was released or taken over")
+	}
+
+	l.holder.Expires = time.Now().UTC().Round(time.Second).Add(l.lease)
+	data, err := json.Marshal(&l.holder)
+	if err != nil {
+		return fmt.Errorf("error serializing lock: %v", err)
+	}
+	return l.path.WriteFile(bytes.NewReader(data), nil)
+}
+
+// Holder returns the holder of the lock
+func (l *Lock) Holder() Holder {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.holder
+}
+
+// Release releases the lock, once it has been released as many times as it was acquired
+func (l *Lock) Release() error {
+	heldMutex.Lock()
+	defer heldMutex.Unlock()
+
+	l.refs--
+	if l.refs > 0 {
+		return nil
+	}
+	delete(held, l.path.Path())
+
+	close(l.stop)
+	<-l.done
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	current, err := Read(l.path)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		// Released by force, or removed with the rest of the cluster state
+		klog.V(2).Infof("lock %q was already released", l.path)
+		return nil
+	}
+	if current.ID != l.holder.ID {
+		klog.Warningf("lock %q was taken over by %s while held", l.path, current)
+		return nil
+	}
+	if err := l.path.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing lock %q: %v", l.path, err)
+	}
+	klog.V(2).Infof("released lock %q", l.path)
+	return nil
+}
+
+// Read returns the holder of the lock at the path, or nil if it is not held
+func Read(p vfs.Path) (*Holder, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading lock %q: %v", p, err)
+	}
+
+	holder := &Holder{}
+	if err := json.Unmarshal(data, holder); err != nil {
+		return nil, fmt.Errorf("error parsing lock %q: %v", p, err)
+	}
+	return holder, nil
+}
+
+// PathForCluster returns the path of the lock on the state of the cluster
+func PathForCluster(clientset simple.Clientset, cluster *api.Cluster) (vfs.Path, error) {
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+	return configBase.Join(PathLock), nil
+}
+
+// ForceRelease removes the lock at the path, regardless of who holds it
+func ForceRelease(p vfs.Path) error {
+	if err := p.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing lock %q: %v", p, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statelock
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestAcquireAndRelease(t *testing.T) {
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "clusters/example.com/"+PathLock)
+
+	lock, err := Acquire(p, "kops update cluster", time.Hour)
+	require.NoError(t, err)
+
+	holder, err := Read(p)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.Equal(t, lock.Holder().ID, holder.ID)
+	assert.Equal(t, "kops update cluster", holder.Command)
+	assert.Equal(t, holder.Acquired.Add(time.Hour), holder.Expires)
+
+	// The lock is reentrant
+	nested, err := Acquire(p, "kops rolling-update cluster", time.Hour)
+	require.NoError(t, err)
+	assert.Same(t, lock, nested)
+	require.NoError(t, nested.Release())
+	holder, err = Read(p)
+	require.NoError(t, err)
+	assert.NotNil(t, holder)
+
+	require.NoError(t, lock.Release())
+	holder, err = Read(p)
+	require.NoError(t, err)
+	assert.Nil(t, holder)
+}
+
+func writeHolder(t *testing.T, p vfs.Path, holder *Holder) {
+	data, err := json.Marshal(holder)
+	require.NoError(t, err)
+	require.NoError(t, p.WriteFile(bytes.NewReader(data), nil))
+}
+
+func TestAcquireLocked(t *testing.T) {
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "clusters/example.com/"+PathLock)
+	other := &Holder{
+		ID:       "other",
+		User:     "alice",
+		Host:     "laptop",
+		PID:      42,
+		Command:  "kops edit cluster",
+		Acquired: time.Now().Add(-time.Minute),
+		Expires:  time.Now().Add(time.Hour),
+	}
+	writeHolder(t, p, other)
+
+	_, err := Acquire(p, "kops update cluster", time.Hour)
+	require.Error(t, err)
+	lockedErr, ok := err.(*LockedError)
+	require.True(t, ok, "expected LockedError, got %v", err)
+	assert.Equal(t, "other", lockedErr.Holder.ID)
+	assert.Contains(t, err.Error(), "alice@laptop (kops edit cluster, pid 42)")
+
+	// An expired lock is taken over
+	other.Expires = time.Now().Add(-time.Second)
+	writeHolder(t, p, other)
+	lock, err := Acquire(p, "kops update cluster", time.Hour)
+	require.NoError(t, err)
+	holder, err := Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, lock.Holder().ID, holder.ID)
+
+	// Releasing a lock that was released by force succeeds
+	require.NoError(t, ForceRelease(p))
+	require.NoError(t, lock.Release())
+}
+
+func TestRenew(t *testing.T) {
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "clusters/example.com/"+PathLock)
+
+	lock, err := Acquire(p, "kops rolling-update cluster", time.Hour)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, lock.Release())
+	}()
+
+	// Simulate the lease running out, then renew it
+	holder := lock.Holder()
+	holder.Expires = time.Now().Add(-time.Minute)
+	writeHolder(t, p, &holder)
+	require.NoError(t, lock.renew())
+
+	current, err := Read(p)
+	require.NoError(t, err)
+	assert.False(t, current.Expired(time.Now()))
+
+	// A lock that was taken over is not renewed
+	holder.ID = "other"
+	writeHolder(t, p, &holder)
+	assert.Error(t, lock.renew())
+	current, err = Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, "other", current.ID)
+}
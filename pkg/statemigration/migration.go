@@ -0,0 +1,404 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statemigration copies the state of a cluster from one state store to another,
+// e.g. from an S3 bucket to a Google Cloud Storage bucket, or between a VFS state store and the Kubernetes API (k8s://).
+// The state is read and written through simple.Clientset, so any state store with a clientset is supported.
+package statemigration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/kubemanifest"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// Plan is the copy of the state of a cluster
+type Plan struct {
+	Source      simple.Clientset
+	Destination simple.Clientset
+
+	// Cluster is the cluster spec to write to the destination
+	Cluster        *kops.Cluster
+	InstanceGroups []*kops.InstanceGroup
+	Keysets        map[string]*fi.Keyset
+	Secrets        map[string]*fi.Secret
+	SSHCredentials []*kops.SSHCredential
+	Addons         kubemanifest.ObjectList
+}
+
+// BuildPlan reads the state of cluster from source, to be written to destination as migrated,
+// the cluster with its references to the state store rewritten.
+// It fails if the cluster already exists in destination, so that the state of another cluster is never overwritten.
+func BuildPlan(ctx context.Context, source simple.Clientset, destination simple.Clientset, cluster *kops.Cluster, migrated *kops.Cluster) (*Plan, error) {
+	name := cluster.ObjectMeta.Name
+
+	if _, err := destination.GetCluster(ctx, name); err == nil {
+		return nil, fmt.Errorf("cluster %q already exists in the destination; refusing to overwrite it", name)
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("error reading cluster %q from the destination: %v", name, err)
+	}
+
+	plan := &Plan{
+		Source:      source,
+		Destination: destination,
+		Cluster:     migrated.DeepCopy(),
+	}
+	resetObjectMeta(&plan.Cluster.ObjectMeta)
+
+	igs, err := source.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading instance groups: %v", err)
+	}
+	for i := range igs.Items {
+		ig := igs.Items[i].DeepCopy()
+		resetObjectMeta(&ig.ObjectMeta)
+		plan.InstanceGroups = append(plan.InstanceGroups, ig)
+	}
+
+	keyStore, err := source.KeyStore(cluster)
+	if err != nil {
+		return nil, err
+	}
+	plan.Keysets, err = keyStore.ListKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("error reading keysets: %v", err)
+	}
+
+	secretStore, err := source.SecretStore(cluster)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := secretStore.ListSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets: %v", err)
+	}
+	plan.Secrets = make(map[string]*fi.Secret)
+	for _, id := range ids {
+		secret, err := secretStore.Secret(id)
+		if err != nil {
+			return nil, fmt.Errorf("error reading secret %q: %v", id, err)
+		}
+		plan.Secrets[id] = secret
+	}
+
+	sshCredentialStore, err := source.SSHCredentialStore(cluster)
+	if err != nil {
+		return nil, err
+	}
+	plan.SSHCredentials, err = sshCredentialStore.ListSSHCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("error reading SSH public keys: %v", err)
+	}
+
+	plan.Addons, err = source.AddonsFor(cluster).List()
+	if err != nil {
+		return nil, fmt.Errorf("error reading addons: %v", err)
+	}
+
+	return plan, nil
+}
+
+// Summary describes the objects to copy, e.g. "2 instance groups, 12 keysets, 3 secrets, 1 SSH public key and 0 addons"
+func (p *Plan) Summary() string {
+	counts := []string{
+		plural(len(p.InstanceGroups), "instance group"),
+		plural(len(p.Keysets), "keyset"),
+		plural(len(p.Secrets), "secret"),
+		plural(len(p.SSHCredentials), "SSH public key"),
+		plural(len(p.Addons), "addon"),
+	}
+	return strings.Join(counts[:len(counts)-1], ", ") + " and " + counts[len(counts)-1]
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Execute writes the state to the destination, and verifies it
+func (p *Plan) Execute(ctx context.Context) error {
+	name := p.Cluster.ObjectMeta.Name
+
+	if _, err := p.Destination.CreateCluster(ctx, p.Cluster); err != nil {
+		return fmt.Errorf("error creating cluster %q: %v", name, err)
+	}
+
+	igClient := p.Destination.InstanceGroupsFor(p.Cluster)
+	for _, ig := range p.InstanceGroups {
+		if _, err := igClient.Create(ctx, ig, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating instance group %q: %v", ig.ObjectMeta.Name, err)
+		}
+	}
+
+	keyStore, err := p.Destination.KeyStore(p.Cluster)
+	if err != nil {
+		return err
+	}
+	for _, keysetName := range sortedKeys(p.Keysets) {
+		if err := keyStore.StoreKeyset(keysetName, p.Keysets[keysetName]); err != nil {
+			return fmt.Errorf("error writing keyset %q: %v", keysetName, err)
+		}
+	}
+
+	secretStore, err := p.Destination.SecretStore(p.Cluster)
+	if err != nil {
+		return err
+	}
+	for _, id := range sortedSecretIDs(p.Secrets) {
+		if _, err := secretStore.ReplaceSecret(id, p.Secrets[id]); err != nil {
+			return fmt.Errorf("error writing secret %q: %v", id, err)
+		}
+	}
+
+	sshCredentialStore, err := p.Destination.SSHCredentialStore(p.Cluster)
+	if err != nil {
+		return err
+	}
+	for _, sshCredential := range p.SSHCredentials {
+		if err := sshCredentialStore.AddSSHPublicKey(sshCredential.ObjectMeta.Name, []byte(sshCredential.Spec.PublicKey)); err != nil {
+			return fmt.Errorf("error writing SSH public key %q: %v", sshCredential.ObjectMeta.Name, err)
+		}
+	}
+
+	if len(p.Addons) != 0 {
+		if err := p.Destination.AddonsFor(p.Cluster).Replace(p.Addons); err != nil {
+			return fmt.Errorf("error writing addons: %v", err)
+		}
+	}
+
+	return p.Verify(ctx)
+}
+
+// Verify reads the state back from the destination and checks that it matches the state that was read from the source
+func (p *Plan) Verify(ctx context.Context) error {
+	name := p.Cluster.ObjectMeta.Name
+
+	cluster, err := p.Destination.GetCluster(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error reading cluster %q: %v", name, err)
+	}
+	match, err := equalSpecs(&kops.Cluster{Spec: cluster.Spec}, &kops.Cluster{Spec: p.Cluster.Spec})
+	if err != nil {
+		return err
+	}
+	if !match {
+		return fmt.Errorf("the copy of cluster %q does not match the original", name)
+	}
+
+	igs, err := p.Destination.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error reading instance groups: %v", err)
+	}
+	copiedIGs := make(map[string]*kops.InstanceGroup)
+	for i := range igs.Items {
+		copiedIGs[igs.Items[i].ObjectMeta.Name] = &igs.Items[i]
+	}
+	for _, ig := range p.InstanceGroups {
+		copied := copiedIGs[ig.ObjectMeta.Name]
+		if copied == nil {
+			return fmt.Errorf("instance group %q was not copied", ig.ObjectMeta.Name)
+		}
+		match, err := equalSpecs(&kops.InstanceGroup{Spec: copied.Spec}, &kops.InstanceGroup{Spec: ig.Spec})
+		if err != nil {
+			return err
+		}
+		if !match {
+			return fmt.Errorf("the copy of instance group %q does not match the original", ig.ObjectMeta.Name)
+		}
+	}
+
+	keyStore, err := p.Destination.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+	for _, keysetName := range sortedKeys(p.Keysets) {
+		copied, err := keyStore.FindKeyset(keysetName)
+		if err != nil {
+			return fmt.Errorf("error reading keyset %q: %v", keysetName, err)
+		}
+		if err := compareKeysets(copied, p.Keysets[keysetName]); err != nil {
+			return fmt.Errorf("the copy of keyset %q does not match the original: %v", keysetName, err)
+		}
+	}
+
+	secretStore, err := p.Destination.SecretStore(cluster)
+	if err != nil {
+		return err
+	}
+	for _, id := range sortedSecretIDs(p.Secrets) {
+		copied, err := secretStore.FindSecret(id)
+		if err != nil {
+			return fmt.Errorf("error reading secret %q: %v", id, err)
+		}
+		if copied == nil {
+			return fmt.Errorf("secret %q was not copied", id)
+		}
+		if !bytes.Equal(copied.Data, p.Secrets[id].Data) {
+			return fmt.Errorf("the copy of secret %q does not match the original", id)
+		}
+	}
+
+	sshCredentialStore, err := p.Destination.SSHCredentialStore(cluster)
+	if err != nil {
+		return err
+	}
+	for _, sshCredential := range p.SSHCredentials {
+		copies, err := sshCredentialStore.FindSSHPublicKeys(sshCredential.ObjectMeta.Name)
+		if err != nil {
+			return fmt.Errorf("error reading SSH public key %q: %v", sshCredential.ObjectMeta.Name, err)
+		}
+		found := false
+		for _, copied := range copies {
+			if strings.TrimSpace(copied.Spec.PublicKey) == strings.TrimSpace(sshCredential.Spec.PublicKey) {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("SSH public key %q was not copied", sshCredential.ObjectMeta.Name)
+		}
+	}
+
+	if len(p.Addons) != 0 {
+		copied, err := p.Destination.AddonsFor(cluster).List()
+		if err != nil {
+			return fmt.Errorf("error reading addons: %v", err)
+		}
+		expected, err := p.Addons.ToYAML()
+		if err != nil {
+			return err
+		}
+		actual, err := copied.ToYAML()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(actual, expected) {
+			return fmt.Errorf("the copy of the addons does not match the original")
+		}
+	}
+
+	return nil
+}
+
+// equalSpecs compares two objects in their versioned form once defaulted,
+// as a state store may apply the API defaults when it reads an object back
+func equalSpecs(a runtime.Object, b runtime.Object) (bool, error) {
+	aYaml, err := defaultedYaml(a)
+	if err != nil {
+		return false, err
+	}
+	bYaml, err := defaultedYaml(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aYaml, bYaml), nil
+}
+
+func defaultedYaml(obj runtime.Object) ([]byte, error) {
+	y, err := kopscodecs.ToVersionedYaml(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing object: %v", err)
+	}
+	defaulted, _, err := kopscodecs.Decode(y, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing object: %v", err)
+	}
+	return kopscodecs.ToVersionedYaml(defaulted)
+}
+
+// compareKeysets checks that a copied keyset has the same primary and the same certificates as the original
+func compareKeysets(copied *fi.Keyset, original *fi.Keyset) error {
+	if copied == nil {
+		return fmt.Errorf("not found")
+	}
+	if (copied.Primary == nil) != (original.Primary == nil) || (copied.Primary != nil && copied.Primary.Id != original.Primary.Id) {
+		return fmt.Errorf("the primary keypair differs")
+	}
+	if len(copied.Items) != len(original.Items) {
+		return fmt.Errorf("found %d keypairs, expected %d", len(copied.Items), len(original.Items))
+	}
+	for id, item := range original.Items {
+		copiedItem := copied.Items[id]
+		if copiedItem == nil {
+			return fmt.Errorf("keypair %q was not copied", id)
+		}
+		if item.Certificate != nil && (copiedItem.Certificate == nil || !copiedItem.Certificate.Certificate.Equal(item.Certificate.Certificate)) {
+			return fmt.Errorf("the certificate of keypair %q differs", id)
+		}
+	}
+	return nil
+}
+
+// RewritePath rewrites a path within source to the same path within destination.
+// It returns false if the path is not within source.
+func RewritePath(p string, source vfs.Path, destination string) (string, bool, error) {
+	parsed, err := vfs.Context.BuildVfsPath(p)
+	if err != nil {
+		return "", false, fmt.Errorf("error building path for %q: %v", p, err)
+	}
+
+	sourcePath := strings.TrimSuffix(source.Path(), "/")
+	parsedPath := strings.TrimSuffix(parsed.Path(), "/")
+	destination = strings.TrimSuffix(destination, "/")
+	if parsedPath == sourcePath {
+		return destination, true, nil
+	}
+	if strings.HasPrefix(parsedPath, sourcePath+"/") {
+		return destination + strings.TrimPrefix(parsedPath, sourcePath), true, nil
+	}
+	return p, false, nil
+}
+
+// resetObjectMeta clears the fields of an object that are set by the state store it was read from
+func resetObjectMeta(meta *metav1.ObjectMeta) {
+	meta.Namespace = ""
+	meta.UID = ""
+	meta.ResourceVersion = ""
+	meta.Generation = 0
+	meta.SelfLink = ""
+	meta.ManagedFields = nil
+}
+
+func sortedKeys(keysets map[string]*fi.Keyset) []string {
+	var names []string
+	for name := range keysets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedSecretIDs(secrets map[string]*fi.Secret) []string {
+	var ids []string
+	for id := range secrets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
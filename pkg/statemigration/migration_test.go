@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statemigration
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/pkg/apis/kops"
+	kopsfake "k8s.io/kops/pkg/client/clientset_generated/clientset/fake"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/client/simple/api"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/kubemanifest"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/pkg/testutils"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+const testSSHPublicKey = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAgQCtWu40XQo8dczLsCq0OWV+hxm9uV3WxeH9Kgh4sMzQxNtoU1pvW0XdjpkBesRKGoolfWeCLXWxpyQb1IaiMkKoz7MdhQ/6UKjMjP66aFWWp3pwD0uj0HuJ7tq4gKHKRYGTaZIRWpzUiANBrjugVgA+Sd7E/mYwc/DMXkIyRZbvhQ=="
+
+func newTestVFSClientset(t *testing.T, base string) simple.Clientset {
+	basePath, err := vfs.Context.BuildVfsPath(base)
+	require.NoError(t, err)
+	return vfsclientset.NewVFSClientset(basePath)
+}
+
+func newTestRESTClientset() simple.Clientset {
+	return &api.RESTClientset{
+		BaseURL:    &url.URL{Scheme: "k8s"},
+		KopsClient: kopsfake.NewSimpleClientset().Kops(),
+		KubeClient: kubefake.NewSimpleClientset(),
+	}
+}
+
+// createTestState creates a cluster with an instance group, a keyset, a secret, an SSH public key and an addon
+func createTestState(t *testing.T, clientset simple.Clientset, configBase string) *kops.Cluster {
+	ctx := context.Background()
+
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	cluster.Spec.ConfigBase = configBase
+	cluster, err := clientset.CreateCluster(ctx, cluster)
+	require.NoError(t, err)
+
+	ig := testutils.BuildMinimalNodeInstanceGroup("nodes", "subnet-us-mock-1a")
+	_, err = clientset.InstanceGroupsFor(cluster).Create(ctx, &ig, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	privateKey, err := pki.GeneratePrivateKey()
+	require.NoError(t, err)
+	serial := pki.BuildPKISerial(1)
+	cert, _, _, err := pki.IssueCert(&pki.IssueCertRequest{
+		Type:       "ca",
+		Subject:    pkix.Name{CommonName: fi.CertificateIDCA, SerialNumber: serial.String()},
+		Serial:     serial,
+		PrivateKey: privateKey,
+	}, nil)
+	require.NoError(t, err)
+	keyset, err := fi.NewKeyset(cert, privateKey)
+	require.NoError(t, err)
+	keyStore, err := clientset.KeyStore(cluster)
+	require.NoError(t, err)
+	require.NoError(t, keyStore.StoreKeyset(fi.CertificateIDCA, keyset))
+
+	secretStore, err := clientset.SecretStore(cluster)
+	require.NoError(t, err)
+	_, _, err = secretStore.GetOrCreateSecret("admin", &fi.Secret{Data: []byte("password")})
+	require.NoError(t, err)
+
+	sshCredentialStore, err := clientset.SSHCredentialStore(cluster)
+	require.NoError(t, err)
+	require.NoError(t, sshCredentialStore.AddSSHPublicKey("admin", []byte(testSSHPublicKey)))
+
+	addons, err := kubemanifest.LoadObjectsFrom([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: addon\n"))
+	require.NoError(t, err)
+	require.NoError(t, clientset.AddonsFor(cluster).Replace(addons))
+
+	return cluster
+}
+
+func TestMigration(t *testing.T) {
+	ctx := context.Background()
+	vfs.Context.ResetMemfsContext(true)
+
+	vfsSource := newTestVFSClientset(t, "memfs://old")
+	cluster := createTestState(t, vfsSource, "memfs://old/minimal.example.com")
+
+	// From a VFS state store to the Kubernetes API
+	restDestination := newTestRESTClientset()
+	plan, err := BuildPlan(ctx, vfsSource, restDestination, cluster, cluster)
+	require.NoError(t, err)
+	assert.Equal(t, "1 instance group, 1 keyset, 1 secret, 1 SSH public key and 1 addon", plan.Summary())
+	require.NoError(t, plan.Execute(ctx))
+
+	// From the Kubernetes API to another VFS state store, with the references to the state store rewritten
+	restCluster, err := restDestination.GetCluster(ctx, cluster.ObjectMeta.Name)
+	require.NoError(t, err)
+	migrated := restCluster.DeepCopy()
+	migrated.Spec.ConfigBase = "memfs://new/minimal.example.com"
+	vfsDestination := newTestVFSClientset(t, "memfs://new")
+	plan, err = BuildPlan(ctx, restDestination, vfsDestination, restCluster, migrated)
+	require.NoError(t, err)
+	require.NoError(t, plan.Execute(ctx))
+
+	copied, err := vfsDestination.GetCluster(ctx, cluster.ObjectMeta.Name)
+	require.NoError(t, err)
+	assert.Equal(t, "memfs://new/minimal.example.com", copied.Spec.ConfigBase)
+	configBase, err := vfsDestination.ConfigBaseFor(copied)
+	require.NoError(t, err)
+	_, err = configBase.Join("pki", "private", fi.CertificateIDCA, "keyset.yaml").ReadFile()
+	assert.NoError(t, err, "the keyset should be written under the new ConfigBase")
+
+	// A copy that was changed fails verification
+	secretStore, err := vfsDestination.SecretStore(copied)
+	require.NoError(t, err)
+	_, err = secretStore.ReplaceSecret("admin", &fi.Secret{Data: []byte("changed")})
+	require.NoError(t, err)
+	assert.EqualError(t, plan.Verify(ctx), `the copy of secret "admin" does not match the original`)
+
+	// The state is not copied over an existing cluster
+	_, err = BuildPlan(ctx, vfsSource, vfsDestination, cluster, cluster)
+	assert.EqualError(t, err, `cluster "minimal.example.com" already exists in the destination; refusing to overwrite it`)
+	_, err = BuildPlan(ctx, vfsSource, vfsSource, cluster, cluster)
+	assert.EqualError(t, err, `cluster "minimal.example.com" already exists in the destination; refusing to overwrite it`)
+}
+
+func TestRewritePath(t *testing.T) {
+	source, err := vfs.Context.BuildVfsPath("s3://old/minimal.example.com")
+	require.NoError(t, err)
+	fsSource, err := vfs.Context.BuildVfsPath("file:///tmp/old/minimal.example.com")
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		source   vfs.Path
+		expected string
+		ok       bool
+	}{
+		{path: "s3://old/minimal.example.com", source: source, expected: "gs://new/minimal.example.com", ok: true},
+		{path: "s3://old/minimal.example.com/", source: source, expected: "gs://new/minimal.example.com", ok: true},
+		{path: "s3://old/minimal.example.com/pki", source: source, expected: "gs://new/minimal.example.com/pki", ok: true},
+		{path: "s3://old/minimal.example.com2/pki", source: source, expected: "s3://old/minimal.example.com2/pki", ok: false},
+		{path: "s3://keys/minimal.example.com", source: source, expected: "s3://keys/minimal.example.com", ok: false},
+		{path: "file:///tmp/old/minimal.example.com/backups/etcd/main", source: fsSource, expected: "gs://new/minimal.example.com/backups/etcd/main", ok: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			actual, ok, err := RewritePath(test.path, test.source, "gs://new/minimal.example.com")
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
@@ -46,6 +46,7 @@ import (
 	"k8s.io/kops/cloudmock/aws/mockiam"
 	"k8s.io/kops/cloudmock/aws/mockroute53"
 	gcemock "k8s.io/kops/cloudmock/gce"
+	"k8s.io/kops/cloudmock/hetzner/mockhcloud"
 	"k8s.io/kops/cloudmock/openstack/mockblockstorage"
 	"k8s.io/kops/cloudmock/openstack/mockcompute"
 	"k8s.io/kops/cloudmock/openstack/mockdns"
@@ -56,6 +57,10 @@ import (
 	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azuretasks"
+	"k8s.io/kops/upup/pkg/fi/cloudup/do"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/util/pkg/vfs"
 )
@@ -72,6 +77,9 @@ type IntegrationTestHarness struct {
 
 	// originalPKIDefaultPrivateKeySize is the saved pki.DefaultPrivateKeySize value, restored on Close
 	originalPKIDefaultPrivateKeySize int
+
+	// mockHetzner is the mock Hetzner Cloud API, stopped on Close
+	mockHetzner *mockhcloud.MockClient
 }
 
 func NewIntegrationTestHarness(t *testing.T) *IntegrationTestHarness {
@@ -127,6 +135,11 @@ func (h *IntegrationTestHarness) Close() {
 	if h.originalPKIDefaultPrivateKeySize != 0 {
 		pki.DefaultPrivateKeySize = h.originalPKIDefaultPrivateKeySize
 	}
+
+	if h.mockHetzner != nil {
+		h.mockHetzner.Server.Close()
+		os.Unsetenv("HCLOUD_TOKEN")
+	}
 }
 
 func (h *IntegrationTestHarness) SetupMockAWS() *awsup.MockAWSCloud {
@@ -297,6 +310,29 @@ func (h *IntegrationTestHarness) SetupMockGCE() *gcemock.MockGCECloud {
 	return gcemock.InstallMockGCECloud("us-test1", "testproject")
 }
 
+// SetupMockDO configures a mock DigitalOcean cloud provider
+func (h *IntegrationTestHarness) SetupMockDO() do.DOCloud {
+	return do.InstallMockDOCloud("nyc1")
+}
+
+// SetupMockHetzner configures a mock Hetzner Cloud provider
+func (h *IntegrationTestHarness) SetupMockHetzner() *mockhcloud.MockClient {
+	h.mockHetzner = mockhcloud.CreateClient()
+	hetzner.InstallMockHetznerCloud("eu-central", h.mockHetzner.Client())
+
+	// The token is passed to the cloud controller manager and the servers
+	os.Setenv("HCLOUD_TOKEN", "mock")
+
+	return h.mockHetzner
+}
+
+// SetupMockAzure configures a mock Azure cloud provider
+func (h *IntegrationTestHarness) SetupMockAzure() *azuretasks.MockAzureCloud {
+	cloud := azuretasks.NewMockAzureCloud("eastus")
+	azure.InstallMockAzureCloud(cloud)
+	return cloud
+}
+
 func SetupMockOpenstack() *openstack.MockCloud {
 	c := openstack.InstallMockOpenstackCloud("us-test1")
 	c.MockCinderClient = mockblockstorage.CreateClient()
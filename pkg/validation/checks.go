@@ -0,0 +1,376 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// ValidationCheck is a check made when validating a cluster, in addition to
+// the readiness of the nodes and of the system-critical pods.
+type ValidationCheck interface {
+	// Name identifies the check in errors.
+	Name() string
+	// Check returns the failures found in the cluster.
+	// An error is returned if the check could not be made.
+	Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error)
+}
+
+// NewValidationChecks returns the checks configured in the cluster's spec.validation.
+func NewValidationChecks(cluster *kops.Cluster) []ValidationCheck {
+	spec := cluster.Spec.Validation
+	if spec == nil {
+		return nil
+	}
+
+	var checks []ValidationCheck
+	for _, ds := range spec.DaemonSets {
+		checks = append(checks, &daemonSetCheck{namespace: ds.Namespace, name: ds.Name})
+	}
+	for _, namespace := range spec.DeploymentNamespaces {
+		checks = append(checks, &deploymentsCheck{namespace: namespace})
+	}
+	if fi.BoolValue(spec.CoreDNSReady) {
+		checks = append(checks, &coreDNSReadyCheck{})
+	}
+	if fi.BoolValue(spec.APIServerReadyz) {
+		checks = append(checks, &readyzCheck{})
+	}
+	for _, custom := range spec.Custom {
+		checks = append(checks, &customResourceCheck{spec: custom})
+	}
+	return checks
+}
+
+// restClientFor returns the REST client to make raw requests to the API server with.
+func restClientFor(override rest.Interface, client kubernetes.Interface) (rest.Interface, error) {
+	if override != nil {
+		return override, nil
+	}
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, errors.New("no REST client available")
+	}
+	return restClient, nil
+}
+
+// daemonSetCheck checks that a DaemonSet is fully rolled out.
+type daemonSetCheck struct {
+	namespace string
+	name      string
+}
+
+var _ ValidationCheck = &daemonSetCheck{}
+
+func (c *daemonSetCheck) Name() string {
+	return "daemonset/" + c.namespace + "/" + c.name
+}
+
+func (c *daemonSetCheck) Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error) {
+	failure := func(message string) []*ValidationError {
+		return []*ValidationError{{
+			Kind:    "DaemonSet",
+			Name:    c.namespace + "/" + c.name,
+			Message: message,
+		}}
+	}
+
+	ds, err := client.AppsV1().DaemonSets(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return failure(fmt.Sprintf("daemonset %q not found", c.namespace+"/"+c.name)), nil
+		}
+		return nil, fmt.Errorf("error getting daemonset %q: %v", c.namespace+"/"+c.name, err)
+	}
+
+	status := ds.Status
+	if status.ObservedGeneration < ds.Generation {
+		return failure(fmt.Sprintf("daemonset %q update has not been observed", ds.Name)), nil
+	}
+	if status.UpdatedNumberScheduled < status.DesiredNumberScheduled || status.NumberAvailable < status.DesiredNumberScheduled {
+		return failure(fmt.Sprintf("daemonset %q is not rolled out: %d desired, %d updated, %d available",
+			ds.Name, status.DesiredNumberScheduled, status.UpdatedNumberScheduled, status.NumberAvailable)), nil
+	}
+	return nil, nil
+}
+
+// deploymentsCheck checks that all Deployments in a namespace are fully available.
+type deploymentsCheck struct {
+	namespace string
+}
+
+var _ ValidationCheck = &deploymentsCheck{}
+
+func (c *deploymentsCheck) Name() string {
+	return "deployments/" + c.namespace
+}
+
+func (c *deploymentsCheck) Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error) {
+	deployments, err := client.AppsV1().Deployments(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments in namespace %q: %v", c.namespace, err)
+	}
+
+	var failures []*ValidationError
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		var message string
+		status := deployment.Status
+		if status.ObservedGeneration < deployment.Generation {
+			message = fmt.Sprintf("deployment %q update has not been observed", deployment.Name)
+		} else if status.UpdatedReplicas < replicas || status.AvailableReplicas < replicas {
+			message = fmt.Sprintf("deployment %q is not available: %d desired, %d updated, %d available",
+				deployment.Name, replicas, status.UpdatedReplicas, status.AvailableReplicas)
+		} else {
+			continue
+		}
+		failures = append(failures, &ValidationError{
+			Kind:    "Deployment",
+			Name:    deployment.Namespace + "/" + deployment.Name,
+			Message: message,
+		})
+	}
+	return failures, nil
+}
+
+// coreDNSReadyCheck checks that the cluster DNS service has ready endpoints
+// and that each running CoreDNS pod reports itself ready. It does not resolve
+// any names, as the cluster DNS service is not reachable from outside the cluster.
+type coreDNSReadyCheck struct{}
+
+var _ ValidationCheck = &coreDNSReadyCheck{}
+
+func (c *coreDNSReadyCheck) Name() string {
+	return "coredns-ready"
+}
+
+func (c *coreDNSReadyCheck) Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error) {
+	var failures []*ValidationError
+
+	endpoints, err := client.CoreV1().Endpoints("kube-system").Get(ctx, "kube-dns", metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error getting kube-dns endpoints: %v", err)
+	}
+	readyAddresses := 0
+	if err == nil {
+		for _, subset := range endpoints.Subsets {
+			readyAddresses += len(subset.Addresses)
+		}
+	}
+	if readyAddresses == 0 {
+		failures = append(failures, &ValidationError{
+			Kind:    "Service",
+			Name:    "kube-system/kube-dns",
+			Message: "service \"kube-dns\" has no ready endpoints",
+		})
+	}
+
+	pods, err := client.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=kube-dns"})
+	if err != nil {
+		return nil, fmt.Errorf("error listing kube-dns pods: %v", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != v1.PodRunning || !hasContainer(pod, "coredns") {
+			// Pods that are not running are reported by the system-critical pod checks
+			continue
+		}
+		if _, err := client.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, "8181", "ready", nil).DoRaw(ctx); err != nil {
+			failures = append(failures, &ValidationError{
+				Kind:    "Pod",
+				Name:    pod.Namespace + "/" + pod.Name,
+				Message: fmt.Sprintf("coredns pod %q is not ready: %v", pod.Name, err),
+			})
+		}
+	}
+
+	return failures, nil
+}
+
+func hasContainer(pod *v1.Pod, name string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readyzCheck reports the failing checks of the API server's /readyz endpoint.
+type readyzCheck struct {
+	// restClient overrides the client's REST client, for testing.
+	restClient rest.Interface
+}
+
+var _ ValidationCheck = &readyzCheck{}
+
+func (c *readyzCheck) Name() string {
+	return "apiserver-readyz"
+}
+
+func (c *readyzCheck) Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error) {
+	restClient, err := restClientFor(c.restClient, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failing /readyz responds with an error status, with the same verbose body
+	body, err := restClient.Get().AbsPath("/readyz").Param("verbose", "").DoRaw(ctx)
+
+	var failures []*ValidationError
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "[-]") {
+			continue
+		}
+		name := strings.TrimPrefix(line, "[-]")
+		if i := strings.Index(name, " "); i != -1 {
+			name = name[:i]
+		}
+		failures = append(failures, &ValidationError{
+			Kind:    "APIServer",
+			Name:    name,
+			Message: fmt.Sprintf("API server readiness check %s", strings.TrimPrefix(line, "[-]")),
+		})
+	}
+	if len(failures) == 0 && err != nil {
+		return nil, fmt.Errorf("error querying /readyz: %v", err)
+	}
+
+	return failures, nil
+}
+
+// customResourceCheck checks a status condition of custom resources.
+type customResourceCheck struct {
+	spec kops.CustomValidationCheck
+
+	// restClient overrides the client's REST client, for testing.
+	restClient rest.Interface
+}
+
+var _ ValidationCheck = &customResourceCheck{}
+
+func (c *customResourceCheck) Name() string {
+	return c.spec.Name
+}
+
+func (c *customResourceCheck) Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error) {
+	restClient, err := restClientFor(c.restClient, client)
+	if err != nil {
+		return nil, err
+	}
+
+	conditionType := c.spec.ConditionType
+	if conditionType == "" {
+		conditionType = "Ready"
+	}
+	conditionStatus := c.spec.ConditionStatus
+	if conditionStatus == "" {
+		conditionStatus = "True"
+	}
+
+	path := "/apis/" + c.spec.APIVersion
+	if !strings.Contains(c.spec.APIVersion, "/") {
+		path = "/api/" + c.spec.APIVersion
+	}
+	if c.spec.Namespace != "" {
+		path += "/namespaces/" + c.spec.Namespace
+	}
+	path += "/" + c.spec.Resource
+
+	request := restClient.Get().AbsPath(path)
+	if c.spec.LabelSelector != "" {
+		request = request.Param("labelSelector", c.spec.LabelSelector)
+	}
+	body, err := request.DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []*ValidationError{{
+				Kind:    "ValidationCheck",
+				Name:    c.spec.Name,
+				Message: fmt.Sprintf("validation check %q: resource %q not found in %q", c.spec.Name, c.spec.Resource, c.spec.APIVersion),
+			}}, nil
+		}
+		return nil, fmt.Errorf("error listing %s: %v", c.spec.Resource, err)
+	}
+
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(body); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", c.spec.Resource, err)
+	}
+
+	var failures []*ValidationError
+	for i := range list.Items {
+		item := &list.Items[i]
+		name := item.GetName()
+		if item.GetNamespace() != "" {
+			name = item.GetNamespace() + "/" + name
+		}
+
+		status, found := conditionStatusOf(item, conditionType)
+		if found && status == conditionStatus {
+			continue
+		}
+		message := fmt.Sprintf("validation check %q: %s %q has no %s condition", c.spec.Name, item.GetKind(), name, conditionType)
+		if found {
+			message = fmt.Sprintf("validation check %q: %s %q condition %s is %q, not %q", c.spec.Name, item.GetKind(), name, conditionType, status, conditionStatus)
+		}
+		failures = append(failures, &ValidationError{
+			Kind:    item.GetKind(),
+			Name:    name,
+			Message: message,
+		})
+	}
+	return failures, nil
+}
+
+// conditionStatusOf returns the status of the object's status condition of the given type
+func conditionStatusOf(obj *unstructured.Unstructured, conditionType string) (string, bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _, _ := unstructured.NestedString(condition, "type"); t != conditionType {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(condition, "status")
+		return status, true
+	}
+	return "", false
+}
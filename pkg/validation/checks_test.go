@@ -0,0 +1,286 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	restfake "k8s.io/client-go/rest/fake"
+	testingclient "k8s.io/client-go/testing"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func failureNames(failures []*ValidationError) []string {
+	var names []string
+	for _, failure := range failures {
+		names = append(names, failure.Kind+"/"+failure.Name)
+	}
+	return names
+}
+
+func TestNewValidationChecks(t *testing.T) {
+	cluster := &kopsapi.Cluster{}
+	assert.Empty(t, NewValidationChecks(cluster))
+
+	cluster.Spec.Validation = &kopsapi.ClusterValidationSpec{
+		DaemonSets:           []kopsapi.ValidationResourceReference{{Namespace: "kube-system", Name: "cilium"}},
+		DeploymentNamespaces: []string{"ingress"},
+		CoreDNSReady:         fi.Bool(true),
+		APIServerReadyz:      fi.Bool(false),
+		Custom:               []kopsapi.CustomValidationCheck{{Name: "certificates"}},
+	}
+	var names []string
+	for _, check := range NewValidationChecks(cluster) {
+		names = append(names, check.Name())
+	}
+	assert.Equal(t, []string{"daemonset/kube-system/cilium", "deployments/ingress", "coredns-ready", "certificates"}, names)
+}
+
+func TestDaemonSetCheck(t *testing.T) {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "cilium", Namespace: "kube-system", Generation: 2},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration:     2,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberAvailable:        2,
+		},
+	}
+	client := fake.NewSimpleClientset(ds)
+	check := &daemonSetCheck{namespace: "kube-system", name: "cilium"}
+
+	failures, err := check.Check(context.TODO(), client)
+	require.NoError(t, err)
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, "kube-system/cilium", failures[0].Name)
+		assert.Equal(t, `daemonset "cilium" is not rolled out: 3 desired, 3 updated, 2 available`, failures[0].Message)
+	}
+
+	ds.Status.NumberAvailable = 3
+	_, err = client.AppsV1().DaemonSets("kube-system").UpdateStatus(context.TODO(), ds, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	failures, err = check.Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+
+	failures, err = (&daemonSetCheck{namespace: "kube-system", name: "missing"}).Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DaemonSet/kube-system/missing"}, failureNames(failures))
+}
+
+func TestDeploymentsCheck(t *testing.T) {
+	deployment := func(name string, replicas, available int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ingress"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				UpdatedReplicas:   replicas,
+				AvailableReplicas: available,
+			},
+		}
+	}
+	other := deployment("other", 1, 0)
+	other.Namespace = "default"
+	client := fake.NewSimpleClientset(
+		deployment("available", 2, 2),
+		deployment("unavailable", 2, 1),
+		other,
+	)
+
+	failures, err := (&deploymentsCheck{namespace: "ingress"}).Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Deployment/ingress/unavailable"}, failureNames(failures))
+}
+
+func TestCoreDNSReadyCheck(t *testing.T) {
+	pod := func(name string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-dns"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "coredns"}}},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+			Subsets: []v1.EndpointSubset{
+				{NotReadyAddresses: []v1.EndpointAddress{{IP: "100.96.1.2"}}},
+			},
+		},
+		pod("coredns-ready", v1.PodRunning),
+		pod("coredns-notready", v1.PodRunning),
+		pod("coredns-pending", v1.PodPending),
+	)
+
+	var proxied []string
+	client.PrependProxyReactor("pods", func(action testingclient.Action) (bool, rest.ResponseWrapper, error) {
+		get := action.(testingclient.ProxyGetAction)
+		assert.Equal(t, "8181", get.GetPort())
+		assert.Equal(t, "ready", get.GetPath())
+		proxied = append(proxied, get.GetName())
+		if get.GetName() == "coredns-notready" {
+			return true, &fakeResponse{err: errors.New("the server is currently unable to handle the request")}, nil
+		}
+		return true, &fakeResponse{body: []byte("OK")}, nil
+	})
+
+	failures, err := (&coreDNSReadyCheck{}).Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Service/kube-system/kube-dns", "Pod/kube-system/coredns-notready"}, failureNames(failures))
+	assert.ElementsMatch(t, []string{"coredns-ready", "coredns-notready"}, proxied)
+}
+
+type fakeResponse struct {
+	body []byte
+	err  error
+}
+
+var _ rest.ResponseWrapper = &fakeResponse{}
+
+func (r *fakeResponse) DoRaw(context.Context) ([]byte, error) {
+	return r.body, r.err
+}
+
+func (r *fakeResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(r.body)), r.err
+}
+
+// fakeRESTClient returns a REST client that responds to requests for the path with the status and body
+func fakeRESTClient(path string, status int, body string) *restfake.RESTClient {
+	return &restfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != path {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: status,
+				Header:     http.Header{"Content-Type": []string{"text/plain"}},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		}),
+	}
+}
+
+func TestReadyzCheck(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	check := &readyzCheck{restClient: fakeRESTClient("/readyz", http.StatusOK, "[+]ping ok\n[+]etcd ok\nreadyz check passed\n")}
+	failures, err := check.Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+
+	check = &readyzCheck{restClient: fakeRESTClient("/readyz", http.StatusInternalServerError,
+		"[+]ping ok\n[-]etcd failed: reason withheld\n[-]informer-sync failed: reason withheld\nreadyz check failed\n")}
+	failures, err = check.Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"APIServer/etcd", "APIServer/informer-sync"}, failureNames(failures))
+	if assert.Len(t, failures, 2) {
+		assert.Equal(t, "API server readiness check etcd failed: reason withheld", failures[0].Message)
+	}
+
+	_, err = (&readyzCheck{}).Check(context.TODO(), client)
+	assert.Error(t, err, "no REST client")
+}
+
+func TestCustomResourceCheck(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	body := `{
+  "apiVersion": "cert-manager.io/v1",
+  "kind": "CertificateList",
+  "items": [
+    {"metadata": {"name": "ready", "namespace": "ingress"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}},
+    {"metadata": {"name": "expired", "namespace": "ingress"}, "status": {"conditions": [{"type": "Ready", "status": "False"}]}},
+    {"metadata": {"name": "new", "namespace": "ingress"}}
+  ]
+}`
+	check := &customResourceCheck{
+		spec: kopsapi.CustomValidationCheck{
+			Name:          "certificates",
+			APIVersion:    "cert-manager.io/v1",
+			Resource:      "certificates",
+			Namespace:     "ingress",
+			LabelSelector: "app=web",
+		},
+		restClient: fakeRESTClient("/apis/cert-manager.io/v1/namespaces/ingress/certificates", http.StatusOK, body),
+	}
+	failures, err := check.Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Certificate/ingress/expired", "Certificate/ingress/new"}, failureNames(failures))
+	if assert.Len(t, failures, 2) {
+		assert.Equal(t, `validation check "certificates": Certificate "ingress/expired" condition Ready is "False", not "True"`, failures[0].Message)
+	}
+	assert.Equal(t, "app=web", check.restClient.(*restfake.RESTClient).Req.URL.Query().Get("labelSelector"))
+
+	check.spec.Namespace = ""
+	failures, err = check.Check(context.TODO(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ValidationCheck/certificates"}, failureNames(failures), "resource not found")
+}
+
+// failingCheck is a ValidationCheck that always reports a failure
+type failingCheck struct{}
+
+func (c *failingCheck) Name() string {
+	return "failing"
+}
+
+func (c *failingCheck) Check(ctx context.Context, client kubernetes.Interface) ([]*ValidationError, error) {
+	return []*ValidationError{{Kind: "Test", Name: "failing", Message: "check failed"}}, nil
+}
+
+func Test_ValidateAdditionalChecks(t *testing.T) {
+	cluster := &kopsapi.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "testcluster.k8s.local"},
+		Spec: kopsapi.ClusterSpec{
+			Validation: &kopsapi.ClusterValidationSpec{
+				DaemonSets: []kopsapi.ValidationResourceReference{{Namespace: "kube-system", Name: "cilium"}},
+			},
+		},
+	}
+	ig := kopsapi.InstanceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "master-1"},
+		Spec:       kopsapi.InstanceGroupSpec{Role: kopsapi.InstanceGroupRoleMaster},
+	}
+	groups := map[string]*cloudinstances.CloudInstanceGroup{
+		"master-1": {InstanceGroup: &ig},
+	}
+
+	mockcloud := BuildMockCloud(t, groups, cluster, []kopsapi.InstanceGroup{ig})
+	validator, err := NewClusterValidator(cluster, mockcloud, &kopsapi.InstanceGroupList{Items: []kopsapi.InstanceGroup{ig}}, "https://api.testcluster.k8s.local", fake.NewSimpleClientset(), &failingCheck{})
+	require.NoError(t, err)
+	v, err := validator.Validate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DaemonSet/kube-system/cilium", "Test/failing"}, failureNames(v.Failures))
+}
@@ -63,6 +63,7 @@ type clusterValidatorImpl struct {
 	instanceGroups []*kops.InstanceGroup
 	host           string
 	k8sClient      kubernetes.Interface
+	checks         []ValidationCheck
 }
 
 func (v *ValidationCluster) addError(failure *ValidationError) {
@@ -98,7 +99,9 @@ func hasPlaceHolderIP(host string) (bool, error) {
 	return false, nil
 }
 
-func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupList *kops.InstanceGroupList, host string, k8sClient kubernetes.Interface) (ClusterValidator, error) {
+// NewClusterValidator returns a ClusterValidator that makes the checks configured in the cluster's spec,
+// followed by any additional checks given.
+func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupList *kops.InstanceGroupList, host string, k8sClient kubernetes.Interface, checks ...ValidationCheck) (ClusterValidator, error) {
 	var instanceGroups []*kops.InstanceGroup
 
 	for i := range instanceGroupList.Items {
@@ -116,6 +119,7 @@ func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupLis
 		instanceGroups: instanceGroups,
 		host:           host,
 		k8sClient:      k8sClient,
+		checks:         append(NewValidationChecks(cluster), checks...),
 	}, nil
 }
 
@@ -165,6 +169,14 @@ func (v *clusterValidatorImpl) Validate() (*ValidationCluster, error) {
 		return nil, fmt.Errorf("cannot get pod health for %q: %v", clusterName, err)
 	}
 
+	for _, check := range v.checks {
+		failures, err := check.Check(ctx, v.k8sClient)
+		if err != nil {
+			return nil, fmt.Errorf("error running validation check %q: %v", check.Name(), err)
+		}
+		validation.Failures = append(validation.Failures, failures...)
+	}
+
 	return validation, nil
 }
 
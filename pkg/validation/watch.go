@@ -0,0 +1,232 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// EventReasonValidationFailed is the reason of the warning event recorded when a validation failure appears
+	EventReasonValidationFailed = "ValidationFailed"
+	// EventReasonValidationRecovered is the reason of the event recorded when a validation failure is resolved
+	EventReasonValidationRecovered = "ValidationRecovered"
+)
+
+// Watcher periodically validates a cluster, exporting the results as Prometheus metrics and,
+// if it has an EventRecorder, recording Kubernetes events as validation failures appear and are resolved.
+type Watcher struct {
+	validator ClusterValidator
+	recorder  record.EventRecorder
+
+	// failures are the failures of the previous validation, keyed by failureKey
+	failures map[string]*ValidationError
+
+	runs                  *prometheus.CounterVec
+	lastRun               prometheus.Gauge
+	lastSuccess           prometheus.Gauge
+	lastDuration          prometheus.Gauge
+	failureCount          *prometheus.GaugeVec
+	failure               *prometheus.GaugeVec
+	nodes                 *prometheus.GaugeVec
+	instanceGroupFailures *prometheus.GaugeVec
+}
+
+// NewWatcher returns a Watcher using the validator, registering its metrics with the registerer.
+// The recorder may be nil, in which case no events are recorded.
+func NewWatcher(validator ClusterValidator, registerer prometheus.Registerer, recorder record.EventRecorder) (*Watcher, error) {
+	w := &Watcher{
+		validator: validator,
+		recorder:  recorder,
+		failures:  make(map[string]*ValidationError),
+
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "runs_total",
+			Help:      "Number of cluster validations, by result: success, failure, or error.",
+		}, []string{"result"}),
+		lastRun: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "last_run_timestamp_seconds",
+			Help:      "Time of the last cluster validation.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Time of the last cluster validation that found no failures.",
+		}),
+		lastDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "last_run_duration_seconds",
+			Help:      "Duration of the last cluster validation.",
+		}),
+		failureCount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "failures",
+			Help:      "Number of failures found by the last cluster validation, by kind.",
+		}, []string{"kind"}),
+		failure: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "failure",
+			Help:      "Failures found by the last cluster validation; 1 for each failure.",
+		}, []string{"kind", "name", "instance_group"}),
+		instanceGroupFailures: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "instance_group_failures",
+			Help:      "Number of failures found by the last cluster validation, by instance group.",
+		}, []string{"instance_group"}),
+		nodes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kops",
+			Subsystem: "validation",
+			Name:      "nodes",
+			Help:      "Number of nodes found by the last cluster validation, by role and ready status.",
+		}, []string{"role", "ready"}),
+	}
+
+	for _, collector := range []prometheus.Collector{w.runs, w.lastRun, w.lastSuccess, w.lastDuration, w.failureCount, w.failure, w.instanceGroupFailures, w.nodes} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("error registering validation metrics: %v", err)
+		}
+	}
+
+	return w, nil
+}
+
+// Run validates the cluster every interval until the context is done
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := w.ValidateOnce()
+		if err != nil {
+			klog.Warningf("unexpected error during validation: %v", err)
+		} else if len(result.Failures) != 0 {
+			klog.Warningf("cluster did not pass validation: %d failure(s)", len(result.Failures))
+		} else {
+			klog.Infof("cluster passed validation")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ValidateOnce validates the cluster, updating the metrics and recording events for the changes in failures
+func (w *Watcher) ValidateOnce() (*ValidationCluster, error) {
+	start := time.Now()
+	result, err := w.validator.Validate()
+	w.lastRun.Set(float64(start.Unix()))
+	w.lastDuration.Set(time.Since(start).Seconds())
+
+	if err != nil {
+		w.runs.WithLabelValues("error").Inc()
+		return nil, err
+	}
+
+	if len(result.Failures) == 0 {
+		w.runs.WithLabelValues("success").Inc()
+		w.lastSuccess.Set(float64(start.Unix()))
+	} else {
+		w.runs.WithLabelValues("failure").Inc()
+	}
+
+	w.failureCount.Reset()
+	w.failure.Reset()
+	w.instanceGroupFailures.Reset()
+	for _, failure := range result.Failures {
+		instanceGroup := ""
+		if failure.InstanceGroup != nil {
+			instanceGroup = failure.InstanceGroup.Name
+		}
+		w.failureCount.WithLabelValues(failure.Kind).Inc()
+		w.failure.WithLabelValues(failure.Kind, failure.Name, instanceGroup).Set(1)
+		if instanceGroup != "" {
+			w.instanceGroupFailures.WithLabelValues(instanceGroup).Inc()
+		}
+	}
+
+	w.nodes.Reset()
+	for _, node := range result.Nodes {
+		w.nodes.WithLabelValues(node.Role, string(node.Status)).Inc()
+	}
+
+	w.recordEvents(result.Failures)
+
+	return result, nil
+}
+
+// recordEvents records an event for each failure that was not found by the previous validation,
+// and for each failure of the previous validation that was not found this time
+func (w *Watcher) recordEvents(failures []*ValidationError) {
+	current := make(map[string]*ValidationError)
+	for _, failure := range failures {
+		current[failureKey(failure)] = failure
+	}
+
+	if w.recorder != nil {
+		for key, failure := range current {
+			if _, found := w.failures[key]; !found {
+				w.recorder.Event(involvedObject(failure), v1.EventTypeWarning, EventReasonValidationFailed, failure.Message)
+			}
+		}
+		for key, failure := range w.failures {
+			if _, found := current[key]; !found {
+				w.recorder.Eventf(involvedObject(failure), v1.EventTypeNormal, EventReasonValidationRecovered, "Resolved: %s", failure.Message)
+			}
+		}
+	}
+
+	w.failures = current
+}
+
+func failureKey(failure *ValidationError) string {
+	return failure.Kind + "/" + failure.Name + "/" + failure.Message
+}
+
+// involvedObject returns the object to which to attach events about the failure.
+// Failures that are not about a node or pod are attached to the kube-system namespace.
+func involvedObject(failure *ValidationError) *v1.ObjectReference {
+	switch failure.Kind {
+	case "Node":
+		return &v1.ObjectReference{Kind: "Node", APIVersion: "v1", Name: failure.Name}
+	case "Pod":
+		if tokens := strings.SplitN(failure.Name, "/", 2); len(tokens) == 2 {
+			return &v1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: tokens[0], Name: tokens[1]}
+		}
+	}
+	return &v1.ObjectReference{Kind: "Namespace", APIVersion: "v1", Name: metav1.NamespaceSystem}
+}
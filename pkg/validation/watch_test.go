@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// sequenceValidator returns each of its results in turn
+type sequenceValidator struct {
+	results []*ValidationCluster
+}
+
+func (v *sequenceValidator) Validate() (*ValidationCluster, error) {
+	if len(v.results) == 0 {
+		return nil, fmt.Errorf("no more results")
+	}
+	result := v.results[0]
+	v.results = v.results[1:]
+	return result, nil
+}
+
+// gatherMetrics returns the values of the gathered metrics, keyed by name and label values
+func gatherMetrics(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range metric.GetLabel() {
+				key += "," + label.GetName() + "=" + label.GetValue()
+			}
+			switch {
+			case metric.GetGauge() != nil:
+				values[key] = metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				values[key] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+func Test_WatcherMetricsAndEvents(t *testing.T) {
+	ig := &kops.InstanceGroup{ObjectMeta: metav1.ObjectMeta{Name: "nodes"}}
+	validator := &sequenceValidator{
+		results: []*ValidationCluster{
+			{
+				Failures: []*ValidationError{
+					{Kind: "InstanceGroup", Name: "nodes", Message: "InstanceGroup \"nodes\" did not have enough nodes 1 vs 2", InstanceGroup: ig},
+					{Kind: "Pod", Name: "kube-system/kube-dns-1", Message: "system-cluster-critical pod \"kube-dns-1\" is pending"},
+				},
+				Nodes: []*ValidationNode{
+					{Name: "node-1", Role: "node", Status: v1.ConditionTrue},
+				},
+			},
+			{
+				Nodes: []*ValidationNode{
+					{Name: "node-1", Role: "node", Status: v1.ConditionTrue},
+					{Name: "node-2", Role: "node", Status: v1.ConditionTrue},
+				},
+			},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	recorder := record.NewFakeRecorder(10)
+	watcher, err := NewWatcher(validator, registry, recorder)
+	require.NoError(t, err)
+
+	_, err = watcher.ValidateOnce()
+	require.NoError(t, err)
+
+	metrics := gatherMetrics(t, registry)
+	assert.Equal(t, 1.0, metrics["kops_validation_runs_total,result=failure"])
+	assert.Equal(t, 1.0, metrics["kops_validation_failures,kind=InstanceGroup"])
+	assert.Equal(t, 1.0, metrics["kops_validation_failures,kind=Pod"])
+	assert.Equal(t, 1.0, metrics["kops_validation_failure,instance_group=nodes,kind=InstanceGroup,name=nodes"])
+	assert.Equal(t, 1.0, metrics["kops_validation_instance_group_failures,instance_group=nodes"])
+	assert.Equal(t, 1.0, metrics["kops_validation_nodes,ready=True,role=node"])
+	assert.Len(t, recorder.Events, 2)
+	for i := 0; i < 2; i++ {
+		assert.Contains(t, <-recorder.Events, "Warning ValidationFailed")
+	}
+
+	_, err = watcher.ValidateOnce()
+	require.NoError(t, err)
+
+	metrics = gatherMetrics(t, registry)
+	assert.Equal(t, 1.0, metrics["kops_validation_runs_total,result=success"])
+	assert.NotContains(t, metrics, "kops_validation_failures,kind=InstanceGroup")
+	assert.NotContains(t, metrics, "kops_validation_instance_group_failures,instance_group=nodes")
+	assert.Equal(t, 2.0, metrics["kops_validation_nodes,ready=True,role=node"])
+	assert.Len(t, recorder.Events, 2)
+	for i := 0; i < 2; i++ {
+		assert.Contains(t, <-recorder.Events, "Normal ValidationRecovered Resolved: ")
+	}
+
+	_, err = watcher.ValidateOnce()
+	assert.Error(t, err)
+	metrics = gatherMetrics(t, registry)
+	assert.Equal(t, 1.0, metrics["kops_validation_runs_total,result=error"])
+	assert.Len(t, recorder.Events, 0)
+}
+
+func Test_WatcherInvolvedObject(t *testing.T) {
+	grid := []struct {
+		failure  *ValidationError
+		expected *v1.ObjectReference
+	}{
+		{
+			failure:  &ValidationError{Kind: "Node", Name: "node-1"},
+			expected: &v1.ObjectReference{Kind: "Node", APIVersion: "v1", Name: "node-1"},
+		},
+		{
+			failure:  &ValidationError{Kind: "Pod", Name: "kube-system/kube-dns-1"},
+			expected: &v1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: "kube-system", Name: "kube-dns-1"},
+		},
+		{
+			failure:  &ValidationError{Kind: "InstanceGroup", Name: "nodes"},
+			expected: &v1.ObjectReference{Kind: "Namespace", APIVersion: "v1", Name: "kube-system"},
+		},
+	}
+	for _, g := range grid {
+		assert.Equal(t, g.expected, involvedObject(g.failure), "%s %s", g.failure.Kind, g.failure.Name)
+	}
+}
@@ -72,7 +72,7 @@ func run() error {
 	flag.BoolVar(&containerized, "containerized", containerized, "Set if we are running containerized.")
 	flag.BoolVar(&initializeRBAC, "initialize-rbac", initializeRBAC, "Set if we should initialize RBAC")
 	flag.BoolVar(&master, "master", master, "Whether or not this node is a master")
-	flag.StringVar(&cloud, "cloud", "aws", "CloudProvider we are using (aws,digitalocean,gce,openstack)")
+	flag.StringVar(&cloud, "cloud", "aws", "CloudProvider we are using (aws,digitalocean,gce,hetzner,openstack)")
 	flag.StringVar(&clusterID, "cluster-id", clusterID, "Cluster ID")
 	flag.StringVar(&dnsInternalSuffix, "dns-internal-suffix", dnsInternalSuffix, "DNS suffix for internal domain names")
 	flag.StringVar(&dnsServer, "dns-server", dnsServer, "DNS Server")
@@ -206,6 +206,19 @@ func run() error {
 		if clusterID == "" {
 			clusterID = azureVolumes.ClusterID()
 		}
+	} else if cloud == "hetzner" {
+		klog.Info("Initializing Hetzner volumes")
+		hetznerVolumes, err := protokube.NewHetznerVolumes()
+		if err != nil {
+			klog.Errorf("Error initializing Hetzner: %q", err)
+			os.Exit(1)
+		}
+		volumes = hetznerVolumes
+		internalIP = hetznerVolumes.InternalIP()
+
+		if clusterID == "" {
+			clusterID = hetznerVolumes.ClusterID()
+		}
 	} else {
 		klog.Errorf("Unknown cloud %q", cloud)
 		os.Exit(1)
@@ -286,6 +299,12 @@ func run() error {
 				return err
 			}
 			gossipName = volumes.(*protokube.AzureVolumes).InstanceID()
+		} else if cloud == "hetzner" {
+			gossipSeeds, err = volumes.(*protokube.HetznerVolumes).GossipSeeds()
+			if err != nil {
+				return err
+			}
+			gossipName = volumes.(*protokube.HetznerVolumes).InstanceName()
 		} else {
 			klog.Fatalf("seed provider for %q not yet implemented", cloud)
 		}
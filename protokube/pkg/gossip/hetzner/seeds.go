@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/protokube/pkg/gossip"
+)
+
+type SeedProvider struct {
+	hcloudClient *hcloud.Client
+	// labelSelector selects the servers of the cluster
+	labelSelector string
+}
+
+var _ gossip.SeedProvider = &SeedProvider{}
+
+func (p *SeedProvider) GetSeeds() ([]string, error) {
+	var seeds []string
+
+	servers, err := p.hcloudClient.Server.AllWithOpts(context.TODO(), hcloud.ServerListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: p.labelSelector,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers matching %q: %v", p.labelSelector, err)
+	}
+
+	for _, server := range servers {
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.IP == nil {
+				continue
+			}
+			klog.V(4).Infof("Appending a seed for server %q, with ip=%s", server.Name, privateNet.IP)
+			seeds = append(seeds, privateNet.IP.String())
+		}
+	}
+
+	return seeds, nil
+}
+
+func NewSeedProvider(hcloudClient *hcloud.Client, labelSelector string) (*SeedProvider, error) {
+	klog.V(4).Infof("Trying new seed provider with label selector: %s", labelSelector)
+
+	return &SeedProvider{
+		hcloudClient:  hcloudClient,
+		labelSelector: labelSelector,
+	}, nil
+}
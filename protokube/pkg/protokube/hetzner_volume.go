@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protokube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/hetznercloud/hcloud-go/hcloud/metadata"
+	"k8s.io/klog/v2"
+
+	"k8s.io/kops/protokube/pkg/etcd"
+	"k8s.io/kops/protokube/pkg/gossip"
+	gossiphetzner "k8s.io/kops/protokube/pkg/gossip/hetzner"
+)
+
+const (
+	hetznerLabelClusterName   = "kops.k8s.io/cluster"
+	hetznerLabelInstanceGroup = "kops.k8s.io/instance-group"
+	hetznerLabelVolumeRole    = "kops.k8s.io/volume-role"
+)
+
+// HetznerVolumes defines the Hetzner Cloud volume implementation
+type HetznerVolumes struct {
+	hcloudClient *hcloud.Client
+
+	clusterID  string
+	server     *hcloud.Server
+	internalIP net.IP
+}
+
+var _ Volumes = &HetznerVolumes{}
+
+// NewHetznerVolumes returns a new Hetzner Cloud volume provider, expecting the env var HCLOUD_TOKEN
+func NewHetznerVolumes() (*HetznerVolumes, error) {
+	accessToken := os.Getenv("HCLOUD_TOKEN")
+	if accessToken == "" {
+		return nil, errors.New("HCLOUD_TOKEN is required")
+	}
+
+	serverID, err := metadata.NewClient().InstanceID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server id from metadata: %v", err)
+	}
+
+	hcloudClient := hcloud.NewClient(
+		hcloud.WithToken(accessToken),
+		hcloud.WithApplication("protokube", ""),
+	)
+
+	server, _, err := hcloudClient.Server.GetByID(context.TODO(), serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server %d: %v", serverID, err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server %d not found", serverID)
+	}
+
+	clusterID := server.Labels[hetznerLabelClusterName]
+	if clusterID == "" {
+		return nil, fmt.Errorf("server %q is missing the %q label", server.Name, hetznerLabelClusterName)
+	}
+
+	var internalIP net.IP
+	for _, privateNet := range server.PrivateNet {
+		internalIP = privateNet.IP
+	}
+	if internalIP == nil {
+		return nil, fmt.Errorf("server %q is not attached to a private network", server.Name)
+	}
+
+	return &HetznerVolumes{
+		hcloudClient: hcloudClient,
+		clusterID:    clusterID,
+		server:       server,
+		internalIP:   internalIP,
+	}, nil
+}
+
+// ClusterID returns the name of the cluster the server belongs to
+func (a *HetznerVolumes) ClusterID() string {
+	return a.clusterID
+}
+
+// InternalIP returns the IP of the server on the private network
+func (a *HetznerVolumes) InternalIP() net.IP {
+	return a.internalIP
+}
+
+// InstanceName returns the name of the server
+func (a *HetznerVolumes) InstanceName() string {
+	return a.server.Name
+}
+
+// FindVolumes returns the etcd volumes of the cluster in the location of the server
+func (a *HetznerVolumes) FindVolumes() ([]*Volume, error) {
+	hetznerVolumes, err := a.hcloudClient.Volume.AllWithOpts(context.TODO(), hcloud.VolumeListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: hetznerLabelClusterName + "=" + a.clusterID,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %v", err)
+	}
+
+	var volumes []*Volume
+	for _, hetznerVolume := range hetznerVolumes {
+		if hetznerVolume.Location == nil || a.server.Datacenter == nil || a.server.Datacenter.Location == nil ||
+			hetznerVolume.Location.Name != a.server.Datacenter.Location.Name {
+			continue
+		}
+
+		etcdClusterName := hetznerVolume.Labels[hetznerLabelVolumeRole]
+		if etcdClusterName == "" {
+			klog.V(2).Infof("Ignoring volume %q without the %q label", hetznerVolume.Name, hetznerLabelVolumeRole)
+			continue
+		}
+		memberName := hetznerVolume.Labels[hetznerLabelInstanceGroup]
+
+		volume := &Volume{
+			ID: strconv.Itoa(hetznerVolume.ID),
+			Info: VolumeInfo{
+				Description: hetznerVolume.Name,
+				EtcdClusters: []*etcd.EtcdClusterSpec{
+					{
+						ClusterKey: etcdClusterName,
+						NodeName:   memberName,
+						NodeNames:  []string{memberName},
+					},
+				},
+			},
+		}
+		if hetznerVolume.Server != nil {
+			volume.AttachedTo = strconv.Itoa(hetznerVolume.Server.ID)
+			if hetznerVolume.Server.ID == a.server.ID {
+				volume.LocalDevice = hetznerVolume.LinuxDevice
+			}
+		}
+
+		volumes = append(volumes, volume)
+	}
+
+	return volumes, nil
+}
+
+// AttachVolume attaches the volume to the server, waiting for the attachment to complete
+func (a *HetznerVolumes) AttachVolume(volume *Volume) error {
+	volumeID, err := strconv.Atoi(volume.ID)
+	if err != nil {
+		return fmt.Errorf("failed to convert volume id %q: %v", volume.ID, err)
+	}
+
+	action, _, err := a.hcloudClient.Volume.Attach(context.TODO(), &hcloud.Volume{ID: volumeID}, a.server)
+	if err != nil {
+		return fmt.Errorf("error attaching volume %q: %v", volume.ID, err)
+	}
+
+	_, errCh := a.hcloudClient.Action.WatchProgress(context.TODO(), action)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("error waiting for volume %q to attach: %v", volume.ID, err)
+	}
+
+	hetznerVolume, _, err := a.hcloudClient.Volume.GetByID(context.TODO(), volumeID)
+	if err != nil {
+		return fmt.Errorf("error getting volume %q: %v", volume.ID, err)
+	}
+	if hetznerVolume == nil {
+		return fmt.Errorf("volume %q not found", volume.ID)
+	}
+	if hetznerVolume.Server == nil || hetznerVolume.Server.ID != a.server.ID {
+		return fmt.Errorf("volume %q is attached to another server", volume.ID)
+	}
+
+	volume.AttachedTo = strconv.Itoa(a.server.ID)
+	volume.LocalDevice = hetznerVolume.LinuxDevice
+
+	return nil
+}
+
+// FindMountedVolume returns the device of the volume if it is present on the server
+func (a *HetznerVolumes) FindMountedVolume(volume *Volume) (string, error) {
+	device := volume.LocalDevice
+
+	_, err := os.Stat(pathFor(device))
+	if err == nil {
+		return device, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("error checking for device %q: %v", device, err)
+	}
+
+	return "", nil
+}
+
+// GossipSeeds returns a seed provider listing the servers of the cluster
+func (a *HetznerVolumes) GossipSeeds() (gossip.SeedProvider, error) {
+	return gossiphetzner.NewSeedProvider(a.hcloudClient, hetznerLabelClusterName+"="+a.clusterID)
+}
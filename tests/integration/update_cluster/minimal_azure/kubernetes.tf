@@ -0,0 +1,383 @@
+locals {
+  cluster_name = "minimal-azure.k8s.local"
+  region       = "eastus"
+}
+
+output "cluster_name" {
+  value = "minimal-azure.k8s.local"
+}
+
+output "region" {
+  value = "eastus"
+}
+
+provider "azurerm" {
+  features {
+  }
+}
+
+resource "aws_s3_bucket_object" "cluster-completed-spec" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_cluster-completed.spec_content")
+  key                    = "tests/minimal-azure.k8s.local/cluster-completed.spec"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "etcd-cluster-spec-events" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_etcd-cluster-spec-events_content")
+  key                    = "tests/minimal-azure.k8s.local/backups/etcd/events/control/etcd-cluster-spec"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "etcd-cluster-spec-main" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_etcd-cluster-spec-main_content")
+  key                    = "tests/minimal-azure.k8s.local/backups/etcd/main/control/etcd-cluster-spec"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "kops-version-txt" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_kops-version.txt_content")
+  key                    = "tests/minimal-azure.k8s.local/kops-version.txt"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "manifests-etcdmanager-events" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_manifests-etcdmanager-events_content")
+  key                    = "tests/minimal-azure.k8s.local/manifests/etcd/events.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "manifests-etcdmanager-main" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_manifests-etcdmanager-main_content")
+  key                    = "tests/minimal-azure.k8s.local/manifests/etcd/main.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "manifests-static-kube-apiserver-healthcheck" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_manifests-static-kube-apiserver-healthcheck_content")
+  key                    = "tests/minimal-azure.k8s.local/manifests/static/kube-apiserver-healthcheck.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-bootstrap" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-bootstrap_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/bootstrap-channel.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-core-addons-k8s-io" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-core.addons.k8s.io_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/core.addons.k8s.io/v1.4.0.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-coredns-addons-k8s-io-k8s-1-12" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-coredns.addons.k8s.io-k8s-1.12_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/coredns.addons.k8s.io/k8s-1.12.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-dns-controller-addons-k8s-io-k8s-1-12" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-dns-controller.addons.k8s.io-k8s-1.12_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/dns-controller.addons.k8s.io/k8s-1.12.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-kops-controller-addons-k8s-io-k8s-1-16" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-kops-controller.addons.k8s.io-k8s-1.16_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/kops-controller.addons.k8s.io/k8s-1.16.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-kubelet-api-rbac-addons-k8s-io-k8s-1-9" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-kubelet-api.rbac.addons.k8s.io-k8s-1.9_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/kubelet-api.rbac.addons.k8s.io/k8s-1.9.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-limit-range-addons-k8s-io" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-limit-range.addons.k8s.io_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/limit-range.addons.k8s.io/v1.5.0.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-azure-k8s-local-addons-rbac-addons-k8s-io-k8s-1-8" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-azure.k8s.local-addons-rbac.addons.k8s.io-k8s-1.8_content")
+  key                    = "tests/minimal-azure.k8s.local/addons/rbac.addons.k8s.io/k8s-1.8.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "nodeupconfig-master-eastus-1" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_nodeupconfig-master-eastus-1_content")
+  key                    = "tests/minimal-azure.k8s.local/igconfig/master/master-eastus-1/nodeupconfig.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "nodeupconfig-nodes" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_nodeupconfig-nodes_content")
+  key                    = "tests/minimal-azure.k8s.local/igconfig/node/nodes/nodeupconfig.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "azurerm_lb" "api-minimal-azure-k8s-local" {
+  frontend_ip_configuration {
+    name                 = "LoadBalancerFrontEnd"
+    public_ip_address_id = azurerm_public_ip.api-minimal-azure-k8s-local.id
+  }
+  location            = "eastus"
+  name                = "api-minimal-azure.k8s.local"
+  resource_group_name = azurerm_resource_group.minimal-azure-k8s-local.name
+  sku                 = "Basic"
+  tags = {
+    "KubernetesCluster" = "test-cluster"
+  }
+}
+
+resource "azurerm_lb_backend_address_pool" "api-minimal-azure-k8s-local" {
+  loadbalancer_id = azurerm_lb.api-minimal-azure-k8s-local.id
+  name            = "LoadBalancerBackEnd"
+}
+
+resource "azurerm_lb_probe" "api-minimal-azure-k8s-local" {
+  interval_in_seconds = 15
+  loadbalancer_id     = azurerm_lb.api-minimal-azure-k8s-local.id
+  name                = "Health-TCP-443"
+  number_of_probes    = 4
+  port                = 443
+  protocol            = "Tcp"
+  resource_group_name = azurerm_resource_group.minimal-azure-k8s-local.name
+}
+
+resource "azurerm_lb_rule" "api-minimal-azure-k8s-local" {
+  backend_address_pool_id        = azurerm_lb_backend_address_pool.api-minimal-azure-k8s-local.id
+  backend_port                   = 443
+  enable_floating_ip             = false
+  frontend_ip_configuration_name = "LoadBalancerFrontEnd"
+  frontend_port                  = 443
+  idle_timeout_in_minutes        = 4
+  load_distribution              = "Default"
+  loadbalancer_id                = azurerm_lb.api-minimal-azure-k8s-local.id
+  name                           = "TCP-443"
+  probe_id                       = azurerm_lb_probe.api-minimal-azure-k8s-local.id
+  protocol                       = "Tcp"
+  resource_group_name            = azurerm_resource_group.minimal-azure-k8s-local.name
+}
+
+resource "azurerm_linux_virtual_machine_scale_set" "master-eastus-1-masters-minimal-azure-k8s-local" {
+  admin_ssh_key {
+    public_key = file("${path.module}/data/azurerm_linux_virtual_machine_scale_set_master-eastus-1.masters.minimal-azure.k8s.local_public_key")
+    username   = "admin-user"
+  }
+  admin_username                  = "admin-user"
+  computer_name_prefix            = "master-eastus-1"
+  custom_data                     = filebase64("${path.module}/data/azurerm_linux_virtual_machine_scale_set_master-eastus-1.masters.minimal-azure.k8s.local_custom_data")
+  disable_password_authentication = true
+  identity {
+    type = "SystemAssigned"
+  }
+  instances = 1
+  location  = "eastus"
+  name      = "master-eastus-1.masters.minimal-azure.k8s.local"
+  network_interface {
+    enable_ip_forwarding = true
+    ip_configuration {
+      load_balancer_backend_address_pool_ids = [azurerm_lb_backend_address_pool.api-minimal-azure-k8s-local.id]
+      name                                   = "master-eastus-1.masters.minimal-azure.k8s.local-ipconfig"
+      primary                                = true
+      public_ip_address {
+        name = "master-eastus-1.masters.minimal-azure.k8s.local-publicipconfig"
+      }
+      subnet_id = azurerm_subnet.eastus.id
+      version   = "IPv4"
+    }
+    name    = "master-eastus-1.masters.minimal-azure.k8s.local-netconfig"
+    primary = true
+  }
+  os_disk {
+    caching              = "ReadWrite"
+    disk_size_gb         = 64
+    storage_account_type = "Premium_LRS"
+  }
+  resource_group_name = azurerm_resource_group.minimal-azure-k8s-local.name
+  sku                 = "Standard_B2ms"
+  source_image_reference {
+    offer     = "0001-com-ubuntu-server-focal"
+    publisher = "Canonical"
+    sku       = "20_04-lts"
+    version   = "20.04.202105130"
+  }
+  tags = {
+    "KubernetesCluster"         = "test-cluster"
+    "k8s.io_role_master"        = "1"
+    "kops.k8s.io_instancegroup" = "master-eastus-1"
+  }
+  upgrade_mode = "Manual"
+  zones        = ["1"]
+}
+
+resource "azurerm_linux_virtual_machine_scale_set" "nodes-minimal-azure-k8s-local" {
+  admin_ssh_key {
+    public_key = file("${path.module}/data/azurerm_linux_virtual_machine_scale_set_nodes.minimal-azure.k8s.local_public_key")
+    username   = "admin-user"
+  }
+  admin_username                  = "admin-user"
+  computer_name_prefix            = "nodes"
+  custom_data                     = filebase64("${path.module}/data/azurerm_linux_virtual_machine_scale_set_nodes.minimal-azure.k8s.local_custom_data")
+  disable_password_authentication = true
+  identity {
+    type = "SystemAssigned"
+  }
+  instances = 2
+  location  = "eastus"
+  name      = "nodes.minimal-azure.k8s.local"
+  network_interface {
+    enable_ip_forwarding = true
+    ip_configuration {
+      name    = "nodes.minimal-azure.k8s.local-ipconfig"
+      primary = true
+      public_ip_address {
+        name = "nodes.minimal-azure.k8s.local-publicipconfig"
+      }
+      subnet_id = azurerm_subnet.eastus.id
+      version   = "IPv4"
+    }
+    name    = "nodes.minimal-azure.k8s.local-netconfig"
+    primary = true
+  }
+  os_disk {
+    caching              = "ReadWrite"
+    disk_size_gb         = 128
+    storage_account_type = "Premium_LRS"
+  }
+  resource_group_name = azurerm_resource_group.minimal-azure-k8s-local.name
+  sku                 = "Standard_B2ms"
+  source_image_reference {
+    offer     = "0001-com-ubuntu-server-focal"
+    publisher = "Canonical"
+    sku       = "20_04-lts"
+    version   = "20.04.202105130"
+  }
+  tags = {
+    "KubernetesCluster"         = "test-cluster"
+    "k8s.io_role_node"          = "1"
+    "kops.k8s.io_instancegroup" = "nodes"
+  }
+  upgrade_mode = "Manual"
+  zones        = ["1"]
+}
+
+resource "azurerm_managed_disk" "a-etcd-events-minimal-azure-k8s-local" {
+  create_option        = "Empty"
+  disk_size_gb         = 20
+  location             = "eastus"
+  name                 = "a.etcd-events.minimal-azure.k8s.local"
+  resource_group_name  = azurerm_resource_group.minimal-azure-k8s-local.name
+  storage_account_type = "Standard_LRS"
+  tags = {
+    "KubernetesCluster"                             = "test-cluster"
+    "k8s.io_etcd_events"                            = "a/a"
+    "k8s.io_role_master"                            = "1"
+    "kubernetes.io_cluster_minimal-azure.k8s.local" = "owned"
+  }
+}
+
+resource "azurerm_managed_disk" "a-etcd-main-minimal-azure-k8s-local" {
+  create_option        = "Empty"
+  disk_size_gb         = 20
+  location             = "eastus"
+  name                 = "a.etcd-main.minimal-azure.k8s.local"
+  resource_group_name  = azurerm_resource_group.minimal-azure-k8s-local.name
+  storage_account_type = "Standard_LRS"
+  tags = {
+    "KubernetesCluster"                             = "test-cluster"
+    "k8s.io_etcd_main"                              = "a/a"
+    "k8s.io_role_master"                            = "1"
+    "kubernetes.io_cluster_minimal-azure.k8s.local" = "owned"
+  }
+}
+
+resource "azurerm_public_ip" "api-minimal-azure-k8s-local" {
+  allocation_method   = "Dynamic"
+  ip_version          = "IPv4"
+  location            = "eastus"
+  name                = "api-minimal-azure.k8s.local"
+  resource_group_name = azurerm_resource_group.minimal-azure-k8s-local.name
+  tags = {
+    "KubernetesCluster" = "test-cluster"
+  }
+}
+
+resource "azurerm_resource_group" "minimal-azure-k8s-local" {
+  location = "eastus"
+  name     = "minimal-azure.k8s.local"
+  tags = {
+    "KubernetesCluster" = "test-cluster"
+  }
+}
+
+resource "azurerm_role_assignment" "master-eastus-1-masters-minimal-azure-k8s-local-blob" {
+  principal_id       = azurerm_linux_virtual_machine_scale_set.master-eastus-1-masters-minimal-azure-k8s-local.identity[0].principal_id
+  role_definition_id = format("%s/providers/Microsoft.Authorization/roleDefinitions/ba92f5b4-2d11-453d-a403-e96b0029c9fe", azurerm_resource_group.minimal-azure-k8s-local.id)
+  scope              = azurerm_resource_group.minimal-azure-k8s-local.id
+}
+
+resource "azurerm_role_assignment" "master-eastus-1-masters-minimal-azure-k8s-local-owner" {
+  principal_id       = azurerm_linux_virtual_machine_scale_set.master-eastus-1-masters-minimal-azure-k8s-local.identity[0].principal_id
+  role_definition_id = format("%s/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", azurerm_resource_group.minimal-azure-k8s-local.id)
+  scope              = azurerm_resource_group.minimal-azure-k8s-local.id
+}
+
+resource "azurerm_role_assignment" "nodes-minimal-azure-k8s-local-blob" {
+  principal_id       = azurerm_linux_virtual_machine_scale_set.nodes-minimal-azure-k8s-local.identity[0].principal_id
+  role_definition_id = format("%s/providers/Microsoft.Authorization/roleDefinitions/ba92f5b4-2d11-453d-a403-e96b0029c9fe", azurerm_resource_group.minimal-azure-k8s-local.id)
+  scope              = azurerm_resource_group.minimal-azure-k8s-local.id
+}
+
+resource "azurerm_role_assignment" "nodes-minimal-azure-k8s-local-owner" {
+  principal_id       = azurerm_linux_virtual_machine_scale_set.nodes-minimal-azure-k8s-local.identity[0].principal_id
+  role_definition_id = format("%s/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", azurerm_resource_group.minimal-azure-k8s-local.id)
+  scope              = azurerm_resource_group.minimal-azure-k8s-local.id
+}
+
+resource "azurerm_subnet" "eastus" {
+  address_prefixes     = ["10.0.32.0/19"]
+  name                 = "eastus"
+  resource_group_name  = azurerm_resource_group.minimal-azure-k8s-local.name
+  virtual_network_name = azurerm_virtual_network.minimal-azure-k8s-local.name
+}
+
+resource "azurerm_virtual_network" "minimal-azure-k8s-local" {
+  address_space       = ["10.0.0.0/16"]
+  location            = "eastus"
+  name                = "minimal-azure.k8s.local"
+  resource_group_name = azurerm_resource_group.minimal-azure-k8s-local.name
+  tags = {
+    "KubernetesCluster" = "test-cluster"
+  }
+}
+
+terraform {
+  required_version = ">= 0.12.26"
+  required_providers {
+    azurerm = {
+      "source"  = "hashicorp/azurerm"
+      "version" = ">= 2.60.0"
+    }
+  }
+}
@@ -0,0 +1,214 @@
+locals {
+  cluster_name = "minimal-do.k8s.local"
+  region       = "nyc1"
+}
+
+output "cluster_name" {
+  value = "minimal-do.k8s.local"
+}
+
+output "region" {
+  value = "nyc1"
+}
+
+provider "digitalocean" {
+}
+
+resource "aws_s3_bucket_object" "cluster-completed-spec" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_cluster-completed.spec_content")
+  key                    = "tests/minimal-do.k8s.local/cluster-completed.spec"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "etcd-cluster-spec-events" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_etcd-cluster-spec-events_content")
+  key                    = "tests/minimal-do.k8s.local/backups/etcd/events/control/etcd-cluster-spec"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "etcd-cluster-spec-main" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_etcd-cluster-spec-main_content")
+  key                    = "tests/minimal-do.k8s.local/backups/etcd/main/control/etcd-cluster-spec"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "kops-version-txt" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_kops-version.txt_content")
+  key                    = "tests/minimal-do.k8s.local/kops-version.txt"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "manifests-etcdmanager-events" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_manifests-etcdmanager-events_content")
+  key                    = "tests/minimal-do.k8s.local/manifests/etcd/events.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "manifests-etcdmanager-main" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_manifests-etcdmanager-main_content")
+  key                    = "tests/minimal-do.k8s.local/manifests/etcd/main.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "manifests-static-kube-apiserver-healthcheck" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_manifests-static-kube-apiserver-healthcheck_content")
+  key                    = "tests/minimal-do.k8s.local/manifests/static/kube-apiserver-healthcheck.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-bootstrap" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-bootstrap_content")
+  key                    = "tests/minimal-do.k8s.local/addons/bootstrap-channel.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-core-addons-k8s-io" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-core.addons.k8s.io_content")
+  key                    = "tests/minimal-do.k8s.local/addons/core.addons.k8s.io/v1.4.0.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-coredns-addons-k8s-io-k8s-1-12" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-coredns.addons.k8s.io-k8s-1.12_content")
+  key                    = "tests/minimal-do.k8s.local/addons/coredns.addons.k8s.io/k8s-1.12.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-digitalocean-cloud-controller-addons-k8s-io-k8s-1-8" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-digitalocean-cloud-controller.addons.k8s.io-k8s-1.8_content")
+  key                    = "tests/minimal-do.k8s.local/addons/digitalocean-cloud-controller.addons.k8s.io/k8s-1.8.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-dns-controller-addons-k8s-io-k8s-1-12" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-dns-controller.addons.k8s.io-k8s-1.12_content")
+  key                    = "tests/minimal-do.k8s.local/addons/dns-controller.addons.k8s.io/k8s-1.12.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-kops-controller-addons-k8s-io-k8s-1-16" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-kops-controller.addons.k8s.io-k8s-1.16_content")
+  key                    = "tests/minimal-do.k8s.local/addons/kops-controller.addons.k8s.io/k8s-1.16.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-kubelet-api-rbac-addons-k8s-io-k8s-1-9" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-kubelet-api.rbac.addons.k8s.io-k8s-1.9_content")
+  key                    = "tests/minimal-do.k8s.local/addons/kubelet-api.rbac.addons.k8s.io/k8s-1.9.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-limit-range-addons-k8s-io" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-limit-range.addons.k8s.io_content")
+  key                    = "tests/minimal-do.k8s.local/addons/limit-range.addons.k8s.io/v1.5.0.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "minimal-do-k8s-local-addons-rbac-addons-k8s-io-k8s-1-8" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_minimal-do.k8s.local-addons-rbac.addons.k8s.io-k8s-1.8_content")
+  key                    = "tests/minimal-do.k8s.local/addons/rbac.addons.k8s.io/k8s-1.8.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "nodeupconfig-master-nyc1-1" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_nodeupconfig-master-nyc1-1_content")
+  key                    = "tests/minimal-do.k8s.local/igconfig/master/master-nyc1-1/nodeupconfig.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_bucket_object" "nodeupconfig-nodes" {
+  bucket                 = "testingBucket"
+  content                = file("${path.module}/data/aws_s3_bucket_object_nodeupconfig-nodes_content")
+  key                    = "tests/minimal-do.k8s.local/igconfig/node/nodes/nodeupconfig.yaml"
+  server_side_encryption = "AES256"
+}
+
+resource "digitalocean_droplet" "master-nyc1-1-masters-minimal-do-k8s-local" {
+  count     = 1
+  image     = "ubuntu-20-04-x64"
+  name      = "master-nyc1-1.masters.minimal-do.k8s.local"
+  region    = "nyc1"
+  size      = "s-2vcpu-4gb"
+  ssh_keys  = ["c4:a6:ed:9a:a8:89:b9:e2:c3:9c:d6:63:eb:9c:71:57"]
+  tags      = ["KubernetesCluster:minimal-do-k8s-local", "k8s-index:etcd-1", "KubernetesCluster-Master:minimal-do-k8s-local", "kops-instancegroup:master-nyc1-1"]
+  user_data = file("${path.module}/data/digitalocean_droplet_master-nyc1-1.masters.minimal-do.k8s.local_user_data")
+}
+
+resource "digitalocean_droplet" "nodes-minimal-do-k8s-local" {
+  count     = 2
+  image     = "ubuntu-20-04-x64"
+  name      = "nodes.minimal-do.k8s.local"
+  region    = "nyc1"
+  size      = "s-2vcpu-4gb"
+  ssh_keys  = ["c4:a6:ed:9a:a8:89:b9:e2:c3:9c:d6:63:eb:9c:71:57"]
+  tags      = ["KubernetesCluster:minimal-do-k8s-local", "kops-instancegroup:nodes"]
+  user_data = file("${path.module}/data/digitalocean_droplet_nodes.minimal-do.k8s.local_user_data")
+}
+
+resource "digitalocean_loadbalancer" "api-minimal-do-k8s-local" {
+  droplet_tag = "KubernetesCluster-Master:minimal-do-k8s-local"
+  forwarding_rule {
+    entry_port      = 443
+    entry_protocol  = "https"
+    target_port     = 443
+    target_protocol = "https"
+    tls_passthrough = true
+  }
+  forwarding_rule {
+    entry_port      = 80
+    entry_protocol  = "http"
+    target_port     = 80
+    target_protocol = "http"
+  }
+  healthcheck {
+    check_interval_seconds   = 60
+    healthy_threshold        = 5
+    port                     = 443
+    protocol                 = "tcp"
+    response_timeout_seconds = 5
+    unhealthy_threshold      = 3
+  }
+  name   = "api-minimal-do-k8s-local"
+  region = "nyc1"
+}
+
+resource "digitalocean_volume" "kops-etcd-1-etcd-events-minimal-do-k8s-local" {
+  name   = "kops-etcd-1-etcd-events-minimal-do-k8s-local"
+  region = "nyc1"
+  size   = 20
+  tags   = ["KubernetesCluster:minimal-do-k8s-local", "etcdCluster-events:etcd-1", "k8s-index:etcd-1"]
+}
+
+resource "digitalocean_volume" "kops-etcd-1-etcd-main-minimal-do-k8s-local" {
+  name   = "kops-etcd-1-etcd-main-minimal-do-k8s-local"
+  region = "nyc1"
+  size   = 20
+  tags   = ["KubernetesCluster:minimal-do-k8s-local", "etcdCluster-main:etcd-1", "k8s-index:etcd-1"]
+}
+
+terraform {
+  required_version = ">= 0.12.26"
+  required_providers {
+    digitalocean = {
+      "source"  = "digitalocean/digitalocean"
+      "version" = ">= 2.7.0"
+    }
+  }
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package fi
 
+import "net/http"
+
 // Authenticator generates authentication credentials for requests.
 type Authenticator interface {
 	CreateToken(body []byte) (string, error)
@@ -32,5 +34,7 @@ type VerifyResult struct {
 
 // Verifier verifies authentication credentials for requests.
 type Verifier interface {
-	VerifyToken(token string, body []byte) (*VerifyResult, error)
+	// VerifyToken verifies the token of the request with the body.
+	// rawRequest is the request the token was sent with, for verifiers that also check the connection.
+	VerifyToken(rawRequest *http.Request, token string, body []byte) (*VerifyResult, error)
 }
@@ -22,8 +22,10 @@ import (
 	"math/big"
 
 	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/apis/kops"
 	kopsinternalversion "k8s.io/kops/pkg/client/clientset_generated/clientset/typed/kops/internalversion"
@@ -31,21 +33,25 @@ import (
 	"k8s.io/kops/util/pkg/vfs"
 )
 
-// ClientsetCAStore is a CAStore implementation that stores keypairs in Keyset on a API server
+// ClientsetCAStore is a CAStore implementation that stores keypairs in Keyset on a API server.
+// If secrets is set, the private keys are stored in Kubernetes Secrets rather than in the Keysets,
+// so that access to them can be restricted separately.
 type ClientsetCAStore struct {
 	cluster   *kops.Cluster
 	namespace string
 	clientset kopsinternalversion.KopsInterface
+	secrets   corev1client.SecretsGetter
 }
 
 var _ CAStore = &ClientsetCAStore{}
 var _ SSHCredentialStore = &ClientsetCAStore{}
 
-// NewClientsetCAStore is the constructor for ClientsetCAStore
-func NewClientsetCAStore(cluster *kops.Cluster, clientset kopsinternalversion.KopsInterface, namespace string) CAStore {
+// NewClientsetCAStore is the constructor for ClientsetCAStore; secrets may be nil to store the private keys in the Keysets
+func NewClientsetCAStore(cluster *kops.Cluster, clientset kopsinternalversion.KopsInterface, secrets corev1client.SecretsGetter, namespace string) CAStore {
 	c := &ClientsetCAStore{
 		cluster:   cluster,
 		clientset: clientset,
+		secrets:   secrets,
 		namespace: namespace,
 	}
 
@@ -114,6 +120,16 @@ func (c *ClientsetCAStore) loadKeyset(ctx context.Context, name string) (*Keyset
 		return nil, fmt.Errorf("error reading keyset %q: %v", name, err)
 	}
 
+	if c.secrets != nil {
+		secret, err := c.secrets.Secrets(c.namespace).Get(ctx, KubernetesSecretName(KubernetesSecretTypeKeyset, name), metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("error reading private keys of keyset %q: %v", name, err)
+		}
+		if err == nil {
+			addPrivateMaterial(o, secret)
+		}
+	}
+
 	keyset, err := parseKeyset(o)
 	if err != nil {
 		return nil, err
@@ -121,6 +137,16 @@ func (c *ClientsetCAStore) loadKeyset(ctx context.Context, name string) (*Keyset
 	return keyset, nil
 }
 
+// addPrivateMaterial adds the private keys held by the Secret to the items of the Keyset
+func addPrivateMaterial(o *kops.Keyset, secret *corev1.Secret) {
+	for i := range o.Spec.Keys {
+		item := &o.Spec.Keys[i]
+		if privateMaterial := secret.Data[item.Id]; len(privateMaterial) != 0 {
+			item.PrivateMaterial = privateMaterial
+		}
+	}
+}
+
 // FindPrimary returns the primary KeysetItem in the Keyset
 func FindPrimary(keyset *kops.Keyset) *kops.KeysetItem {
 	var primary *kops.KeysetItem
@@ -174,10 +200,27 @@ func (c *ClientsetCAStore) ListKeysets() (map[string]*Keyset, error) {
 			return nil, fmt.Errorf("error listing Keysets: %v", err)
 		}
 
+		privateKeys := make(map[string]*corev1.Secret)
+		if c.secrets != nil {
+			secrets, err := c.secrets.Secrets(c.namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: LabelKubernetesSecretType + "=" + KubernetesSecretTypeKeyset,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error listing private keys: %v", err)
+			}
+			for i := range secrets.Items {
+				secret := &secrets.Items[i]
+				privateKeys[secret.Annotations[AnnotationKubernetesSecretName]] = secret
+			}
+		}
+
 		for i := range list.Items {
 			keyset := &list.Items[i]
 			switch keyset.Spec.Type {
 			case kops.SecretTypeKeypair:
+				if secret := privateKeys[keyset.Name]; secret != nil {
+					addPrivateMaterial(keyset, secret)
+				}
 				item, err := parseKeyset(keyset)
 				if err != nil {
 					return nil, fmt.Errorf("parsing keyset %q: %w", keyset.Name, err)
@@ -232,6 +275,13 @@ func (c *ClientsetCAStore) storeKeyset(ctx context.Context, name string, keyset
 		return err
 	}
 
+	if c.secrets != nil {
+		// The private keys are written first, so that a Keyset never refers to private keys that are not stored
+		if err := c.storePrivateMaterial(ctx, kopsKeyset); err != nil {
+			return err
+		}
+	}
+
 	oldKeyset, err := client.Get(ctx, name, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
 		oldKeyset = nil
@@ -259,6 +309,48 @@ func (c *ClientsetCAStore) storeKeyset(ctx context.Context, name string, keyset
 	return nil
 }
 
+// storePrivateMaterial moves the private keys of the Keyset to its Secret
+func (c *ClientsetCAStore) storePrivateMaterial(ctx context.Context, o *kops.Keyset) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        KubernetesSecretName(KubernetesSecretTypeKeyset, o.Name),
+			Labels:      map[string]string{LabelKubernetesSecretType: KubernetesSecretTypeKeyset},
+			Annotations: map[string]string{AnnotationKubernetesSecretName: o.Name},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: make(map[string][]byte),
+	}
+	for i := range o.Spec.Keys {
+		item := &o.Spec.Keys[i]
+		if len(item.PrivateMaterial) != 0 {
+			secret.Data[item.Id] = item.PrivateMaterial
+			item.PrivateMaterial = nil
+		}
+	}
+
+	return WriteKubernetesSecret(ctx, c.secrets.Secrets(c.namespace), secret)
+}
+
+// WriteKubernetesSecret creates the Secret, or replaces the data of the existing Secret
+func WriteKubernetesSecret(ctx context.Context, client corev1client.SecretInterface, secret *corev1.Secret) error {
+	existing, err := client.Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("error reading secret %q: %v", secret.Name, err)
+		}
+		if _, err := client.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating secret %q: %v", secret.Name, err)
+		}
+		return nil
+	}
+
+	secret.ObjectMeta.ResourceVersion = existing.ObjectMeta.ResourceVersion
+	if _, err := client.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating secret %q: %v", secret.Name, err)
+	}
+	return nil
+}
+
 // addSSHCredential saves the specified SSH Credential to the registry, doing an update or insert
 func (c *ClientsetCAStore) addSSHCredential(ctx context.Context, name string, publicKey string) error {
 	create := false
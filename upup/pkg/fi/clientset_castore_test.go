@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/pkg/apis/kops"
+	kopsfake "k8s.io/kops/pkg/client/clientset_generated/clientset/fake"
+	"k8s.io/kops/pkg/pki"
+)
+
+func TestClientsetCAStoreRoundTripWithSecrets(t *testing.T) {
+	ctx := context.Background()
+	kopsClient := kopsfake.NewSimpleClientset().Kops()
+	kubeClient := kubefake.NewSimpleClientset()
+
+	s := NewClientsetCAStore(&kops.Cluster{}, kopsClient, kubeClient.CoreV1(), "minimal-example-com")
+
+	cert, privateKey, _, err := pki.IssueCert(&pki.IssueCertRequest{
+		Type:    "ca",
+		Subject: pkix.Name{CommonName: "kubernetes"},
+	}, nil)
+	require.NoError(t, err)
+	keyset, err := NewKeyset(cert, privateKey)
+	require.NoError(t, err)
+	require.NoError(t, s.StoreKeyset("kubernetes-ca", keyset))
+
+	o, err := kopsClient.Keysets("minimal-example-com").Get(ctx, "kubernetes-ca", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, o.Spec.Keys, 1)
+	assert.NotEmpty(t, o.Spec.Keys[0].PublicMaterial)
+	assert.Empty(t, o.Spec.Keys[0].PrivateMaterial, "private key stored in the Keyset")
+
+	expected, err := privateKey.AsString()
+	require.NoError(t, err)
+
+	secret, err := kubeClient.CoreV1().Secrets("minimal-example-com").Get(ctx, "kops-keyset-kubernetes-ca", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, KubernetesSecretTypeKeyset, secret.Labels[LabelKubernetesSecretType])
+	assert.Equal(t, "kubernetes-ca", secret.Annotations[AnnotationKubernetesSecretName])
+	assert.Equal(t, expected, string(secret.Data[o.Spec.Keys[0].Id]))
+
+	roundTrip, err := s.FindKeyset("kubernetes-ca")
+	require.NoError(t, err)
+	require.NotNil(t, roundTrip)
+	require.NotNil(t, roundTrip.Primary.PrivateKey)
+	actual, err := roundTrip.Primary.PrivateKey.AsString()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	keysets, err := s.(*ClientsetCAStore).ListKeysets()
+	require.NoError(t, err)
+	require.Contains(t, keysets, "kubernetes-ca")
+	assert.NotNil(t, keysets["kubernetes-ca"].Primary.PrivateKey)
+}
+
+func TestKubernetesSecretName(t *testing.T) {
+	grid := []struct {
+		secretType string
+		name       string
+		expected   string
+	}{
+		{secretType: KubernetesSecretTypeSecret, name: "admin", expected: "kops-secret-admin"},
+		{secretType: KubernetesSecretTypeKeyset, name: "kubernetes-ca", expected: "kops-keyset-kubernetes-ca"},
+		{secretType: KubernetesSecretTypeSecret, name: "system:dns", expected: "kops-secret-system-dns-"},
+		{secretType: KubernetesSecretTypeKeyset, name: "Service_Account", expected: "kops-keyset-service-account-"},
+		{secretType: KubernetesSecretTypeSecret, name: "a.-b", expected: "kops-secret-a--b-"},
+		{secretType: KubernetesSecretTypeSecret, name: strings.Repeat("x", 300) + ":", expected: "kops-secret-" + strings.Repeat("x", 232) + "-"},
+	}
+	for _, g := range grid {
+		t.Run(g.expected, func(t *testing.T) {
+			actual := KubernetesSecretName(g.secretType, g.name)
+			assert.Empty(t, validation.IsDNS1123Subdomain(actual), "name %q is not valid", actual)
+			if g.expected[len(g.expected)-1] == '-' {
+				assert.Regexp(t, "^"+g.expected+"[0-9a-f]{8}$", actual)
+			} else {
+				assert.Equal(t, g.expected, actual)
+			}
+		})
+	}
+
+	// Names that differ only in characters that are not valid in Kubernetes names must not collide
+	assert.NotEqual(t, KubernetesSecretName(KubernetesSecretTypeSecret, "a:b"), KubernetesSecretName(KubernetesSecretTypeSecret, "a_b"))
+}
@@ -345,6 +345,11 @@ var zonesToCloud = map[string]kops.CloudProviderID{
 
 	"blr1": kops.CloudProviderDO,
 
+	"fsn1": kops.CloudProviderHetzner,
+	"nbg1": kops.CloudProviderHetzner,
+	"hel1": kops.CloudProviderHetzner,
+	"ash":  kops.CloudProviderHetzner,
+
 	"cn-qingdao-b": kops.CloudProviderALI,
 	"cn-qingdao-c": kops.CloudProviderALI,
 
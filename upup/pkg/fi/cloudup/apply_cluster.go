@@ -53,6 +53,7 @@ import (
 	"k8s.io/kops/pkg/model/components/kubeapiserver"
 	"k8s.io/kops/pkg/model/domodel"
 	"k8s.io/kops/pkg/model/gcemodel"
+	"k8s.io/kops/pkg/model/hetznermodel"
 	"k8s.io/kops/pkg/model/iam"
 	"k8s.io/kops/pkg/model/openstackmodel"
 	"k8s.io/kops/pkg/templates"
@@ -66,7 +67,9 @@ import (
 	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
 	"k8s.io/kops/upup/pkg/fi/cloudup/do"
 	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/resourcegraph"
 	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
 	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 	"k8s.io/kops/upup/pkg/fi/fitasks"
@@ -87,7 +90,7 @@ const (
 
 var (
 	// TerraformCloudProviders is the list of cloud providers with terraform target support
-	TerraformCloudProviders = []kops.CloudProviderID{kops.CloudProviderAWS, kops.CloudProviderGCE, kops.CloudProviderALI}
+	TerraformCloudProviders = []kops.CloudProviderID{kops.CloudProviderAWS, kops.CloudProviderGCE, kops.CloudProviderALI, kops.CloudProviderOpenstack, kops.CloudProviderDO, kops.CloudProviderAzure}
 )
 
 type ApplyClusterCmd struct {
@@ -139,6 +142,9 @@ type ApplyClusterCmd struct {
 	// GetAssets is whether this is called just to obtain the list of assets.
 	GetAssets bool
 
+	// DryRunOut is where the dry-run report is printed; it defaults to stdout.
+	DryRunOut io.Writer
+
 	// TaskMap is the map of tasks that we built (output)
 	TaskMap map[string]fi.Task
 
@@ -417,6 +423,19 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 			}
 
 		}
+	case kops.CloudProviderHetzner:
+		{
+			if !featureflag.Hetzner.Enabled() {
+				return fmt.Errorf("Hetzner Cloud support is currently alpha, and is feature-gated.  export KOPS_FEATURE_FLAGS=Hetzner")
+			}
+
+			if len(sshPublicKeys) == 0 {
+				return fmt.Errorf("SSH public key must be specified when running with Hetzner Cloud (create with `kops create secret --name %s sshpublickey admin -i ~/.ssh/id_rsa.pub`)", cluster.ObjectMeta.Name)
+			}
+			if len(sshPublicKeys) > 1 {
+				return fmt.Errorf("exactly one 'admin' SSH public key can be specified when running with Hetzner Cloud; please delete a key using `kops delete secret`")
+			}
+		}
 	case kops.CloudProviderAWS:
 		{
 			awsCloud := cloud.(awsup.AWSCloud)
@@ -610,6 +629,16 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 				&domodel.APILoadBalancerModelBuilder{DOModelContext: doModelContext, Lifecycle: securityLifecycle},
 				&domodel.DropletBuilder{DOModelContext: doModelContext, BootstrapScriptBuilder: bootstrapScriptBuilder, Lifecycle: clusterLifecycle},
 			)
+		case kops.CloudProviderHetzner:
+			hetznerModelContext := &hetznermodel.HetznerModelContext{
+				KopsModelContext: modelContext,
+			}
+			l.Builders = append(l.Builders,
+				&hetznermodel.NetworkModelBuilder{HetznerModelContext: hetznerModelContext, Lifecycle: networkLifecycle},
+				&hetznermodel.FirewallModelBuilder{HetznerModelContext: hetznerModelContext, Lifecycle: securityLifecycle},
+				&hetznermodel.APILoadBalancerModelBuilder{HetznerModelContext: hetznerModelContext, Lifecycle: clusterLifecycle},
+				&hetznermodel.ServerGroupModelBuilder{HetznerModelContext: hetznerModelContext, BootstrapScriptBuilder: bootstrapScriptBuilder, Lifecycle: clusterLifecycle},
+			)
 		case kops.CloudProviderGCE:
 			gceModelContext := &gcemodel.GCEModelContext{
 				KopsModelContext: modelContext,
@@ -688,6 +717,8 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 			target = awsup.NewAWSAPITarget(cloud.(awsup.AWSCloud))
 		case kops.CloudProviderDO:
 			target = do.NewDOAPITarget(cloud.(do.DOCloud))
+		case kops.CloudProviderHetzner:
+			target = hetzner.NewHetznerAPITarget(cloud.(hetzner.HetznerCloud))
 		case kops.CloudProviderOpenstack:
 			target = openstack.NewOpenstackAPITarget(cloud.(openstack.OpenstackCloud))
 		case kops.CloudProviderALI:
@@ -739,8 +770,18 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 		fmt.Printf("%s\n", starline)
 		fmt.Printf("\n")
 
+	case TargetResourceGraph:
+		checkExisting = false
+		target = resourcegraph.NewResourceGraphTarget(cloud, c.OutDir)
+
+		// Can cause conflicts with the tools managing the resources
+		shouldPrecreateDNS = false
+
 	case TargetDryRun:
 		var out io.Writer = os.Stdout
+		if c.DryRunOut != nil {
+			out = c.DryRunOut
+		}
 		if c.GetAssets {
 			out = io.Discard
 		}
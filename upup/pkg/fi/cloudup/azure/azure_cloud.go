@@ -78,8 +78,20 @@ type azureCloudImplementation struct {
 
 var _ fi.Cloud = &azureCloudImplementation{}
 
+// azureCloudInstances are the clouds returned by NewAzureCloud, keyed by location.
+var azureCloudInstances map[string]AzureCloud = make(map[string]AzureCloud)
+
+// InstallMockAzureCloud makes NewAzureCloud return the cloud for its location, for use in tests.
+func InstallMockAzureCloud(c AzureCloud) {
+	azureCloudInstances[c.Region()] = c
+}
+
 // NewAzureCloud creates a new AzureCloud.
 func NewAzureCloud(subscriptionID, location string, tags map[string]string) (AzureCloud, error) {
+	if raw := azureCloudInstances[location]; raw != nil {
+		return raw, nil
+	}
+
 	authorizer, err := auth.NewAuthorizerFromEnvironment()
 	if err != nil {
 		return nil, err
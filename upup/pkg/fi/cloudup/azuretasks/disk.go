@@ -18,12 +18,15 @@ package azuretasks
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-01/compute"
 	"github.com/Azure/go-autorest/autorest/to"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // Disk is an Azure Managed Disk.
@@ -123,3 +126,39 @@ func (*Disk) RenderAzure(t *azure.AzureAPITarget, a, e, changes *Disk) error {
 		name,
 		disk)
 }
+
+// terraformAzureDisk represents the azurerm_managed_disk resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/managed_disk
+type terraformAzureDisk struct {
+	Name               *string                  `json:"name" cty:"name"`
+	ResourceGroupName  *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	Location           *string                  `json:"location" cty:"location"`
+	CreateOption       *string                  `json:"create_option" cty:"create_option"`
+	StorageAccountType *string                  `json:"storage_account_type" cty:"storage_account_type"`
+	DiskSizeGB         *int32                   `json:"disk_size_gb" cty:"disk_size_gb"`
+	Tags               map[string]*string       `json:"tags,omitempty" cty:"tags"`
+}
+
+// RenderTerraform renders the Disk.
+func (*Disk) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Disk) error {
+	tf := &terraformAzureDisk{
+		Name:              e.Name,
+		ResourceGroupName: e.ResourceGroup.TerraformLink(),
+		Location:          fi.String(t.Cloud.Region()),
+		CreateOption:      fi.String(string(compute.Empty)),
+		// The API creates disks of this type when none is specified.
+		StorageAccountType: fi.String(string(compute.StandardLRS)),
+		DiskSizeGB:         e.SizeGB,
+		Tags:               e.Tags,
+	}
+	return t.RenderResource("azurerm_managed_disk", e.TerraformName(), tf)
+}
+
+// TerraformName returns the name of the terraform resource, which cannot start with a digit.
+func (d *Disk) TerraformName() string {
+	name := fi.StringValue(d.Name)
+	if name[0] >= '0' && name[0] <= '9' {
+		return fmt.Sprintf("disk-%v", name)
+	}
+	return name
+}
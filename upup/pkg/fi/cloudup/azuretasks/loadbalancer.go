@@ -25,6 +25,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // LoadBalancer is an Azure Cloud LoadBalancer
@@ -199,3 +201,134 @@ func (*LoadBalancer) RenderAzure(t *azure.AzureAPITarget, a, e, changes *LoadBal
 		*e.Name,
 		lb)
 }
+
+// terraformAzureLoadBalancer represents the azurerm_lb resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/lb
+type terraformAzureLoadBalancer struct {
+	Name                    *string                                            `json:"name" cty:"name"`
+	ResourceGroupName       *terraformWriter.Literal                           `json:"resource_group_name" cty:"resource_group_name"`
+	Location                *string                                            `json:"location" cty:"location"`
+	SKU                     *string                                            `json:"sku" cty:"sku"`
+	FrontendIPConfiguration *terraformAzureLoadBalancerFrontendIPConfiguration `json:"frontend_ip_configuration" cty:"frontend_ip_configuration"`
+	Tags                    map[string]*string                                 `json:"tags,omitempty" cty:"tags"`
+}
+
+type terraformAzureLoadBalancerFrontendIPConfiguration struct {
+	Name                       *string                  `json:"name" cty:"name"`
+	PublicIPAddressID          *terraformWriter.Literal `json:"public_ip_address_id,omitempty" cty:"public_ip_address_id"`
+	SubnetID                   *terraformWriter.Literal `json:"subnet_id,omitempty" cty:"subnet_id"`
+	PrivateIPAddressAllocation *string                  `json:"private_ip_address_allocation,omitempty" cty:"private_ip_address_allocation"`
+}
+
+// terraformAzureLoadBalancerBackendAddressPool represents the azurerm_lb_backend_address_pool resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/lb_backend_address_pool
+type terraformAzureLoadBalancerBackendAddressPool struct {
+	Name           *string                  `json:"name" cty:"name"`
+	LoadBalancerID *terraformWriter.Literal `json:"loadbalancer_id" cty:"loadbalancer_id"`
+}
+
+// terraformAzureLoadBalancerProbe represents the azurerm_lb_probe resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/lb_probe
+type terraformAzureLoadBalancerProbe struct {
+	Name              *string                  `json:"name" cty:"name"`
+	ResourceGroupName *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	LoadBalancerID    *terraformWriter.Literal `json:"loadbalancer_id" cty:"loadbalancer_id"`
+	Protocol          *string                  `json:"protocol" cty:"protocol"`
+	Port              *int32                   `json:"port" cty:"port"`
+	IntervalInSeconds *int32                   `json:"interval_in_seconds" cty:"interval_in_seconds"`
+	NumberOfProbes    *int32                   `json:"number_of_probes" cty:"number_of_probes"`
+}
+
+// terraformAzureLoadBalancerRule represents the azurerm_lb_rule resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/lb_rule
+type terraformAzureLoadBalancerRule struct {
+	Name                        *string                  `json:"name" cty:"name"`
+	ResourceGroupName           *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	LoadBalancerID              *terraformWriter.Literal `json:"loadbalancer_id" cty:"loadbalancer_id"`
+	Protocol                    *string                  `json:"protocol" cty:"protocol"`
+	FrontendPort                *int32                   `json:"frontend_port" cty:"frontend_port"`
+	BackendPort                 *int32                   `json:"backend_port" cty:"backend_port"`
+	FrontendIPConfigurationName *string                  `json:"frontend_ip_configuration_name" cty:"frontend_ip_configuration_name"`
+	BackendAddressPoolID        *terraformWriter.Literal `json:"backend_address_pool_id" cty:"backend_address_pool_id"`
+	ProbeID                     *terraformWriter.Literal `json:"probe_id" cty:"probe_id"`
+	IdleTimeoutInMinutes        *int32                   `json:"idle_timeout_in_minutes" cty:"idle_timeout_in_minutes"`
+	EnableFloatingIP            *bool                    `json:"enable_floating_ip" cty:"enable_floating_ip"`
+	LoadDistribution            *string                  `json:"load_distribution" cty:"load_distribution"`
+}
+
+// RenderTerraform renders the Loadbalancer along with its backend address pool, probe and rule,
+// which are separate resources in terraform.
+func (*LoadBalancer) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LoadBalancer) error {
+	name := *e.Name
+	subscriptionID := t.Cloud.(azure.AzureCloud).SubscriptionID()
+
+	feConfig := &terraformAzureLoadBalancerFrontendIPConfiguration{
+		Name: fi.String("LoadBalancerFrontEnd"),
+	}
+	if fi.BoolValue(e.External) {
+		// The Public IP Address is named after the Loadbalancer.
+		feConfig.PublicIPAddressID = terraformWriter.LiteralProperty("azurerm_public_ip", name, "id")
+	} else {
+		feConfig.SubnetID = e.Subnet.TerraformLink(subscriptionID)
+		feConfig.PrivateIPAddressAllocation = fi.String(string(network.Dynamic))
+	}
+
+	tf := &terraformAzureLoadBalancer{
+		Name:                    e.Name,
+		ResourceGroupName:       e.ResourceGroup.TerraformLink(),
+		Location:                fi.String(t.Cloud.Region()),
+		SKU:                     fi.String(string(network.LoadBalancerSkuNameBasic)),
+		FrontendIPConfiguration: feConfig,
+		Tags:                    e.Tags,
+	}
+	if err := t.RenderResource("azurerm_lb", name, tf); err != nil {
+		return err
+	}
+
+	pool := &terraformAzureLoadBalancerBackendAddressPool{
+		Name:           fi.String("LoadBalancerBackEnd"),
+		LoadBalancerID: e.TerraformLink(),
+	}
+	if err := t.RenderResource("azurerm_lb_backend_address_pool", name, pool); err != nil {
+		return err
+	}
+
+	probe := &terraformAzureLoadBalancerProbe{
+		Name:              fi.String("Health-TCP-443"),
+		ResourceGroupName: e.ResourceGroup.TerraformLink(),
+		LoadBalancerID:    e.TerraformLink(),
+		Protocol:          fi.String(string(network.ProbeProtocolTCP)),
+		Port:              fi.Int32(443),
+		IntervalInSeconds: fi.Int32(15),
+		NumberOfProbes:    fi.Int32(4),
+	}
+	if err := t.RenderResource("azurerm_lb_probe", name, probe); err != nil {
+		return err
+	}
+
+	rule := &terraformAzureLoadBalancerRule{
+		Name:                        fi.String("TCP-443"),
+		ResourceGroupName:           e.ResourceGroup.TerraformLink(),
+		LoadBalancerID:              e.TerraformLink(),
+		Protocol:                    fi.String(string(network.TransportProtocolTCP)),
+		FrontendPort:                fi.Int32(443),
+		BackendPort:                 fi.Int32(443),
+		FrontendIPConfigurationName: feConfig.Name,
+		BackendAddressPoolID:        e.terraformBackendAddressPoolID(),
+		ProbeID:                     terraformWriter.LiteralProperty("azurerm_lb_probe", name, "id"),
+		IdleTimeoutInMinutes:        fi.Int32(4),
+		EnableFloatingIP:            fi.Bool(false),
+		LoadDistribution:            fi.String(string(network.LoadDistributionDefault)),
+	}
+	return t.RenderResource("azurerm_lb_rule", name, rule)
+}
+
+// TerraformLink returns the ID of the Loadbalancer.
+func (lb *LoadBalancer) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("azurerm_lb", *lb.Name, "id")
+}
+
+// terraformBackendAddressPoolID returns the ID of the backend address pool of the Loadbalancer.
+func (lb *LoadBalancer) terraformBackendAddressPoolID() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("azurerm_lb_backend_address_pool", *lb.Name, "id")
+}
@@ -24,6 +24,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // PublicIPAddress is an Azure Cloud Public IP Address
@@ -120,3 +122,32 @@ func (*PublicIPAddress) RenderAzure(t *azure.AzureAPITarget, a, e, changes *Publ
 		*e.Name,
 		p)
 }
+
+// terraformAzurePublicIPAddress represents the azurerm_public_ip resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/public_ip
+type terraformAzurePublicIPAddress struct {
+	Name              *string                  `json:"name" cty:"name"`
+	ResourceGroupName *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	Location          *string                  `json:"location" cty:"location"`
+	AllocationMethod  *string                  `json:"allocation_method" cty:"allocation_method"`
+	IPVersion         *string                  `json:"ip_version" cty:"ip_version"`
+	Tags              map[string]*string       `json:"tags,omitempty" cty:"tags"`
+}
+
+// RenderTerraform renders the Public IP Address.
+func (*PublicIPAddress) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *PublicIPAddress) error {
+	tf := &terraformAzurePublicIPAddress{
+		Name:              e.Name,
+		ResourceGroupName: e.ResourceGroup.TerraformLink(),
+		Location:          fi.String(t.Cloud.Region()),
+		AllocationMethod:  fi.String(string(network.Dynamic)),
+		IPVersion:         fi.String(string(network.IPv4)),
+		Tags:              e.Tags,
+	}
+	return t.RenderResource("azurerm_public_ip", *e.Name, tf)
+}
+
+// TerraformLink returns the ID of the Public IP Address.
+func (p *PublicIPAddress) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("azurerm_public_ip", *p.Name, "id")
+}
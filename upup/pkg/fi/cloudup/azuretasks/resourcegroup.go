@@ -18,12 +18,15 @@ package azuretasks
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2020-06-01/resources"
 	"github.com/Azure/go-autorest/autorest/to"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // ResourceGroup is an Azure resource group.
@@ -110,3 +113,41 @@ func (*ResourceGroup) RenderAzure(t *azure.AzureAPITarget, a, e, changes *Resour
 			Tags:     e.Tags,
 		})
 }
+
+// terraformAzureResourceGroup represents the azurerm_resource_group resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/resource_group
+type terraformAzureResourceGroup struct {
+	Name     *string            `json:"name" cty:"name"`
+	Location *string            `json:"location" cty:"location"`
+	Tags     map[string]*string `json:"tags,omitempty" cty:"tags"`
+}
+
+// RenderTerraform renders the Resource Group, unless it is shared.
+func (*ResourceGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *ResourceGroup) error {
+	if fi.BoolValue(e.Shared) {
+		return nil
+	}
+
+	tf := &terraformAzureResourceGroup{
+		Name:     e.Name,
+		Location: fi.String(t.Cloud.Region()),
+		Tags:     e.Tags,
+	}
+	return t.RenderResource("azurerm_resource_group", *e.Name, tf)
+}
+
+// TerraformLink returns the name of the Resource Group.
+func (r *ResourceGroup) TerraformLink() *terraformWriter.Literal {
+	if fi.BoolValue(r.Shared) {
+		return terraformWriter.LiteralFromStringValue(*r.Name)
+	}
+	return terraformWriter.LiteralProperty("azurerm_resource_group", *r.Name, "name")
+}
+
+// terraformID returns the ID of the Resource Group, which is used as the scope of role assignments.
+func (r *ResourceGroup) terraformID(subscriptionID string) *terraformWriter.Literal {
+	if fi.BoolValue(r.Shared) {
+		return terraformWriter.LiteralFromStringValue(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscriptionID, *r.Name))
+	}
+	return terraformWriter.LiteralProperty("azurerm_resource_group", *r.Name, "id")
+}
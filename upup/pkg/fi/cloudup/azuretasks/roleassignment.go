@@ -24,6 +24,8 @@ import (
 
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-01/compute"
 	// Use 2018-01-01-preview API as we need the version to create
@@ -178,3 +180,37 @@ func createNewRoleAssignment(t *azure.AzureAPITarget, e *RoleAssignment) error {
 	e.ID = ra.ID
 	return nil
 }
+
+// terraformAzureRoleAssignment represents the azurerm_role_assignment resource in terraform.
+// The name of the Role Assignment is left to terraform to generate.
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/role_assignment
+type terraformAzureRoleAssignment struct {
+	Scope            *terraformWriter.Literal `json:"scope" cty:"scope"`
+	RoleDefinitionID *terraformWriter.Literal `json:"role_definition_id" cty:"role_definition_id"`
+	PrincipalID      *terraformWriter.Literal `json:"principal_id" cty:"principal_id"`
+}
+
+// RenderTerraform renders the Role Assignment, granting the role to the identity of the VM Scale Set.
+func (*RoleAssignment) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *RoleAssignment) error {
+	subscriptionID := t.Cloud.(azure.AzureCloud).SubscriptionID()
+	roleDefinitionPath := fmt.Sprintf("/providers/Microsoft.Authorization/roleDefinitions/%s", *e.RoleDefID)
+
+	// The role definition is scoped to the resource group, as when creating the Role Assignment through the API.
+	var roleDefinitionID *terraformWriter.Literal
+	if fi.BoolValue(e.ResourceGroup.Shared) {
+		roleDefinitionID = terraformWriter.LiteralFromStringValue(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s%s", subscriptionID, *e.ResourceGroup.Name, roleDefinitionPath))
+	} else {
+		rg := terraformWriter.LiteralProperty("azurerm_resource_group", *e.ResourceGroup.Name, "id")
+		roleDefinitionID = terraformWriter.LiteralFunctionExpression("format", []string{
+			fmt.Sprintf("%q", "%s"+roleDefinitionPath),
+			rg.ResourceType + "." + rg.ResourceName + "." + rg.ResourceProp,
+		})
+	}
+
+	tf := &terraformAzureRoleAssignment{
+		Scope:            e.ResourceGroup.terraformID(subscriptionID),
+		RoleDefinitionID: roleDefinitionID,
+		PrincipalID:      e.VMScaleSet.terraformPrincipalID(),
+	}
+	return t.RenderResource("azurerm_role_assignment", *e.Name, tf)
+}
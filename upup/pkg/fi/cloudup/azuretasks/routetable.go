@@ -24,6 +24,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // RouteTable is an Azure Route Table.
@@ -112,3 +114,28 @@ func (*RouteTable) RenderAzure(t *azure.AzureAPITarget, a, e, changes *RouteTabl
 		*e.Name,
 		rt)
 }
+
+// terraformAzureRouteTable represents the azurerm_route_table resource in terraform.
+// Routes are managed by the cloud controller manager, so none are rendered.
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/route_table
+type terraformAzureRouteTable struct {
+	Name              *string                  `json:"name" cty:"name"`
+	ResourceGroupName *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	Location          *string                  `json:"location" cty:"location"`
+	Tags              map[string]*string       `json:"tags,omitempty" cty:"tags"`
+}
+
+// RenderTerraform renders the Route Table, unless it is shared.
+func (*RouteTable) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *RouteTable) error {
+	if fi.BoolValue(e.Shared) {
+		return nil
+	}
+
+	tf := &terraformAzureRouteTable{
+		Name:              e.Name,
+		ResourceGroupName: e.ResourceGroup.TerraformLink(),
+		Location:          fi.String(t.Cloud.Region()),
+		Tags:              e.Tags,
+	}
+	return t.RenderResource("azurerm_route_table", *e.Name, tf)
+}
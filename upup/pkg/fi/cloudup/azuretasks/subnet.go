@@ -23,6 +23,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // Subnet is an Azure subnet.
@@ -119,3 +121,41 @@ func (*Subnet) RenderAzure(t *azure.AzureAPITarget, a, e, changes *Subnet) error
 		*e.Name,
 		subnet)
 }
+
+// terraformAzureSubnet represents the azurerm_subnet resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/subnet
+type terraformAzureSubnet struct {
+	Name               *string                  `json:"name" cty:"name"`
+	ResourceGroupName  *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	VirtualNetworkName *terraformWriter.Literal `json:"virtual_network_name" cty:"virtual_network_name"`
+	AddressPrefixes    []string                 `json:"address_prefixes" cty:"address_prefixes"`
+}
+
+// RenderTerraform renders the Subnet, unless it is shared.
+func (*Subnet) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Subnet) error {
+	if fi.BoolValue(e.Shared) {
+		return nil
+	}
+
+	tf := &terraformAzureSubnet{
+		Name:               e.Name,
+		ResourceGroupName:  e.ResourceGroup.TerraformLink(),
+		VirtualNetworkName: e.VirtualNetwork.TerraformLink(),
+		AddressPrefixes:    []string{fi.StringValue(e.CIDR)},
+	}
+	return t.RenderResource("azurerm_subnet", *e.Name, tf)
+}
+
+// TerraformLink returns the ID of the Subnet.
+func (s *Subnet) TerraformLink(subscriptionID string) *terraformWriter.Literal {
+	if fi.BoolValue(s.Shared) {
+		subnetID := SubnetID{
+			SubscriptionID:     subscriptionID,
+			ResourceGroupName:  *s.ResourceGroup.Name,
+			VirtualNetworkName: *s.VirtualNetwork.Name,
+			SubnetName:         *s.Name,
+		}
+		return terraformWriter.LiteralFromStringValue(subnetID.String())
+	}
+	return terraformWriter.LiteralProperty("azurerm_subnet", *s.Name, "id")
+}
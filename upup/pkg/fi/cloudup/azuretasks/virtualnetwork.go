@@ -25,6 +25,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // VirtualNetwork is an Azure Virtual Network.
@@ -136,3 +138,37 @@ func (*VirtualNetwork) RenderAzure(t *azure.AzureAPITarget, a, e, changes *Virtu
 		*e.Name,
 		vnet)
 }
+
+// terraformAzureVirtualNetwork represents the azurerm_virtual_network resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/virtual_network
+type terraformAzureVirtualNetwork struct {
+	Name              *string                  `json:"name" cty:"name"`
+	ResourceGroupName *terraformWriter.Literal `json:"resource_group_name" cty:"resource_group_name"`
+	Location          *string                  `json:"location" cty:"location"`
+	AddressSpace      []string                 `json:"address_space" cty:"address_space"`
+	Tags              map[string]*string       `json:"tags,omitempty" cty:"tags"`
+}
+
+// RenderTerraform renders the Virtual Network, unless it is shared.
+func (*VirtualNetwork) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *VirtualNetwork) error {
+	if fi.BoolValue(e.Shared) {
+		return nil
+	}
+
+	tf := &terraformAzureVirtualNetwork{
+		Name:              e.Name,
+		ResourceGroupName: e.ResourceGroup.TerraformLink(),
+		Location:          fi.String(t.Cloud.Region()),
+		AddressSpace:      []string{fi.StringValue(e.CIDR)},
+		Tags:              e.Tags,
+	}
+	return t.RenderResource("azurerm_virtual_network", *e.Name, tf)
+}
+
+// TerraformLink returns the name of the Virtual Network.
+func (n *VirtualNetwork) TerraformLink() *terraformWriter.Literal {
+	if fi.BoolValue(n.Shared) {
+		return terraformWriter.LiteralFromStringValue(*n.Name)
+	}
+	return terraformWriter.LiteralProperty("azurerm_virtual_network", *n.Name, "name")
+}
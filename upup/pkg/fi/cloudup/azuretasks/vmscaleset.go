@@ -27,6 +27,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // SubnetID contains the resource ID/names required to construct a subnet ID.
@@ -386,3 +388,163 @@ func (s *VMScaleSet) RenderAzure(t *azure.AzureAPITarget, a, e, changes *VMScale
 	e.PrincipalID = result.Identity.PrincipalID
 	return nil
 }
+
+// terraformAzureVMScaleSet represents the azurerm_linux_virtual_machine_scale_set resource in terraform
+// https://registry.terraform.io/providers/hashicorp/azurerm/latest/docs/resources/linux_virtual_machine_scale_set
+type terraformAzureVMScaleSet struct {
+	Name                          *string                                 `json:"name" cty:"name"`
+	ResourceGroupName             *terraformWriter.Literal                `json:"resource_group_name" cty:"resource_group_name"`
+	Location                      *string                                 `json:"location" cty:"location"`
+	SKU                           *string                                 `json:"sku" cty:"sku"`
+	Instances                     *int64                                  `json:"instances" cty:"instances"`
+	Zones                         []string                                `json:"zones,omitempty" cty:"zones"`
+	ComputerNamePrefix            *string                                 `json:"computer_name_prefix" cty:"computer_name_prefix"`
+	AdminUsername                 *string                                 `json:"admin_username" cty:"admin_username"`
+	AdminSSHKey                   *terraformAzureVMScaleSetAdminSSHKey    `json:"admin_ssh_key,omitempty" cty:"admin_ssh_key"`
+	DisablePasswordAuthentication *bool                                   `json:"disable_password_authentication" cty:"disable_password_authentication"`
+	CustomData                    *terraformWriter.Literal                `json:"custom_data,omitempty" cty:"custom_data"`
+	SourceImageID                 *string                                 `json:"source_image_id,omitempty" cty:"source_image_id"`
+	SourceImageReference          *terraformAzureVMScaleSetImageReference `json:"source_image_reference,omitempty" cty:"source_image_reference"`
+	OSDisk                        *terraformAzureVMScaleSetOSDisk         `json:"os_disk" cty:"os_disk"`
+	NetworkInterface              *terraformAzureVMScaleSetNetwork        `json:"network_interface" cty:"network_interface"`
+	Identity                      *terraformAzureVMScaleSetIdentity       `json:"identity" cty:"identity"`
+	UpgradeMode                   *string                                 `json:"upgrade_mode" cty:"upgrade_mode"`
+	Tags                          map[string]*string                      `json:"tags,omitempty" cty:"tags"`
+}
+
+type terraformAzureVMScaleSetAdminSSHKey struct {
+	Username  *string                  `json:"username" cty:"username"`
+	PublicKey *terraformWriter.Literal `json:"public_key" cty:"public_key"`
+}
+
+type terraformAzureVMScaleSetImageReference struct {
+	Publisher *string `json:"publisher" cty:"publisher"`
+	Offer     *string `json:"offer" cty:"offer"`
+	SKU       *string `json:"sku" cty:"sku"`
+	Version   *string `json:"version" cty:"version"`
+}
+
+type terraformAzureVMScaleSetOSDisk struct {
+	Caching            *string `json:"caching" cty:"caching"`
+	StorageAccountType *string `json:"storage_account_type" cty:"storage_account_type"`
+	DiskSizeGB         *int32  `json:"disk_size_gb,omitempty" cty:"disk_size_gb"`
+}
+
+type terraformAzureVMScaleSetNetwork struct {
+	Name               *string                                  `json:"name" cty:"name"`
+	Primary            *bool                                    `json:"primary" cty:"primary"`
+	EnableIPForwarding *bool                                    `json:"enable_ip_forwarding" cty:"enable_ip_forwarding"`
+	IPConfiguration    *terraformAzureVMScaleSetIPConfiguration `json:"ip_configuration" cty:"ip_configuration"`
+}
+
+type terraformAzureVMScaleSetIPConfiguration struct {
+	Name                              *string                                  `json:"name" cty:"name"`
+	Primary                           *bool                                    `json:"primary" cty:"primary"`
+	Version                           *string                                  `json:"version" cty:"version"`
+	SubnetID                          *terraformWriter.Literal                 `json:"subnet_id" cty:"subnet_id"`
+	LoadBalancerBackendAddressPoolIDs []*terraformWriter.Literal               `json:"load_balancer_backend_address_pool_ids,omitempty" cty:"load_balancer_backend_address_pool_ids"`
+	PublicIPAddress                   *terraformAzureVMScaleSetPublicIPAddress `json:"public_ip_address,omitempty" cty:"public_ip_address"`
+}
+
+type terraformAzureVMScaleSetPublicIPAddress struct {
+	Name *string `json:"name" cty:"name"`
+}
+
+type terraformAzureVMScaleSetIdentity struct {
+	Type *string `json:"type" cty:"type"`
+}
+
+// RenderTerraform renders the VM Scale Set.
+func (*VMScaleSet) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *VMScaleSet) error {
+	name := *e.Name
+	subscriptionID := t.Cloud.(azure.AzureCloud).SubscriptionID()
+
+	tf := &terraformAzureVMScaleSet{
+		Name:                          e.Name,
+		ResourceGroupName:             e.ResourceGroup.TerraformLink(),
+		Location:                      fi.String(t.Cloud.Region()),
+		SKU:                           e.SKUName,
+		Instances:                     e.Capacity,
+		Zones:                         e.Zones,
+		ComputerNamePrefix:            e.ComputerNamePrefix,
+		AdminUsername:                 e.AdminUser,
+		DisablePasswordAuthentication: fi.Bool(true),
+		UpgradeMode:                   fi.String(string(compute.UpgradeModeManual)),
+		// Assign a system-assigned managed identity so that
+		// Azure creates an identity for VMs and provision
+		// its credentials on the VMs.
+		Identity: &terraformAzureVMScaleSetIdentity{
+			Type: fi.String("SystemAssigned"),
+		},
+		Tags: e.Tags,
+	}
+
+	if e.SSHPublicKey != nil {
+		publicKey, err := t.AddFileResource("azurerm_linux_virtual_machine_scale_set", name, "public_key", fi.NewStringResource(*e.SSHPublicKey), false)
+		if err != nil {
+			return err
+		}
+		tf.AdminSSHKey = &terraformAzureVMScaleSetAdminSSHKey{
+			Username:  e.AdminUser,
+			PublicKey: publicKey,
+		}
+	}
+
+	if e.CustomData != nil {
+		customData, err := t.AddFileResource("azurerm_linux_virtual_machine_scale_set", name, "custom_data", e.CustomData, true)
+		if err != nil {
+			return err
+		}
+		tf.CustomData = customData
+	}
+
+	if image := e.StorageProfile.ImageReference; image != nil {
+		if image.ID != nil {
+			tf.SourceImageID = image.ID
+		} else {
+			tf.SourceImageReference = &terraformAzureVMScaleSetImageReference{
+				Publisher: image.Publisher,
+				Offer:     image.Offer,
+				SKU:       image.Sku,
+				Version:   image.Version,
+			}
+		}
+	}
+	if osDisk := e.StorageProfile.OsDisk; osDisk != nil {
+		tf.OSDisk = &terraformAzureVMScaleSetOSDisk{
+			Caching:    fi.String(string(osDisk.Caching)),
+			DiskSizeGB: osDisk.DiskSizeGB,
+		}
+		if osDisk.ManagedDisk != nil {
+			tf.OSDisk.StorageAccountType = fi.String(string(osDisk.ManagedDisk.StorageAccountType))
+		}
+	}
+
+	ipConfig := &terraformAzureVMScaleSetIPConfiguration{
+		Name:     fi.String(name + "-ipconfig"),
+		Primary:  fi.Bool(true),
+		Version:  fi.String(string(compute.IPv4)),
+		SubnetID: e.Subnet.TerraformLink(subscriptionID),
+	}
+	if fi.BoolValue(e.RequirePublicIP) {
+		ipConfig.PublicIPAddress = &terraformAzureVMScaleSetPublicIPAddress{
+			Name: fi.String(name + "-publicipconfig"),
+		}
+	}
+	if e.LoadBalancer != nil {
+		ipConfig.LoadBalancerBackendAddressPoolIDs = []*terraformWriter.Literal{e.LoadBalancer.terraformBackendAddressPoolID()}
+	}
+	tf.NetworkInterface = &terraformAzureVMScaleSetNetwork{
+		Name:               fi.String(name + "-netconfig"),
+		Primary:            fi.Bool(true),
+		EnableIPForwarding: fi.Bool(true),
+		IPConfiguration:    ipConfig,
+	}
+
+	return t.RenderResource("azurerm_linux_virtual_machine_scale_set", name, tf)
+}
+
+// terraformPrincipalID returns the ID of the system-assigned identity of the VM Scale Set.
+func (s *VMScaleSet) terraformPrincipalID() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("azurerm_linux_virtual_machine_scale_set", *s.Name, "identity[0].principal_id")
+}
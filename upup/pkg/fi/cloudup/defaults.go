@@ -57,7 +57,7 @@ func PerformAssignments(c *kops.Cluster, cloud fi.Cloud) error {
 		}
 	}
 
-	setNetworkCIDR := (cloud.ProviderID() == kops.CloudProviderAWS) || (cloud.ProviderID() == kops.CloudProviderALI) || (cloud.ProviderID() == kops.CloudProviderAzure)
+	setNetworkCIDR := (cloud.ProviderID() == kops.CloudProviderAWS) || (cloud.ProviderID() == kops.CloudProviderALI) || (cloud.ProviderID() == kops.CloudProviderAzure) || (cloud.ProviderID() == kops.CloudProviderHetzner)
 	if setNetworkCIDR && c.Spec.NetworkCIDR == "" {
 		if c.SharedVPC() {
 			var vpcInfo *fi.VPCInfo
@@ -89,6 +89,8 @@ func PerformAssignments(c *kops.Cluster, cloud fi.Cloud) error {
 				c.Spec.NetworkCIDR = "172.20.0.0/16"
 			} else if cloud.ProviderID() == kops.CloudProviderALI {
 				c.Spec.NetworkCIDR = "192.168.0.0/16"
+			} else if cloud.ProviderID() == kops.CloudProviderHetzner {
+				c.Spec.NetworkCIDR = "10.0.0.0/16"
 			}
 		}
 
@@ -111,9 +113,9 @@ func PerformAssignments(c *kops.Cluster, cloud fi.Cloud) error {
 		c.Spec.MasterPublicName = "api." + c.ObjectMeta.Name
 	}
 
-	// We only assign subnet CIDRs on AWS, OpenStack, Ali, and Azure.
+	// We only assign subnet CIDRs on AWS, OpenStack, Ali, Azure and Hetzner.
 	pd := cloud.ProviderID()
-	if pd == kops.CloudProviderAWS || pd == kops.CloudProviderOpenstack || pd == kops.CloudProviderALI || pd == kops.CloudProviderAzure {
+	if pd == kops.CloudProviderAWS || pd == kops.CloudProviderOpenstack || pd == kops.CloudProviderALI || pd == kops.CloudProviderAzure || pd == kops.CloudProviderHetzner {
 		// TODO: Use vpcInfo
 		err := assignCIDRsToSubnets(c, cloud)
 		if err != nil {
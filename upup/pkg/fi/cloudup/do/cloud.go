@@ -95,7 +95,13 @@ func (t *TokenSource) Token() (*oauth2.Token, error) {
 
 // NewCloud returns a Cloud, expecting the env var DIGITALOCEAN_ACCESS_TOKEN
 // NewCloud will return an err if DIGITALOCEAN_ACCESS_TOKEN is not defined
+var doCloudInstances map[string]DOCloud = make(map[string]DOCloud)
+
 func NewDOCloud(region string) (DOCloud, error) {
+	if raw := doCloudInstances[region]; raw != nil {
+		return raw, nil
+	}
+
 	accessToken := os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
 	if accessToken == "" {
 		return nil, errors.New("DIGITALOCEAN_ACCESS_TOKEN is required")
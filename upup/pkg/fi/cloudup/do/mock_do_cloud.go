@@ -29,7 +29,7 @@ import (
 	"k8s.io/kops/upup/pkg/fi"
 )
 
-var _ fi.Cloud = (*doCloudMockImplementation)(nil)
+var _ DOCloud = (*doCloudMockImplementation)(nil)
 
 type doCloudMockImplementation struct {
 	Client *godo.Client
@@ -37,6 +37,13 @@ type doCloudMockImplementation struct {
 	region string
 }
 
+// InstallMockDOCloud makes NewDOCloud return a mock cloud for the region
+func InstallMockDOCloud(region string) *doCloudMockImplementation {
+	c := BuildMockDOCloud(region)
+	doCloudInstances[region] = c
+	return c
+}
+
 func BuildMockDOCloud(region string) *doCloudMockImplementation {
 	return &doCloudMockImplementation{region: region, Client: godo.NewClient(nil)}
 }
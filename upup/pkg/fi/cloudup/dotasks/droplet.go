@@ -25,7 +25,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/do"
-	_ "k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // Droplet represents a group of droplets. In the future it
@@ -193,3 +194,42 @@ func (_ *Droplet) CheckChanges(a, e, changes *Droplet) error {
 	}
 	return nil
 }
+
+// terraformDroplet represents the digitalocean_droplet resource in terraform
+// https://registry.terraform.io/providers/digitalocean/digitalocean/latest/docs/resources/droplet
+type terraformDroplet struct {
+	Count    *int                     `json:"count" cty:"count"`
+	Name     *string                  `json:"name" cty:"name"`
+	Region   *string                  `json:"region" cty:"region"`
+	Size     *string                  `json:"size" cty:"size"`
+	Image    *string                  `json:"image" cty:"image"`
+	SSHKeys  []string                 `json:"ssh_keys,omitempty" cty:"ssh_keys"`
+	Tags     []string                 `json:"tags,omitempty" cty:"tags"`
+	UserData *terraformWriter.Literal `json:"user_data,omitempty" cty:"user_data"`
+}
+
+func (_ *Droplet) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Droplet) error {
+	name := fi.StringValue(e.Name)
+
+	tf := &terraformDroplet{
+		Count:  fi.Int(e.Count),
+		Name:   e.Name,
+		Region: e.Region,
+		Size:   e.Size,
+		Image:  e.Image,
+		Tags:   e.Tags,
+	}
+	if e.SSHKey != nil {
+		tf.SSHKeys = []string{fi.StringValue(e.SSHKey)}
+	}
+
+	if e.UserData != nil {
+		userData, err := t.AddFileResource("digitalocean_droplet", name, "user_data", e.UserData, false)
+		if err != nil {
+			return err
+		}
+		tf.UserData = userData
+	}
+
+	return t.RenderResource("digitalocean_droplet", name, tf)
+}
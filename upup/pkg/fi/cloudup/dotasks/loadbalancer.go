@@ -29,6 +29,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/do"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
 )
 
 // +kops:fitask
@@ -103,32 +104,6 @@ func (_ *LoadBalancer) CheckChanges(a, e, changes *LoadBalancer) error {
 }
 
 func (_ *LoadBalancer) RenderDO(t *do.DOAPITarget, a, e, changes *LoadBalancer) error {
-	Rules := []godo.ForwardingRule{
-		{
-			EntryProtocol:  "https",
-			EntryPort:      443,
-			TargetProtocol: "https",
-			TargetPort:     443,
-			TlsPassthrough: true,
-		},
-		{
-			EntryProtocol:  "http",
-			EntryPort:      80,
-			TargetProtocol: "http",
-			TargetPort:     80,
-		},
-	}
-
-	HealthCheck := &godo.HealthCheck{
-		Protocol:               "tcp",
-		Port:                   443,
-		Path:                   "",
-		CheckIntervalSeconds:   60,
-		ResponseTimeoutSeconds: 5,
-		UnhealthyThreshold:     3,
-		HealthyThreshold:       5,
-	}
-
 	// check if load balancer exist.
 	loadBalancers, err := t.Cloud.GetAllLoadBalancers()
 
@@ -154,8 +129,8 @@ func (_ *LoadBalancer) RenderDO(t *do.DOAPITarget, a, e, changes *LoadBalancer)
 		Name:            fi.StringValue(e.Name),
 		Region:          fi.StringValue(e.Region),
 		Tag:             fi.StringValue(e.DropletTag),
-		ForwardingRules: Rules,
-		HealthCheck:     HealthCheck,
+		ForwardingRules: loadBalancerForwardingRules(),
+		HealthCheck:     loadBalancerHealthCheck(),
 	})
 
 	if err != nil {
@@ -169,6 +144,98 @@ func (_ *LoadBalancer) RenderDO(t *do.DOAPITarget, a, e, changes *LoadBalancer)
 	return nil
 }
 
+// loadBalancerForwardingRules returns the forwarding rules of the API load balancer
+func loadBalancerForwardingRules() []godo.ForwardingRule {
+	return []godo.ForwardingRule{
+		{
+			EntryProtocol:  "https",
+			EntryPort:      443,
+			TargetProtocol: "https",
+			TargetPort:     443,
+			TlsPassthrough: true,
+		},
+		{
+			EntryProtocol:  "http",
+			EntryPort:      80,
+			TargetProtocol: "http",
+			TargetPort:     80,
+		},
+	}
+}
+
+// loadBalancerHealthCheck returns the health check of the API load balancer
+func loadBalancerHealthCheck() *godo.HealthCheck {
+	return &godo.HealthCheck{
+		Protocol:               "tcp",
+		Port:                   443,
+		Path:                   "",
+		CheckIntervalSeconds:   60,
+		ResponseTimeoutSeconds: 5,
+		UnhealthyThreshold:     3,
+		HealthyThreshold:       5,
+	}
+}
+
+// terraformLoadBalancer represents the digitalocean_loadbalancer resource in terraform
+// https://registry.terraform.io/providers/digitalocean/digitalocean/latest/docs/resources/loadbalancer
+type terraformLoadBalancer struct {
+	Name            *string                    `json:"name" cty:"name"`
+	Region          *string                    `json:"region" cty:"region"`
+	DropletTag      *string                    `json:"droplet_tag" cty:"droplet_tag"`
+	ForwardingRules []*terraformForwardingRule `json:"forwarding_rule" cty:"forwarding_rule"`
+	HealthCheck     *terraformHealthCheck      `json:"healthcheck" cty:"healthcheck"`
+}
+
+type terraformForwardingRule struct {
+	EntryProtocol  *string `json:"entry_protocol" cty:"entry_protocol"`
+	EntryPort      *int    `json:"entry_port" cty:"entry_port"`
+	TargetProtocol *string `json:"target_protocol" cty:"target_protocol"`
+	TargetPort     *int    `json:"target_port" cty:"target_port"`
+	TLSPassthrough *bool   `json:"tls_passthrough,omitempty" cty:"tls_passthrough"`
+}
+
+type terraformHealthCheck struct {
+	Protocol               *string `json:"protocol" cty:"protocol"`
+	Port                   *int    `json:"port" cty:"port"`
+	CheckIntervalSeconds   *int    `json:"check_interval_seconds" cty:"check_interval_seconds"`
+	ResponseTimeoutSeconds *int    `json:"response_timeout_seconds" cty:"response_timeout_seconds"`
+	UnhealthyThreshold     *int    `json:"unhealthy_threshold" cty:"unhealthy_threshold"`
+	HealthyThreshold       *int    `json:"healthy_threshold" cty:"healthy_threshold"`
+}
+
+func (_ *LoadBalancer) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LoadBalancer) error {
+	tf := &terraformLoadBalancer{
+		Name:       e.Name,
+		Region:     e.Region,
+		DropletTag: e.DropletTag,
+	}
+
+	for _, rule := range loadBalancerForwardingRules() {
+		tfRule := &terraformForwardingRule{
+			EntryProtocol:  fi.String(rule.EntryProtocol),
+			EntryPort:      fi.Int(rule.EntryPort),
+			TargetProtocol: fi.String(rule.TargetProtocol),
+			TargetPort:     fi.Int(rule.TargetPort),
+		}
+		if rule.TlsPassthrough {
+			tfRule.TLSPassthrough = fi.Bool(true)
+		}
+		tf.ForwardingRules = append(tf.ForwardingRules, tfRule)
+	}
+
+	healthCheck := loadBalancerHealthCheck()
+	tf.HealthCheck = &terraformHealthCheck{
+		Protocol:               fi.String(healthCheck.Protocol),
+		Port:                   fi.Int(healthCheck.Port),
+		CheckIntervalSeconds:   fi.Int(healthCheck.CheckIntervalSeconds),
+		ResponseTimeoutSeconds: fi.Int(healthCheck.ResponseTimeoutSeconds),
+		UnhealthyThreshold:     fi.Int(healthCheck.UnhealthyThreshold),
+		HealthyThreshold:       fi.Int(healthCheck.HealthyThreshold),
+	}
+
+	return t.RenderResource("digitalocean_loadbalancer", fi.StringValue(e.Name), tf)
+}
+
 func (lb *LoadBalancer) IsForAPIServer() bool {
 	return lb.ForAPIServer
 }
@@ -201,9 +268,11 @@ func (lb *LoadBalancer) FindIPAddress(c *fi.Context) (*string, error) {
 			return nil, fmt.Errorf("LoadBalancers.List returned error: %v", err)
 		}
 
+		found := false
 		for _, loadbalancer := range loadBalancers {
 			if strings.Contains(loadbalancer.Name, fi.StringValue(lb.Name)) {
 				// load balancer already exists.
+				found = true
 				address := loadbalancer.IP
 				if isIPv4(address) {
 					klog.V(10).Infof("load balancer address=%s", address)
@@ -211,6 +280,10 @@ func (lb *LoadBalancer) FindIPAddress(c *fi.Context) (*string, error) {
 				}
 			}
 		}
+		if !found {
+			// The load balancer has not been created yet, e.g. when rendering terraform
+			return nil, nil
+		}
 	}
 
 	const lbWaitTime = 10 * time.Second
@@ -19,6 +19,7 @@ package dotasks
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/digitalocean/godo"
 
@@ -132,9 +133,10 @@ func (_ *Volume) RenderDO(t *do.DOAPITarget, a, e, changes *Volume) error {
 // terraformVolume represents the digitalocean_volume resource in terraform
 // https://www.terraform.io/docs/providers/do/r/volume.html
 type terraformVolume struct {
-	Name   *string `json:"name" cty:"name"`
-	SizeGB *int64  `json:"size" cty:"size"`
-	Region *string `json:"region" cty:"region"`
+	Name   *string  `json:"name" cty:"name"`
+	SizeGB *int64   `json:"size" cty:"size"`
+	Region *string  `json:"region" cty:"region"`
+	Tags   []string `json:"tags,omitempty" cty:"tags"`
 }
 
 func (_ *Volume) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Volume) error {
@@ -143,5 +145,10 @@ func (_ *Volume) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Vo
 		SizeGB: e.SizeGB,
 		Region: e.Region,
 	}
+	for k, v := range e.Tags {
+		// DO tags don't accept =. Separate the key and value with an ":"
+		tf.Tags = append(tf.Tags, fmt.Sprintf("%s:%s", k, v))
+	}
+	sort.Strings(tf.Tags)
 	return t.RenderResource("digitalocean_volume", *e.Name, tf)
 }
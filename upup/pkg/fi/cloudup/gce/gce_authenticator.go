@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const GCEAuthenticationTokenPrefix = "x-gce-id "
+
+// gceAuthenticator authenticates with the identity token of the instance from the GCE metadata server
+type gceAuthenticator struct {
+}
+
+var _ fi.Authenticator = &gceAuthenticator{}
+
+func NewGCEAuthenticator() (fi.Authenticator, error) {
+	return &gceAuthenticator{}, nil
+}
+
+// AuthenticationAudience returns the audience of the identity token for the body.
+// The audience contains the hash of the body, so the token is only valid for this particular body content.
+func AuthenticationAudience(body []byte) string {
+	sha := sha256.Sum256(body)
+	return "kops-controller/" + base64.RawURLEncoding.EncodeToString(sha[:])
+}
+
+func (a *gceAuthenticator) CreateToken(body []byte) (string, error) {
+	// The full format includes the project, zone and name of the instance in the token
+	suffix := "instance/service-accounts/default/identity?format=full&audience=" + url.QueryEscape(AuthenticationAudience(body))
+	token, err := metadata.Get(suffix)
+	if err != nil {
+		return "", fmt.Errorf("failed to get identity token from GCE metadata: %w", err)
+	}
+	return GCEAuthenticationTokenPrefix + strings.TrimSpace(token), nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	compute "google.golang.org/api/compute/v1"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	nodeidentitygce "k8s.io/kops/pkg/nodeidentity/gce"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const (
+	// googleIssuer is the issuer of the identity tokens of GCE instances
+	googleIssuer = "https://accounts.google.com"
+	// googleCertsURL serves the public keys that sign the identity tokens
+	googleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+	// googleCertsMaxAge is how long we use the public keys before fetching them again
+	googleCertsMaxAge = time.Hour
+	// googleCertsMinAge is how long we wait before fetching the public keys again for an unknown key
+	googleCertsMinAge = time.Minute
+)
+
+type GCEVerifierOptions struct {
+	// ProjectID is the GCP project of the cluster; nodes must be in this project.
+	ProjectID string `json:"projectID"`
+	// ClusterName is the name of the cluster; nodes must be created from an instance template of this cluster.
+	ClusterName string `json:"clusterName"`
+}
+
+// computeEngineClaims are the claims about the instance in an identity token in the full format
+type computeEngineClaims struct {
+	ProjectID    string `json:"project_id"`
+	Zone         string `json:"zone"`
+	InstanceID   string `json:"instance_id"`
+	InstanceName string `json:"instance_name"`
+}
+
+type identityClaims struct {
+	jwt.Claims
+	Google struct {
+		ComputeEngine *computeEngineClaims `json:"compute_engine"`
+	} `json:"google"`
+}
+
+// keySource provides the public keys that sign the identity tokens
+type keySource interface {
+	Key(kid string) (*jose.JSONWebKey, error)
+}
+
+type gceVerifier struct {
+	opt GCEVerifierOptions
+
+	compute *compute.Service
+	keys    keySource
+	now     func() time.Time
+}
+
+var _ fi.Verifier = &gceVerifier{}
+
+func NewGCEVerifier(opt *GCEVerifierOptions) (fi.Verifier, error) {
+	computeService, err := compute.NewService(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error building compute API client: %v", err)
+	}
+
+	return &gceVerifier{
+		opt:     *opt,
+		compute: computeService,
+		keys: &googleKeySource{
+			url:    googleCertsURL,
+			client: http.Client{Timeout: 30 * time.Second},
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (v *gceVerifier) VerifyToken(rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	if !strings.HasPrefix(token, GCEAuthenticationTokenPrefix) {
+		return nil, fmt.Errorf("incorrect authorization type")
+	}
+	token = strings.TrimPrefix(token, GCEAuthenticationTokenPrefix)
+
+	identity, err := v.verifyIdentityToken(token, body)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := v.compute.Instances.Get(v.opt.ProjectID, identity.Zone, identity.InstanceName).Context(rawRequest.Context()).Do()
+	if err != nil {
+		return nil, fmt.Errorf("fetching instance %q: %v", identity.InstanceName, err)
+	}
+	// The name of a deleted instance can be reused, so we check the token is for this particular instance
+	if strconv.FormatUint(instance.Id, 10) != identity.InstanceID {
+		return nil, fmt.Errorf("instance %q has id %d, but the token is for id %s", instance.Name, instance.Id, identity.InstanceID)
+	}
+	if instance.Status != "RUNNING" {
+		return nil, fmt.Errorf("found instance %q, but status is %q", instance.Name, instance.Status)
+	}
+
+	instanceTemplate, err := nodeidentitygce.InstanceTemplateForInstance(v.compute, v.opt.ProjectID, identity.Zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	if clusterName := nodeidentitygce.GetMetadataValue(instanceTemplate.Properties.Metadata, "cluster-name"); clusterName != v.opt.ClusterName {
+		return nil, fmt.Errorf("instance %q is in cluster %q, not %q", instance.Name, clusterName, v.opt.ClusterName)
+	}
+	igName := nodeidentitygce.GetMetadataValue(instanceTemplate.Properties.Metadata, nodeidentitygce.MetadataKeyInstanceGroupName)
+	if igName == "" {
+		return nil, fmt.Errorf("ig name not set on instance template %s", instanceTemplate.Name)
+	}
+
+	return &fi.VerifyResult{
+		NodeName:          instance.Name,
+		InstanceGroupName: igName,
+	}, nil
+}
+
+// verifyIdentityToken verifies the signature and the claims of the identity token,
+// returning the claims about the instance it was issued to
+func (v *gceVerifier) verifyIdentityToken(token string, body []byte) (*computeEngineClaims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("parsing identity token: %v", err)
+	}
+	if len(parsed.Headers) != 1 {
+		return nil, fmt.Errorf("identity token has %d signatures", len(parsed.Headers))
+	}
+	if parsed.Headers[0].Algorithm != string(jose.RS256) {
+		return nil, fmt.Errorf("identity token has unexpected algorithm %q", parsed.Headers[0].Algorithm)
+	}
+
+	key, err := v.keys.Key(parsed.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &identityClaims{}
+	if err := parsed.Claims(key, claims); err != nil {
+		return nil, fmt.Errorf("verifying identity token: %v", err)
+	}
+
+	// The audience ensures the token has signed the body content
+	err = claims.ValidateWithLeeway(jwt.Expected{
+		Issuer:   googleIssuer,
+		Audience: jwt.Audience{AuthenticationAudience(body)},
+		Time:     v.now(),
+	}, jwt.DefaultLeeway)
+	if err != nil {
+		return nil, fmt.Errorf("validating identity token: %v", err)
+	}
+
+	identity := claims.Google.ComputeEngine
+	if identity == nil {
+		return nil, fmt.Errorf("identity token does not contain the instance; the full format is required")
+	}
+	if identity.ProjectID != v.opt.ProjectID {
+		return nil, fmt.Errorf("instance %q is in project %q, not %q", identity.InstanceName, identity.ProjectID, v.opt.ProjectID)
+	}
+	return identity, nil
+}
+
+// googleKeySource fetches the public keys from Google, caching them
+type googleKeySource struct {
+	url    string
+	client http.Client
+
+	mutex   sync.Mutex
+	keys    *jose.JSONWebKeySet
+	fetched time.Time
+}
+
+var _ keySource = &googleKeySource{}
+
+func (s *googleKeySource) Key(kid string) (*jose.JSONWebKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Keys are rotated, so we fetch them again when they are old or we don't know the key
+	age := time.Since(s.fetched)
+	if s.keys == nil || age > googleCertsMaxAge || (len(s.keys.Key(kid)) == 0 && age > googleCertsMinAge) {
+		keys, err := s.fetch()
+		if err != nil {
+			return nil, err
+		}
+		s.keys = keys
+		s.fetched = time.Now()
+	}
+
+	matches := s.keys.Key(kid)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("identity token is signed with unknown key %q", kid)
+	}
+	return &matches[0], nil
+}
+
+func (s *googleKeySource) fetch() (*jose.JSONWebKeySet, error) {
+	response, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching Google public keys: %v", err)
+	}
+	defer response.Body.Close()
+
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading Google public keys: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d fetching Google public keys: %s", response.StatusCode, string(b))
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(b, keys); err != nil {
+		return nil, fmt.Errorf("parsing Google public keys: %v", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// staticKeySource serves a fixed set of public keys
+type staticKeySource struct {
+	keys jose.JSONWebKeySet
+}
+
+func (s *staticKeySource) Key(kid string) (*jose.JSONWebKey, error) {
+	matches := s.keys.Key(kid)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return &matches[0], nil
+}
+
+func signIdentityToken(t *testing.T, key *rsa.PrivateKey, kid string, claims interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", kid))
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifyIdentityToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"apiVersion":"bootstrap.kops.k8s.io/v1alpha1"}`)
+	instance := &computeEngineClaims{
+		ProjectID:    "testproject",
+		Zone:         "us-test1-a",
+		InstanceID:   "1234567890",
+		InstanceName: "nodes-abcd",
+	}
+
+	buildClaims := func(audience string, expiry time.Time, instance *computeEngineClaims) interface{} {
+		claims := &identityClaims{
+			Claims: jwt.Claims{
+				Issuer:   googleIssuer,
+				Audience: jwt.Audience{audience},
+				IssuedAt: jwt.NewNumericDate(expiry.Add(-time.Hour)),
+				Expiry:   jwt.NewNumericDate(expiry),
+			},
+		}
+		claims.Google.ComputeEngine = instance
+		return claims
+	}
+
+	verifier := &gceVerifier{
+		opt: GCEVerifierOptions{ProjectID: "testproject", ClusterName: "minimal.example.com"},
+		keys: &staticKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key1", Algorithm: string(jose.RS256), Use: "sig"},
+		}}},
+		now: func() time.Time { return now },
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{
+			name:  "valid",
+			token: signIdentityToken(t, key, "key1", buildClaims(AuthenticationAudience(body), now.Add(time.Hour), instance)),
+		},
+		{
+			name:  "other body",
+			token: signIdentityToken(t, key, "key1", buildClaims(AuthenticationAudience([]byte("{}")), now.Add(time.Hour), instance)),
+			err:   "validating identity token: square/go-jose/jwt: validation failed, invalid audience claim (aud)",
+		},
+		{
+			name:  "expired",
+			token: signIdentityToken(t, key, "key1", buildClaims(AuthenticationAudience(body), now.Add(-time.Hour), instance)),
+			err:   "validating identity token: square/go-jose/jwt: validation failed, token is expired (exp)",
+		},
+		{
+			name:  "unknown key",
+			token: signIdentityToken(t, otherKey, "key2", buildClaims(AuthenticationAudience(body), now.Add(time.Hour), instance)),
+			err:   "unknown key \"key2\"",
+		},
+		{
+			name:  "wrong signature",
+			token: signIdentityToken(t, otherKey, "key1", buildClaims(AuthenticationAudience(body), now.Add(time.Hour), instance)),
+			err:   "verifying identity token: square/go-jose: error in cryptographic primitive",
+		},
+		{
+			name: "other project",
+			token: signIdentityToken(t, key, "key1", buildClaims(AuthenticationAudience(body), now.Add(time.Hour), &computeEngineClaims{
+				ProjectID:    "otherproject",
+				Zone:         "us-test1-a",
+				InstanceID:   "1234567890",
+				InstanceName: "nodes-abcd",
+			})),
+			err: "instance \"nodes-abcd\" is in project \"otherproject\", not \"testproject\"",
+		},
+		{
+			name:  "standard format",
+			token: signIdentityToken(t, key, "key1", buildClaims(AuthenticationAudience(body), now.Add(time.Hour), nil)),
+			err:   "identity token does not contain the instance; the full format is required",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, err := verifier.verifyIdentityToken(test.token, body)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, instance, identity)
+		})
+	}
+}
+
+func TestGoogleKeySource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		keys := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key1", Algorithm: string(jose.RS256), Use: "sig"},
+		}}
+		_ = json.NewEncoder(w).Encode(keys)
+	}))
+	defer server.Close()
+
+	source := &googleKeySource{url: server.URL}
+
+	found, err := source.Key("key1")
+	require.NoError(t, err)
+	assert.Equal(t, "key1", found.KeyID)
+	assert.Equal(t, 1, requests)
+
+	// The keys are cached, and only fetched again for an unknown key once they are old enough
+	_, err = source.Key("key1")
+	require.NoError(t, err)
+	_, err = source.Key("key2")
+	assert.EqualError(t, err, "identity token is signed with unknown key \"key2\"")
+	assert.Equal(t, 1, requests)
+
+	source.fetched = source.fetched.Add(-2 * googleCertsMinAge)
+	_, err = source.Key("key2")
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+}
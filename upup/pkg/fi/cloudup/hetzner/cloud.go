@@ -0,0 +1,399 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kops"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const (
+	// TagKubernetesClusterName is the label holding the name of the cluster owning a resource.
+	TagKubernetesClusterName = "kops.k8s.io/cluster"
+	// TagKubernetesInstanceGroup is the label holding the instance group of a server or etcd volume.
+	TagKubernetesInstanceGroup = "kops.k8s.io/instance-group"
+	// TagKubernetesInstanceRole is the label holding the role of the instance group of a server.
+	TagKubernetesInstanceRole = "kops.k8s.io/instance-role"
+	// TagKubernetesInstanceUserData is the label holding a hash of the user data a server was created with.
+	TagKubernetesInstanceUserData = "kops.k8s.io/instance-userdata"
+	// TagKubernetesInstanceNeedsUpdate is the label marking a server whose configuration is out of date.
+	TagKubernetesInstanceNeedsUpdate = "kops.k8s.io/needs-update"
+	// TagKubernetesFirewallRole is the label holding the instance role a firewall applies to.
+	TagKubernetesFirewallRole = "kops.k8s.io/firewall-role"
+	// TagKubernetesVolumeRole is the label holding the name of the etcd cluster stored on a volume.
+	TagKubernetesVolumeRole = "kops.k8s.io/volume-role"
+)
+
+// HetznerCloud exposes all the interfaces required to operate on Hetzner Cloud resources
+type HetznerCloud interface {
+	fi.Cloud
+	ActionClient() *hcloud.ActionClient
+	SSHKeyClient() *hcloud.SSHKeyClient
+	NetworkClient() *hcloud.NetworkClient
+	FirewallClient() *hcloud.FirewallClient
+	LoadBalancerClient() *hcloud.LoadBalancerClient
+	PlacementGroupClient() *hcloud.PlacementGroupClient
+	ServerClient() *hcloud.ServerClient
+	VolumeClient() *hcloud.VolumeClient
+	GetSSHKeys(labels map[string]string) ([]*hcloud.SSHKey, error)
+	GetNetworks(labels map[string]string) ([]*hcloud.Network, error)
+	GetFirewalls(labels map[string]string) ([]*hcloud.Firewall, error)
+	GetLoadBalancers(labels map[string]string) ([]*hcloud.LoadBalancer, error)
+	GetPlacementGroups(labels map[string]string) ([]*hcloud.PlacementGroup, error)
+	GetServers(labels map[string]string) ([]*hcloud.Server, error)
+	GetVolumes(labels map[string]string) ([]*hcloud.Volume, error)
+	WaitForActions(actions ...*hcloud.Action) error
+}
+
+// static compile time check to validate HetznerCloud's fi.Cloud Interface.
+var _ fi.Cloud = &hetznerCloudImplementation{}
+
+// hetznerCloudImplementation holds the hcloud client object to interact with Hetzner Cloud resources.
+type hetznerCloudImplementation struct {
+	Client *hcloud.Client
+
+	// region holds the network zone of the cluster.
+	region string
+}
+
+var hetznerCloudInstances map[string]HetznerCloud = make(map[string]HetznerCloud)
+
+// NewHetznerCloud returns a Cloud for the network zone, expecting the env var HCLOUD_TOKEN
+func NewHetznerCloud(region string) (HetznerCloud, error) {
+	if raw := hetznerCloudInstances[region]; raw != nil {
+		return raw, nil
+	}
+
+	accessToken := os.Getenv("HCLOUD_TOKEN")
+	if accessToken == "" {
+		return nil, errors.New("HCLOUD_TOKEN is required")
+	}
+
+	client := hcloud.NewClient(
+		hcloud.WithToken(accessToken),
+		hcloud.WithApplication("kops", kops.Version),
+	)
+
+	return &hetznerCloudImplementation{
+		Client: client,
+		region: region,
+	}, nil
+}
+
+// LabelSelector returns the hcloud label selector matching all the labels.
+func LabelSelector(labels map[string]string) string {
+	var terms []string
+	for k, v := range labels {
+		terms = append(terms, k+"="+v)
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
+
+// ProviderID returns the kops api identifier for Hetzner Cloud provider
+func (c *hetznerCloudImplementation) ProviderID() kopsapi.CloudProviderID {
+	return kopsapi.CloudProviderHetzner
+}
+
+// Region returns the network zone we will target
+func (c *hetznerCloudImplementation) Region() string {
+	return c.region
+}
+
+// DNS is not implemented, Hetzner clusters must use gossip
+func (c *hetznerCloudImplementation) DNS() (dnsprovider.Interface, error) {
+	return nil, errors.New("DNS is not supported on Hetzner Cloud, use a gossip cluster name ending in .k8s.local")
+}
+
+// FindVPCInfo is not implemented, it's only here to satisfy the fi.Cloud interface
+func (c *hetznerCloudImplementation) FindVPCInfo(id string) (*fi.VPCInfo, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *hetznerCloudImplementation) ActionClient() *hcloud.ActionClient {
+	return &c.Client.Action
+}
+
+func (c *hetznerCloudImplementation) SSHKeyClient() *hcloud.SSHKeyClient {
+	return &c.Client.SSHKey
+}
+
+func (c *hetznerCloudImplementation) NetworkClient() *hcloud.NetworkClient {
+	return &c.Client.Network
+}
+
+func (c *hetznerCloudImplementation) FirewallClient() *hcloud.FirewallClient {
+	return &c.Client.Firewall
+}
+
+func (c *hetznerCloudImplementation) LoadBalancerClient() *hcloud.LoadBalancerClient {
+	return &c.Client.LoadBalancer
+}
+
+func (c *hetznerCloudImplementation) PlacementGroupClient() *hcloud.PlacementGroupClient {
+	return &c.Client.PlacementGroup
+}
+
+func (c *hetznerCloudImplementation) ServerClient() *hcloud.ServerClient {
+	return &c.Client.Server
+}
+
+func (c *hetznerCloudImplementation) VolumeClient() *hcloud.VolumeClient {
+	return &c.Client.Volume
+}
+
+func (c *hetznerCloudImplementation) GetSSHKeys(labels map[string]string) ([]*hcloud.SSHKey, error) {
+	return c.SSHKeyClient().AllWithOpts(context.TODO(), hcloud.SSHKeyListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+func (c *hetznerCloudImplementation) GetNetworks(labels map[string]string) ([]*hcloud.Network, error) {
+	return c.NetworkClient().AllWithOpts(context.TODO(), hcloud.NetworkListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+func (c *hetznerCloudImplementation) GetFirewalls(labels map[string]string) ([]*hcloud.Firewall, error) {
+	return c.FirewallClient().AllWithOpts(context.TODO(), hcloud.FirewallListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+func (c *hetznerCloudImplementation) GetLoadBalancers(labels map[string]string) ([]*hcloud.LoadBalancer, error) {
+	return c.LoadBalancerClient().AllWithOpts(context.TODO(), hcloud.LoadBalancerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+func (c *hetznerCloudImplementation) GetPlacementGroups(labels map[string]string) ([]*hcloud.PlacementGroup, error) {
+	return c.PlacementGroupClient().AllWithOpts(context.TODO(), hcloud.PlacementGroupListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+func (c *hetznerCloudImplementation) GetServers(labels map[string]string) ([]*hcloud.Server, error) {
+	return c.ServerClient().AllWithOpts(context.TODO(), hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+func (c *hetznerCloudImplementation) GetVolumes(labels map[string]string) ([]*hcloud.Volume, error) {
+	return c.VolumeClient().AllWithOpts(context.TODO(), hcloud.VolumeListOpts{ListOpts: hcloud.ListOpts{LabelSelector: LabelSelector(labels)}})
+}
+
+// WaitForActions blocks until all the actions have finished, returning the first error encountered
+func (c *hetznerCloudImplementation) WaitForActions(actions ...*hcloud.Action) error {
+	var pending []*hcloud.Action
+	for _, action := range actions {
+		if action != nil {
+			pending = append(pending, action)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	_, errCh := c.ActionClient().WatchOverallProgress(context.TODO(), pending)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("error waiting for actions: %v", err)
+	}
+	return nil
+}
+
+func (c *hetznerCloudImplementation) DeleteInstance(i *cloudinstances.CloudInstance) error {
+	serverID, err := strconv.Atoi(i.ID)
+	if err != nil {
+		return fmt.Errorf("failed to convert server ID %q to int: %v", i.ID, err)
+	}
+
+	_, err = c.ServerClient().Delete(context.TODO(), &hcloud.Server{ID: serverID})
+	if err != nil {
+		return fmt.Errorf("error deleting server %d: %v", serverID, err)
+	}
+
+	klog.V(8).Infof("deleted server %d", serverID)
+
+	return nil
+}
+
+func (c *hetznerCloudImplementation) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
+	for _, instance := range append(g.Ready, g.NeedUpdate...) {
+		if err := c.DeleteInstance(instance); err != nil {
+			return err
+		}
+	}
+
+	placementGroups, err := c.GetPlacementGroups(map[string]string{
+		TagKubernetesClusterName:   g.InstanceGroup.Labels[kopsapi.LabelClusterName],
+		TagKubernetesInstanceGroup: g.InstanceGroup.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing placement groups: %v", err)
+	}
+	for _, placementGroup := range placementGroups {
+		if _, err := c.PlacementGroupClient().Delete(context.TODO(), placementGroup); err != nil {
+			return fmt.Errorf("error deleting placement group %q: %v", placementGroup.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// DetachInstance is not supported: Hetzner servers are not managed by an autoscaling group that can surge.
+func (c *hetznerCloudImplementation) DetachInstance(i *cloudinstances.CloudInstance) error {
+	return fmt.Errorf("hetzner cloud provider does not support surging")
+}
+
+func (c *hetznerCloudImplementation) GetCloudGroups(cluster *kopsapi.Cluster, instancegroups []*kopsapi.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
+	return getCloudGroups(c, cluster, instancegroups, warnUnmatched, nodes)
+}
+
+func getCloudGroups(c HetznerCloud, cluster *kopsapi.Cluster, instancegroups []*kopsapi.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
+	nodeMap := cloudinstances.GetNodeMap(nodes, cluster)
+
+	servers, err := c.GetServers(map[string]string{TagKubernetesClusterName: cluster.Name})
+	if err != nil {
+		return nil, fmt.Errorf("error listing servers: %v", err)
+	}
+
+	serversByGroup := make(map[string][]*hcloud.Server)
+	for _, server := range servers {
+		name := server.Labels[TagKubernetesInstanceGroup]
+		serversByGroup[name] = append(serversByGroup[name], server)
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	for name, members := range serversByGroup {
+		var instancegroup *kopsapi.InstanceGroup
+		for _, ig := range instancegroups {
+			if ig.Name == name {
+				instancegroup = ig
+				break
+			}
+		}
+		if instancegroup == nil {
+			if warnUnmatched {
+				klog.Warningf("Found servers with no corresponding instance group %q", name)
+			}
+			continue
+		}
+
+		groups[instancegroup.Name], err = buildCloudInstanceGroup(instancegroup, members, nodeMap)
+		if err != nil {
+			return nil, fmt.Errorf("error getting cloud instance group %q: %v", instancegroup.Name, err)
+		}
+	}
+
+	return groups, nil
+}
+
+func buildCloudInstanceGroup(ig *kopsapi.InstanceGroup, servers []*hcloud.Server, nodeMap map[string]*v1.Node) (*cloudinstances.CloudInstanceGroup, error) {
+	cg := &cloudinstances.CloudInstanceGroup{
+		HumanName:     ig.Name,
+		InstanceGroup: ig,
+		Raw:           servers,
+		MinSize:       int(fi.Int32Value(ig.Spec.MinSize)),
+		TargetSize:    int(fi.Int32Value(ig.Spec.MinSize)),
+		MaxSize:       int(fi.Int32Value(ig.Spec.MaxSize)),
+	}
+
+	for _, server := range servers {
+		id := strconv.Itoa(server.ID)
+		status := cloudinstances.CloudInstanceStatusUpToDate
+		if _, ok := server.Labels[TagKubernetesInstanceNeedsUpdate]; ok {
+			status = cloudinstances.CloudInstanceStatusNeedsUpdate
+		}
+		cm, err := cg.NewCloudInstance(id, status, nodeMap[id])
+		if err != nil {
+			return nil, fmt.Errorf("error creating cloud instance group member: %v", err)
+		}
+		cm.MachineType = server.ServerType.Name
+		cm.Roles = []string{strings.ToLower(string(ig.Spec.Role))}
+		for _, privateNet := range server.PrivateNet {
+			cm.PrivateIP = privateNet.IP.String()
+		}
+	}
+
+	return cg, nil
+}
+
+func (c *hetznerCloudImplementation) GetApiIngressStatus(cluster *kopsapi.Cluster) ([]fi.ApiIngressStatus, error) {
+	return getApiIngressStatus(c, cluster)
+}
+
+func getApiIngressStatus(c HetznerCloud, cluster *kopsapi.Cluster) ([]fi.ApiIngressStatus, error) {
+	var ingresses []fi.ApiIngressStatus
+	if cluster.Spec.MasterPublicName == "" {
+		return nil, nil
+	}
+
+	loadBalancers, err := c.GetLoadBalancers(map[string]string{TagKubernetesClusterName: cluster.Name})
+	if err != nil {
+		return nil, fmt.Errorf("error listing load balancers: %v", err)
+	}
+
+	for _, lb := range loadBalancers {
+		if lb.PublicNet.Enabled && lb.PublicNet.IPv4.IP != nil {
+			ingresses = append(ingresses, fi.ApiIngressStatus{IP: lb.PublicNet.IPv4.IP.String()})
+		}
+	}
+
+	return ingresses, nil
+}
+
+// FindClusterStatus discovers the status of the cluster, by looking for the labelled etcd volumes
+func (c *hetznerCloudImplementation) FindClusterStatus(cluster *kopsapi.Cluster) (*kopsapi.ClusterStatus, error) {
+	return findClusterStatus(c, cluster)
+}
+
+func findClusterStatus(c HetznerCloud, cluster *kopsapi.Cluster) (*kopsapi.ClusterStatus, error) {
+	volumes, err := c.GetVolumes(map[string]string{TagKubernetesClusterName: cluster.Name})
+	if err != nil {
+		return nil, fmt.Errorf("error listing volumes: %v", err)
+	}
+
+	statusMap := make(map[string]*kopsapi.EtcdClusterStatus)
+	for _, volume := range volumes {
+		etcdClusterName := volume.Labels[TagKubernetesVolumeRole]
+		if etcdClusterName == "" {
+			continue
+		}
+
+		status := statusMap[etcdClusterName]
+		if status == nil {
+			status = &kopsapi.EtcdClusterStatus{
+				Name: etcdClusterName,
+			}
+			statusMap[etcdClusterName] = status
+		}
+
+		status.Members = append(status.Members, &kopsapi.EtcdMemberStatus{
+			Name:     volume.Labels[TagKubernetesInstanceGroup],
+			VolumeId: strconv.Itoa(volume.ID),
+		})
+	}
+
+	status := &kopsapi.ClusterStatus{}
+	for _, v := range statusMap {
+		status.EtcdClusters = append(status.EtcdClusters, *v)
+	}
+	klog.V(2).Infof("Cluster status (from cloud): %v", fi.DebugAsJsonString(status))
+	return status, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/kops/cloudmock/hetzner/mockhcloud"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{
+		TagKubernetesInstanceGroup: "nodes",
+		TagKubernetesClusterName:   "test.k8s.local",
+	}
+
+	expected := "kops.k8s.io/cluster=test.k8s.local,kops.k8s.io/instance-group=nodes"
+	if selector := LabelSelector(labels); selector != expected {
+		t.Errorf("expected label selector %q, got %q", expected, selector)
+	}
+}
+
+func TestGetCloudGroups(t *testing.T) {
+	mock := mockhcloud.CreateClient()
+	defer mock.Server.Close()
+	cloud := InstallMockHetznerCloud("eu-central", mock.Client())
+
+	cluster := &kops.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	nodes := &kops.InstanceGroup{}
+	nodes.Name = "nodes"
+	nodes.Spec.Role = kops.InstanceGroupRoleNode
+	nodes.Spec.MinSize = fi.Int32(2)
+	nodes.Spec.MaxSize = fi.Int32(2)
+
+	servers := []struct {
+		name        string
+		group       string
+		cluster     string
+		needsUpdate bool
+	}{
+		{name: "nodes-1", group: "nodes", cluster: "test.k8s.local"},
+		{name: "nodes-2", group: "nodes", cluster: "test.k8s.local", needsUpdate: true},
+		{name: "other-1", group: "nodes", cluster: "other.k8s.local"},
+		{name: "unknown-1", group: "unknown", cluster: "test.k8s.local"},
+	}
+	for _, server := range servers {
+		labels := map[string]string{
+			TagKubernetesClusterName:   server.cluster,
+			TagKubernetesInstanceGroup: server.group,
+		}
+		if server.needsUpdate {
+			labels[TagKubernetesInstanceNeedsUpdate] = ""
+		}
+		_, _, err := cloud.ServerClient().Create(context.TODO(), hcloud.ServerCreateOpts{
+			Name:       server.name,
+			ServerType: &hcloud.ServerType{Name: "cx21"},
+			Image:      &hcloud.Image{Name: "ubuntu-20.04"},
+			Location:   &hcloud.Location{Name: "fsn1"},
+			Labels:     labels,
+		})
+		if err != nil {
+			t.Fatalf("error creating server %q: %v", server.name, err)
+		}
+	}
+
+	groups, err := cloud.GetCloudGroups(cluster, []*kops.InstanceGroup{nodes}, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+
+	group := groups["nodes"]
+	if group == nil {
+		t.Fatalf("expected group %q, got %v", "nodes", groups)
+	}
+	if len(group.Ready) != 1 {
+		t.Errorf("expected 1 up to date server, got %d", len(group.Ready))
+	}
+	if len(group.NeedUpdate) != 1 {
+		t.Errorf("expected 1 server needing update, got %d", len(group.NeedUpdate))
+	}
+	for _, member := range group.NeedUpdate {
+		if member.Status != cloudinstances.CloudInstanceStatusNeedsUpdate {
+			t.Errorf("expected status %q, got %q", cloudinstances.CloudInstanceStatusNeedsUpdate, member.Status)
+		}
+		if member.MachineType != "cx21" {
+			t.Errorf("expected machine type %q, got %q", "cx21", member.MachineType)
+		}
+	}
+}
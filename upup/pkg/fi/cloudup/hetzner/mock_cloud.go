@@ -0,0 +1,32 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// InstallMockHetznerCloud makes NewHetznerCloud return a cloud for the network zone
+// that talks to the client, typically pointed at a mock API server.
+func InstallMockHetznerCloud(region string, client *hcloud.Client) HetznerCloud {
+	c := &hetznerCloudImplementation{
+		Client: client,
+		region: region,
+	}
+	hetznerCloudInstances[region] = c
+	return c
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// locationNetworkZones maps the Hetzner Cloud locations to the network zone they belong to
+var locationNetworkZones = map[string]hcloud.NetworkZone{
+	"fsn1": hcloud.NetworkZoneEUCentral,
+	"nbg1": hcloud.NetworkZoneEUCentral,
+	"hel1": hcloud.NetworkZoneEUCentral,
+	"ash":  hcloud.NetworkZoneUSEast,
+}
+
+// NetworkZone returns the network zone of a location
+func NetworkZone(location string) (hcloud.NetworkZone, error) {
+	networkZone, ok := locationNetworkZones[location]
+	if !ok {
+		return "", fmt.Errorf("unknown Hetzner Cloud location %q", location)
+	}
+	return networkZone, nil
+}
+
+// FindRegion returns the network zone of the cluster; all its subnets must be in the same one
+func FindRegion(cluster *kops.Cluster) (string, error) {
+	region := ""
+	for _, subnet := range cluster.Spec.Subnets {
+		networkZone, err := NetworkZone(subnet.Zone)
+		if err != nil {
+			return "", err
+		}
+		if region != "" && region != string(networkZone) {
+			return "", fmt.Errorf("clusters cannot span multiple network zones (found %q and %q)", region, networkZone)
+		}
+		region = string(networkZone)
+	}
+	if region == "" {
+		return "", fmt.Errorf("unable to determine network zone, no subnets are defined")
+	}
+	return region, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+func TestFindRegion(t *testing.T) {
+	tests := []struct {
+		desc          string
+		zones         []string
+		expected      string
+		expectedError bool
+	}{
+		{
+			desc:     "single location",
+			zones:    []string{"fsn1"},
+			expected: "eu-central",
+		},
+		{
+			desc:     "locations in the same network zone",
+			zones:    []string{"fsn1", "nbg1", "hel1"},
+			expected: "eu-central",
+		},
+		{
+			desc:          "locations in different network zones",
+			zones:         []string{"fsn1", "ash"},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown location",
+			zones:         []string{"us-east-1a"},
+			expectedError: true,
+		},
+		{
+			desc:          "no subnets",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cluster := &kops.Cluster{}
+			for _, zone := range test.zones {
+				cluster.Spec.Subnets = append(cluster.Spec.Subnets, kops.ClusterSubnetSpec{Name: zone, Zone: zone})
+			}
+
+			region, err := FindRegion(cluster)
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("expected error, got region %q", region)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if region != test.expected {
+				t.Errorf("expected region %q, got %q", test.expected, region)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznertasks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+)
+
+// +kops:fitask
+type Firewall struct {
+	Name      *string
+	ID        *int
+	Lifecycle fi.Lifecycle
+
+	// Selector is the label selector of the servers the firewall is applied to
+	Selector string
+	Rules    []*FirewallRule
+
+	Labels map[string]string
+}
+
+// FirewallRule is an inbound rule of a Firewall
+type FirewallRule struct {
+	SourceIPs []string
+	Protocol  string
+	Port      *string
+}
+
+var _ fi.HasDependencies = &FirewallRule{}
+
+func (e *FirewallRule) GetDependencies(tasks map[string]fi.Task) []fi.Task {
+	return nil
+}
+
+var _ fi.CompareWithID = &Firewall{}
+
+func (v *Firewall) CompareWithID() *string {
+	if v.ID == nil {
+		return nil
+	}
+	return fi.String(strconv.Itoa(*v.ID))
+}
+
+func (v *Firewall) Find(c *fi.Context) (*Firewall, error) {
+	cloud := c.Cloud.(hetzner.HetznerCloud)
+
+	firewall, _, err := cloud.FirewallClient().GetByName(context.TODO(), fi.StringValue(v.Name))
+	if err != nil {
+		return nil, fmt.Errorf("error finding firewall %q: %v", fi.StringValue(v.Name), err)
+	}
+	if firewall == nil {
+		return nil, nil
+	}
+
+	actual := &Firewall{
+		Name:      fi.String(firewall.Name),
+		ID:        fi.Int(firewall.ID),
+		Lifecycle: v.Lifecycle,
+		Labels:    firewall.Labels,
+	}
+	for _, resource := range firewall.AppliedTo {
+		if resource.Type == hcloud.FirewallResourceTypeLabelSelector && resource.LabelSelector != nil {
+			actual.Selector = resource.LabelSelector.Selector
+		}
+	}
+	for _, rule := range firewall.Rules {
+		if rule.Direction != hcloud.FirewallRuleDirectionIn {
+			continue
+		}
+		r := &FirewallRule{
+			Protocol: string(rule.Protocol),
+			Port:     rule.Port,
+		}
+		for _, sourceIP := range rule.SourceIPs {
+			r.SourceIPs = append(r.SourceIPs, sourceIP.String())
+		}
+		actual.Rules = append(actual.Rules, r)
+	}
+	v.ID = actual.ID
+
+	return actual, nil
+}
+
+func (v *Firewall) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(v, c)
+}
+
+func (_ *Firewall) CheckChanges(a, e, changes *Firewall) error {
+	if a != nil {
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+		if changes.ID != nil {
+			return fi.CannotChangeField("ID")
+		}
+	} else {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.Selector == "" {
+			return fi.RequiredField("Selector")
+		}
+	}
+	return nil
+}
+
+func buildFirewallRules(rules []*FirewallRule) ([]hcloud.FirewallRule, error) {
+	var result []hcloud.FirewallRule
+	for _, rule := range rules {
+		r := hcloud.FirewallRule{
+			Direction: hcloud.FirewallRuleDirectionIn,
+			Protocol:  hcloud.FirewallRuleProtocol(rule.Protocol),
+			Port:      rule.Port,
+		}
+		for _, sourceIP := range rule.SourceIPs {
+			_, ipNet, err := net.ParseCIDR(sourceIP)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing firewall source IP range %q: %v", sourceIP, err)
+			}
+			r.SourceIPs = append(r.SourceIPs, *ipNet)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func (_ *Firewall) RenderHetzner(t *hetzner.HetznerAPITarget, a, e, changes *Firewall) error {
+	client := t.Cloud.FirewallClient()
+
+	rules, err := buildFirewallRules(e.Rules)
+	if err != nil {
+		return err
+	}
+
+	if a == nil {
+		result, _, err := client.Create(context.TODO(), hcloud.FirewallCreateOpts{
+			Name:   fi.StringValue(e.Name),
+			Labels: e.Labels,
+			Rules:  rules,
+			ApplyTo: []hcloud.FirewallResource{
+				{
+					Type:          hcloud.FirewallResourceTypeLabelSelector,
+					LabelSelector: &hcloud.FirewallResourceLabelSelector{Selector: e.Selector},
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error creating firewall %q: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(result.Actions...); err != nil {
+			return err
+		}
+		e.ID = fi.Int(result.Firewall.ID)
+		return nil
+	}
+
+	firewall := &hcloud.Firewall{ID: fi.IntValue(a.ID)}
+
+	if changes.Labels != nil {
+		_, _, err := client.Update(context.TODO(), firewall, hcloud.FirewallUpdateOpts{
+			Labels: e.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating labels of firewall %q: %v", fi.StringValue(e.Name), err)
+		}
+	}
+
+	if changes.Rules != nil {
+		actions, _, err := client.SetRules(context.TODO(), firewall, hcloud.FirewallSetRulesOpts{
+			Rules: rules,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating rules of firewall %q: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(actions...); err != nil {
+			return err
+		}
+	}
+
+	if changes.Selector != "" {
+		var actions []*hcloud.Action
+		if a.Selector != "" {
+			removed, _, err := client.RemoveResources(context.TODO(), firewall, []hcloud.FirewallResource{
+				{
+					Type:          hcloud.FirewallResourceTypeLabelSelector,
+					LabelSelector: &hcloud.FirewallResourceLabelSelector{Selector: a.Selector},
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("error removing label selector from firewall %q: %v", fi.StringValue(e.Name), err)
+			}
+			actions = append(actions, removed...)
+		}
+		applied, _, err := client.ApplyResources(context.TODO(), firewall, []hcloud.FirewallResource{
+			{
+				Type:          hcloud.FirewallResourceTypeLabelSelector,
+				LabelSelector: &hcloud.FirewallResourceLabelSelector{Selector: e.Selector},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error applying label selector to firewall %q: %v", fi.StringValue(e.Name), err)
+		}
+		actions = append(actions, applied...)
+		if err := t.Cloud.WaitForActions(actions...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznertasks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+)
+
+// +kops:fitask
+type LoadBalancer struct {
+	Name      *string
+	ID        *int
+	Lifecycle fi.Lifecycle
+
+	Network  *Network
+	Location string
+	Type     string
+	Services []*LoadBalancerService
+	// Target is the label selector of the servers the load balancer forwards to, over the private network
+	Target string
+
+	Labels map[string]string
+}
+
+// LoadBalancerService is a TCP service exposed by a LoadBalancer
+type LoadBalancerService struct {
+	Protocol        string
+	ListenerPort    *int
+	DestinationPort *int
+}
+
+var _ fi.HasDependencies = &LoadBalancerService{}
+
+func (e *LoadBalancerService) GetDependencies(tasks map[string]fi.Task) []fi.Task {
+	return nil
+}
+
+var _ fi.CompareWithID = &LoadBalancer{}
+var _ fi.HasAddress = &LoadBalancer{}
+
+func (v *LoadBalancer) CompareWithID() *string {
+	if v.ID == nil {
+		return nil
+	}
+	return fi.String(strconv.Itoa(*v.ID))
+}
+
+func (v *LoadBalancer) IsForAPIServer() bool {
+	return true
+}
+
+func (v *LoadBalancer) FindIPAddress(c *fi.Context) (*string, error) {
+	cloud := c.Cloud.(hetzner.HetznerCloud)
+
+	loadBalancer, _, err := cloud.LoadBalancerClient().GetByName(context.TODO(), fi.StringValue(v.Name))
+	if err != nil {
+		return nil, fmt.Errorf("error finding load balancer %q: %v", fi.StringValue(v.Name), err)
+	}
+	if loadBalancer == nil || loadBalancer.PublicNet.IPv4.IP == nil {
+		// The load balancer has not been created yet
+		return nil, nil
+	}
+
+	address := loadBalancer.PublicNet.IPv4.IP.String()
+	return &address, nil
+}
+
+func (v *LoadBalancer) Find(c *fi.Context) (*LoadBalancer, error) {
+	cloud := c.Cloud.(hetzner.HetznerCloud)
+
+	loadBalancer, _, err := cloud.LoadBalancerClient().GetByName(context.TODO(), fi.StringValue(v.Name))
+	if err != nil {
+		return nil, fmt.Errorf("error finding load balancer %q: %v", fi.StringValue(v.Name), err)
+	}
+	if loadBalancer == nil {
+		return nil, nil
+	}
+
+	actual := &LoadBalancer{
+		Name:      fi.String(loadBalancer.Name),
+		ID:        fi.Int(loadBalancer.ID),
+		Lifecycle: v.Lifecycle,
+		Labels:    loadBalancer.Labels,
+	}
+	if loadBalancer.Location != nil {
+		actual.Location = loadBalancer.Location.Name
+	}
+	if loadBalancer.LoadBalancerType != nil {
+		actual.Type = loadBalancer.LoadBalancerType.Name
+	}
+	for _, service := range loadBalancer.Services {
+		actual.Services = append(actual.Services, &LoadBalancerService{
+			Protocol:        string(service.Protocol),
+			ListenerPort:    fi.Int(service.ListenPort),
+			DestinationPort: fi.Int(service.DestinationPort),
+		})
+	}
+	for _, target := range loadBalancer.Targets {
+		if target.Type == hcloud.LoadBalancerTargetTypeLabelSelector && target.LabelSelector != nil {
+			actual.Target = target.LabelSelector.Selector
+		}
+	}
+	for _, privateNet := range loadBalancer.PrivateNet {
+		if privateNet.Network == nil {
+			continue
+		}
+		if v.Network != nil && fi.IntValue(v.Network.ID) == privateNet.Network.ID {
+			actual.Network = v.Network
+		} else {
+			actual.Network = &Network{ID: fi.Int(privateNet.Network.ID)}
+		}
+	}
+	v.ID = actual.ID
+
+	return actual, nil
+}
+
+func (v *LoadBalancer) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(v, c)
+}
+
+func (_ *LoadBalancer) CheckChanges(a, e, changes *LoadBalancer) error {
+	if a != nil {
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+		if changes.ID != nil {
+			return fi.CannotChangeField("ID")
+		}
+		if changes.Location != "" {
+			return fi.CannotChangeField("Location")
+		}
+		if changes.Type != "" {
+			return fi.CannotChangeField("Type")
+		}
+	} else {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.Location == "" {
+			return fi.RequiredField("Location")
+		}
+		if e.Type == "" {
+			return fi.RequiredField("Type")
+		}
+	}
+	return nil
+}
+
+func (_ *LoadBalancer) RenderHetzner(t *hetzner.HetznerAPITarget, a, e, changes *LoadBalancer) error {
+	client := t.Cloud.LoadBalancerClient()
+
+	if a == nil {
+		opts := hcloud.LoadBalancerCreateOpts{
+			Name:             fi.StringValue(e.Name),
+			LoadBalancerType: &hcloud.LoadBalancerType{Name: e.Type},
+			Location:         &hcloud.Location{Name: e.Location},
+			Labels:           e.Labels,
+			PublicInterface:  fi.Bool(true),
+		}
+		if e.Network != nil {
+			opts.Network = &hcloud.Network{ID: fi.IntValue(e.Network.ID)}
+		}
+		if e.Target != "" {
+			opts.Targets = []hcloud.LoadBalancerCreateOptsTarget{
+				{
+					Type:          hcloud.LoadBalancerTargetTypeLabelSelector,
+					LabelSelector: hcloud.LoadBalancerCreateOptsTargetLabelSelector{Selector: e.Target},
+					UsePrivateIP:  fi.Bool(e.Network != nil),
+				},
+			}
+		}
+		for _, service := range e.Services {
+			opts.Services = append(opts.Services, hcloud.LoadBalancerCreateOptsService{
+				Protocol:        hcloud.LoadBalancerServiceProtocol(service.Protocol),
+				ListenPort:      service.ListenerPort,
+				DestinationPort: service.DestinationPort,
+			})
+		}
+
+		result, _, err := client.Create(context.TODO(), opts)
+		if err != nil {
+			return fmt.Errorf("error creating load balancer %q: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(result.Action); err != nil {
+			return err
+		}
+		e.ID = fi.Int(result.LoadBalancer.ID)
+		return nil
+	}
+
+	loadBalancer := &hcloud.LoadBalancer{ID: fi.IntValue(a.ID)}
+
+	if changes.Labels != nil {
+		_, _, err := client.Update(context.TODO(), loadBalancer, hcloud.LoadBalancerUpdateOpts{
+			Labels: e.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating labels of load balancer %q: %v", fi.StringValue(e.Name), err)
+		}
+	}
+
+	if changes.Network != nil {
+		klog.V(2).Infof("Attaching load balancer %q to network %q", fi.StringValue(e.Name), fi.StringValue(e.Network.Name))
+		action, _, err := client.AttachToNetwork(context.TODO(), loadBalancer, hcloud.LoadBalancerAttachToNetworkOpts{
+			Network: &hcloud.Network{ID: fi.IntValue(e.Network.ID)},
+		})
+		if err != nil {
+			return fmt.Errorf("error attaching load balancer %q to network: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(action); err != nil {
+			return err
+		}
+	}
+
+	if changes.Services != nil {
+		for _, service := range e.Services {
+			var existing *LoadBalancerService
+			for _, s := range a.Services {
+				if fi.IntValue(s.ListenerPort) == fi.IntValue(service.ListenerPort) {
+					existing = s
+				}
+			}
+
+			var action *hcloud.Action
+			var err error
+			if existing == nil {
+				action, _, err = client.AddService(context.TODO(), loadBalancer, hcloud.LoadBalancerAddServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocol(service.Protocol),
+					ListenPort:      service.ListenerPort,
+					DestinationPort: service.DestinationPort,
+				})
+			} else if existing.Protocol != service.Protocol || fi.IntValue(existing.DestinationPort) != fi.IntValue(service.DestinationPort) {
+				action, _, err = client.UpdateService(context.TODO(), loadBalancer, fi.IntValue(service.ListenerPort), hcloud.LoadBalancerUpdateServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocol(service.Protocol),
+					DestinationPort: service.DestinationPort,
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("error updating service %d of load balancer %q: %v", fi.IntValue(service.ListenerPort), fi.StringValue(e.Name), err)
+			}
+			if err := t.Cloud.WaitForActions(action); err != nil {
+				return err
+			}
+		}
+	}
+
+	if changes.Target != "" {
+		if a.Target != "" {
+			action, _, err := client.RemoveLabelSelectorTarget(context.TODO(), loadBalancer, a.Target)
+			if err != nil {
+				return fmt.Errorf("error removing target from load balancer %q: %v", fi.StringValue(e.Name), err)
+			}
+			if err := t.Cloud.WaitForActions(action); err != nil {
+				return err
+			}
+		}
+		action, _, err := client.AddLabelSelectorTarget(context.TODO(), loadBalancer, hcloud.LoadBalancerAddLabelSelectorTargetOpts{
+			Selector:     e.Target,
+			UsePrivateIP: fi.Bool(e.Network != nil),
+		})
+		if err != nil {
+			return fmt.Errorf("error adding target to load balancer %q: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(action); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznertasks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+)
+
+// +kops:fitask
+type Network struct {
+	Name      *string
+	ID        *int
+	Lifecycle fi.Lifecycle
+
+	// Region is the network zone the subnets are created in
+	Region  string
+	IPRange string
+	Subnets []string
+
+	Labels map[string]string
+}
+
+var _ fi.CompareWithID = &Network{}
+
+func (v *Network) CompareWithID() *string {
+	if v.ID == nil {
+		return nil
+	}
+	return fi.String(strconv.Itoa(*v.ID))
+}
+
+func (v *Network) Find(c *fi.Context) (*Network, error) {
+	cloud := c.Cloud.(hetzner.HetznerCloud)
+
+	network, _, err := cloud.NetworkClient().GetByName(context.TODO(), fi.StringValue(v.Name))
+	if err != nil {
+		return nil, fmt.Errorf("error finding network %q: %v", fi.StringValue(v.Name), err)
+	}
+	if network == nil {
+		return nil, nil
+	}
+
+	actual := &Network{
+		Name:      fi.String(network.Name),
+		ID:        fi.Int(network.ID),
+		Lifecycle: v.Lifecycle,
+		Labels:    network.Labels,
+	}
+	if network.IPRange != nil {
+		actual.IPRange = network.IPRange.String()
+	}
+	for _, subnet := range network.Subnets {
+		actual.Region = string(subnet.NetworkZone)
+		if subnet.IPRange != nil {
+			actual.Subnets = append(actual.Subnets, subnet.IPRange.String())
+		}
+	}
+	sort.Strings(actual.Subnets)
+	v.ID = actual.ID
+
+	return actual, nil
+}
+
+func (v *Network) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(v, c)
+}
+
+func (_ *Network) CheckChanges(a, e, changes *Network) error {
+	if a != nil {
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+		if changes.ID != nil {
+			return fi.CannotChangeField("ID")
+		}
+		if changes.IPRange != "" {
+			return fi.CannotChangeField("IPRange")
+		}
+		if changes.Region != "" {
+			return fi.CannotChangeField("Region")
+		}
+	} else {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.IPRange == "" {
+			return fi.RequiredField("IPRange")
+		}
+		if e.Region == "" {
+			return fi.RequiredField("Region")
+		}
+	}
+	return nil
+}
+
+func (_ *Network) RenderHetzner(t *hetzner.HetznerAPITarget, a, e, changes *Network) error {
+	client := t.Cloud.NetworkClient()
+
+	if a == nil {
+		_, ipRange, err := net.ParseCIDR(e.IPRange)
+		if err != nil {
+			return fmt.Errorf("error parsing network IP range %q: %v", e.IPRange, err)
+		}
+		opts := hcloud.NetworkCreateOpts{
+			Name:    fi.StringValue(e.Name),
+			IPRange: ipRange,
+			Labels:  e.Labels,
+		}
+		for _, subnet := range e.Subnets {
+			_, subnetIPRange, err := net.ParseCIDR(subnet)
+			if err != nil {
+				return fmt.Errorf("error parsing subnet IP range %q: %v", subnet, err)
+			}
+			opts.Subnets = append(opts.Subnets, hcloud.NetworkSubnet{
+				Type:        hcloud.NetworkSubnetTypeCloud,
+				IPRange:     subnetIPRange,
+				NetworkZone: hcloud.NetworkZone(e.Region),
+			})
+		}
+
+		network, _, err := client.Create(context.TODO(), opts)
+		if err != nil {
+			return fmt.Errorf("error creating network %q: %v", fi.StringValue(e.Name), err)
+		}
+		e.ID = fi.Int(network.ID)
+		return nil
+	}
+
+	network := &hcloud.Network{ID: fi.IntValue(a.ID)}
+
+	if changes.Labels != nil {
+		_, _, err := client.Update(context.TODO(), network, hcloud.NetworkUpdateOpts{
+			Labels: e.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating labels of network %q: %v", fi.StringValue(e.Name), err)
+		}
+	}
+
+	if changes.Subnets != nil {
+		for _, subnet := range e.Subnets {
+			if fi.ArrayContains(a.Subnets, subnet) {
+				continue
+			}
+			_, subnetIPRange, err := net.ParseCIDR(subnet)
+			if err != nil {
+				return fmt.Errorf("error parsing subnet IP range %q: %v", subnet, err)
+			}
+			klog.V(2).Infof("Adding subnet %s to network %q", subnet, fi.StringValue(e.Name))
+			action, _, err := client.AddSubnet(context.TODO(), network, hcloud.NetworkAddSubnetOpts{
+				Subnet: hcloud.NetworkSubnet{
+					Type:        hcloud.NetworkSubnetTypeCloud,
+					IPRange:     subnetIPRange,
+					NetworkZone: hcloud.NetworkZone(e.Region),
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("error adding subnet %s to network %q: %v", subnet, fi.StringValue(e.Name), err)
+			}
+			if err := t.Cloud.WaitForActions(action); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznertasks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+)
+
+// maxPlacementGroupServers is the number of servers a spread placement group can hold
+const maxPlacementGroupServers = 10
+
+// ServerGroup represents the servers of an instance group, spread across hosts by a placement group
+// +kops:fitask
+type ServerGroup struct {
+	Name      *string
+	Lifecycle fi.Lifecycle
+
+	SSHKeys []*SSHKey
+	Network *Network
+
+	Count      int
+	NeedUpdate []string
+
+	Location string
+	Size     string
+	Image    string
+
+	UserData fi.Resource
+
+	Labels map[string]string
+}
+
+var _ fi.CompareWithID = &ServerGroup{}
+
+func (v *ServerGroup) CompareWithID() *string {
+	return v.Name
+}
+
+// selectorLabels returns the labels identifying the servers of the group
+func (v *ServerGroup) selectorLabels() map[string]string {
+	return map[string]string{
+		hetzner.TagKubernetesClusterName:   v.Labels[hetzner.TagKubernetesClusterName],
+		hetzner.TagKubernetesInstanceGroup: v.Labels[hetzner.TagKubernetesInstanceGroup],
+	}
+}
+
+func (v *ServerGroup) Find(c *fi.Context) (*ServerGroup, error) {
+	cloud := c.Cloud.(hetzner.HetznerCloud)
+
+	servers, err := cloud.GetServers(v.selectorLabels())
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, nil
+	}
+
+	userDataHash, err := v.userDataHash()
+	if err != nil {
+		return nil, err
+	}
+
+	// The configuration of existing servers is not changed, they are marked for replacement instead
+	actual := &ServerGroup{
+		Name:      v.Name,
+		Lifecycle: v.Lifecycle,
+		SSHKeys:   v.SSHKeys,
+		Network:   v.Network,
+		Count:     len(servers),
+		Location:  v.Location,
+		Size:      v.Size,
+		Image:     v.Image,
+		UserData:  v.UserData,
+		Labels:    v.Labels,
+	}
+	for _, server := range servers {
+		if _, ok := server.Labels[hetzner.TagKubernetesInstanceNeedsUpdate]; ok {
+			continue
+		}
+		if server.Labels[hetzner.TagKubernetesInstanceUserData] != userDataHash ||
+			(server.ServerType != nil && server.ServerType.Name != v.Size) ||
+			(server.Image != nil && server.Image.Name != "" && server.Image.Name != v.Image) {
+			actual.NeedUpdate = append(actual.NeedUpdate, server.Name)
+		}
+	}
+
+	return actual, nil
+}
+
+// userDataHash returns a short hash of the user data, stored as a label on the servers to detect changes
+func (v *ServerGroup) userDataHash() (string, error) {
+	userData, err := fi.ResourceAsBytes(v.UserData)
+	if err != nil {
+		return "", fmt.Errorf("error rendering user data of %q: %v", fi.StringValue(v.Name), err)
+	}
+	sum := sha256.Sum256(userData)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+func (v *ServerGroup) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(v, c)
+}
+
+func (_ *ServerGroup) CheckChanges(a, e, changes *ServerGroup) error {
+	if a != nil {
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+	} else {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.Location == "" {
+			return fi.RequiredField("Location")
+		}
+		if e.Size == "" {
+			return fi.RequiredField("Size")
+		}
+		if e.Image == "" {
+			return fi.RequiredField("Image")
+		}
+	}
+	return nil
+}
+
+func (_ *ServerGroup) RenderHetzner(t *hetzner.HetznerAPITarget, a, e, changes *ServerGroup) error {
+	client := t.Cloud.ServerClient()
+
+	actualCount := 0
+	if a != nil {
+		actualCount = a.Count
+	}
+
+	if actualCount < e.Count {
+		userData, err := fi.ResourceAsString(e.UserData)
+		if err != nil {
+			return err
+		}
+		userDataHash, err := e.userDataHash()
+		if err != nil {
+			return err
+		}
+
+		placementGroup, err := ensurePlacementGroup(t, e)
+		if err != nil {
+			return err
+		}
+
+		labels := make(map[string]string)
+		for k, v := range e.Labels {
+			labels[k] = v
+		}
+		labels[hetzner.TagKubernetesInstanceUserData] = userDataHash
+
+		opts := hcloud.ServerCreateOpts{
+			ServerType:     &hcloud.ServerType{Name: e.Size},
+			Image:          &hcloud.Image{Name: e.Image},
+			Location:       &hcloud.Location{Name: e.Location},
+			UserData:       userData,
+			Labels:         labels,
+			PlacementGroup: placementGroup,
+		}
+		for _, sshKey := range e.SSHKeys {
+			opts.SSHKeys = append(opts.SSHKeys, &hcloud.SSHKey{ID: fi.IntValue(sshKey.ID)})
+		}
+		if e.Network != nil {
+			opts.Networks = []*hcloud.Network{{ID: fi.IntValue(e.Network.ID)}}
+		}
+
+		for i := actualCount; i < e.Count; i++ {
+			name, err := serverName(fi.StringValue(e.Name))
+			if err != nil {
+				return err
+			}
+			opts.Name = name
+
+			klog.V(2).Infof("Creating server %q", name)
+			result, _, err := client.Create(context.TODO(), opts)
+			if err != nil {
+				return fmt.Errorf("error creating server %q: %v", name, err)
+			}
+			if err := t.Cloud.WaitForActions(append([]*hcloud.Action{result.Action}, result.NextActions...)...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if actualCount > e.Count {
+		servers, err := t.Cloud.GetServers(e.selectorLabels())
+		if err != nil {
+			return err
+		}
+		for _, server := range servers[:actualCount-e.Count] {
+			klog.V(2).Infof("Deleting server %q", server.Name)
+			if _, err := client.Delete(context.TODO(), server); err != nil {
+				return fmt.Errorf("error deleting server %q: %v", server.Name, err)
+			}
+		}
+	}
+
+	if a != nil && len(a.NeedUpdate) > 0 {
+		servers, err := t.Cloud.GetServers(e.selectorLabels())
+		if err != nil {
+			return err
+		}
+		for _, server := range servers {
+			if !fi.ArrayContains(a.NeedUpdate, server.Name) {
+				continue
+			}
+			labels := make(map[string]string)
+			for k, v := range server.Labels {
+				labels[k] = v
+			}
+			labels[hetzner.TagKubernetesInstanceNeedsUpdate] = ""
+
+			klog.V(2).Infof("Marking server %q as needing update", server.Name)
+			_, _, err := client.Update(context.TODO(), server, hcloud.ServerUpdateOpts{
+				Labels: labels,
+			})
+			if err != nil {
+				return fmt.Errorf("error labelling server %q: %v", server.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ensurePlacementGroup returns the spread placement group of the servers, creating it when missing.
+// Groups larger than a placement group can hold are not spread.
+func ensurePlacementGroup(t *hetzner.HetznerAPITarget, e *ServerGroup) (*hcloud.PlacementGroup, error) {
+	if e.Count > maxPlacementGroupServers {
+		return nil, nil
+	}
+
+	client := t.Cloud.PlacementGroupClient()
+
+	name := fi.StringValue(e.Name) + "." + e.Labels[hetzner.TagKubernetesClusterName]
+	placementGroup, _, err := client.GetByName(context.TODO(), name)
+	if err != nil {
+		return nil, fmt.Errorf("error finding placement group %q: %v", name, err)
+	}
+	if placementGroup != nil {
+		if len(placementGroup.Servers)+e.Count > maxPlacementGroupServers {
+			return nil, nil
+		}
+		return placementGroup, nil
+	}
+
+	klog.V(2).Infof("Creating placement group %q", name)
+	result, _, err := client.Create(context.TODO(), hcloud.PlacementGroupCreateOpts{
+		Name:   name,
+		Labels: e.selectorLabels(),
+		Type:   hcloud.PlacementGroupTypeSpread,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating placement group %q: %v", name, err)
+	}
+	if err := t.Cloud.WaitForActions(result.Action); err != nil {
+		return nil, err
+	}
+
+	return result.PlacementGroup, nil
+}
+
+// serverName returns a random name for a new server of the group
+func serverName(groupName string) (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("error generating server name: %v", err)
+	}
+	return groupName + "-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetznertasks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
+)
+
+// +kops:fitask
+type Volume struct {
+	Name      *string
+	ID        *int
+	Lifecycle fi.Lifecycle
+
+	Location string
+	// Size is the size of the volume in GB
+	Size int
+
+	Labels map[string]string
+}
+
+var _ fi.CompareWithID = &Volume{}
+
+func (v *Volume) CompareWithID() *string {
+	if v.ID == nil {
+		return nil
+	}
+	return fi.String(strconv.Itoa(*v.ID))
+}
+
+func (v *Volume) Find(c *fi.Context) (*Volume, error) {
+	cloud := c.Cloud.(hetzner.HetznerCloud)
+
+	volume, _, err := cloud.VolumeClient().GetByName(context.TODO(), fi.StringValue(v.Name))
+	if err != nil {
+		return nil, fmt.Errorf("error finding volume %q: %v", fi.StringValue(v.Name), err)
+	}
+	if volume == nil {
+		return nil, nil
+	}
+
+	actual := &Volume{
+		Name:      fi.String(volume.Name),
+		ID:        fi.Int(volume.ID),
+		Lifecycle: v.Lifecycle,
+		Size:      volume.Size,
+		Labels:    volume.Labels,
+	}
+	if volume.Location != nil {
+		actual.Location = volume.Location.Name
+	}
+	v.ID = actual.ID
+
+	return actual, nil
+}
+
+func (v *Volume) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(v, c)
+}
+
+func (_ *Volume) CheckChanges(a, e, changes *Volume) error {
+	if a != nil {
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+		if changes.ID != nil {
+			return fi.CannotChangeField("ID")
+		}
+		if changes.Location != "" {
+			return fi.CannotChangeField("Location")
+		}
+		if changes.Size != 0 && e.Size < a.Size {
+			return fmt.Errorf("volumes cannot be shrunk (from %dGB to %dGB)", a.Size, e.Size)
+		}
+	} else {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.Location == "" {
+			return fi.RequiredField("Location")
+		}
+		if e.Size == 0 {
+			return fi.RequiredField("Size")
+		}
+	}
+	return nil
+}
+
+func (_ *Volume) RenderHetzner(t *hetzner.HetznerAPITarget, a, e, changes *Volume) error {
+	client := t.Cloud.VolumeClient()
+
+	if a == nil {
+		result, _, err := client.Create(context.TODO(), hcloud.VolumeCreateOpts{
+			Name:     fi.StringValue(e.Name),
+			Size:     e.Size,
+			Location: &hcloud.Location{Name: e.Location},
+			Labels:   e.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating volume %q: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(result.Action); err != nil {
+			return err
+		}
+		e.ID = fi.Int(result.Volume.ID)
+		return nil
+	}
+
+	volume := &hcloud.Volume{ID: fi.IntValue(a.ID)}
+
+	if changes.Labels != nil {
+		_, _, err := client.Update(context.TODO(), volume, hcloud.VolumeUpdateOpts{
+			Labels: e.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating labels of volume %q: %v", fi.StringValue(e.Name), err)
+		}
+	}
+
+	if changes.Size != 0 {
+		action, _, err := client.Resize(context.TODO(), volume, e.Size)
+		if err != nil {
+			return fmt.Errorf("error resizing volume %q: %v", fi.StringValue(e.Name), err)
+		}
+		if err := t.Cloud.WaitForActions(action); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
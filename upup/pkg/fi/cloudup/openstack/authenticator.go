@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const (
+	OpenstackAuthenticationTokenPrefix = "x-openstack-metadata "
+
+	// instanceMetadataURL serves the metadata of the instance
+	instanceMetadataURL = "http://169.254.169.254/openstack/latest/meta_data.json"
+)
+
+// openstackAuthenticator authenticates with the ID of the instance from the OpenStack metadata service.
+// The ID is not a secret, so the verifier also checks the request comes from an address of the instance.
+type openstackAuthenticator struct {
+	client http.Client
+}
+
+var _ fi.Authenticator = &openstackAuthenticator{}
+
+func NewOpenstackAuthenticator() (fi.Authenticator, error) {
+	return &openstackAuthenticator{
+		client: http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (a *openstackAuthenticator) CreateToken(body []byte) (string, error) {
+	response, err := a.client.Get(instanceMetadataURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to get instance metadata: %w", err)
+	}
+	defer response.Body.Close()
+
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read instance metadata: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code %d from instance metadata: %s", response.StatusCode, string(b))
+	}
+
+	metadata := struct {
+		ServerID string `json:"uuid"`
+	}{}
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return "", fmt.Errorf("failed to parse instance metadata: %w", err)
+	}
+	if metadata.ServerID == "" {
+		return "", fmt.Errorf("instance metadata did not contain uuid")
+	}
+
+	return OpenstackAuthenticationTokenPrefix + metadata.ServerID, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type OpenstackVerifierOptions struct {
+	// ClusterName is the name of the cluster; nodes must be servers of this cluster.
+	ClusterName string `json:"clusterName"`
+}
+
+type openstackVerifier struct {
+	opt OpenstackVerifierOptions
+
+	novaClient *gophercloud.ServiceClient
+}
+
+var _ fi.Verifier = &openstackVerifier{}
+
+func NewOpenstackVerifier(opt *OpenstackVerifierOptions) (fi.Verifier, error) {
+	env, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	region := os.Getenv("OS_REGION_NAME")
+	if region == "" {
+		return nil, fmt.Errorf("unable to find region")
+	}
+
+	provider, err := openstack.NewClient(env.IdentityEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	ua := gophercloud.UserAgent{}
+	ua.Prepend("kops/kops-controller")
+	provider.UserAgent = ua
+
+	// kops-controller should be able to renew its tokens against OpenStack API
+	env.AllowReauth = true
+
+	err = openstack.Authenticate(provider, env)
+	if err != nil {
+		return nil, err
+	}
+
+	novaClient, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{
+		Type:   "compute",
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error building nova client: %v", err)
+	}
+
+	return &openstackVerifier{
+		opt:        *opt,
+		novaClient: novaClient,
+	}, nil
+}
+
+func (o openstackVerifier) VerifyToken(rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	if !strings.HasPrefix(token, OpenstackAuthenticationTokenPrefix) {
+		return nil, fmt.Errorf("incorrect authorization type")
+	}
+	serverID := strings.TrimPrefix(token, OpenstackAuthenticationTokenPrefix)
+
+	server, err := servers.Get(o.novaClient, serverID).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("fetching server %q: %v", serverID, err)
+	}
+
+	return o.verifyServer(rawRequest, server)
+}
+
+// verifyServer checks the server is a node of the cluster and the request comes from one of its addresses
+func (o openstackVerifier) verifyServer(rawRequest *http.Request, server *servers.Server) (*fi.VerifyResult, error) {
+	if server.Status != "ACTIVE" {
+		return nil, fmt.Errorf("found server %q, but status is %q", server.Name, server.Status)
+	}
+	if clusterName := server.Metadata["k8s"]; clusterName != o.opt.ClusterName {
+		return nil, fmt.Errorf("server %q is in cluster %q, not %q", server.Name, clusterName, o.opt.ClusterName)
+	}
+	igName := server.Metadata["KopsInstanceGroup"]
+	if igName == "" {
+		return nil, fmt.Errorf("could not find tag 'KopsInstanceGroup' from server %q metadata", server.Name)
+	}
+
+	// The server ID is not a secret, so we rely on the network to authenticate the server
+	host, _, err := net.SplitHostPort(rawRequest.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing remote address %q: %v", rawRequest.RemoteAddr, err)
+	}
+	remoteIP := net.ParseIP(host)
+	found := false
+	for _, address := range serverAddresses(server) {
+		if ip := net.ParseIP(address); ip != nil && ip.Equal(remoteIP) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("request from %s is not from an address of server %q", host, server.Name)
+	}
+
+	return &fi.VerifyResult{
+		NodeName:          server.Name,
+		InstanceGroupName: igName,
+	}, nil
+}
+
+// serverAddresses returns the fixed and floating addresses of the server on all its networks
+func serverAddresses(server *servers.Server) []string {
+	var addresses []string
+	for _, networkAddresses := range server.Addresses {
+		list, ok := networkAddresses.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, addr := range list {
+			addrMap, ok := addr.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if address, ok := addrMap[openstackAddress].(string); ok {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestVerifyServer(t *testing.T) {
+	buildServer := func() *servers.Server {
+		return &servers.Server{
+			ID:     "f6ce9f4e-8b6c-4b2a-9b0e-3c1f0c2d4e5f",
+			Name:   "nodes-1-minimal-example-com",
+			Status: "ACTIVE",
+			Metadata: map[string]string{
+				"k8s":               "minimal.example.com",
+				"KopsInstanceGroup": "nodes-1",
+			},
+			Addresses: map[string]interface{}{
+				"minimal.example.com": []interface{}{
+					map[string]interface{}{"addr": "192.168.1.10", "version": float64(4), "OS-EXT-IPS:type": "fixed"},
+					map[string]interface{}{"addr": "203.0.113.10", "version": float64(4), "OS-EXT-IPS:type": "floating"},
+				},
+			},
+		}
+	}
+
+	verifier := openstackVerifier{
+		opt: OpenstackVerifierOptions{ClusterName: "minimal.example.com"},
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		mutate     func(server *servers.Server)
+		err        string
+	}{
+		{
+			name:       "fixed address",
+			remoteAddr: "192.168.1.10:43210",
+		},
+		{
+			name:       "floating address",
+			remoteAddr: "203.0.113.10:43210",
+		},
+		{
+			name:       "other address",
+			remoteAddr: "192.168.1.11:43210",
+			err:        "request from 192.168.1.11 is not from an address of server \"nodes-1-minimal-example-com\"",
+		},
+		{
+			name:       "other cluster",
+			remoteAddr: "192.168.1.10:43210",
+			mutate:     func(server *servers.Server) { server.Metadata["k8s"] = "other.example.com" },
+			err:        "server \"nodes-1-minimal-example-com\" is in cluster \"other.example.com\", not \"minimal.example.com\"",
+		},
+		{
+			name:       "no instance group",
+			remoteAddr: "192.168.1.10:43210",
+			mutate:     func(server *servers.Server) { delete(server.Metadata, "KopsInstanceGroup") },
+			err:        "could not find tag 'KopsInstanceGroup' from server \"nodes-1-minimal-example-com\" metadata",
+		},
+		{
+			name:       "not active",
+			remoteAddr: "192.168.1.10:43210",
+			mutate:     func(server *servers.Server) { server.Status = "SHUTOFF" },
+			err:        "found server \"nodes-1-minimal-example-com\", but status is \"SHUTOFF\"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := buildServer()
+			if test.mutate != nil {
+				test.mutate(server)
+			}
+
+			result, err := verifier.verifyServer(&http.Request{RemoteAddr: test.remoteAddr}, server)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &fi.VerifyResult{
+				NodeName:          "nodes-1-minimal-example-com",
+				InstanceGroupName: "nodes-1",
+			}, result)
+		})
+	}
+}
@@ -28,6 +28,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -79,7 +81,8 @@ func (e *FloatingIP) IsForAPIServer() bool {
 
 func (e *FloatingIP) FindIPAddress(context *fi.Context) (*string, error) {
 	if e.ID == nil {
-		if e.LB != nil && e.LB.ID == nil {
+		// The floating IP has not been created yet, e.g. when rendering terraform
+		if e.LB == nil || e.LB.ID == nil {
 			return nil, nil
 		}
 	}
@@ -308,3 +311,42 @@ func (f *FloatingIP) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, chan
 	klog.V(2).Infof("Openstack task Instance::RenderOpenstack did nothing")
 	return nil
 }
+
+type terraformFloatingIP struct {
+	Pool        *string                  `json:"pool,omitempty" cty:"pool"`
+	Description *string                  `json:"description,omitempty" cty:"description"`
+	SubnetID    *string                  `json:"subnet_id,omitempty" cty:"subnet_id"`
+	PortID      *terraformWriter.Literal `json:"port_id,omitempty" cty:"port_id"`
+}
+
+func (_ *FloatingIP) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *FloatingIP) error {
+	cloud := t.Cloud.(openstack.OpenstackCloud)
+
+	external, err := cloud.GetExternalNetwork()
+	if err != nil {
+		return fmt.Errorf("Failed to find external network: %v", err)
+	}
+
+	tf := &terraformFloatingIP{
+		Pool:        fi.String(external.Name),
+		Description: e.Name,
+	}
+	if e.LB != nil {
+		tf.PortID = e.LB.TerraformLink("vip_port_id")
+	}
+
+	// instance floatingips comes from the same subnet as the kubernetes API floatingip
+	lbSubnet, err := cloud.GetLBFloatingSubnet()
+	if err != nil {
+		return fmt.Errorf("Failed to find floatingip subnet: %v", err)
+	}
+	if lbSubnet != nil {
+		tf.SubnetID = fi.String(lbSubnet.ID)
+	}
+
+	return t.RenderResource("openstack_networking_floatingip_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *FloatingIP) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_networking_floatingip_v2", fi.StringValue(e.Name), "address")
+}
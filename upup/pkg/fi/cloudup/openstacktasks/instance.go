@@ -31,6 +31,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -426,3 +428,116 @@ func bootFromVolume(m map[string]string) bool {
 		return false
 	}
 }
+
+type terraformInstance struct {
+	Name             *string                           `json:"name,omitempty" cty:"name"`
+	ImageName        *string                           `json:"image_name,omitempty" cty:"image_name"`
+	FlavorName       *string                           `json:"flavor_name,omitempty" cty:"flavor_name"`
+	KeyPair          *terraformWriter.Literal          `json:"key_pair,omitempty" cty:"key_pair"`
+	SecurityGroups   []string                          `json:"security_groups,omitempty" cty:"security_groups"`
+	Networks         []*terraformInstanceNetwork       `json:"network,omitempty" cty:"network"`
+	Metadata         map[string]string                 `json:"metadata,omitempty" cty:"metadata"`
+	UserData         *terraformWriter.Literal          `json:"user_data,omitempty" cty:"user_data"`
+	AvailabilityZone *string                           `json:"availability_zone,omitempty" cty:"availability_zone"`
+	SchedulerHints   []*terraformInstanceSchedulerHint `json:"scheduler_hints,omitempty" cty:"scheduler_hints"`
+	BlockDevices     []*terraformInstanceBlockDevice   `json:"block_device,omitempty" cty:"block_device"`
+}
+
+type terraformInstanceNetwork struct {
+	Port *terraformWriter.Literal `json:"port,omitempty" cty:"port"`
+}
+
+type terraformInstanceSchedulerHint struct {
+	Group *terraformWriter.Literal `json:"group,omitempty" cty:"group"`
+}
+
+type terraformInstanceBlockDevice struct {
+	UUID                *string `json:"uuid,omitempty" cty:"uuid"`
+	SourceType          *string `json:"source_type,omitempty" cty:"source_type"`
+	DestinationType     *string `json:"destination_type,omitempty" cty:"destination_type"`
+	BootIndex           *int    `json:"boot_index,omitempty" cty:"boot_index"`
+	VolumeSize          *int    `json:"volume_size,omitempty" cty:"volume_size"`
+	DeleteOnTermination *bool   `json:"delete_on_termination,omitempty" cty:"delete_on_termination"`
+}
+
+type terraformFloatingIPAssociation struct {
+	FloatingIP *terraformWriter.Literal `json:"floating_ip,omitempty" cty:"floating_ip"`
+	PortID     *terraformWriter.Literal `json:"port_id,omitempty" cty:"port_id"`
+}
+
+func (_ *Instance) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Instance) error {
+	name := fi.StringValue(e.Name)
+	keyPairName := openstackKeyPairName(fi.StringValue(e.SSHKey))
+
+	tf := &terraformInstance{
+		Name:           e.Name,
+		ImageName:      e.Image,
+		FlavorName:     e.Flavor,
+		KeyPair:        terraformWriter.LiteralProperty("openstack_compute_keypair_v2", keyPairName, "name"),
+		SecurityGroups: e.SecurityGroups,
+		Networks: []*terraformInstanceNetwork{
+			{
+				Port: e.Port.TerraformLink(),
+			},
+		},
+		Metadata:         e.Metadata,
+		AvailabilityZone: e.AvailabilityZone,
+		SchedulerHints: []*terraformInstanceSchedulerHint{
+			{
+				Group: e.ServerGroup.TerraformLink(),
+			},
+		},
+	}
+
+	if e.UserData != nil {
+		userData, err := t.AddFileResource("openstack_compute_instance_v2", name, "user_data", e.UserData, false)
+		if err != nil {
+			return err
+		}
+		tf.UserData = userData
+	}
+
+	if bootFromVolume(e.Metadata) {
+		image, err := t.Cloud.(openstack.OpenstackCloud).GetImage(fi.StringValue(e.Image))
+		if err != nil {
+			return fmt.Errorf("Error getting image information: %v", err)
+		}
+		volumeSize := image.MinDiskGigabytes
+		if s, ok := e.Metadata[openstack.BOOT_VOLUME_SIZE]; ok {
+			i, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("Invalid value for %v: %v", openstack.BOOT_VOLUME_SIZE, err)
+			}
+			volumeSize = int(i)
+		}
+		tf.BlockDevices = []*terraformInstanceBlockDevice{
+			{
+				UUID:                fi.String(image.ID),
+				SourceType:          fi.String("image"),
+				DestinationType:     fi.String("volume"),
+				BootIndex:           fi.Int(0),
+				VolumeSize:          fi.Int(volumeSize),
+				DeleteOnTermination: fi.Bool(true),
+			},
+		}
+	}
+
+	// The pool association renders a member for each instance of the server group
+	e.ServerGroup.AddNewMember(name)
+
+	if err := t.RenderResource("openstack_compute_instance_v2", name, tf); err != nil {
+		return err
+	}
+
+	if e.FloatingIP != nil {
+		association := &terraformFloatingIPAssociation{
+			FloatingIP: e.FloatingIP.TerraformLink(),
+			PortID:     e.Port.TerraformLink(),
+		}
+		if err := t.RenderResource("openstack_networking_floatingip_associate_v2", name, association); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
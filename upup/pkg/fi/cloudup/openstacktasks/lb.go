@@ -29,6 +29,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -247,3 +249,25 @@ func (_ *LB) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LB)
 	klog.V(2).Infof("Openstack task LB::RenderOpenstack did nothing")
 	return nil
 }
+
+type terraformLB struct {
+	Name             *string                    `json:"name,omitempty" cty:"name"`
+	VipSubnetID      *terraformWriter.Literal   `json:"vip_subnet_id,omitempty" cty:"vip_subnet_id"`
+	SecurityGroupIDs []*terraformWriter.Literal `json:"security_group_ids,omitempty" cty:"security_group_ids"`
+}
+
+func (_ *LB) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LB) error {
+	tf := &terraformLB{
+		Name:        e.Name,
+		VipSubnetID: terraformWriter.LiteralProperty("openstack_networking_subnet_v2", fi.StringValue(e.Subnet), "id"),
+	}
+	if e.SecurityGroup != nil {
+		tf.SecurityGroupIDs = []*terraformWriter.Literal{e.SecurityGroup.TerraformLink()}
+	}
+
+	return t.RenderResource("openstack_lb_loadbalancer_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *LB) TerraformLink(prop string) *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_lb_loadbalancer_v2", fi.StringValue(e.Name), prop)
+}
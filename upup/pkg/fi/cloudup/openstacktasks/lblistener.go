@@ -25,6 +25,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -166,3 +168,28 @@ func (_ *LBListener) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, chan
 	klog.V(2).Infof("Openstack task LB::RenderOpenstack did nothing")
 	return nil
 }
+
+type terraformLBListener struct {
+	Name           *string                  `json:"name,omitempty" cty:"name"`
+	LoadbalancerID *terraformWriter.Literal `json:"loadbalancer_id,omitempty" cty:"loadbalancer_id"`
+	DefaultPoolID  *terraformWriter.Literal `json:"default_pool_id,omitempty" cty:"default_pool_id"`
+	Protocol       *string                  `json:"protocol,omitempty" cty:"protocol"`
+	ProtocolPort   *int                     `json:"protocol_port,omitempty" cty:"protocol_port"`
+	AllowedCIDRs   []string                 `json:"allowed_cidrs,omitempty" cty:"allowed_cidrs"`
+}
+
+func (_ *LBListener) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LBListener) error {
+	tf := &terraformLBListener{
+		Name:           e.Name,
+		LoadbalancerID: e.Pool.Loadbalancer.TerraformLink("id"),
+		DefaultPoolID:  e.Pool.TerraformLink(),
+		Protocol:       fi.String(string(listeners.ProtocolTCP)),
+		ProtocolPort:   fi.Int(443),
+	}
+
+	if openstackutil.IsOctaviaFeatureSupported(t.Cloud.(openstack.OpenstackCloud).LoadBalancerClient(), openstackutil.OctaviaFeatureVIPACL) {
+		tf.AllowedCIDRs = e.AllowedCIDRs
+	}
+
+	return t.RenderResource("openstack_lb_listener_v2", fi.StringValue(e.Name), tf)
+}
@@ -23,6 +23,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -151,3 +153,25 @@ func (_ *LBPool) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 	klog.V(2).Infof("Openstack task LB::RenderOpenstack did nothing")
 	return nil
 }
+
+type terraformLBPool struct {
+	Name           *string                  `json:"name,omitempty" cty:"name"`
+	LoadbalancerID *terraformWriter.Literal `json:"loadbalancer_id,omitempty" cty:"loadbalancer_id"`
+	LBMethod       *string                  `json:"lb_method,omitempty" cty:"lb_method"`
+	Protocol       *string                  `json:"protocol,omitempty" cty:"protocol"`
+}
+
+func (_ *LBPool) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LBPool) error {
+	tf := &terraformLBPool{
+		Name:           e.Name,
+		LoadbalancerID: e.Loadbalancer.TerraformLink("id"),
+		LBMethod:       fi.String(string(v2pools.LBMethodRoundRobin)),
+		Protocol:       fi.String(string(v2pools.ProtocolTCP)),
+	}
+
+	return t.RenderResource("openstack_lb_pool_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *LBPool) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_lb_pool_v2", fi.StringValue(e.Name), "id")
+}
@@ -23,6 +23,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -147,3 +149,35 @@ func (_ *Network) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 	klog.V(2).Infof("Using an existing Openstack network, id=%s", fi.StringValue(e.ID))
 	return nil
 }
+
+type terraformNetwork struct {
+	Name                  *string   `json:"name,omitempty" cty:"name"`
+	AdminStateUp          *bool     `json:"admin_state_up,omitempty" cty:"admin_state_up"`
+	AvailabilityZoneHints []*string `json:"availability_zone_hints,omitempty" cty:"availability_zone_hints"`
+	Tags                  []string  `json:"tags,omitempty" cty:"tags"`
+}
+
+func (_ *Network) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Network) error {
+	// An existing network is referenced by its ID
+	if fi.StringValue(e.ID) != "" {
+		return nil
+	}
+
+	tf := &terraformNetwork{
+		Name:                  e.Name,
+		AdminStateUp:          fi.Bool(true),
+		AvailabilityZoneHints: e.AvailabilityZoneHints,
+	}
+	if fi.StringValue(e.Tag) != "" {
+		tf.Tags = []string{fi.StringValue(e.Tag)}
+	}
+
+	return t.RenderResource("openstack_networking_network_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *Network) TerraformLink() *terraformWriter.Literal {
+	if fi.StringValue(e.ID) != "" {
+		return terraformWriter.LiteralFromStringValue(fi.StringValue(e.ID))
+	}
+	return terraformWriter.LiteralProperty("openstack_networking_network_v2", fi.StringValue(e.Name), "id")
+}
@@ -26,6 +26,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -186,3 +188,27 @@ func (_ *PoolAssociation) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e,
 	klog.V(2).Infof("Openstack task PoolAssociation::RenderOpenstack did nothing")
 	return nil
 }
+
+type terraformPoolMember struct {
+	Name         *string                  `json:"name,omitempty" cty:"name"`
+	PoolID       *terraformWriter.Literal `json:"pool_id,omitempty" cty:"pool_id"`
+	SubnetID     *terraformWriter.Literal `json:"subnet_id,omitempty" cty:"subnet_id"`
+	Address      *terraformWriter.Literal `json:"address,omitempty" cty:"address"`
+	ProtocolPort *int                     `json:"protocol_port,omitempty" cty:"protocol_port"`
+}
+
+func (_ *PoolAssociation) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *PoolAssociation) error {
+	for _, member := range e.ServerGroup.GetMembers() {
+		tf := &terraformPoolMember{
+			Name:         e.Name,
+			PoolID:       e.Pool.TerraformLink(),
+			SubnetID:     e.Pool.Loadbalancer.TerraformLink("vip_subnet_id"),
+			Address:      terraformWriter.LiteralProperty("openstack_compute_instance_v2", member, "access_ip_v4"),
+			ProtocolPort: e.ProtocolPort,
+		}
+		if err := t.RenderResource("openstack_lb_member_v2", member, tf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -25,6 +25,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -232,3 +234,51 @@ func portCreateOptsFromPortTask(t *openstack.OpenstackAPITarget, a, e, changes *
 		FixedIPs:       fixedIPs,
 	}, nil
 }
+
+type terraformPort struct {
+	Name             *string                    `json:"name,omitempty" cty:"name"`
+	NetworkID        *terraformWriter.Literal   `json:"network_id,omitempty" cty:"network_id"`
+	SecurityGroupIDs []*terraformWriter.Literal `json:"security_group_ids,omitempty" cty:"security_group_ids"`
+	FixedIPs         []*terraformPortFixedIP    `json:"fixed_ip,omitempty" cty:"fixed_ip"`
+	Tags             []string                   `json:"tags,omitempty" cty:"tags"`
+}
+
+type terraformPortFixedIP struct {
+	SubnetID *terraformWriter.Literal `json:"subnet_id,omitempty" cty:"subnet_id"`
+}
+
+func (*Port) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Port) error {
+	tf := &terraformPort{
+		Name:      e.Name,
+		NetworkID: e.Network.TerraformLink(),
+	}
+	for _, sg := range e.SecurityGroups {
+		tf.SecurityGroupIDs = append(tf.SecurityGroupIDs, sg.TerraformLink())
+	}
+	for _, sgName := range e.AdditionalSecurityGroups {
+		gs, err := t.Cloud.(openstack.OpenstackCloud).ListSecurityGroups(secgroup.ListOpts{
+			Name: sgName,
+		})
+		if err != nil {
+			return fmt.Errorf("error listing additional SecurityGroup %s: %v", sgName, err)
+		}
+		if len(gs) == 0 {
+			return fmt.Errorf("Additional SecurityGroup not found for name %s", sgName)
+		}
+		tf.SecurityGroupIDs = append(tf.SecurityGroupIDs, terraformWriter.LiteralFromStringValue(gs[0].ID))
+	}
+	for _, subnet := range e.Subnets {
+		tf.FixedIPs = append(tf.FixedIPs, &terraformPortFixedIP{
+			SubnetID: subnet.TerraformLink(),
+		})
+	}
+	if fi.StringValue(e.Tag) != "" {
+		tf.Tags = []string{fi.StringValue(e.Tag)}
+	}
+
+	return t.RenderResource("openstack_networking_port_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *Port) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_networking_port_v2", fi.StringValue(e.Name), "id")
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+func Test_LoadBalancer_RenderTerraform(t *testing.T) {
+	outdir, err := ioutil.TempDir("", "kops-render-")
+	if err != nil {
+		t.Fatalf("failed to create local render directory: %v", err)
+	}
+	defer os.RemoveAll(outdir)
+
+	target := terraform.NewTerraformTarget(openstack.BuildMockOpenstackCloud("us-test1"), "", outdir, nil)
+
+	network := &Network{Name: fi.String("cluster"), ID: fi.String("")}
+	subnet := &Subnet{Name: fi.String("subnet"), Network: network}
+	serverGroup := &ServerGroup{Name: fi.String("masters"), Policies: []string{"anti-affinity"}}
+	lb := &LB{Name: fi.String("api"), Subnet: subnet.Name}
+	pool := &LBPool{Name: fi.String("api-https"), Loadbalancer: lb}
+	port := &Port{Name: fi.String("port-master-1"), Network: network, Subnets: []*Subnet{subnet}}
+	instance := &Instance{
+		Name:        fi.String("master-1"),
+		Port:        port,
+		Flavor:      fi.String("m1.medium"),
+		Image:       fi.String("ubuntu"),
+		SSHKey:      fi.String("kubernetes.cluster"),
+		ServerGroup: serverGroup,
+		FloatingIP:  &FloatingIP{Name: fi.String("fip-master-1")},
+	}
+	association := &PoolAssociation{
+		Name:         serverGroup.Name,
+		Pool:         pool,
+		ServerGroup:  serverGroup,
+		ProtocolPort: fi.Int(443),
+	}
+
+	if err := lb.RenderTerraform(target, nil, lb, nil); err != nil {
+		t.Fatalf("error rendering LB: %v", err)
+	}
+	if err := pool.RenderTerraform(target, nil, pool, nil); err != nil {
+		t.Fatalf("error rendering LBPool: %v", err)
+	}
+	if err := instance.RenderTerraform(target, nil, instance, nil); err != nil {
+		t.Fatalf("error rendering Instance: %v", err)
+	}
+	if err := association.RenderTerraform(target, nil, association, nil); err != nil {
+		t.Fatalf("error rendering PoolAssociation: %v", err)
+	}
+	if err := target.Finish(make(map[string]fi.Task)); err != nil {
+		t.Fatalf("error finishing target: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(path.Join(outdir, "kubernetes.tf"))
+	if err != nil {
+		t.Fatalf("error reading rendered terraform: %v", err)
+	}
+
+	expected := []string{
+		`resource "openstack_lb_loadbalancer_v2" "api" {
+  name          = "api"
+  vip_subnet_id = openstack_networking_subnet_v2.subnet.id
+}`,
+		`resource "openstack_lb_member_v2" "master-1" {
+  address       = openstack_compute_instance_v2.master-1.access_ip_v4
+  name          = "masters"
+  pool_id       = openstack_lb_pool_v2.api-https.id
+  protocol_port = 443
+  subnet_id     = openstack_lb_loadbalancer_v2.api.vip_subnet_id
+}`,
+		`resource "openstack_lb_pool_v2" "api-https" {
+  lb_method       = "ROUND_ROBIN"
+  loadbalancer_id = openstack_lb_loadbalancer_v2.api.id
+  name            = "api-https"
+  protocol        = "TCP"
+}`,
+		`resource "openstack_networking_floatingip_associate_v2" "master-1" {
+  floating_ip = openstack_networking_floatingip_v2.fip-master-1.address
+  port_id     = openstack_networking_port_v2.port-master-1.id
+}`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(content), e) {
+			t.Errorf("rendered terraform does not contain expected resource:\n%s", diff.FormatDiff(e, string(content)))
+		}
+	}
+}
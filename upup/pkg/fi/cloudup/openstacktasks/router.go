@@ -23,6 +23,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -39,7 +41,7 @@ func (n *Router) CompareWithID() *string {
 	return n.ID
 }
 
-//NewRouterTaskFromCloud initializes and returns a new Router
+// NewRouterTaskFromCloud initializes and returns a new Router
 func NewRouterTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, router *routers.Router, find *Router) (*Router, error) {
 	actual := &Router{
 		ID:                    fi.String(router.ID),
@@ -133,3 +135,49 @@ func (_ *Router) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 	klog.V(2).Infof("Using an existing Openstack router, id=%s", fi.StringValue(e.ID))
 	return nil
 }
+
+type terraformRouter struct {
+	Name                  *string                `json:"name,omitempty" cty:"name"`
+	AdminStateUp          *bool                  `json:"admin_state_up,omitempty" cty:"admin_state_up"`
+	AvailabilityZoneHints []*string              `json:"availability_zone_hints,omitempty" cty:"availability_zone_hints"`
+	ExternalNetworkID     *string                `json:"external_network_id,omitempty" cty:"external_network_id"`
+	ExternalFixedIPs      []*terraformExternalIP `json:"external_fixed_ip,omitempty" cty:"external_fixed_ip"`
+}
+
+type terraformExternalIP struct {
+	SubnetID *string `json:"subnet_id,omitempty" cty:"subnet_id"`
+}
+
+func (_ *Router) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Router) error {
+	cloud := t.Cloud.(openstack.OpenstackCloud)
+
+	floatingNet, err := cloud.GetExternalNetwork()
+	if err != nil {
+		return fmt.Errorf("Could not list external networks for gateway: %v", err)
+	}
+
+	tf := &terraformRouter{
+		Name:                  e.Name,
+		AdminStateUp:          fi.Bool(true),
+		AvailabilityZoneHints: e.AvailabilityZoneHints,
+		ExternalNetworkID:     fi.String(floatingNet.ID),
+	}
+
+	routerFloatingSubnet, err := cloud.GetExternalSubnet()
+	if err != nil {
+		return fmt.Errorf("Failed to find floatingip subnet: %v", err)
+	}
+	if routerFloatingSubnet != nil {
+		tf.ExternalFixedIPs = []*terraformExternalIP{
+			{
+				SubnetID: fi.String(routerFloatingSubnet.ID),
+			},
+		}
+	}
+
+	return t.RenderResource("openstack_networking_router_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *Router) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_networking_router_v2", fi.StringValue(e.Name), "id")
+}
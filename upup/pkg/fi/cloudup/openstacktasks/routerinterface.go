@@ -24,6 +24,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -139,3 +141,17 @@ func (_ *RouterInterface) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e,
 	klog.V(2).Infof("Using an existing Openstack router interface, id=%s", fi.StringValue(e.ID))
 	return nil
 }
+
+type terraformRouterInterface struct {
+	RouterID *terraformWriter.Literal `json:"router_id,omitempty" cty:"router_id"`
+	SubnetID *terraformWriter.Literal `json:"subnet_id,omitempty" cty:"subnet_id"`
+}
+
+func (_ *RouterInterface) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *RouterInterface) error {
+	tf := &terraformRouterInterface{
+		RouterID: e.Router.TerraformLink(),
+		SubnetID: e.Subnet.TerraformLink(),
+	}
+
+	return t.RenderResource("openstack_networking_router_interface_v2", fi.StringValue(e.Name), tf)
+}
@@ -26,6 +26,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -348,3 +350,29 @@ func (r *PortRemovalRule) Matches(rule sgr.SecGroupRule) bool {
 	}
 	return true
 }
+
+type terraformSecurityGroup struct {
+	Name               *string `json:"name,omitempty" cty:"name"`
+	Description        *string `json:"description,omitempty" cty:"description"`
+	DeleteDefaultRules *bool   `json:"delete_default_rules,omitempty" cty:"delete_default_rules"`
+}
+
+func (_ *SecurityGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *SecurityGroup) error {
+	// A group that is being removed is not part of the configuration
+	if e.RemoveGroup {
+		return nil
+	}
+
+	// The egress rules that are created by default are part of the model
+	tf := &terraformSecurityGroup{
+		Name:               e.Name,
+		Description:        e.Description,
+		DeleteDefaultRules: fi.Bool(true),
+	}
+
+	return t.RenderResource("openstack_networking_secgroup_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *SecurityGroup) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_networking_secgroup_v2", fi.StringValue(e.Name), "id")
+}
@@ -23,6 +23,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 func Int(v int) *int {
@@ -236,3 +238,43 @@ func (o *SecurityGroupRule) FindDeletions(c *fi.Context) ([]fi.Deletion, error)
 		},
 	}, nil
 }
+
+type terraformSecurityGroupRule struct {
+	Direction       *string                  `json:"direction,omitempty" cty:"direction"`
+	EtherType       *string                  `json:"ethertype,omitempty" cty:"ethertype"`
+	SecurityGroupID *terraformWriter.Literal `json:"security_group_id,omitempty" cty:"security_group_id"`
+	PortRangeMin    *int                     `json:"port_range_min,omitempty" cty:"port_range_min"`
+	PortRangeMax    *int                     `json:"port_range_max,omitempty" cty:"port_range_max"`
+	Protocol        *string                  `json:"protocol,omitempty" cty:"protocol"`
+	RemoteIPPrefix  *string                  `json:"remote_ip_prefix,omitempty" cty:"remote_ip_prefix"`
+	RemoteGroupID   *terraformWriter.Literal `json:"remote_group_id,omitempty" cty:"remote_group_id"`
+}
+
+func (*SecurityGroupRule) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *SecurityGroupRule) error {
+	// Rules that are being removed are not part of the configuration
+	if fi.BoolValue(e.Delete) {
+		return nil
+	}
+
+	tf := &terraformSecurityGroupRule{
+		Direction:       e.Direction,
+		EtherType:       e.EtherType,
+		SecurityGroupID: e.SecGroup.TerraformLink(),
+		Protocol:        e.Protocol,
+	}
+	if fi.IntValue(e.PortRangeMin) != 0 || fi.IntValue(e.PortRangeMax) != 0 {
+		tf.PortRangeMin = e.PortRangeMin
+		tf.PortRangeMax = e.PortRangeMax
+	}
+	if fi.StringValue(e.Protocol) == "" {
+		tf.Protocol = nil
+	}
+	if fi.StringValue(e.RemoteIPPrefix) != "" {
+		tf.RemoteIPPrefix = e.RemoteIPPrefix
+	}
+	if e.RemoteGroup != nil {
+		tf.RemoteGroupID = e.RemoteGroup.TerraformLink()
+	}
+
+	return t.RenderResource("openstack_networking_secgroup_rule_v2", e.String(), tf)
+}
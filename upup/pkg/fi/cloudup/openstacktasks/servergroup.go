@@ -26,6 +26,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -211,3 +213,21 @@ func (s *ServerGroup) GetMembers() []string {
 	s.gotMemberList = true
 	return s.members
 }
+
+type terraformServerGroup struct {
+	Name     *string  `json:"name,omitempty" cty:"name"`
+	Policies []string `json:"policies,omitempty" cty:"policies"`
+}
+
+func (_ *ServerGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *ServerGroup) error {
+	tf := &terraformServerGroup{
+		Name:     e.Name,
+		Policies: e.Policies,
+	}
+
+	return t.RenderResource("openstack_compute_servergroup_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *ServerGroup) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_compute_servergroup_v2", fi.StringValue(e.Name), "id")
+}
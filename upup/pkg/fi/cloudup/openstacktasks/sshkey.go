@@ -26,6 +26,8 @@ import (
 	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -137,3 +139,25 @@ func (_ *SSHKey) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 	klog.V(2).Infof("Using an existing Openstack keypair, id=%s", fi.StringValue(e.KeyFingerprint))
 	return nil
 }
+
+type terraformSSHKey struct {
+	Name      *string                  `json:"name,omitempty" cty:"name"`
+	PublicKey *terraformWriter.Literal `json:"public_key,omitempty" cty:"public_key"`
+}
+
+func (_ *SSHKey) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *SSHKey) error {
+	name := openstackKeyPairName(fi.StringValue(e.Name))
+	tf := &terraformSSHKey{
+		Name: fi.String(name),
+	}
+
+	if e.PublicKey != nil {
+		publicKey, err := t.AddFileResource("openstack_compute_keypair_v2", name, "public_key", e.PublicKey, false)
+		if err != nil {
+			return fmt.Errorf("error rendering SSHKey PublicKey: %v", err)
+		}
+		tf.PublicKey = publicKey
+	}
+
+	return t.RenderResource("openstack_compute_keypair_v2", name, tf)
+}
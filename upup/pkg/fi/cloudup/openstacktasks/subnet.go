@@ -24,6 +24,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
 // +kops:fitask
@@ -200,3 +202,33 @@ func (*Subnet) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *S
 	klog.V(2).Infof("Using an existing Openstack subnet, id=%s", fi.StringValue(e.ID))
 	return nil
 }
+
+type terraformSubnet struct {
+	Name           *string                  `json:"name,omitempty" cty:"name"`
+	NetworkID      *terraformWriter.Literal `json:"network_id,omitempty" cty:"network_id"`
+	CIDR           *string                  `json:"cidr,omitempty" cty:"cidr"`
+	IPVersion      *int                     `json:"ip_version,omitempty" cty:"ip_version"`
+	EnableDHCP     *bool                    `json:"enable_dhcp,omitempty" cty:"enable_dhcp"`
+	DNSNameservers []*string                `json:"dns_nameservers,omitempty" cty:"dns_nameservers"`
+	Tags           []string                 `json:"tags,omitempty" cty:"tags"`
+}
+
+func (*Subnet) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Subnet) error {
+	tf := &terraformSubnet{
+		Name:           e.Name,
+		NetworkID:      e.Network.TerraformLink(),
+		CIDR:           e.CIDR,
+		IPVersion:      fi.Int(4),
+		EnableDHCP:     fi.Bool(true),
+		DNSNameservers: e.DNSServers,
+	}
+	if fi.StringValue(e.Tag) != "" {
+		tf.Tags = []string{fi.StringValue(e.Tag)}
+	}
+
+	return t.RenderResource("openstack_networking_subnet_v2", fi.StringValue(e.Name), tf)
+}
+
+func (e *Subnet) TerraformLink() *terraformWriter.Literal {
+	return terraformWriter.LiteralProperty("openstack_networking_subnet_v2", fi.StringValue(e.Name), "id")
+}
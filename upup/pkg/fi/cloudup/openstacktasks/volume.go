@@ -23,6 +23,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
 )
 
 // +kops:fitask
@@ -156,3 +157,37 @@ func (_ *Volume) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 	klog.V(2).Infof("Openstack task Volume::RenderOpenstack did nothing")
 	return nil
 }
+
+type terraformVolume struct {
+	Name             *string           `json:"name,omitempty" cty:"name"`
+	Size             *int64            `json:"size,omitempty" cty:"size"`
+	AvailabilityZone *string           `json:"availability_zone,omitempty" cty:"availability_zone"`
+	VolumeType       *string           `json:"volume_type,omitempty" cty:"volume_type"`
+	Metadata         map[string]string `json:"metadata,omitempty" cty:"metadata"`
+}
+
+func (_ *Volume) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *Volume) error {
+	storageAZ, err := t.Cloud.(openstack.OpenstackCloud).GetStorageAZFromCompute(fi.StringValue(e.AvailabilityZone))
+	if err != nil {
+		return fmt.Errorf("Failed to get storage availability zone: %s", err)
+	}
+
+	tf := &terraformVolume{
+		Name:             e.Name,
+		Size:             e.SizeGB,
+		AvailabilityZone: fi.String(storageAZ.ZoneName),
+		VolumeType:       e.VolumeType,
+		Metadata:         e.Tags,
+	}
+
+	return t.RenderResource("openstack_blockstorage_volume_v3", e.TerraformName(), tf)
+}
+
+// TerraformName returns the name of the terraform resource, which cannot start with a digit
+func (e *Volume) TerraformName() string {
+	name := fi.StringValue(e.Name)
+	if name[0] >= '0' && name[0] <= '9' {
+		return fmt.Sprintf("volume-%v", name)
+	}
+	return name
+}
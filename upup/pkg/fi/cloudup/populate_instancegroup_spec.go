@@ -35,22 +35,25 @@ import (
 
 // Default Machine types for various types of instance group machine
 const (
-	defaultNodeMachineTypeGCE   = "n1-standard-2"
-	defaultNodeMachineTypeDO    = "s-2vcpu-4gb"
-	defaultNodeMachineTypeALI   = "ecs.n2.medium"
-	defaultNodeMachineTypeAzure = "Standard_B2ms"
+	defaultNodeMachineTypeGCE     = "n1-standard-2"
+	defaultNodeMachineTypeDO      = "s-2vcpu-4gb"
+	defaultNodeMachineTypeALI     = "ecs.n2.medium"
+	defaultNodeMachineTypeAzure   = "Standard_B2ms"
+	defaultNodeMachineTypeHetzner = "cx21"
 
 	defaultBastionMachineTypeGCE   = "f1-micro"
 	defaultBastionMachineTypeALI   = "ecs.n2.small"
 	defaultBastionMachineTypeAzure = "Standard_B2ms"
 
-	defaultMasterMachineTypeGCE   = "n1-standard-1"
-	defaultMasterMachineTypeDO    = "s-2vcpu-4gb"
-	defaultMasterMachineTypeALI   = "ecs.n2.medium"
-	defaultMasterMachineTypeAzure = "Standard_B2ms"
+	defaultMasterMachineTypeGCE     = "n1-standard-1"
+	defaultMasterMachineTypeDO      = "s-2vcpu-4gb"
+	defaultMasterMachineTypeALI     = "ecs.n2.medium"
+	defaultMasterMachineTypeAzure   = "Standard_B2ms"
+	defaultMasterMachineTypeHetzner = "cx21"
 
-	defaultDONodeImage  = "ubuntu-20-04-x64"
-	defaultALINodeImage = "centos_7_04_64_20G_alibase_201701015.vhd"
+	defaultDONodeImage      = "ubuntu-20-04-x64"
+	defaultALINodeImage     = "centos_7_04_64_20G_alibase_201701015.vhd"
+	defaultHetznerNodeImage = "ubuntu-20.04"
 )
 
 // TODO: this hardcoded list can be replaced with DescribeInstanceTypes' DedicatedHostsSupported field
@@ -197,6 +200,16 @@ func defaultMachineType(cloud fi.Cloud, cluster *kops.Cluster, ig *kops.Instance
 
 		}
 
+	case kops.CloudProviderHetzner:
+		switch ig.Spec.Role {
+		case kops.InstanceGroupRoleMaster:
+			return defaultMasterMachineTypeHetzner, nil
+
+		case kops.InstanceGroupRoleNode:
+			return defaultNodeMachineTypeHetzner, nil
+
+		}
+
 	case kops.CloudProviderOpenstack:
 		instanceType, err := cloud.(openstack.OpenstackCloud).DefaultInstanceType(cluster, ig)
 		if err != nil {
@@ -257,6 +270,8 @@ func defaultImage(cluster *kops.Cluster, channel *kops.Channel, architecture arc
 		return defaultDONodeImage
 	case kops.CloudProviderALI:
 		return defaultALINodeImage
+	case kops.CloudProviderHetzner:
+		return defaultHetznerNodeImage
 	}
 	klog.Infof("Cannot set default Image for CloudProvider=%q", cluster.Spec.CloudProvider)
 	return ""
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcegraph
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+var (
+	// unsafeFilenameCharacters are the characters replaced in the names of the files of fi.Resources
+	unsafeFilenameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+	typeResource      = reflect.TypeOf((*fi.Resource)(nil)).Elem()
+	typeTask          = reflect.TypeOf((*fi.Task)(nil)).Elem()
+	typeJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// converter converts the properties of resources to values that marshal to JSON,
+// replacing references to tasks with their keys and fi.Resources with files
+type converter struct {
+	// keys are the keys of the tasks in the task map
+	keys map[fi.Task]string
+	// files are the contents of the fi.Resources, keyed by their path relative to the output directory
+	files map[string][]byte
+}
+
+// properties converts the properties of the resource with the key.
+// If the properties are the task itself, the Name and Lifecycle fields are omitted, as they are part of the resource.
+func (c *converter) properties(key string, properties interface{}) (map[string]interface{}, error) {
+	_, isTask := properties.(fi.Task)
+
+	v := reflect.ValueOf(properties)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unexpected properties type %T", properties)
+	}
+
+	prefix := sanitizeFilename(key)
+	result := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		if isTask && (field.Name == "Name" || field.Name == "Lifecycle") {
+			continue
+		}
+		value, err := c.convert(v.Field(i), prefix+"_"+field.Name)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+		if value != nil {
+			result[field.Name] = value
+		}
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// convert converts the value at the path; nil values, and empty slices and maps, are converted to nil
+func (c *converter) convert(v reflect.Value, path string) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+
+	if v.Type().Implements(typeResource) {
+		contents, err := fi.ResourceAsBytes(v.Interface().(fi.Resource))
+		if err != nil {
+			return nil, fmt.Errorf("error reading resource: %v", err)
+		}
+		file := "data/" + path
+		c.files[file] = contents
+		return map[string]string{"$file": file}, nil
+	}
+	if v.Type().Implements(typeTask) && v.Kind() == reflect.Ptr {
+		return map[string]string{"$ref": c.taskKey(v.Interface().(fi.Task))}, nil
+	}
+	if v.Type().Implements(typeJSONMarshaler) || v.Type().Implements(typeTextMarshaler) {
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return c.convert(v.Elem(), path)
+
+	case reflect.Struct:
+		result := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			value, err := c.convert(v.Field(i), path+"_"+field.Name)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", field.Name, err)
+			}
+			if value != nil {
+				result[field.Name] = value
+			}
+		}
+		return result, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		var result []interface{}
+		for i := 0; i < v.Len(); i++ {
+			value, err := c.convert(v.Index(i), path+"_"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		result := make(map[string]interface{})
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprintf("%v", iter.Key().Interface())
+			value, err := c.convert(iter.Value(), path+"_"+sanitizeFilename(k))
+			if err != nil {
+				return nil, err
+			}
+			result[k] = value
+		}
+		return result, nil
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil, fmt.Errorf("unhandled type %v", v.Type())
+
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// taskKey returns the key of the task in the task map, or builds it from the task type and name if the task is not in the task map
+func (c *converter) taskKey(task fi.Task) string {
+	if key, found := c.keys[task]; found {
+		return key
+	}
+	name := ""
+	if hasName, ok := task.(fi.HasName); ok {
+		name = fi.StringValue(hasName.GetName())
+	}
+	return fi.TypeNameForTask(task) + "/" + name
+}
+
+// sanitizeFilename replaces the characters of s that are not safe in filenames
+func sanitizeFilename(s string) string {
+	return unsafeFilenameCharacters.ReplaceAllString(s, "_")
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const (
+	// APIVersion is the version of the format of the resource graph
+	APIVersion = "resourcegraph.kops.k8s.io/v1alpha1"
+	// Kind is the kind of the resource graph document
+	Kind = "ResourceGraph"
+
+	// OutputFile is the name of the file to which the resource graph is written, in the output directory
+	OutputFile = "resources.json"
+)
+
+// ResourceGraphTarget renders the tasks as a graph of typed resources with their properties and references,
+// for consumption by other infrastructure as code tools.
+// Tasks can control how they are rendered with a RenderResourceGraph method; other tasks are rendered by reflection.
+type ResourceGraphTarget struct {
+	Cloud fi.Cloud
+
+	outDir string
+
+	// mutex protects the following items (resources)
+	mutex     sync.Mutex
+	resources map[fi.Task]interface{}
+}
+
+var _ fi.Target = &ResourceGraphTarget{}
+var _ fi.ResourceGraphRenderer = &ResourceGraphTarget{}
+
+func NewResourceGraphTarget(cloud fi.Cloud, outDir string) *ResourceGraphTarget {
+	return &ResourceGraphTarget{
+		Cloud:     cloud,
+		outDir:    outDir,
+		resources: make(map[fi.Task]interface{}),
+	}
+}
+
+// ResourceGraph is the document written by the ResourceGraphTarget
+type ResourceGraph struct {
+	APIVersion    string `json:"apiVersion"`
+	Kind          string `json:"kind"`
+	CloudProvider string `json:"cloudProvider"`
+	Region        string `json:"region,omitempty"`
+	// Resources are the resources, sorted by key
+	Resources []*Resource `json:"resources"`
+}
+
+// Resource is a task rendered as a resource
+type Resource struct {
+	// Key identifies the resource, as type/name; references to the resource use the key
+	Key string `json:"key"`
+	// Type is the type of the task
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Lifecycle fi.Lifecycle `json:"lifecycle,omitempty"`
+	// Properties are the properties of the resource.
+	// A reference to another resource is rendered as {"$ref": "<key>"}, and file contents as {"$file": "<path>"}, relative to the output directory.
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	// DependsOn are the keys of the resources that must be created before this resource
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func (t *ResourceGraphTarget) ProcessDeletions() bool {
+	// The consumer of the resource graph manages the lifecycle of the resources
+	return false
+}
+
+// RenderResource records the properties of the resource for the task.
+// The properties are converted when the target finishes, so they may reference other tasks and contain fi.Resources.
+func (t *ResourceGraphTarget) RenderResource(task fi.Task, properties interface{}) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, found := t.resources[task]; found {
+		return fmt.Errorf("resource for task %v already rendered", task)
+	}
+	t.resources[task] = properties
+	return nil
+}
+
+// RenderResourceGraphTask renders a task that does not have a RenderResourceGraph method, using its fields as properties
+func (t *ResourceGraphTarget) RenderResourceGraphTask(a, e, changes fi.Task) error {
+	return t.RenderResource(e, e)
+}
+
+func (t *ResourceGraphTarget) Finish(taskMap map[string]fi.Task) error {
+	keys := make(map[fi.Task]string)
+	for k, task := range taskMap {
+		keys[task] = k
+	}
+	dependencies := fi.FindTaskDependencies(taskMap)
+
+	c := &converter{
+		keys:  keys,
+		files: make(map[string][]byte),
+	}
+
+	graph := &ResourceGraph{
+		APIVersion:    APIVersion,
+		Kind:          Kind,
+		CloudProvider: string(t.Cloud.ProviderID()),
+		Region:        t.Cloud.Region(),
+		Resources:     []*Resource{},
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for task, properties := range t.resources {
+		key, found := keys[task]
+		if !found {
+			return fmt.Errorf("rendered task %v not found in task map", task)
+		}
+
+		resource := &Resource{
+			Key:       key,
+			Type:      fi.TypeNameForTask(task),
+			DependsOn: append([]string(nil), dependencies[key]...),
+		}
+		if hasName, ok := task.(fi.HasName); ok {
+			resource.Name = fi.StringValue(hasName.GetName())
+		}
+		if hasLifecycle, ok := task.(fi.HasLifecycle); ok {
+			resource.Lifecycle = hasLifecycle.GetLifecycle()
+		}
+		sort.Strings(resource.DependsOn)
+
+		props, err := c.properties(key, properties)
+		if err != nil {
+			return fmt.Errorf("error rendering properties of %q: %v", key, err)
+		}
+		resource.Properties = props
+
+		graph.Resources = append(graph.Resources, resource)
+	}
+	sort.Slice(graph.Resources, func(i, j int) bool {
+		return graph.Resources[i].Key < graph.Resources[j].Key
+	})
+
+	jsonBytes, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling resource graph to json: %v", err)
+	}
+
+	files := c.files
+	files[OutputFile] = append(jsonBytes, '\n')
+
+	for relativePath, contents := range files {
+		p := path.Join(t.outDir, relativePath)
+
+		err = os.MkdirAll(path.Dir(p), os.FileMode(0755))
+		if err != nil {
+			return fmt.Errorf("error creating output directory %q: %v", path.Dir(p), err)
+		}
+
+		err = ioutil.WriteFile(p, contents, os.FileMode(0644))
+		if err != nil {
+			return fmt.Errorf("error writing resource graph to output file %q: %v", p, err)
+		}
+	}
+
+	klog.Infof("Resource graph output is in %s", t.outDir)
+
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcegraph
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+type Network struct {
+	Name      *string
+	Lifecycle fi.Lifecycle
+	CIDR      *string
+	Tags      map[string]string
+}
+
+func (n *Network) Run(c *fi.Context) error { return nil }
+func (n *Network) GetName() *string        { return n.Name }
+
+type Server struct {
+	Name     *string
+	Network  *Network
+	UserData fi.Resource
+	Ports    []int
+	secret   string
+}
+
+func (s *Server) Run(c *fi.Context) error { return nil }
+func (s *Server) GetName() *string        { return s.Name }
+
+// RenderResourceGraph renders the server with properties different from its fields
+func (s *Server) RenderResourceGraph(t *ResourceGraphTarget, a, e, changes *Server) error {
+	return t.RenderResource(e, &struct {
+		Network *Network
+		Image   string
+	}{
+		Network: e.Network,
+		Image:   "ubuntu",
+	})
+}
+
+func Test_ResourceGraphTarget(t *testing.T) {
+	outDir := t.TempDir()
+
+	network := &Network{
+		Name:      fi.String("main"),
+		Lifecycle: fi.LifecycleSync,
+		CIDR:      fi.String("10.0.0.0/16"),
+		Tags:      map[string]string{"KubernetesCluster": "example.com"},
+	}
+	server := &Server{
+		Name:     fi.String("master"),
+		Network:  network,
+		UserData: fi.NewStringResource("#!/bin/bash"),
+		secret:   "secret",
+	}
+	taskMap := map[string]fi.Task{
+		"Network/main":  network,
+		"Server/master": server,
+	}
+
+	target := NewResourceGraphTarget(awsup.BuildMockAWSCloud("us-test-1", "a"), outDir)
+	require.NoError(t, target.RenderResourceGraphTask(nil, network, nil))
+	require.NoError(t, target.RenderResourceGraphTask(nil, server, nil))
+	assert.Error(t, target.RenderResourceGraphTask(nil, server, nil), "rendering a task twice")
+	require.NoError(t, target.Finish(taskMap))
+
+	data, err := ioutil.ReadFile(filepath.Join(outDir, OutputFile))
+	require.NoError(t, err)
+	var graph map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &graph))
+
+	expected := map[string]interface{}{
+		"apiVersion":    "resourcegraph.kops.k8s.io/v1alpha1",
+		"kind":          "ResourceGraph",
+		"cloudProvider": "aws",
+		"region":        "us-test-1",
+		"resources": []interface{}{
+			map[string]interface{}{
+				"key":  "Network/main",
+				"type": "Network",
+				"name": "main",
+				"properties": map[string]interface{}{
+					"CIDR": "10.0.0.0/16",
+					"Tags": map[string]interface{}{"KubernetesCluster": "example.com"},
+				},
+			},
+			map[string]interface{}{
+				"key":  "Server/master",
+				"type": "Server",
+				"name": "master",
+				"properties": map[string]interface{}{
+					"Network":  map[string]interface{}{"$ref": "Network/main"},
+					"UserData": map[string]interface{}{"$file": "data/Server_master_UserData"},
+				},
+				"dependsOn": []interface{}{"Network/main"},
+			},
+		},
+	}
+	assert.Equal(t, expected, graph)
+
+	userData, err := ioutil.ReadFile(filepath.Join(outDir, "data", "Server_master_UserData"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/bash", string(userData))
+}
+
+func Test_ResourceGraphTarget_RenderResource(t *testing.T) {
+	outDir := t.TempDir()
+
+	network := &Network{Name: fi.String("main")}
+	server := &Server{Name: fi.String("master"), Network: network}
+	taskMap := map[string]fi.Task{
+		"Network/main":  network,
+		"Server/master": server,
+	}
+
+	target := NewResourceGraphTarget(awsup.BuildMockAWSCloud("us-test-1", "a"), outDir)
+	require.NoError(t, server.RenderResourceGraph(target, nil, server, nil))
+	require.NoError(t, target.Finish(taskMap))
+
+	data, err := ioutil.ReadFile(filepath.Join(outDir, OutputFile))
+	require.NoError(t, err)
+	var graph ResourceGraph
+	require.NoError(t, json.Unmarshal(data, &graph))
+
+	require.Len(t, graph.Resources, 1)
+	assert.Equal(t, map[string]interface{}{
+		"Network": map[string]interface{}{"$ref": "Network/main"},
+		"Image":   "ubuntu",
+	}, graph.Resources[0].Properties)
+}
@@ -44,8 +44,8 @@ import (
 	"k8s.io/klog/v2"
 	kopscontrollerconfig "k8s.io/kops/cmd/kops-controller/pkg/config"
 	"k8s.io/kops/pkg/apis/kops"
-	apiModel "k8s.io/kops/pkg/apis/kops/model"
 	"k8s.io/kops/pkg/apis/kops/util"
+	"k8s.io/kops/pkg/certificates"
 	"k8s.io/kops/pkg/dns"
 	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/pkg/model"
@@ -55,7 +55,9 @@ import (
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
 	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/util/pkg/env"
+	"k8s.io/kops/util/pkg/slice"
 )
 
 // TemplateFunctions provides a collection of methods used throughout the templates
@@ -140,6 +142,9 @@ func (tf *TemplateFunctions) AddTo(dest template.FuncMap, secretStore fi.SecretS
 	dest["DO_TOKEN"] = func() string {
 		return os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
 	}
+	dest["HCLOUD_TOKEN"] = func() string {
+		return os.Getenv("HCLOUD_TOKEN")
+	}
 
 	if featureflag.Spotinst.Enabled() {
 		if creds, err := spotinst.LoadCredentials(); err == nil {
@@ -485,17 +490,12 @@ func (tf *TemplateFunctions) KopsControllerConfig() (string, error) {
 	}
 
 	if tf.UseKopsControllerForNodeBootstrap() {
-		certNames := []string{"kubelet", "kubelet-server"}
-		signingCAs := []string{fi.CertificateIDCA}
-		if apiModel.UseCiliumEtcd(cluster) {
-			certNames = append(certNames, "etcd-client-cilium")
-			signingCAs = append(signingCAs, "etcd-clients-ca-cilium")
-		}
-		if cluster.Spec.KubeProxy.Enabled == nil || *cluster.Spec.KubeProxy.Enabled {
-			certNames = append(certNames, "kube-proxy")
-		}
-		if cluster.Spec.Networking.Kuberouter != nil {
-			certNames = append(certNames, "kube-router")
+		var certNames, signingCAs []string
+		for _, cert := range certificates.KopsControllerCerts(cluster) {
+			certNames = append(certNames, cert.Name)
+			if !slice.Contains(signingCAs, cert.Signer) {
+				signingCAs = append(signingCAs, cert.Signer)
+			}
 		}
 
 		pkiDir := "/etc/kubernetes/kops-controller/pki"
@@ -539,6 +539,15 @@ func (tf *TemplateFunctions) KopsControllerConfig() (string, error) {
 				NodesRoles: nodesRoles.List(),
 				Region:     tf.Region,
 			}
+		case kops.CloudProviderGCE:
+			config.Server.Provider.GCE = &gce.GCEVerifierOptions{
+				ProjectID:   cluster.Spec.Project,
+				ClusterName: tf.ClusterName(),
+			}
+		case kops.CloudProviderOpenstack:
+			config.Server.Provider.OpenStack = &openstack.OpenstackVerifierOptions{
+				ClusterName: tf.ClusterName(),
+			}
 		default:
 			return "", fmt.Errorf("unsupported cloud provider %s", cluster.Spec.CloudProvider)
 		}
@@ -25,6 +25,7 @@ import (
 	"github.com/zclconf/go-cty/cty/gocty"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/featureflag"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
 	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
 )
 
@@ -41,13 +42,24 @@ func (t *TerraformTarget) finishHCL2() error {
 	providerName := string(t.Cloud.ProviderID())
 	if t.Cloud.ProviderID() == kops.CloudProviderGCE {
 		providerName = "google"
+	} else if t.Cloud.ProviderID() == kops.CloudProviderAzure {
+		providerName = "azurerm"
 	}
 	providerBlock := rootBody.AppendNewBlock("provider", []string{providerName})
 	providerBody := providerBlock.Body()
 	if t.Cloud.ProviderID() == kops.CloudProviderGCE {
 		providerBody.SetAttributeValue("project", cty.StringVal(t.Project))
 	}
-	providerBody.SetAttributeValue("region", cty.StringVal(t.Cloud.Region()))
+	if t.Cloud.ProviderID() == kops.CloudProviderAzure {
+		// The azurerm provider requires a features block, even if empty
+		providerBody.AppendNewBlock("features", []string{})
+		if subscriptionID := t.Cloud.(azure.AzureCloud).SubscriptionID(); subscriptionID != "" {
+			providerBody.SetAttributeValue("subscription_id", cty.StringVal(subscriptionID))
+		}
+	} else if t.Cloud.ProviderID() != kops.CloudProviderDO {
+		// The region of DigitalOcean and Azure resources is set on each resource
+		providerBody.SetAttributeValue("region", cty.StringVal(t.Cloud.Region()))
+	}
 	for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
 		providerBody.SetAttributeValue(k, cty.StringVal(v))
 	}
@@ -117,6 +129,21 @@ func (t *TerraformTarget) finishHCL2() error {
 				"version": cty.StringVal(">= 1.33.0"),
 			})
 		}
+	} else if t.Cloud.ProviderID() == kops.CloudProviderOpenstack {
+		writeMap(requiredProvidersBody, "openstack", map[string]cty.Value{
+			"source":  cty.StringVal("terraform-provider-openstack/openstack"),
+			"version": cty.StringVal(">= 1.41.0"),
+		})
+	} else if t.Cloud.ProviderID() == kops.CloudProviderDO {
+		writeMap(requiredProvidersBody, "digitalocean", map[string]cty.Value{
+			"source":  cty.StringVal("digitalocean/digitalocean"),
+			"version": cty.StringVal(">= 2.7.0"),
+		})
+	} else if t.Cloud.ProviderID() == kops.CloudProviderAzure {
+		writeMap(requiredProvidersBody, "azurerm", map[string]cty.Value{
+			"source":  cty.StringVal("hashicorp/azurerm"),
+			"version": cty.StringVal(">= 2.60.0"),
+		})
 	}
 
 	bytes := hclwrite.Format(f.Bytes())
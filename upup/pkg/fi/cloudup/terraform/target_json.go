@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
 )
 
 func (t *TerraformTarget) finishJSON() error {
@@ -45,6 +46,29 @@ func (t *TerraformTarget) finishJSON() error {
 			providerAWS[k] = v
 		}
 		providersByName["aws"] = providerAWS
+	} else if t.Cloud.ProviderID() == kops.CloudProviderOpenstack {
+		providerOpenstack := make(map[string]interface{})
+		providerOpenstack["region"] = t.Cloud.Region()
+		for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
+			providerOpenstack[k] = v
+		}
+		providersByName["openstack"] = providerOpenstack
+	} else if t.Cloud.ProviderID() == kops.CloudProviderDO {
+		providerDO := make(map[string]interface{})
+		for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
+			providerDO[k] = v
+		}
+		providersByName["digitalocean"] = providerDO
+	} else if t.Cloud.ProviderID() == kops.CloudProviderAzure {
+		providerAzure := make(map[string]interface{})
+		providerAzure["features"] = map[string]interface{}{}
+		if subscriptionID := t.Cloud.(azure.AzureCloud).SubscriptionID(); subscriptionID != "" {
+			providerAzure["subscription_id"] = subscriptionID
+		}
+		for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
+			providerAzure[k] = v
+		}
+		providersByName["azurerm"] = providerAzure
 	}
 
 	outputs, err := t.GetOutputs()
@@ -96,6 +120,30 @@ func (t *TerraformTarget) finishJSON() error {
 			requiredProviderAWS[k] = v
 		}
 		requiredProvidersByName["aws"] = requiredProviderAWS
+	} else if t.Cloud.ProviderID() == kops.CloudProviderOpenstack {
+		requiredProviderOpenstack := make(map[string]interface{})
+		requiredProviderOpenstack["source"] = "terraform-provider-openstack/openstack"
+		requiredProviderOpenstack["version"] = ">= 1.41.0"
+		for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
+			requiredProviderOpenstack[k] = v
+		}
+		requiredProvidersByName["openstack"] = requiredProviderOpenstack
+	} else if t.Cloud.ProviderID() == kops.CloudProviderDO {
+		requiredProviderDO := make(map[string]interface{})
+		requiredProviderDO["source"] = "digitalocean/digitalocean"
+		requiredProviderDO["version"] = ">= 2.7.0"
+		for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
+			requiredProviderDO[k] = v
+		}
+		requiredProvidersByName["digitalocean"] = requiredProviderDO
+	} else if t.Cloud.ProviderID() == kops.CloudProviderAzure {
+		requiredProviderAzure := make(map[string]interface{})
+		requiredProviderAzure["source"] = "hashicorp/azurerm"
+		requiredProviderAzure["version"] = ">= 2.60.0"
+		for k, v := range tfGetProviderExtraConfig(t.clusterSpecTarget) {
+			requiredProviderAzure[k] = v
+		}
+		requiredProvidersByName["azurerm"] = requiredProviderAzure
 	}
 
 	if len(requiredProvidersByName) != 0 {
@@ -32,6 +32,7 @@ import (
 	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
 	"k8s.io/kops/upup/pkg/fi/cloudup/do"
 	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 )
 
@@ -110,6 +111,19 @@ func BuildCloud(cluster *kops.Cluster) (fi.Cloud, error) {
 
 			cloud = doCloud
 		}
+	case kops.CloudProviderHetzner:
+		{
+			region, err := hetzner.FindRegion(cluster)
+			if err != nil {
+				return nil, err
+			}
+			hetznerCloud, err := hetzner.NewHetznerCloud(region)
+			if err != nil {
+				return nil, fmt.Errorf("error initializing hetzner cloud: %s", err)
+			}
+
+			cloud = hetznerCloud
+		}
 
 	case kops.CloudProviderOpenstack:
 		{
@@ -185,6 +199,9 @@ func SupportedClouds() []string {
 	if featureflag.AlphaAllowGCE.Enabled() {
 		clouds = append(clouds, string(kops.CloudProviderGCE))
 	}
+	if featureflag.Hetzner.Enabled() {
+		clouds = append(clouds, string(kops.CloudProviderHetzner))
+	}
 
 	sort.Strings(clouds)
 	return clouds
@@ -217,6 +217,10 @@ func (c *Context) Render(a, e, changes Task) error {
 
 	}
 	if renderer == nil {
+		// Only the resource graph target falls back to rendering the task from its fields
+		if resourceGraph, ok := c.Target.(ResourceGraphRenderer); ok {
+			return resourceGraph.RenderResourceGraphTask(a, e, changes)
+		}
 		return fmt.Errorf("could not find Render method on type %T (target %T)", e, c.Target)
 	}
 	rendererArgs = append(rendererArgs, reflect.ValueOf(a))
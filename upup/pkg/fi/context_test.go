@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unrenderableTask is a task without any Render method
+type unrenderableTask struct {
+	Name string
+}
+
+func (t *unrenderableTask) Run(c *Context) error {
+	return nil
+}
+
+// fakeTarget is a target that cannot render tasks it has no Render method for
+type fakeTarget struct{}
+
+func (t *fakeTarget) Finish(taskMap map[string]Task) error {
+	return nil
+}
+
+func (t *fakeTarget) ProcessDeletions() bool {
+	return false
+}
+
+// fakeResourceGraphTarget is a target that renders any task from its fields
+type fakeResourceGraphTarget struct {
+	fakeTarget
+	rendered []Task
+}
+
+func (t *fakeResourceGraphTarget) RenderResourceGraphTask(a, e, changes Task) error {
+	t.rendered = append(t.rendered, e)
+	return nil
+}
+
+func TestRenderFallsBackOnlyForResourceGraph(t *testing.T) {
+	task := &unrenderableTask{Name: "task"}
+
+	c := &Context{Target: &fakeTarget{}}
+	err := c.Render(nil, task, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "could not find Render method")
+	}
+
+	target := &fakeResourceGraphTarget{}
+	c = &Context{Target: target}
+	assert.NoError(t, c.Render(nil, task, nil))
+	assert.Equal(t, []Task{task}, target.rendered)
+}
@@ -18,6 +18,8 @@ package fi
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -151,56 +153,8 @@ func (t *DryRunTarget) PrintReport(taskMap map[string]Task, out io.Writer) error
 				taskName := getTaskName(r.changes)
 				fmt.Fprintf(b, "  %s/%s\n", taskName, idForTask(taskMap, r.e))
 
-				changes := reflect.ValueOf(r.changes)
-				if changes.Kind() == reflect.Ptr && !changes.IsNil() {
-					changes = changes.Elem()
-				}
-
-				if changes.Kind() == reflect.Struct {
-					for i := 0; i < changes.NumField(); i++ {
-
-						field := changes.Field(i)
-
-						fieldName := changes.Type().Field(i).Name
-						if changes.Type().Field(i).PkgPath != "" {
-							// Not exported
-							continue
-						}
-
-						fieldValue := reflectutils.ValueAsString(field)
-
-						shouldPrint := true
-						if fieldName == "Name" {
-							// The field name is already printed above, no need to repeat it.
-							shouldPrint = false
-						}
-						if fieldName == "Lifecycle" {
-							// Lifecycle is a "system" field; no need to show it
-							shouldPrint = false
-						}
-						if fieldValue == "<nil>" || fieldValue == "<resource>" {
-							// Uninformative
-							shouldPrint = false
-						}
-						if fieldValue == "id:<nil>" {
-							// Uninformative, but we can often print the name instead
-							name := ""
-							if field.CanInterface() {
-								hasName, ok := field.Interface().(HasName)
-								if ok {
-									name = StringValue(hasName.GetName())
-								}
-							}
-							if name != "" {
-								fieldValue = "name:" + name
-							} else {
-								shouldPrint = false
-							}
-						}
-						if shouldPrint {
-							fmt.Fprintf(b, "  \t%-20s\t%s\n", fieldName, fieldValue)
-						}
-					}
+				for _, change := range buildCreateList(r.changes) {
+					fmt.Fprintf(b, "  \t%-20s\t%s\n", change.FieldName, change.Description)
 				}
 
 				fmt.Fprintf(b, "\n")
@@ -275,6 +229,69 @@ func (t *DryRunTarget) PrintReport(taskMap map[string]Task, out io.Writer) error
 type change struct {
 	FieldName   string
 	Description string
+	// Before is the current value of the field; it is not valid for creates
+	Before reflect.Value
+	// After is the value to which the field would be set
+	After reflect.Value
+}
+
+// buildCreateList returns the informative fields of a task that is to be created
+func buildCreateList(changes Task) []change {
+	var changeList []change
+
+	valC := reflect.ValueOf(changes)
+	if valC.Kind() == reflect.Ptr && !valC.IsNil() {
+		valC = valC.Elem()
+	}
+
+	if valC.Kind() == reflect.Struct {
+		for i := 0; i < valC.NumField(); i++ {
+
+			field := valC.Field(i)
+
+			fieldName := valC.Type().Field(i).Name
+			if valC.Type().Field(i).PkgPath != "" {
+				// Not exported
+				continue
+			}
+
+			fieldValue := reflectutils.ValueAsString(field)
+
+			shouldPrint := true
+			if fieldName == "Name" {
+				// The field name is already printed above, no need to repeat it.
+				shouldPrint = false
+			}
+			if fieldName == "Lifecycle" {
+				// Lifecycle is a "system" field; no need to show it
+				shouldPrint = false
+			}
+			if fieldValue == "<nil>" || fieldValue == "<resource>" {
+				// Uninformative
+				shouldPrint = false
+			}
+			if fieldValue == "id:<nil>" {
+				// Uninformative, but we can often print the name instead
+				name := ""
+				if field.CanInterface() {
+					hasName, ok := field.Interface().(HasName)
+					if ok {
+						name = StringValue(hasName.GetName())
+					}
+				}
+				if name != "" {
+					fieldValue = "name:" + name
+				} else {
+					shouldPrint = false
+				}
+			}
+			if shouldPrint {
+				changeList = append(changeList, change{FieldName: fieldName, Description: fieldValue, After: field})
+			}
+		}
+	}
+
+	return changeList
 }
 
 func buildChangeList(a, e, changes Task) ([]change, error) {
@@ -319,6 +336,7 @@ func buildChangeList(a, e, changes Task) ([]change, error) {
 			}
 
 			description := ""
+			var before, after reflect.Value
 			ignored := false
 			if fieldValE.CanInterface() {
 
@@ -330,17 +348,21 @@ func buildChangeList(a, e, changes Task) ([]change, error) {
 					resE, okE := tryResourceAsString(fieldValE)
 					if okA && okE {
 						description = diff.FormatDiff(resA, resE)
+						before = reflect.ValueOf(resA)
+						after = reflect.ValueOf(resE)
 					}
 				}
 
 				if !ignored && description == "" {
+					before = fieldValA
+					after = fieldValE
 					description = fmt.Sprintf(" %v -> %v", reflectutils.ValueAsString(fieldValA), reflectutils.ValueAsString(fieldValE))
 				}
 			}
 			if ignored {
 				continue
 			}
-			changeList = append(changeList, change{FieldName: valC.Type().Field(i).Name, Description: description})
+			changeList = append(changeList, change{FieldName: valC.Type().Field(i).Name, Description: description, Before: before, After: after})
 		}
 	} else {
 		return nil, fmt.Errorf("unhandled change type: %v", valC.Type())
@@ -414,6 +436,198 @@ func (t *DryRunTarget) Changes() (map[string]Task, map[string]Task) {
 	return creates, updates
 }
 
+// ChangeAction is the kind of change that would be made to a task's resource
+type ChangeAction string
+
+const (
+	ChangeActionCreate ChangeAction = "create"
+	ChangeActionUpdate ChangeAction = "update"
+	ChangeActionDelete ChangeAction = "delete"
+)
+
+// TaskChange describes a change that would be made to the resource of a task
+type TaskChange struct {
+	// Key identifies the task, as type/name
+	Key string `json:"key"`
+	// Type is the type of the task
+	Type string `json:"type"`
+	// Name is the name of the task
+	Name string `json:"name"`
+	// Action is the change that would be made
+	Action ChangeAction `json:"action"`
+	// Fields are the fields that would be set or changed; they are not set for deletions
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange describes a field of a task that would be set or changed
+type FieldChange struct {
+	// Field is the name of the field
+	Field string `json:"field"`
+	// Description describes the value being set or the change being made
+	Description string `json:"description,omitempty"`
+	// Before is the current value of the field; it is not set for creates.
+	// References to other tasks are rendered as their keys, and resources as their contents.
+	Before interface{} `json:"before,omitempty"`
+	// After is the value to which the field would be set, rendered as Before is
+	After interface{} `json:"after,omitempty"`
+}
+
+// TaskChanges returns the changes that would be made, in the order they are printed in the report
+func (t *DryRunTarget) TaskChanges(taskMap map[string]Task) ([]*TaskChange, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var creates []*render
+	var updates []*render
+	for _, r := range t.changes {
+		if r.aIsNil {
+			creates = append(creates, r)
+		} else {
+			updates = append(updates, r)
+		}
+	}
+	sort.Sort(ByTaskKey(creates))
+	sort.Sort(ByTaskKey(updates))
+
+	keys := make(map[Task]string)
+	for k, task := range taskMap {
+		keys[task] = k
+	}
+
+	var taskChanges []*TaskChange
+	for _, r := range creates {
+		taskChanges = append(taskChanges, newTaskChange(taskMap, keys, r, ChangeActionCreate, buildCreateList(r.changes)))
+	}
+	for _, r := range updates {
+		changeList, err := buildChangeList(r.a, r.e, r.changes)
+		if err != nil {
+			return nil, err
+		}
+		taskChanges = append(taskChanges, newTaskChange(taskMap, keys, r, ChangeActionUpdate, changeList))
+	}
+
+	deletions := append([]Deletion(nil), t.deletions...)
+	sort.Sort(DeletionByTaskName(deletions))
+	for _, d := range deletions {
+		taskChanges = append(taskChanges, &TaskChange{
+			Key:    d.TaskName() + "/" + d.Item(),
+			Type:   d.TaskName(),
+			Name:   d.Item(),
+			Action: ChangeActionDelete,
+		})
+	}
+
+	return taskChanges, nil
+}
+
+func newTaskChange(taskMap map[string]Task, keys map[Task]string, r *render, action ChangeAction, changeList []change) *TaskChange {
+	taskName := getTaskName(r.changes)
+	name := idForTask(taskMap, r.e)
+	taskChange := &TaskChange{
+		Key:    taskName + "/" + name,
+		Type:   taskName,
+		Name:   name,
+		Action: action,
+	}
+	for _, c := range changeList {
+		taskChange.Fields = append(taskChange.Fields, FieldChange{
+			Field:       c.FieldName,
+			Description: c.Description,
+			Before:      jsonValue(keys, c.Before),
+			After:       jsonValue(keys, c.After),
+		})
+	}
+	return taskChange
+}
+
+var (
+	typeResource      = reflect.TypeOf((*Resource)(nil)).Elem()
+	typeTask          = reflect.TypeOf((*Task)(nil)).Elem()
+	typeJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonValue converts the value of a field to a value that marshals to JSON. References to other tasks
+// are converted to the keys of the tasks, and resources to their contents. Nil values are converted to nil.
+func jsonValue(keys map[Task]string, v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+	if v.Type().Implements(typeResource) {
+		if s, ok := tryResourceAsString(v); ok {
+			return s
+		}
+		return nil
+	}
+	if v.Type().Implements(typeTask) && v.Kind() == reflect.Ptr {
+		task := v.Interface().(Task)
+		if key, found := keys[task]; found {
+			return key
+		}
+		name := ""
+		if hasName, ok := task.(HasName); ok {
+			name = StringValue(hasName.GetName())
+		}
+		return TypeNameForTask(task) + "/" + name
+	}
+	if v.Type().Implements(typeJSONMarshaler) || v.Type().Implements(typeTextMarshaler) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return jsonValue(keys, v.Elem())
+
+	case reflect.Struct:
+		result := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				// Not exported
+				continue
+			}
+			if value := jsonValue(keys, v.Field(i)); value != nil {
+				result[v.Type().Field(i).Name] = value
+			}
+		}
+		return result
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		result := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, jsonValue(keys, v.Index(i)))
+		}
+		return result
+
+	case reflect.Map:
+		result := make(map[string]interface{})
+		iter := v.MapRange()
+		for iter.Next() {
+			result[fmt.Sprintf("%v", iter.Key().Interface())] = jsonValue(keys, iter.Value())
+		}
+		return result
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+
+	default:
+		return v.Interface()
+	}
+}
+
 // HasChanges returns true iff any changes would have been made
 func (t *DryRunTarget) HasChanges() bool {
 	return len(t.changes)+len(t.deletions) != 0
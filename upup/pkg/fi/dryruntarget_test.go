@@ -18,6 +18,7 @@ package fi
 
 import (
 	"bytes"
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -58,6 +59,7 @@ type testTask struct {
 	Name      *string
 	Lifecycle Lifecycle
 	Tags      map[string]string
+	Parent    *testTask
 }
 
 var _ Task = &testTask{}
@@ -66,6 +68,10 @@ func (*testTask) Run(_ *Context) error {
 	panic("not implemented")
 }
 
+func (t *testTask) GetName() *string {
+	return t.Name
+}
+
 func Test_DryrunTarget_PrintReport(t *testing.T) {
 	builder := assets.NewAssetBuilder(&api.Cluster{
 		Spec: api.ClusterSpec{
@@ -95,3 +101,76 @@ func Test_DryrunTarget_PrintReport(t *testing.T) {
 	err = target.PrintReport(tasks, &out)
 	assert.NoError(t, err, "target.PrintReport()")
 }
+
+func Test_DryrunTarget_TaskChanges(t *testing.T) {
+	builder := assets.NewAssetBuilder(&api.Cluster{
+		Spec: api.ClusterSpec{
+			KubernetesVersion: "1.17.3",
+		},
+	}, false)
+	target := NewDryRunTarget(builder, &bytes.Buffer{})
+
+	created := &testTask{
+		Name:      String("created"),
+		Lifecycle: LifecycleSync,
+		Tags:      map[string]string{"key": "value"},
+	}
+	createChanges := reflect.New(reflect.TypeOf(created).Elem()).Interface().(Task)
+	_ = BuildChanges((*testTask)(nil), created, createChanges)
+	assert.NoError(t, target.Render((*testTask)(nil), created, createChanges))
+
+	actual := &testTask{
+		Name:      String("updated"),
+		Lifecycle: LifecycleSync,
+		Tags:      map[string]string{"key": "old"},
+	}
+	updated := &testTask{
+		Name:      String("updated"),
+		Lifecycle: LifecycleSync,
+		Tags:      map[string]string{"key": "new"},
+	}
+	updateChanges := reflect.New(reflect.TypeOf(updated).Elem()).Interface().(Task)
+	_ = BuildChanges(actual, updated, updateChanges)
+	assert.NoError(t, target.Render(actual, updated, updateChanges))
+
+	child := &testTask{
+		Name:      String("child"),
+		Lifecycle: LifecycleSync,
+		Parent:    updated,
+	}
+	childChanges := reflect.New(reflect.TypeOf(child).Elem()).Interface().(Task)
+	_ = BuildChanges((*testTask)(nil), child, childChanges)
+	assert.NoError(t, target.Render((*testTask)(nil), child, childChanges))
+
+	tasks := map[string]Task{
+		"testTask/child":   child,
+		"testTask/created": created,
+		"testTask/updated": updated,
+	}
+	changes, err := target.TaskChanges(tasks)
+	assert.NoError(t, err, "target.TaskChanges()")
+	if assert.Len(t, changes, 3) {
+		assert.Equal(t, "testTask/child", changes[0].Key)
+		assert.Equal(t, ChangeActionCreate, changes[0].Action)
+		if assert.Len(t, changes[0].Fields, 1) {
+			assert.Equal(t, "Parent", changes[0].Fields[0].Field)
+			assert.Equal(t, "testTask/updated", changes[0].Fields[0].After, "reference rendered as key")
+		}
+
+		assert.Equal(t, "testTask/created", changes[1].Key)
+		assert.Equal(t, ChangeActionCreate, changes[1].Action)
+		assert.Equal(t, []FieldChange{{Field: "Tags", Description: "{key: value}", After: map[string]interface{}{"key": "value"}}}, changes[1].Fields)
+
+		assert.Equal(t, "testTask/updated", changes[2].Key)
+		assert.Equal(t, ChangeActionUpdate, changes[2].Action)
+		if assert.Len(t, changes[2].Fields, 1) {
+			assert.Equal(t, "Tags", changes[2].Fields[0].Field)
+			assert.Equal(t, map[string]interface{}{"key": "old"}, changes[2].Fields[0].Before)
+			assert.Equal(t, map[string]interface{}{"key": "new"}, changes[2].Fields[0].After)
+		}
+	}
+
+	data, err := json.Marshal(changes[2].Fields)
+	assert.NoError(t, err, "json.Marshal()")
+	assert.JSONEq(t, `[{"field": "Tags", "description": " {key: old} -> {key: new}", "before": {"key": "old"}, "after": {"key": "new"}}]`, string(data))
+}
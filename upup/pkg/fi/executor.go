@@ -29,6 +29,9 @@ type executor struct {
 	context *Context
 
 	options RunTasksOptions
+
+	// trace records the task runs, if options.TraceFile is set
+	trace *taskTrace
 }
 
 type taskState struct {
@@ -38,11 +41,30 @@ type taskState struct {
 	deadline     time.Time
 	lastError    error
 	dependencies []*taskState
+
+	// attempts is the number of times the task has been run
+	attempts int
+	// duration is the total time spent running the task, over all attempts
+	duration time.Duration
 }
 
 type RunTasksOptions struct {
 	MaxTaskDuration         time.Duration
 	WaitAfterAllTasksFailed time.Duration
+
+	// MaxConcurrency is the maximum number of tasks run at the same time; 0 means no limit
+	MaxConcurrency int
+	// RateLimiter is waited on before running each task. If it is nil and the cloud is a TaskRateLimiter, the cloud is used.
+	RateLimiter TaskRateLimiter
+	// TraceFile is the path of a file to which a trace of the task runs is written, in the Chrome trace event format
+	TraceFile string
+}
+
+// TaskRateLimiter limits the rate at which tasks are run, e.g. to avoid the throttling of a cloud API.
+// Clouds can implement it to rate limit the tasks run against them.
+type TaskRateLimiter interface {
+	// WaitForTask blocks until the task may be run
+	WaitForTask(task Task)
 }
 
 func (o *RunTasksOptions) InitDefaults() {
@@ -53,6 +75,20 @@ func (o *RunTasksOptions) InitDefaults() {
 // RunTasks executes all the tasks, considering their dependencies
 // It will perform some re-execution on error, retrying as long as progress is still being made
 func (e *executor) RunTasks(taskMap map[string]Task) error {
+	if e.options.RateLimiter == nil && e.context != nil {
+		if rateLimiter, ok := e.context.Cloud.(TaskRateLimiter); ok {
+			e.options.RateLimiter = rateLimiter
+		}
+	}
+	if e.options.TraceFile != "" {
+		e.trace = newTaskTrace()
+		defer func() {
+			if err := e.trace.WriteFile(e.options.TraceFile); err != nil {
+				klog.Warningf("error writing task trace: %v", err)
+			}
+		}()
+	}
+
 	dependencies := FindTaskDependencies(taskMap)
 
 	for _, task := range taskMap {
@@ -132,6 +168,7 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 					continue
 				}
 
+				klog.V(2).Infof("Task %q failed after %v (attempt %d)", ts.key, ts.duration, ts.attempts)
 				remaining := time.Second * time.Duration(int(time.Until(ts.deadline).Seconds()))
 				if _, ok := err.(*TryAgainLaterError); ok {
 					klog.V(2).Infof("Task %q not ready: %v", ts.key, err)
@@ -141,6 +178,7 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 				errors = append(errors, err)
 				ts.lastError = err
 			} else {
+				klog.V(2).Infof("Task %q completed in %v (attempt %d)", ts.key, ts.duration, ts.attempts)
 				ts.done = true
 				ts.lastError = nil
 				progress = true
@@ -176,16 +214,42 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 		return nil
 	}
 
+	// Each running task holds a slot, which also serves as its thread in the trace
+	var slots chan int
+	if e.options.MaxConcurrency > 0 {
+		slots = make(chan int, e.options.MaxConcurrency)
+		for i := 0; i < e.options.MaxConcurrency; i++ {
+			slots <- i
+		}
+	}
+
 	var wg sync.WaitGroup
 	results := make([]error, len(tasks))
 	for i := 0; i < len(tasks); i++ {
+		slot := i
+		if slots != nil {
+			slot = <-slots
+		}
 		wg.Add(1)
-		go func(ts *taskState, index int) {
+		go func(ts *taskState, index int, slot int) {
 			results[index] = fmt.Errorf("function panic")
 			defer wg.Done()
+			if slots != nil {
+				defer func() { slots <- slot }()
+			}
+			if e.options.RateLimiter != nil {
+				e.options.RateLimiter.WaitForTask(ts.task)
+			}
 			klog.V(2).Infof("Executing task %q: %v\n", ts.key, ts.task)
+			ts.attempts++
+			start := time.Now()
 			results[index] = ts.task.Run(e.context)
-		}(tasks[i], i)
+			end := time.Now()
+			ts.duration += end.Sub(start)
+			if e.trace != nil {
+				e.trace.AddTaskRun(ts, slot, start, end, results[index])
+			}
+		}(tasks[i], i, slot)
 	}
 
 	wg.Wait()
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyTracker records the maximum number of tasks running at the same time
+type concurrencyTracker struct {
+	mutex   sync.Mutex
+	running int
+	max     int
+}
+
+// fakeTask is a task that runs for a short time, failing the first Failures times it is run
+type fakeTask struct {
+	Failures int
+
+	tracker *concurrencyTracker
+	runs    int
+}
+
+func (f *fakeTask) Run(c *Context) error {
+	f.tracker.mutex.Lock()
+	f.tracker.running++
+	if f.tracker.running > f.tracker.max {
+		f.tracker.max = f.tracker.running
+	}
+	f.tracker.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	f.tracker.mutex.Lock()
+	f.tracker.running--
+	f.tracker.mutex.Unlock()
+
+	f.runs++
+	if f.runs <= f.Failures {
+		return fmt.Errorf("failure %d", f.runs)
+	}
+	return nil
+}
+
+// countingRateLimiter counts the tasks it is waited on for
+type countingRateLimiter struct {
+	mutex sync.Mutex
+	count int
+}
+
+func (l *countingRateLimiter) WaitForTask(task Task) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.count++
+}
+
+func Test_ExecutorMaxConcurrency(t *testing.T) {
+	tracker := &concurrencyTracker{}
+	tasks := make(map[string]Task)
+	for i := 0; i < 10; i++ {
+		tasks[fmt.Sprintf("fakeTask/%d", i)] = &fakeTask{tracker: tracker}
+	}
+
+	rateLimiter := &countingRateLimiter{}
+	e := &executor{
+		options: RunTasksOptions{
+			MaxTaskDuration: time.Minute,
+			MaxConcurrency:  3,
+			RateLimiter:     rateLimiter,
+		},
+	}
+	require.NoError(t, e.RunTasks(tasks))
+
+	assert.Equal(t, 3, tracker.max, "maximum number of tasks running at the same time")
+	assert.Equal(t, 10, rateLimiter.count, "number of tasks waited on")
+}
+
+func Test_ExecutorTraceFile(t *testing.T) {
+	traceFile := filepath.Join(t.TempDir(), "trace.json")
+
+	tracker := &concurrencyTracker{}
+	tasks := map[string]Task{
+		"fakeTask/ok":    &fakeTask{tracker: tracker},
+		"fakeTask/retry": &fakeTask{tracker: tracker, Failures: 1},
+	}
+
+	e := &executor{
+		options: RunTasksOptions{
+			MaxTaskDuration:         time.Minute,
+			WaitAfterAllTasksFailed: time.Millisecond,
+			TraceFile:               traceFile,
+		},
+	}
+	require.NoError(t, e.RunTasks(tasks))
+
+	data, err := ioutil.ReadFile(traceFile)
+	require.NoError(t, err)
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(data, &trace))
+
+	runs := make(map[string][]traceEvent)
+	for _, event := range trace.TraceEvents {
+		assert.Equal(t, "X", event.Phase)
+		assert.Equal(t, "fakeTask", event.Category)
+		assert.GreaterOrEqual(t, event.Duration, int64(10*time.Millisecond/time.Microsecond))
+		runs[event.Name] = append(runs[event.Name], event)
+	}
+
+	if assert.Len(t, runs["fakeTask/ok"], 1) {
+		assert.Equal(t, 1.0, runs["fakeTask/ok"][0].Args["attempt"])
+		assert.NotContains(t, runs["fakeTask/ok"][0].Args, "error")
+	}
+	if assert.Len(t, runs["fakeTask/retry"], 2) {
+		assert.Equal(t, 1.0, runs["fakeTask/retry"][0].Args["attempt"])
+		assert.Equal(t, "failure 1", runs["fakeTask/retry"][0].Args["error"])
+		assert.Equal(t, 2.0, runs["fakeTask/retry"][1].Args["attempt"])
+		assert.NotContains(t, runs["fakeTask/retry"][1].Args, "error")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// taskTrace records the runs of tasks in the Chrome trace event format,
+// which can be viewed with chrome://tracing or https://ui.perfetto.dev
+type taskTrace struct {
+	mutex  sync.Mutex
+	start  time.Time
+	events []traceEvent
+}
+
+// traceEvent is a complete event of the Chrome trace event format
+type traceEvent struct {
+	Name     string `json:"name"`
+	Category string `json:"cat"`
+	Phase    string `json:"ph"`
+	// Timestamp is the start of the event, in microseconds since the start of the trace
+	Timestamp int64 `json:"ts"`
+	// Duration is the duration of the event, in microseconds
+	Duration int64                  `json:"dur"`
+	PID      int                    `json:"pid"`
+	TID      int                    `json:"tid"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+}
+
+func newTaskTrace() *taskTrace {
+	return &taskTrace{
+		start: time.Now(),
+	}
+}
+
+// AddTaskRun records a run of the task, on the thread of the trace given by slot
+func (t *taskTrace) AddTaskRun(ts *taskState, slot int, start, end time.Time, err error) {
+	args := map[string]interface{}{
+		"attempt": ts.attempts,
+	}
+	if err != nil {
+		args["error"] = err.Error()
+	}
+
+	taskType := reflect.TypeOf(ts.task)
+	if taskType.Kind() == reflect.Ptr {
+		taskType = taskType.Elem()
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.events = append(t.events, traceEvent{
+		Name:      ts.key,
+		Category:  taskType.Name(),
+		Phase:     "X",
+		Timestamp: start.Sub(t.start).Microseconds(),
+		Duration:  end.Sub(start).Microseconds(),
+		PID:       1,
+		TID:       slot,
+		Args:      args,
+	})
+}
+
+// WriteFile writes the trace to the file at path
+func (t *taskTrace) WriteFile(path string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	events := t.events
+	if events == nil {
+		events = []traceEvent{}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling trace: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing trace file %q: %v", path, err)
+	}
+	return nil
+}
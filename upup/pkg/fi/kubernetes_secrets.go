@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Labels and annotations of the Kubernetes Secrets that hold the secrets and private keys of a cluster
+// whose state is stored in a Kubernetes API server
+const (
+	// LabelKubernetesSecretType is the kind of kOps object held by the Secret: keyset or secret
+	LabelKubernetesSecretType = "kops.k8s.io/secret-type"
+	// AnnotationKubernetesSecretName is the name of the keyset or secret held by the Secret,
+	// which is not always a valid name for a Kubernetes object
+	AnnotationKubernetesSecretName = "kops.k8s.io/name"
+
+	// KubernetesSecretTypeKeyset marks the Secrets holding the private keys of keysets
+	KubernetesSecretTypeKeyset = "keyset"
+	// KubernetesSecretTypeSecret marks the Secrets holding secrets
+	KubernetesSecretTypeSecret = "secret"
+)
+
+// KubernetesSecretName returns the name of the Kubernetes Secret holding the keyset or secret with the name.
+// Names that are not valid for a Kubernetes object, e.g. system:dns, are sanitized and suffixed
+// with a hash of the original name, so that they cannot collide with another name.
+func KubernetesSecretName(secretType string, name string) string {
+	prefix := "kops-" + secretType + "-"
+	if len(validation.IsDNS1123Subdomain(prefix+name)) == 0 {
+		return prefix + name
+	}
+
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, name)
+
+	hash := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(hash[:4])
+
+	s := prefix + sanitized
+	if len(s) > validation.DNS1123SubdomainMaxLength-len(suffix) {
+		s = s[:validation.DNS1123SubdomainMaxLength-len(suffix)]
+	}
+	return strings.TrimRight(s, "-") + suffix
+}
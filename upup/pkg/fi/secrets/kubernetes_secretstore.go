@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// kubernetesSecretDataKey is the key of the data of a secret in its Kubernetes Secret
+const kubernetesSecretDataKey = "secret"
+
+// KubernetesSecretStore is a SecretStore backed by Kubernetes Secrets, one for each secret
+type KubernetesSecretStore struct {
+	cluster   *kops.Cluster
+	namespace string
+	client    corev1client.SecretsGetter
+}
+
+var _ fi.SecretStore = &KubernetesSecretStore{}
+
+// NewKubernetesSecretStore is the constructor for KubernetesSecretStore
+func NewKubernetesSecretStore(cluster *kops.Cluster, client corev1client.SecretsGetter, namespace string) fi.SecretStore {
+	return &KubernetesSecretStore{
+		cluster:   cluster,
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+func (c *KubernetesSecretStore) secrets() corev1client.SecretInterface {
+	return c.client.Secrets(c.namespace)
+}
+
+// MirrorTo implements fi.SecretStore::MirrorTo
+func (c *KubernetesSecretStore) MirrorTo(basedir vfs.Path) error {
+	names, err := c.ListSecrets()
+	if err != nil {
+		return fmt.Errorf("error listing secrets for mirror: %v", err)
+	}
+
+	for _, name := range names {
+		secret, err := c.Secret(name)
+		if err != nil {
+			return fmt.Errorf("error reading secret %q for mirror: %v", name, err)
+		}
+
+		p := BuildVfsSecretPath(basedir, name)
+		acl, err := acls.GetACL(p, c.cluster)
+		if err != nil {
+			return fmt.Errorf("error building acl for secret %q for mirror: %v", name, err)
+		}
+
+		klog.Infof("mirroring secret %s -> %s", name, p)
+		if err := createSecret(c.cluster, secret, p, acl, true); err != nil {
+			return fmt.Errorf("error writing secret %q for mirror: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// FindSecret implements fi.SecretStore::FindSecret
+func (c *KubernetesSecretStore) FindSecret(name string) (*fi.Secret, error) {
+	ctx := context.TODO()
+
+	secret, err := c.secrets().Get(ctx, fi.KubernetesSecretName(fi.KubernetesSecretTypeSecret, name), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading secret %q: %v", name, err)
+	}
+	return &fi.Secret{Data: secret.Data[kubernetesSecretDataKey]}, nil
+}
+
+// ListSecrets implements fi.SecretStore::ListSecrets
+func (c *KubernetesSecretStore) ListSecrets() ([]string, error) {
+	ctx := context.TODO()
+
+	list, err := c.secrets().List(ctx, metav1.ListOptions{
+		LabelSelector: fi.LabelKubernetesSecretType + "=" + fi.KubernetesSecretTypeSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets: %v", err)
+	}
+
+	var names []string
+	for i := range list.Items {
+		if name := list.Items[i].Annotations[fi.AnnotationKubernetesSecretName]; name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Secret implements fi.SecretStore::Secret
+func (c *KubernetesSecretStore) Secret(name string) (*fi.Secret, error) {
+	s, err := c.FindSecret(name)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("Secret not found: %q", name)
+	}
+	return s, nil
+}
+
+// DeleteSecret implements fi.SecretStore::DeleteSecret
+func (c *KubernetesSecretStore) DeleteSecret(name string) error {
+	ctx := context.TODO()
+
+	err := c.secrets().Delete(ctx, fi.KubernetesSecretName(fi.KubernetesSecretTypeSecret, name), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting secret %q: %v", name, err)
+	}
+	return nil
+}
+
+// GetOrCreateSecret implements fi.SecretStore::GetOrCreateSecret
+func (c *KubernetesSecretStore) GetOrCreateSecret(name string, secret *fi.Secret) (*fi.Secret, bool, error) {
+	ctx := context.TODO()
+
+	s, err := c.FindSecret(name)
+	if err != nil {
+		return nil, false, err
+	}
+	if s != nil {
+		return s, false, nil
+	}
+
+	if _, err := c.secrets().Create(ctx, c.buildSecret(name, secret), metav1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			klog.Infof("Got already-exists error when writing secret; likely due to concurrent creation")
+			s, err := c.Secret(name)
+			return s, false, err
+		}
+		return nil, false, fmt.Errorf("error creating secret %q: %v", name, err)
+	}
+
+	s, err = c.Secret(name)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to load secret immediately after creation: %v", err)
+	}
+	return s, true, nil
+}
+
+// ReplaceSecret implements fi.SecretStore::ReplaceSecret
+func (c *KubernetesSecretStore) ReplaceSecret(name string, secret *fi.Secret) (*fi.Secret, error) {
+	ctx := context.TODO()
+
+	if err := fi.WriteKubernetesSecret(ctx, c.secrets(), c.buildSecret(name, secret)); err != nil {
+		return nil, fmt.Errorf("unable to write secret: %v", err)
+	}
+
+	s, err := c.Secret(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load secret immediately after creation: %v", err)
+	}
+	return s, nil
+}
+
+// buildSecret builds the Kubernetes Secret holding the secret
+func (c *KubernetesSecretStore) buildSecret(name string, secret *fi.Secret) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fi.KubernetesSecretName(fi.KubernetesSecretTypeSecret, name),
+			Labels:      map[string]string{fi.LabelKubernetesSecretType: fi.KubernetesSecretTypeSecret},
+			Annotations: map[string]string{fi.AnnotationKubernetesSecretName: name},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{kubernetesSecretDataKey: secret.Data},
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestKubernetesSecretStore(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	s := NewKubernetesSecretStore(&kops.Cluster{}, client.CoreV1(), "minimal-example-com")
+
+	secret, err := s.FindSecret("admin")
+	require.NoError(t, err)
+	assert.Nil(t, secret)
+
+	secret, created, err := s.GetOrCreateSecret("admin", &fi.Secret{Data: []byte("password")})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "password", string(secret.Data))
+
+	secret, created, err = s.GetOrCreateSecret("admin", &fi.Secret{Data: []byte("other")})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "password", string(secret.Data))
+
+	_, err = s.ReplaceSecret("system:dns", &fi.Secret{Data: []byte("token")})
+	require.NoError(t, err)
+	secret, err = s.ReplaceSecret("system:dns", &fi.Secret{Data: []byte("rotated")})
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", string(secret.Data))
+
+	o, err := client.CoreV1().Secrets("minimal-example-com").Get(ctx, "kops-secret-admin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, fi.KubernetesSecretTypeSecret, o.Labels[fi.LabelKubernetesSecretType])
+	assert.Equal(t, "admin", o.Annotations[fi.AnnotationKubernetesSecretName])
+	assert.Equal(t, "password", string(o.Data[kubernetesSecretDataKey]))
+
+	names, err := s.ListSecrets()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"admin", "system:dns"}, names)
+
+	vfs.Context.ResetMemfsContext(true)
+	mirror, err := vfs.Context.BuildVfsPath("memfs://tests/secrets")
+	require.NoError(t, err)
+	require.NoError(t, s.MirrorTo(mirror))
+	mirrored, err := NewVFSSecretStore(&kops.Cluster{}, mirror).Secret("system:dns")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", string(mirrored.Data))
+
+	require.NoError(t, s.DeleteSecret("admin"))
+	require.NoError(t, s.DeleteSecret("admin"))
+	names, err = s.ListSecrets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"system:dns"}, names)
+
+	_, err = s.Secret("admin")
+	assert.Error(t, err)
+}
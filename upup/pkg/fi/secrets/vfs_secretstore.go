@@ -25,6 +25,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/acls"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/vfs"
 )
@@ -79,7 +80,7 @@ func (c *VFSSecretStore) MirrorTo(basedir vfs.Path) error {
 
 		klog.Infof("mirroring secret %s -> %s", name, p)
 
-		err = createSecret(secret, p, acl, true)
+		err = createSecret(c.cluster, secret, p, acl, true)
 		if err != nil {
 			return fmt.Errorf("error writing secret %q for mirror: %v", name, err)
 		}
@@ -156,7 +157,7 @@ func (c *VFSSecretStore) GetOrCreateSecret(id string, secret *fi.Secret) (*fi.Se
 			return nil, false, err
 		}
 
-		err = createSecret(secret, p, acl, false)
+		err = createSecret(c.cluster, secret, p, acl, false)
 		if err != nil {
 			if os.IsExist(err) && i == 0 {
 				klog.Infof("Got already-exists error when writing secret; likely due to concurrent creation.  Will retry")
@@ -188,7 +189,7 @@ func (c *VFSSecretStore) ReplaceSecret(id string, secret *fi.Secret) (*fi.Secret
 		return nil, err
 	}
 
-	err = createSecret(secret, p, acl, true)
+	err = createSecret(c.cluster, secret, p, acl, true)
 	if err != nil {
 		return nil, fmt.Errorf("unable to write secret: %v", err)
 	}
@@ -208,6 +209,10 @@ func (c *VFSSecretStore) loadSecret(p vfs.Path) (*fi.Secret, error) {
 			return nil, nil
 		}
 	}
+	data, err = envelope.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting secret from %q: %v", p, err)
+	}
 	s := &fi.Secret{}
 	err = json.Unmarshal(data, s)
 	if err != nil {
@@ -217,11 +222,15 @@ func (c *VFSSecretStore) loadSecret(p vfs.Path) (*fi.Secret, error) {
 }
 
 // createSecret will create the Secret, overwriting an existing secret if replace is true
-func createSecret(s *fi.Secret, p vfs.Path, acl vfs.ACL, replace bool) error {
+func createSecret(cluster *kops.Cluster, s *fi.Secret, p vfs.Path, acl vfs.ACL, replace bool) error {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("error serializing secret: %v", err)
 	}
+	data, err = envelope.EncryptForCluster(cluster, data)
+	if err != nil {
+		return fmt.Errorf("error encrypting secret: %v", err)
+	}
 
 	rs := bytes.NewReader(data)
 	if replace {
@@ -24,3 +24,10 @@ type Target interface {
 	// Some providers (e.g. Terraform) actively keep state, and will delete resources automatically
 	ProcessDeletions() bool
 }
+
+// ResourceGraphRenderer is implemented only by the resource graph target, which renders a task that has
+// no RenderResourceGraph method from the task's fields. Other targets must not implement it: a task without
+// a Render method for the target has to fail to render, rather than be rendered by reflection.
+type ResourceGraphRenderer interface {
+	RenderResourceGraphTask(a, e, changes Task) error
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TaskGraph is the graph of the tasks and their dependencies, as used by the executor to order the tasks
+type TaskGraph struct {
+	Tasks []*TaskGraphNode `json:"tasks"`
+}
+
+// TaskGraphNode is a task in the TaskGraph
+type TaskGraphNode struct {
+	// Key identifies the task, as type/name
+	Key string `json:"key"`
+	// Type is the type of the task
+	Type string `json:"type"`
+	// Lifecycle is the lifecycle of the task, if it has one
+	Lifecycle Lifecycle `json:"lifecycle,omitempty"`
+	// Dependencies are the keys of the tasks that must run before this task
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Action is the change the task would make; it is only set if the changes are known, from a dry run
+	Action ChangeAction `json:"action,omitempty"`
+}
+
+// BuildTaskGraph builds the graph of the tasks, marking the tasks that would make the changes
+func BuildTaskGraph(tasks map[string]Task, changes []*TaskChange) *TaskGraph {
+	dependencies := FindTaskDependencies(tasks)
+
+	actions := make(map[string]ChangeAction)
+	for _, change := range changes {
+		actions[change.Key] = change.Action
+	}
+
+	graph := &TaskGraph{}
+	for key, task := range tasks {
+		node := &TaskGraphNode{
+			Key:          key,
+			Type:         TypeNameForTask(task),
+			Dependencies: append([]string(nil), dependencies[key]...),
+			Action:       actions[key],
+		}
+		if hl, ok := task.(HasLifecycle); ok {
+			node.Lifecycle = hl.GetLifecycle()
+		}
+		sort.Strings(node.Dependencies)
+		graph.Tasks = append(graph.Tasks, node)
+	}
+	sort.Slice(graph.Tasks, func(i, j int) bool {
+		return graph.Tasks[i].Key < graph.Tasks[j].Key
+	})
+
+	return graph
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language, with an edge from each task to each of its dependencies.
+// Tasks that would make changes are filled: green for creates and orange for updates.
+func (g *TaskGraph) WriteDOT(out io.Writer) error {
+	b := &bytes.Buffer{}
+	b.WriteString("digraph tasks {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Tasks {
+		label := node.Key
+		if node.Lifecycle != "" {
+			label += "\\n" + string(node.Lifecycle)
+		}
+		attributes := []string{"label=" + dotQuote(label)}
+		switch node.Action {
+		case ChangeActionCreate:
+			attributes = append(attributes, "style=filled", "fillcolor=palegreen")
+		case ChangeActionUpdate:
+			attributes = append(attributes, "style=filled", "fillcolor=orange")
+		}
+		fmt.Fprintf(b, "  %s [%s];\n", dotQuote(node.Key), strings.Join(attributes, ", "))
+	}
+	for _, node := range g.Tasks {
+		for _, dependency := range node.Dependencies {
+			fmt.Fprintf(b, "  %s -> %s;\n", dotQuote(node.Key), dotQuote(dependency))
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := out.Write(b.Bytes())
+	return err
+}
+
+// dotQuote quotes s as a DOT ID; escape sequences such as \n in labels are preserved
+func dotQuote(s string) string {
+	return "\"" + strings.ReplaceAll(s, "\"", "\\\"") + "\""
+}
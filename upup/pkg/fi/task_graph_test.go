@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type graphNetwork struct {
+	Lifecycle Lifecycle
+}
+
+func (n *graphNetwork) Run(c *Context) error             { return nil }
+func (n *graphNetwork) GetLifecycle() Lifecycle          { return n.Lifecycle }
+func (n *graphNetwork) SetLifecycle(lifecycle Lifecycle) { n.Lifecycle = lifecycle }
+
+type graphInstance struct {
+	Network *graphNetwork
+}
+
+func (i *graphInstance) Run(c *Context) error { return nil }
+
+func Test_TaskGraph(t *testing.T) {
+	network := &graphNetwork{Lifecycle: LifecycleExistsAndValidates}
+	tasks := map[string]Task{
+		"graphNetwork/main":   network,
+		"graphInstance/one":   &graphInstance{Network: network},
+		"graphInstance/two":   &graphInstance{Network: network},
+		"graphInstance/three": &graphInstance{},
+	}
+	changes := []*TaskChange{
+		{Key: "graphInstance/one", Action: ChangeActionCreate},
+		{Key: "graphInstance/two", Action: ChangeActionUpdate},
+		{Key: "graphInstance/old", Action: ChangeActionDelete},
+	}
+
+	graph := BuildTaskGraph(tasks, changes)
+
+	expected := []*TaskGraphNode{
+		{Key: "graphInstance/one", Type: "graphInstance", Dependencies: []string{"graphNetwork/main"}, Action: ChangeActionCreate},
+		{Key: "graphInstance/three", Type: "graphInstance"},
+		{Key: "graphInstance/two", Type: "graphInstance", Dependencies: []string{"graphNetwork/main"}, Action: ChangeActionUpdate},
+		{Key: "graphNetwork/main", Type: "graphNetwork", Lifecycle: LifecycleExistsAndValidates},
+	}
+	assert.Equal(t, expected, graph.Tasks)
+
+	var b bytes.Buffer
+	if err := graph.WriteDOT(&b); err != nil {
+		t.Fatalf("error writing DOT: %v", err)
+	}
+	expectedDOT := `digraph tasks {
+  rankdir=LR;
+  node [shape=box];
+  "graphInstance/one" [label="graphInstance/one", style=filled, fillcolor=palegreen];
+  "graphInstance/three" [label="graphInstance/three"];
+  "graphInstance/two" [label="graphInstance/two", style=filled, fillcolor=orange];
+  "graphNetwork/main" [label="graphNetwork/main\nExistsAndValidates"];
+  "graphInstance/one" -> "graphNetwork/main";
+  "graphInstance/two" -> "graphNetwork/main";
+}
+`
+	assert.Equal(t, expectedDOT, b.String())
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/kops/pkg/acls"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/v1alpha2"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/pkg/kopscodecs"
 	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/pkg/sshcredentials"
@@ -107,6 +108,10 @@ func (c *VFSCAStore) loadKeyset(p vfs.Path) (*Keyset, error) {
 		}
 		return nil, fmt.Errorf("unable to read bundle %q: %v", p, err)
 	}
+	data, err = envelope.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt bundle %q: %v", p, err)
+	}
 
 	o, legacyFormat, err := c.parseKeysetYaml(data)
 	if err != nil {
@@ -184,6 +189,10 @@ func writeKeysetBundle(cluster *kops.Cluster, p vfs.Path, name string, keyset *K
 	if err != nil {
 		return err
 	}
+	objectData, err = envelope.EncryptForCluster(cluster, objectData)
+	if err != nil {
+		return fmt.Errorf("error encrypting keyset %q: %v", name, err)
+	}
 
 	acl, err := acls.GetACL(p, cluster)
 	if err != nil {
@@ -17,13 +17,20 @@ limitations under the License.
 package fi
 
 import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io/ioutil"
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/util/pkg/vfs"
 )
@@ -270,3 +277,67 @@ spec:
 		}
 	}
 }
+
+func TestVFSCAStoreRoundTripEncrypted(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	if err != nil {
+		t.Fatalf("error building vfspath: %v", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "state-store.key")
+	if err := ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))), 0600); err != nil {
+		t.Fatalf("error writing key file: %v", err)
+	}
+
+	cluster := &kops.Cluster{}
+	cluster.Spec.StateStoreEncryption = &kops.StateStoreEncryptionSpec{
+		Provider: envelope.ProviderLocal,
+		Key:      keyFile,
+	}
+	s := NewVFSCAStore(cluster, basePath)
+
+	cert, privateKey, _, err := pki.IssueCert(&pki.IssueCertRequest{
+		Type:    "ca",
+		Subject: pkix.Name{CommonName: "kubernetes"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("error from IssueCert: %v", err)
+	}
+	keyset, err := NewKeyset(cert, privateKey)
+	if err != nil {
+		t.Fatalf("error from NewKeyset: %v", err)
+	}
+	if err := s.StoreKeyset("kubernetes-ca", keyset); err != nil {
+		t.Fatalf("error from StoreKeyset: %v", err)
+	}
+
+	data, err := basePath.Join("private", "kubernetes-ca", "keyset.yaml").ReadFile()
+	if err != nil {
+		t.Fatalf("error reading keyset: %v", err)
+	}
+	if !envelope.IsEncrypted(data) {
+		t.Fatalf("keyset was not encrypted: %q", string(data))
+	}
+
+	roundTrip, err := s.FindKeyset("kubernetes-ca")
+	if err != nil {
+		t.Fatalf("error reading keyset: %v", err)
+	}
+	if roundTrip == nil || roundTrip.Primary == nil || roundTrip.Primary.PrivateKey == nil {
+		t.Fatalf("private keyset was not found")
+	}
+
+	expected, err := privateKey.AsString()
+	if err != nil {
+		t.Fatalf("error serializing private key: %v", err)
+	}
+	actual, err := roundTrip.Primary.PrivateKey.AsString()
+	if err != nil {
+		t.Fatalf("error serializing private key: %v", err)
+	}
+	if actual != expected {
+		t.Fatalf("unexpected round-tripped private key data: %q", actual)
+	}
+}
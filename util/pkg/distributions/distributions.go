@@ -73,6 +73,21 @@ func (d *Distribution) IsSystemd() bool {
 	return true
 }
 
+// IsDeprecated returns true if support for this distribution is deprecated and will be removed in future versions of kOps
+func (d *Distribution) IsDeprecated() bool {
+	switch *d {
+	case DistributionCentos7, DistributionCentos8, DistributionDebian9, DistributionRhel7, DistributionUbuntu1804:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRemoved returns true if this distribution is no longer supported by kOps
+func (d *Distribution) IsRemoved() bool {
+	return *d == DistributionUbuntu1604
+}
+
 // DefaultUsers returns the name of the system users for this distribution
 func (d *Distribution) DefaultUsers() ([]string, error) {
 	switch d.project {
@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package distributions
+
+import (
+	"strings"
+)
+
+// imageDistributions maps substrings of image names to distributions, most specific first.
+// The substrings match the official images listed in docs/operations/images.md and the channels.
+var imageDistributions = []struct {
+	substrings   []string
+	distribution Distribution
+}{
+	{[]string{"flatcar"}, DistributionFlatcar},
+	{[]string{"cos-cloud/", "/cos-", "cos-stable-"}, DistributionContainerOS},
+	{[]string{"amzn2"}, DistributionAmazonLinux2},
+	{[]string{"rhel-7"}, DistributionRhel7},
+	{[]string{"rhel-8"}, DistributionRhel8},
+	{[]string{"centos 7", "centos-7", "centos7"}, DistributionCentos7},
+	{[]string{"centos 8", "centos-8", "centos8"}, DistributionCentos8},
+	{[]string{"debian-9", "stretch"}, DistributionDebian9},
+	{[]string{"debian-10", "buster"}, DistributionDebian10},
+	{[]string{"xenial", "ubuntu-1604", "16.04"}, DistributionUbuntu1604},
+	{[]string{"bionic", "ubuntu-1804", "18.04"}, DistributionUbuntu1804},
+	{[]string{"focal", "ubuntu-2004", "20.04"}, DistributionUbuntu2004},
+	{[]string{"groovy", "ubuntu-2010", "20.10"}, DistributionUbuntu2010},
+	{[]string{"hirsute", "ubuntu-2104", "21.04"}, DistributionUbuntu2104},
+}
+
+// FindDistributionForImage identifies the distribution of a machine image from its name.
+// Images referenced by ID, such as AMI IDs, cannot be identified.
+func FindDistributionForImage(image string) (Distribution, bool) {
+	image = strings.ToLower(image)
+	for _, d := range imageDistributions {
+		for _, s := range d.substrings {
+			if strings.Contains(image, s) {
+				return d.distribution, true
+			}
+		}
+	}
+	return Distribution{}, false
+}
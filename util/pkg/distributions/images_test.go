@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package distributions
+
+import (
+	"testing"
+)
+
+func TestFindDistributionForImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		found    bool
+		expected Distribution
+	}{
+		{
+			image:    "137112412989/amzn2-ami-hvm-2.0.20210427.0-x86_64-gp2",
+			found:    true,
+			expected: DistributionAmazonLinux2,
+		},
+		{
+			image:    "125523088429/CentOS 7.9.2009 x86_64",
+			found:    true,
+			expected: DistributionCentos7,
+		},
+		{
+			image:    "125523088429/CentOS 8.3.2011 x86_64",
+			found:    true,
+			expected: DistributionCentos8,
+		},
+		{
+			image:    "cos-cloud/cos-stable-77-12371-114-0",
+			found:    true,
+			expected: DistributionContainerOS,
+		},
+		{
+			image:    "kope.io/k8s-1.17-debian-stretch-amd64-hvm-ebs-2021-02-05",
+			found:    true,
+			expected: DistributionDebian9,
+		},
+		{
+			image:    "136693071363/debian-10-amd64-20210329-591",
+			found:    true,
+			expected: DistributionDebian10,
+		},
+		{
+			image:    "kope.io/k8s-1.7-debian-jessie-amd64-hvm-ebs-2018-08-17",
+			found:    false,
+			expected: Distribution{},
+		},
+		{
+			image:    "075585003325/Flatcar-stable-2765.2.3-hvm",
+			found:    true,
+			expected: DistributionFlatcar,
+		},
+		{
+			image:    "309956199498/RHEL-7.9_HVM-20210208-x86_64-0-Hourly2-GP2",
+			found:    true,
+			expected: DistributionRhel7,
+		},
+		{
+			image:    "309956199498/RHEL-8.4.0_HVM-20210504-x86_64-2-Hourly2-GP2",
+			found:    true,
+			expected: DistributionRhel8,
+		},
+		{
+			image:    "099720109477/ubuntu/images/hvm-ssd/ubuntu-xenial-16.04-amd64-server-20210429",
+			found:    true,
+			expected: DistributionUbuntu1604,
+		},
+		{
+			image:    "099720109477/ubuntu/images/hvm-ssd/ubuntu-bionic-18.04-amd64-server-20210503",
+			found:    true,
+			expected: DistributionUbuntu1804,
+		},
+		{
+			image:    "099720109477/ubuntu/images/hvm-ssd/ubuntu-focal-20.04-amd64-server-20210503",
+			found:    true,
+			expected: DistributionUbuntu2004,
+		},
+		{
+			image:    "ubuntu-os-cloud/ubuntu-2004-focal-v20210415",
+			found:    true,
+			expected: DistributionUbuntu2004,
+		},
+		{
+			image:    "Canonical:0001-com-ubuntu-server-focal:20_04-lts-gen2:20.04.202104150",
+			found:    true,
+			expected: DistributionUbuntu2004,
+		},
+		{
+			image:    "ami-00579fbb15b954340",
+			found:    false,
+			expected: Distribution{},
+		},
+	}
+
+	for _, test := range tests {
+		actual, found := FindDistributionForImage(test.image)
+		if found != test.found {
+			t.Errorf("unexpected found for %q, actual=%v, expected=%v", test.image, found, test.found)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("unexpected distribution for %q, actual=%v, expected=%v", test.image, actual, test.expected)
+		}
+	}
+}
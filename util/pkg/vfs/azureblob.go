@@ -25,7 +25,6 @@ import (
 	"os"
 	"path"
 	"strings"
-	"sync"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"k8s.io/kops/util/pkg/hashing"
@@ -130,32 +129,30 @@ func (p *AzureBlobPath) WriteTo(w io.Writer) (n int64, err error) {
 	return io.Copy(w, resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 10}))
 }
 
-// createFileLockAzureBLob prevents concurrent creates on the same
-// file while maintaining atomicity of writes.
-//
-// This takes the same approach as S3Path.
-var createFileLockAzureBlob sync.Mutex
-
 // CreateFile writes the file contents only if the file does not already exist.
+// The upload is conditional on the blob not existing, so concurrent creates
+// from different processes cannot both succeed.
 func (p *AzureBlobPath) CreateFile(data io.ReadSeeker, acl ACL) error {
-	createFileLockAzureBlob.Lock()
-	defer createFileLockAzureBlob.Unlock()
-
-	// Check if the blob exists.
-	_, err := p.ReadFile()
-	if err == nil {
-		return os.ErrExist
-	}
-	if !os.IsNotExist(err) {
-		return err
+	err := p.writeFile(data, azblob.BlobAccessConditions{
+		ModifiedAccessConditions: azblob.ModifiedAccessConditions{IfNoneMatch: azblob.ETagAny},
+	})
+	if serr, ok := err.(azblob.StorageError); ok {
+		switch serr.ServiceCode() {
+		case azblob.ServiceCodeBlobAlreadyExists, azblob.ServiceCodeConditionNotMet:
+			return os.ErrExist
+		}
 	}
-	return p.WriteFile(data, acl)
+	return err
 }
 
 // WriteFile writes the blob to the reader.
 //
 // TODO(kenji): Support ACL.
 func (p *AzureBlobPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	return p.writeFile(data, azblob.BlobAccessConditions{})
+}
+
+func (p *AzureBlobPath) writeFile(data io.ReadSeeker, conditions azblob.BlobAccessConditions) error {
 	md5Hash, err := hashing.HashAlgorithmMD5.Hash(data)
 	if err != nil {
 		return err
@@ -178,7 +175,7 @@ func (p *AzureBlobPath) WriteFile(data io.ReadSeeker, acl ACL) error {
 			ContentMD5:  md5Hash.HashValue,
 		},
 		azblob.Metadata{},
-		azblob.BlobAccessConditions{},
+		conditions,
 		azblob.AccessTierNone,
 		azblob.BlobTagsMap{},
 		azblob.ClientProvidedKeyOptions{},
@@ -23,7 +23,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
-	"sync"
 	"syscall"
 
 	"k8s.io/klog/v2"
@@ -89,27 +88,45 @@ func (p *FSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
 	return err
 }
 
-// To prevent concurrent creates on the same file while maintaining atomicity of writes,
-// we take a process-wide lock during the operation.
-// Not a great approach, but fine for a single process (with low concurrency)
-// TODO: should we take a file lock or equivalent here?  Can we use RENAME_NOREPLACE ?
-var createFileLock sync.Mutex
-
+// CreateFile writes the file contents to a temp file and hard links it into place.
+// Linking fails if the file already exists, so concurrent creates from different
+// processes cannot both succeed, and the file is never seen partially written.
 func (p *FSPath) CreateFile(data io.ReadSeeker, acl ACL) error {
-	createFileLock.Lock()
-	defer createFileLock.Unlock()
+	dir := path.Dir(p.location)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directories %q: %v", dir, err)
+	}
 
-	// Check if exists
-	_, err := os.Stat(p.location)
-	if err == nil {
-		return os.ErrExist
+	f, err := ioutil.TempFile(dir, "tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temp file in %q: %v", dir, err)
 	}
 
-	if !os.IsNotExist(err) {
-		return err
+	// Note from here on in we have to close f and delete the temp file
+	tempfile := f.Name()
+	defer func() {
+		if removeErr := os.Remove(tempfile); removeErr != nil {
+			klog.Warningf("unable to remove temp file %q: %v", tempfile, removeErr)
+		}
+	}()
+
+	_, err = io.Copy(f, data)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error during file write of %q: %v", p.location, err)
 	}
 
-	return p.WriteFile(data, acl)
+	if err := os.Link(tempfile, p.location); err != nil {
+		if os.IsExist(err) {
+			return os.ErrExist
+		}
+		return fmt.Errorf("error during file write of %q: link failed: %v", p.location, err)
+	}
+	return nil
 }
 
 // ReadFile implements Path::ReadFile
@@ -114,3 +114,48 @@ func TestWriteTo(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateFileConcurrently(t *testing.T) {
+	TempDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer func() {
+		err := os.RemoveAll(TempDir)
+		if err != nil {
+			t.Errorf("failed to remove temp dir %q: %v", TempDir, err)
+		}
+	}()
+
+	fspath := NewFSPath(path.Join(TempDir, "SubDir", "lock"))
+
+	const writers = 10
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			errs <- fspath.CreateFile(bytes.NewReader([]byte{byte(i)}), nil)
+		}(i)
+	}
+
+	created := 0
+	for i := 0; i < writers; i++ {
+		err := <-errs
+		if err == nil {
+			created++
+		} else if err != os.ErrExist {
+			t.Errorf("Expected to get os.ErrExist, got: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one create to succeed, got %d", created)
+	}
+
+	// The temp files should have been removed
+	files, err := ioutil.ReadDir(path.Join(TempDir, "SubDir"))
+	if err != nil {
+		t.Fatalf("Error reading dir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected only the created file, got %d files", len(files))
+	}
+}
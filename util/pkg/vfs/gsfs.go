@@ -26,7 +26,6 @@ import (
 	"os"
 	"path"
 	"strings"
-	"sync"
 	"time"
 
 	"google.golang.org/api/googleapi"
@@ -148,6 +147,12 @@ func (p *GSPath) Join(relativePath ...string) Path {
 }
 
 func (p *GSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	return p.writeFile(data, acl, false)
+}
+
+// writeFile writes the object; if create is true, the write is conditional on the object not existing,
+// and fails with os.ErrExist if it does.
+func (p *GSPath) writeFile(data io.ReadSeeker, acl ACL, create bool) error {
 	md5Hash, err := hashing.HashAlgorithmMD5.Hash(data)
 	if err != nil {
 		return err
@@ -174,8 +179,16 @@ func (p *GSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
 			return false, fmt.Errorf("error seeking to start of data stream for write to %s: %v", p, err)
 		}
 
-		_, err = p.client.Objects.Insert(p.bucket, obj).Media(data).Do()
+		call := p.client.Objects.Insert(p.bucket, obj).Media(data)
+		if create {
+			// A generation of 0 matches only if there is no live version of the object
+			call = call.IfGenerationMatch(0)
+		}
+		_, err = call.Do()
 		if err != nil {
+			if create && isGCSPreconditionFailed(err) {
+				return true, os.ErrExist
+			}
 			return false, fmt.Errorf("error writing %s: %v", p, err)
 		}
 
@@ -191,27 +204,10 @@ func (p *GSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
 	}
 }
 
-// To prevent concurrent creates on the same file while maintaining atomicity of writes,
-// we take a process-wide lock during the operation.
-// Not a great approach, but fine for a single process (with low concurrency)
-// TODO: should we enable versioning?
-var createFileLockGCS sync.Mutex
-
+// CreateFile writes the object only if it does not already exist, using a precondition on its generation,
+// so that concurrent creates from different processes cannot both succeed.
 func (p *GSPath) CreateFile(data io.ReadSeeker, acl ACL) error {
-	createFileLockGCS.Lock()
-	defer createFileLockGCS.Unlock()
-
-	// Check if exists
-	_, err := p.ReadFile()
-	if err == nil {
-		return os.ErrExist
-	}
-
-	if !os.IsNotExist(err) {
-		return err
-	}
-
-	return p.WriteFile(data, acl)
+	return p.writeFile(data, acl, true)
 }
 
 // ReadFile implements Path::ReadFile
@@ -372,6 +368,14 @@ func (p *GSPath) Hash(a hashing.HashAlgorithm) (*hashing.Hash, error) {
 	return &hashing.Hash{Algorithm: hashing.HashAlgorithmMD5, HashValue: md5Bytes}, nil
 }
 
+func isGCSPreconditionFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+	ae, ok := err.(*googleapi.Error)
+	return ok && ae.Code == http.StatusPreconditionFailed
+}
+
 func isGCSNotFound(err error) bool {
 	if err == nil {
 		return false
@@ -107,21 +107,37 @@ func (p *MemFSPath) WriteFile(r io.ReadSeeker, acl ACL) error {
 	if err != nil {
 		return fmt.Errorf("error reading data: %v", err)
 	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	p.contents = data
 	return nil
 }
 
-func (p *MemFSPath) CreateFile(data io.ReadSeeker, acl ACL) error {
+func (p *MemFSPath) CreateFile(r io.ReadSeeker, acl ACL) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading data: %v", err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	// Check if exists
 	if p.contents != nil {
 		return os.ErrExist
 	}
 
-	return p.WriteFile(data, acl)
+	p.contents = data
+	return nil
 }
 
 // ReadFile implements Path::ReadFile
 func (p *MemFSPath) ReadFile() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	if p.contents == nil {
 		return nil, os.ErrNotExist
 	}
@@ -131,6 +147,9 @@ func (p *MemFSPath) ReadFile() ([]byte, error) {
 
 // WriteTo implements io.WriterTo
 func (p *MemFSPath) WriteTo(out io.Writer) (int64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	if p.contents == nil {
 		return 0, os.ErrNotExist
 	}
@@ -180,6 +199,9 @@ func (p *MemFSPath) String() string {
 }
 
 func (p *MemFSPath) Remove() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	p.contents = nil
 	return nil
 }